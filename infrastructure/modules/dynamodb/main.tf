@@ -16,6 +16,12 @@ resource "aws_dynamodb_table" "transactions" {
     enabled = true
   }
   
+  # Expire short-lived items such as idempotency keys
+  ttl {
+    attribute_name = "expires_at"
+    enabled        = true
+  }
+  
   # Enable deletion protection for production
   deletion_protection_enabled = var.environment == "prod"
   
@@ -22,6 +22,11 @@ resource "aws_lambda_function" "api" {
       OPENAI_API_KEY_SSM    = var.openai_api_key_ssm
       LOG_LEVEL            = var.log_level
       CORS_ORIGINS         = join(",", var.cors_origins)
+      JWT_SECRET           = var.jwt_secret
+      JWKS_FILE            = var.jwks_file
+      JWT_ISSUER           = var.jwt_issuer
+      JWT_AUDIENCE         = var.jwt_audience
+      EXCHANGE_RATES_FILE  = var.exchange_rates_file
     }
   }
   
@@ -71,6 +76,11 @@ resource "aws_lambda_function" "ai_advisor" {
       DYNAMODB_TABLE_NAME   = var.dynamodb_table_name
       OPENAI_API_KEY_SSM    = var.openai_api_key_ssm
       LOG_LEVEL            = var.log_level
+      JWT_SECRET           = var.jwt_secret
+      JWKS_FILE            = var.jwks_file
+      JWT_ISSUER           = var.jwt_issuer
+      JWT_AUDIENCE         = var.jwt_audience
+      EXCHANGE_RATES_FILE  = var.exchange_rates_file
     }
   }
   
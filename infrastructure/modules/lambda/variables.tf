@@ -23,6 +23,37 @@ variable "openai_api_key_ssm" {
   type        = string
 }
 
+variable "jwt_secret" {
+  description = "HS256 shared secret used to validate API bearer tokens"
+  type        = string
+  default     = ""
+  sensitive   = true
+}
+
+variable "jwks_file" {
+  description = "Path (inside the Lambda package) to a JWKS file with RS256 public keys"
+  type        = string
+  default     = ""
+}
+
+variable "exchange_rates_file" {
+  description = "Path (inside the Lambda package) to a CSV or JSON file with daily exchange rates"
+  type        = string
+  default     = ""
+}
+
+variable "jwt_issuer" {
+  description = "Expected issuer (iss) of API bearer tokens"
+  type        = string
+  default     = ""
+}
+
+variable "jwt_audience" {
+  description = "Expected audience (aud) of API bearer tokens"
+  type        = string
+  default     = ""
+}
+
 variable "lambda_execution_role_arn" {
   description = "ARN of the Lambda execution role"
   type        = string
@@ -6,8 +6,10 @@ import (
 	"log"
 	"os"
 
+	"backend/internal/auth"
 	"backend/internal/config"
 	"backend/internal/models"
+	"backend/internal/rates"
 	"backend/internal/repository"
 	"backend/internal/services"
 
@@ -18,6 +20,7 @@ import (
 
 type AIApp struct {
 	aiService services.AIService
+	verifier  *auth.Verifier
 }
 
 func NewAIApp() (*AIApp, error) {
@@ -32,19 +35,37 @@ func NewAIApp() (*AIApp, error) {
 	// Initialize repository with proper parameters
 	repo := repository.NewDynamoDBRepository(dynamoClient, cfg.DynamoDBTableName)
 
+	// Amounts in other currencies are converted with the configured rates file
+	rateStore, err := rates.LoadOptional(cfg.ExchangeRatesFile)
+	if err != nil {
+		return nil, err
+	}
+
 	// Initialize AI service
-	aiService, err := services.NewAIService(cfg, repo)
+	aiService, err := services.NewAIServiceWithRates(cfg, repo, rateStore)
+	if err != nil {
+		return nil, err
+	}
+
+	verifier, err := auth.NewVerifier(cfg)
 	if err != nil {
 		return nil, err
 	}
 
 	return &AIApp{
 		aiService: aiService,
+		verifier:  verifier,
 	}, nil
 }
 
 func (app *AIApp) Handler(ctx context.Context, request events.APIGatewayProxyRequest) (events.APIGatewayProxyResponse, error) {
 	log.Printf("Processing AI request: %s %s", request.HTTPMethod, request.Path)
+
+	// Every AI endpoint is scoped to the authenticated user
+	ctx, errResponse := app.authenticate(ctx, request)
+	if errResponse != nil {
+		return *errResponse, nil
+	}
 	
 	// Handle different AI endpoints
 	switch request.Path {
@@ -128,9 +149,9 @@ func (app *AIApp) handleAdvisorRequest(ctx context.Context, request events.APIGa
 		}, nil
 	}
 
-	userID := request.QueryStringParameters["user_id"]
-	if userID == "" {
-		userID = "default-user"
+	userID, _ := auth.UserIDFromContext(ctx)
+	if requested := request.QueryStringParameters["user_id"]; requested != "" && requested != userID {
+		return errorResponse(models.ErrorCodeForbidden, "Access to another user's data is not allowed"), nil
 	}
 
 	// Build financial context for the user
@@ -179,6 +200,41 @@ func (app *AIApp) handleAdvisorRequest(ctx context.Context, request events.APIGa
 	}, nil
 }
 
+// authenticate validates the bearer token and stores its subject in the context
+func (app *AIApp) authenticate(ctx context.Context, request events.APIGatewayProxyRequest) (context.Context, *events.APIGatewayProxyResponse) {
+	// HTTP APIs lowercase header names, REST APIs keep them as sent
+	authorization := request.Headers["Authorization"]
+	if authorization == "" {
+		authorization = request.Headers["authorization"]
+	}
+
+	token, err := auth.BearerToken(authorization)
+	if err != nil {
+		response := errorResponse(models.ErrorCodeUnauthorized, "Missing or malformed Authorization header")
+		return ctx, &response
+	}
+
+	claims, err := app.verifier.Verify(token)
+	if err != nil {
+		log.Printf("Rejected token: %v", err)
+		response := errorResponse(models.ErrorCodeUnauthorized, "Invalid or expired token")
+		return ctx, &response
+	}
+
+	return auth.WithUserID(ctx, claims.Subject), nil
+}
+
+func errorResponse(code, message string) events.APIGatewayProxyResponse {
+	body, _ := json.Marshal(models.NewErrorResponse(code, message, ""))
+	return events.APIGatewayProxyResponse{
+		StatusCode: models.HTTPStatusFromErrorCode(code),
+		Body:       string(body),
+		Headers: map[string]string{
+			"Content-Type": "application/json",
+		},
+	}
+}
+
 func main() {
 	app, err := NewAIApp()
 	if err != nil {
@@ -11,9 +11,13 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/rs/cors"
 
+	"backend/internal/auth"
 	"backend/internal/config"
 	"backend/internal/database"
 	"backend/internal/handlers"
+	"backend/internal/models"
+	"backend/internal/pagination"
+	"backend/internal/rates"
 	"backend/internal/repository"
 	"backend/internal/services"
 )
@@ -113,35 +117,82 @@ func initializeServices(router *mux.Router) {
 		tableName,
 	)
 
-	// Initialize services
-	transactionService := services.NewTransactionService(transactionRepo)
-	budgetService := services.NewBudgetService(transactionRepo)
-	analyticsService := services.NewAnalyticsService(transactionRepo)
-	
-	// Load configuration for AI service
+	// Load configuration for AI service, authentication and exchange rates
 	cfg, err := config.Load()
 	if err != nil {
 		log.Printf("Warning: Failed to load config: %v", err)
 		cfg = &config.Config{
-			Environment:  "development",
-			OpenAIAPIKey: getEnvOrDefault("OPENAI_API_KEY", ""), // Read from env
+			Environment:       "development",
+			OpenAIAPIKey:      getEnvOrDefault("OPENAI_API_KEY", ""), // Read from env
+			JWTSecret:         getEnvOrDefault("JWT_SECRET", ""),
+			JWKSFile:          getEnvOrDefault("JWKS_FILE", ""),
+			JWTIssuer:         getEnvOrDefault("JWT_ISSUER", ""),
+			JWTAudience:       getEnvOrDefault("JWT_AUDIENCE", ""),
+			ExchangeRatesFile: getEnvOrDefault("EXCHANGE_RATES_FILE", ""),
+			CursorSecret:      getEnvOrDefault("CURSOR_SECRET", getEnvOrDefault("JWT_SECRET", "")),
+			RecurringInterval: time.Hour,
 		}
 	}
 	
-	aiService, err := services.NewAIService(cfg, transactionRepo)
+	// Without a rates file only same-currency amounts can be aggregated
+	rateStore, err := rates.LoadOptional(cfg.ExchangeRatesFile)
+	if err != nil {
+		log.Printf("Warning: Failed to load exchange rates: %v", err)
+		rateStore = rates.NewStore()
+	}
+	
+	// Cursors signed with a random key are only valid until the next cold start
+	if cfg.CursorSecret == "" {
+		log.Printf("Warning: CURSOR_SECRET not set, page cursors will not survive restarts")
+	}
+	
+	// Initialize services
+	transactionService := services.NewTransactionServiceWithCursors(transactionRepo, pagination.NewCodec(cfg.CursorSecret))
+	budgetService := services.NewBudgetServiceWithRates(transactionRepo, rateStore)
+	analyticsService := services.NewAnalyticsServiceWithRates(transactionRepo, rateStore)
+	userService := services.NewUserService(transactionRepo)
+	recurringService := services.NewRecurringService(transactionRepo)
+	importService := services.NewImportService(transactionRepo)
+	ruleService := services.NewRuleService(transactionRepo)
+	categoryService := services.NewCategoryServiceWithRates(transactionRepo, rateStore)
+	accountService := services.NewAccountServiceWithRates(transactionRepo, rateStore)
+	goalService := services.NewGoalServiceWithRates(transactionRepo, rateStore)
+	
+	aiService, err := services.NewAIServiceWithRates(cfg, transactionRepo, rateStore)
 	if err != nil {
 		log.Printf("Warning: Failed to create AI service: %v", err)
 		aiService = nil
 	}
 
+	// Requests are rejected until a JWT secret or JWKS file is configured
+	verifier, err := auth.NewVerifier(cfg)
+	if err != nil {
+		log.Printf("Warning: Failed to configure authentication: %v", err)
+		verifier = nil
+	}
+
 	// Initialize handlers
 	transactionHandler := handlers.NewTransactionHandler(transactionService)
 	budgetHandler := handlers.NewBudgetHandler(budgetService)
 	analyticsHandler := handlers.NewAnalyticsHandler(analyticsService)
 	aiHandler := handlers.NewAIHandler(aiService)
+	userHandler := handlers.NewUserHandler(userService)
+	recurringHandler := handlers.NewRecurringHandler(recurringService)
+	importHandler := handlers.NewImportHandler(importService)
+	ruleHandler := handlers.NewRuleHandler(ruleService)
+	categoryHandler := handlers.NewCategoryHandler(categoryService)
+	accountHandler := handlers.NewAccountHandler(accountService)
+	goalHandler := handlers.NewGoalHandler(goalService)
 
 	// Setup full routes
-	setupFullRoutes(router, transactionHandler, budgetHandler, analyticsHandler, aiHandler)
+	setupFullRoutes(router, verifier, transactionHandler, budgetHandler, analyticsHandler, aiHandler, userHandler, recurringHandler, importHandler, ruleHandler, categoryHandler, accountHandler, goalHandler)
+
+	// Generate due recurring transactions in the background; deployments that
+	// run cmd/scheduler from cron set RECURRING_SCHEDULER_INTERVAL=0
+	if cfg.RecurringInterval > 0 {
+		go services.RunRecurringScheduler(ctx, recurringService, cfg.RecurringInterval)
+		log.Printf("Recurring scheduler running every %s", cfg.RecurringInterval)
+	}
 
 	log.Printf("Services initialized successfully")
 	log.Printf("Environment: %s", dbClient.Config.Environment)
@@ -150,10 +201,18 @@ func initializeServices(router *mux.Router) {
 
 func setupFullRoutes(
 	router *mux.Router,
+	verifier *auth.Verifier,
 	transactionHandler *handlers.TransactionHandler,
 	budgetHandler *handlers.BudgetHandler,
 	analyticsHandler *handlers.AnalyticsHandler,
 	aiHandler *handlers.AIHandler,
+	userHandler *handlers.UserHandler,
+	recurringHandler *handlers.RecurringHandler,
+	importHandler *handlers.ImportHandler,
+	ruleHandler *handlers.RuleHandler,
+	categoryHandler *handlers.CategoryHandler,
+	accountHandler *handlers.AccountHandler,
+	goalHandler *handlers.GoalHandler,
 ) {
 
 	// API version prefix
@@ -162,6 +221,7 @@ func setupFullRoutes(
 	// Transaction routes
 	api.HandleFunc("/transactions", transactionHandler.CreateTransaction).Methods("POST")
 	api.HandleFunc("/transactions", transactionHandler.GetTransactionsByUser).Methods("GET")
+	api.HandleFunc("/transactions/duplicates", transactionHandler.FindDuplicates).Methods("GET")
 	api.HandleFunc("/transactions/{id}", transactionHandler.GetTransaction).Methods("GET")
 	api.HandleFunc("/transactions/{id}", transactionHandler.UpdateTransaction).Methods("PUT")
 	api.HandleFunc("/transactions/{id}", transactionHandler.DeleteTransaction).Methods("DELETE")
@@ -170,15 +230,87 @@ func setupFullRoutes(
 	api.HandleFunc("/transactions/month/{month}", transactionHandler.GetTransactionsByMonth).Methods("GET")
 	api.HandleFunc("/transactions/category/{category}", transactionHandler.GetTransactionsByCategory).Methods("GET")
 
+	// Recurring transaction routes
+	api.HandleFunc("/recurring-transactions", recurringHandler.CreateRecurring).Methods("POST")
+	api.HandleFunc("/recurring-transactions", recurringHandler.ListRecurring).Methods("GET")
+	api.HandleFunc("/recurring-transactions/{id}", recurringHandler.GetRecurring).Methods("GET")
+	api.HandleFunc("/recurring-transactions/{id}", recurringHandler.UpdateRecurring).Methods("PUT")
+	api.HandleFunc("/recurring-transactions/{id}", recurringHandler.DeleteRecurring).Methods("DELETE")
+
+	// Import routes
+	api.HandleFunc("/imports/csv", importHandler.ImportCSV).Methods("POST")
+	api.HandleFunc("/imports/ofx", importHandler.ImportOFX).Methods("POST")
+	api.HandleFunc("/imports/cfdi", importHandler.ImportCFDI).Methods("POST")
+	api.HandleFunc("/imports/mappings", importHandler.ListMappings).Methods("GET")
+	api.HandleFunc("/imports/mappings/{name}", importHandler.GetMapping).Methods("GET")
+	api.HandleFunc("/imports/mappings/{name}", importHandler.SaveMapping).Methods("PUT")
+	api.HandleFunc("/imports/mappings/{name}", importHandler.DeleteMapping).Methods("DELETE")
+
+	// Categorization rule routes
+	api.HandleFunc("/rules", ruleHandler.CreateRule).Methods("POST")
+	api.HandleFunc("/rules", ruleHandler.ListRules).Methods("GET")
+	api.HandleFunc("/rules/apply", ruleHandler.ApplyRules).Methods("POST")
+	api.HandleFunc("/rules/{id}", ruleHandler.GetRule).Methods("GET")
+	api.HandleFunc("/rules/{id}", ruleHandler.UpdateRule).Methods("PUT")
+	api.HandleFunc("/rules/{id}", ruleHandler.DeleteRule).Methods("DELETE")
+
+	// Category catalog routes
+	api.HandleFunc("/categories", categoryHandler.CreateCategory).Methods("POST")
+	api.HandleFunc("/categories", categoryHandler.ListCategories).Methods("GET")
+	api.HandleFunc("/categories/{id}", categoryHandler.GetCategory).Methods("GET")
+	api.HandleFunc("/categories/{id}", categoryHandler.UpdateCategory).Methods("PUT")
+	api.HandleFunc("/categories/{id}", categoryHandler.DeleteCategory).Methods("DELETE")
+	api.HandleFunc("/categories/{id}/rename", categoryHandler.RenameCategory).Methods("POST")
+	api.HandleFunc("/categories/{id}/merge", categoryHandler.MergeCategory).Methods("POST")
+
+	// Account and transfer routes
+	api.HandleFunc("/accounts", accountHandler.CreateAccount).Methods("POST")
+	api.HandleFunc("/accounts", accountHandler.ListAccounts).Methods("GET")
+	api.HandleFunc("/accounts/{id}", accountHandler.GetAccount).Methods("GET")
+	api.HandleFunc("/accounts/{id}", accountHandler.UpdateAccount).Methods("PUT")
+	api.HandleFunc("/accounts/{id}", accountHandler.DeleteAccount).Methods("DELETE")
+	api.HandleFunc("/accounts/{id}/ledger", accountHandler.GetLedger).Methods("GET")
+	api.HandleFunc("/accounts/{id}/statements", accountHandler.GetCardStatements).Methods("GET")
+	api.HandleFunc("/transfers", accountHandler.CreateTransfer).Methods("POST")
+	api.HandleFunc("/transfers/{id}", accountHandler.DeleteTransfer).Methods("DELETE")
+
+	// Savings goal routes
+	api.HandleFunc("/goals", goalHandler.CreateGoal).Methods("POST")
+	api.HandleFunc("/goals", goalHandler.ListGoals).Methods("GET")
+	api.HandleFunc("/goals/{id}", goalHandler.GetGoal).Methods("GET")
+	api.HandleFunc("/goals/{id}", goalHandler.UpdateGoal).Methods("PUT")
+	api.HandleFunc("/goals/{id}", goalHandler.DeleteGoal).Methods("DELETE")
+	api.HandleFunc("/goals/{id}/progress", goalHandler.GetProgress).Methods("GET")
+	api.HandleFunc("/goals/{id}/contributions", goalHandler.AddContribution).Methods("POST")
+	api.HandleFunc("/goals/{id}/contributions", goalHandler.ListContributions).Methods("GET")
+	api.HandleFunc("/goals/{id}/contributions/{contributionId}", goalHandler.DeleteContribution).Methods("DELETE")
+
 	// Analytics routes
 	api.HandleFunc("/analytics/summary", analyticsHandler.GetSummary).Methods("GET")
 	api.HandleFunc("/analytics/categories", analyticsHandler.GetCategoryBreakdown).Methods("GET")
+	api.HandleFunc("/analytics/tags", analyticsHandler.GetTagBreakdown).Methods("GET")
 	api.HandleFunc("/analytics/financial-summary", analyticsHandler.GetFinancialSummary).Methods("GET")
 	api.HandleFunc("/analytics/months", analyticsHandler.GetMonthsWithTransactions).Methods("GET")
-
-	// Budget routes
+	api.HandleFunc("/analytics/monthly/{month}", analyticsHandler.GetMonthlyAnalytics).Methods("GET")
+	api.HandleFunc("/analytics/monthly-trends", analyticsHandler.GetMonthlyTrends).Methods("GET")
+	api.HandleFunc("/analytics/subscriptions", analyticsHandler.GetSubscriptions).Methods("GET")
+	api.HandleFunc("/analytics/forecast", analyticsHandler.GetCashFlowForecast).Methods("GET")
+	api.HandleFunc("/analytics/forecast/backtest", analyticsHandler.BacktestForecast).Methods("GET")
+	api.HandleFunc("/analytics/anomalies", analyticsHandler.GetAnomalies).Methods("GET")
+
+	// Budget routes (static segments are registered before {month} captures)
+	api.HandleFunc("/budgets", budgetHandler.ListBudgets).Methods("GET")
 	api.HandleFunc("/budgets", budgetHandler.CreateOrUpdateBudget).Methods("POST")
+	api.HandleFunc("/budgets/bulk", budgetHandler.BulkUpsertBudgets).Methods("POST")
 	api.HandleFunc("/budgets/{month}", budgetHandler.GetBudgetsByMonth).Methods("GET")
+	api.HandleFunc("/budgets/{month}/utilization", budgetHandler.GetBudgetUtilization).Methods("GET")
+	api.HandleFunc("/budgets/{month}/pacing", budgetHandler.GetBudgetPacing).Methods("GET")
+	api.HandleFunc("/budgets/{month}/categories/{category}", budgetHandler.GetBudget).Methods("GET")
+	api.HandleFunc("/budgets/{month}/categories/{category}", budgetHandler.DeleteBudget).Methods("DELETE")
+
+	// User profile routes
+	api.HandleFunc("/users/me", userHandler.GetProfile).Methods("GET")
+	api.HandleFunc("/users/me", userHandler.UpdateProfile).Methods("PUT")
 
 	// AI advice routes
 	api.HandleFunc("/ai/advice", aiHandler.GetAdvice).Methods("POST")
@@ -196,6 +328,40 @@ func setupFullRoutes(
 
 	// Add logging middleware
 	api.Use(loggingMiddleware)
+
+	// Authenticate every request and scope it to the token subject
+	api.Use(authMiddleware(verifier))
+}
+
+func authMiddleware(verifier *auth.Verifier) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// Health checks and CORS preflight requests stay public
+			if r.Method == http.MethodOptions || r.URL.Path == "/api/v1/health" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if verifier == nil {
+				handlers.RespondError(w, models.ErrorCodeUnauthorized, "Authentication is not configured", "")
+				return
+			}
+
+			token, err := auth.BearerToken(r.Header.Get("Authorization"))
+			if err != nil {
+				handlers.RespondError(w, models.ErrorCodeUnauthorized, "Missing or malformed Authorization header", err.Error())
+				return
+			}
+
+			claims, err := verifier.Verify(token)
+			if err != nil {
+				handlers.RespondError(w, models.ErrorCodeUnauthorized, "Invalid or expired token", err.Error())
+				return
+			}
+
+			next.ServeHTTP(w, r.WithContext(auth.WithUserID(r.Context(), claims.Subject)))
+		})
+	}
 }
 
 func loggingMiddleware(next http.Handler) http.Handler {
@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"backend/internal/database"
+	"backend/internal/models"
+)
+
+// migration rewrites existing items in place and returns how many it touched
+type migration func(ctx context.Context, client *dynamodb.Client, tableName string, dryRun bool) (int, error)
+
+var migrations = map[string]migration{
+	"money":    migrateLegacyAmounts,
+	"id-index": backfillIDIndex,
+}
+
+var (
+	task      = flag.String("task", "", "Migration to run: "+strings.Join(migrationNames(), ", "))
+	tableName = flag.String("table", "", "DynamoDB table name (defaults to {TABLE_PREFIX}-transactions)")
+	dryRun    = flag.Bool("dry-run", false, "Report items that would change without writing")
+)
+
+func main() {
+	flag.Parse()
+
+	run, ok := migrations[*task]
+	if !ok {
+		log.Fatalf("Unknown task %q, expected one of: %s", *task, strings.Join(migrationNames(), ", "))
+	}
+
+	ctx := context.Background()
+
+	dbClient, err := database.NewDynamoDBClient(ctx)
+	if err != nil {
+		log.Fatalf("Failed to create DynamoDB client: %v", err)
+	}
+
+	table := *tableName
+	if table == "" {
+		table = dbClient.Config.TablePrefix + "-transactions"
+	}
+
+	log.Printf("Running migration %q on table %s (dry run: %t)", *task, table, *dryRun)
+
+	count, err := run(ctx, dbClient.Client, table, *dryRun)
+	if err != nil {
+		log.Fatalf("Migration %q failed after %d items: %v", *task, count, err)
+	}
+
+	if *dryRun {
+		log.Printf("Dry run complete: %d items would be migrated", count)
+		return
+	}
+	log.Printf("Migration complete: %d items migrated", count)
+}
+
+func migrationNames() []string {
+	names := make([]string, 0, len(migrations))
+	for name := range migrations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// migrateLegacyAmounts converts float64 "amount" numbers written before the
+// fixed-point Money type into the {minor, currency} map. Keys are left untouched,
+// so items written by older ingestion tools (TX# sort keys) are migrated as-is.
+func migrateLegacyAmounts(ctx context.Context, client *dynamodb.Client, tableName string, dryRun bool) (int, error) {
+	migrated := 0
+	var lastKey map[string]types.AttributeValue
+
+	for {
+		result, err := client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:            aws.String(tableName),
+			ProjectionExpression: aws.String("PK, SK, amount"),
+			FilterExpression:     aws.String("attribute_type(amount, :number)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":number": &types.AttributeValueMemberS{Value: "N"},
+			},
+			ExclusiveStartKey: lastKey,
+		})
+		if err != nil {
+			return migrated, fmt.Errorf("failed to scan table: %w", err)
+		}
+
+		for _, item := range result.Items {
+			if !models.IsLegacyAmount(item["amount"]) {
+				continue
+			}
+
+			var amount models.Money
+			if err := amount.UnmarshalDynamoDBAttributeValue(item["amount"]); err != nil {
+				return migrated, fmt.Errorf("item %s/%s: %w", attributeString(item["PK"]), attributeString(item["SK"]), err)
+			}
+
+			if dryRun {
+				log.Printf("  %s/%s: %s -> %d minor units", attributeString(item["PK"]), attributeString(item["SK"]),
+					item["amount"].(*types.AttributeValueMemberN).Value, amount.Minor)
+				migrated++
+				continue
+			}
+
+			amountAV, err := amount.MarshalDynamoDBAttributeValue()
+			if err != nil {
+				return migrated, fmt.Errorf("failed to marshal amount: %w", err)
+			}
+
+			_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+				TableName: aws.String(tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": item["PK"],
+					"SK": item["SK"],
+				},
+				UpdateExpression:    aws.String("SET amount = :amount"),
+				ConditionExpression: aws.String("attribute_type(amount, :number)"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":amount": amountAV,
+					":number": &types.AttributeValueMemberS{Value: "N"},
+				},
+			})
+			if err != nil {
+				var conditionErr *types.ConditionalCheckFailedException
+				if errors.As(err, &conditionErr) {
+					// Rewritten concurrently by the API, nothing left to do
+					continue
+				}
+				return migrated, fmt.Errorf("failed to update item %s/%s: %w", attributeString(item["PK"]), attributeString(item["SK"]), err)
+			}
+			migrated++
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = result.LastEvaluatedKey
+	}
+
+	return migrated, nil
+}
+
+// backfillIDIndex writes the GSI3 keys used to look transactions up by ID.
+// Items written by older ingestion tools with TX# sort keys are not visible to
+// the API at all, so they are re-keyed to the current layout (new item written
+// and old one deleted in a single transaction).
+func backfillIDIndex(ctx context.Context, client *dynamodb.Client, tableName string, dryRun bool) (int, error) {
+	migrated := 0
+	var lastKey map[string]types.AttributeValue
+
+	for {
+		result, err := client.Scan(ctx, &dynamodb.ScanInput{
+			TableName:        aws.String(tableName),
+			FilterExpression: aws.String("(begins_with(SK, :current) AND attribute_not_exists(GSI3PK)) OR begins_with(SK, :legacy)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":current": &types.AttributeValueMemberS{Value: "TRANSACTION#"},
+				":legacy":  &types.AttributeValueMemberS{Value: "TX#"},
+			},
+			ExclusiveStartKey: lastKey,
+		})
+		if err != nil {
+			return migrated, fmt.Errorf("failed to scan table: %w", err)
+		}
+
+		for _, item := range result.Items {
+			pk, sk := attributeString(item["PK"]), attributeString(item["SK"])
+
+			var tx models.Transaction
+			if err := tx.FromDynamoDBItem(item); err != nil {
+				return migrated, fmt.Errorf("item %s/%s: %w", pk, sk, err)
+			}
+			if tx.ID == "" || tx.UserID == "" {
+				log.Printf("  %s/%s: skipped, missing id or user_id", pk, sk)
+				continue
+			}
+			tx.GenerateKeys()
+
+			if dryRun {
+				if tx.SK != sk {
+					log.Printf("  %s/%s: re-key to %s and index as %s", pk, sk, tx.SK, tx.GSI3PK)
+				} else {
+					log.Printf("  %s/%s: index as %s", pk, sk, tx.GSI3PK)
+				}
+				migrated++
+				continue
+			}
+
+			if tx.SK == sk {
+				_, err = client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+					TableName: aws.String(tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": item["PK"],
+						"SK": item["SK"],
+					},
+					UpdateExpression:    aws.String("SET GSI3PK = :gsi3pk, GSI3SK = :gsi3sk"),
+					ConditionExpression: aws.String("attribute_exists(PK)"),
+					ExpressionAttributeValues: map[string]types.AttributeValue{
+						":gsi3pk": &types.AttributeValueMemberS{Value: tx.GSI3PK},
+						":gsi3sk": &types.AttributeValueMemberS{Value: tx.GSI3SK},
+					},
+				})
+			} else {
+				newItem, marshalErr := tx.ToDynamoDBItem()
+				if marshalErr != nil {
+					return migrated, fmt.Errorf("failed to marshal item %s/%s: %w", pk, sk, marshalErr)
+				}
+				_, err = client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+					TransactItems: []types.TransactWriteItem{
+						{
+							Put: &types.Put{
+								TableName:           aws.String(tableName),
+								Item:                newItem,
+								ConditionExpression: aws.String("attribute_not_exists(PK)"),
+							},
+						},
+						{
+							Delete: &types.Delete{
+								TableName: aws.String(tableName),
+								Key: map[string]types.AttributeValue{
+									"PK": item["PK"],
+									"SK": item["SK"],
+								},
+								ConditionExpression: aws.String("attribute_exists(PK)"),
+							},
+						},
+					},
+				})
+			}
+			if err != nil {
+				var conditionErr *types.ConditionalCheckFailedException
+				var canceledErr *types.TransactionCanceledException
+				if errors.As(err, &conditionErr) || errors.As(err, &canceledErr) {
+					// Deleted or re-keyed concurrently, nothing left to do
+					continue
+				}
+				return migrated, fmt.Errorf("failed to update item %s/%s: %w", pk, sk, err)
+			}
+			migrated++
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = result.LastEvaluatedKey
+	}
+
+	return migrated, nil
+}
+
+func attributeString(av types.AttributeValue) string {
+	if s, ok := av.(*types.AttributeValueMemberS); ok {
+		return s.Value
+	}
+	return ""
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/aws/aws-lambda-go/lambda"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+
+	"backend/internal/config"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+)
+
+// The scheduler generates the transactions of every due recurring rule. It runs
+// once and exits (for cron), keeps running with -interval, or serves scheduled
+// Lambda invocations (e.g. an EventBridge rate rule) when deployed to Lambda.
+var interval = flag.Duration("interval", 0, "Run repeatedly with this delay between runs instead of once")
+
+func main() {
+	flag.Parse()
+
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("Failed to load config: %v", err)
+	}
+
+	repo := repository.NewDynamoDBRepository(dynamodb.NewFromConfig(cfg.AWSConfig), cfg.DynamoDBTableName)
+	service := services.NewRecurringService(repo)
+
+	if os.Getenv("AWS_LAMBDA_RUNTIME_API") != "" {
+		lambda.Start(func(ctx context.Context) (*models.RecurringRunResult, error) {
+			return service.MaterializeDue(ctx, time.Now())
+		})
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if *interval > 0 {
+		log.Printf("Recurring scheduler running every %s on table %s", *interval, cfg.DynamoDBTableName)
+		services.RunRecurringScheduler(ctx, service, *interval)
+		return
+	}
+
+	result, err := service.MaterializeDue(ctx, time.Now())
+	if err != nil {
+		log.Fatalf("Recurring scheduler run failed: %v", err)
+	}
+	log.Printf("Processed %d due rules: %d transactions generated, %d already existed, %d failed",
+		result.Rules, result.Generated, result.Duplicates, result.Failed)
+	if result.Failed > 0 {
+		os.Exit(1)
+	}
+}
@@ -37,11 +37,13 @@ type Transaction struct {
 	
 	// DynamoDB keys - compatible with backend patterns
 	PK     string `json:"-" dynamodbav:"PK"`     // USER#{userID}
-	SK     string `json:"-" dynamodbav:"SK"`     // TX#{date}#{id}
+	SK     string `json:"-" dynamodbav:"SK"`     // TRANSACTION#{timestamp}#{id}
 	GSI1PK string `json:"-" dynamodbav:"GSI1PK"` // MONTH#{YYYY-MM}#{userID}
-	GSI1SK string `json:"-" dynamodbav:"GSI1SK"` // TX#{date}#{id}
+	GSI1SK string `json:"-" dynamodbav:"GSI1SK"` // TRANSACTION#{timestamp}
 	GSI2PK string `json:"-" dynamodbav:"GSI2PK"` // CATEGORY#{category}#{userID}
-	GSI2SK string `json:"-" dynamodbav:"GSI2SK"` // TX#{date}#{id}
+	GSI2SK string `json:"-" dynamodbav:"GSI2SK"` // TRANSACTION#{timestamp}
+	GSI3PK string `json:"-" dynamodbav:"GSI3PK"` // TRANSACTION#{id}
+	GSI3SK string `json:"-" dynamodbav:"GSI3SK"` // USER#{userID}
 	
 	// Metadata
 	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
@@ -188,11 +190,13 @@ func transformTransaction(raw RawTransaction, userID string) Transaction {
 
 	// Generate DynamoDB keys using backend patterns
 	transaction.PK = fmt.Sprintf("USER#%s", userID)
-	transaction.SK = fmt.Sprintf("TX#%s#%s", raw.Date, txID)
+	transaction.SK = fmt.Sprintf("TRANSACTION#%d#%s", parsedDate.Unix(), txID)
 	transaction.GSI1PK = fmt.Sprintf("MONTH#%s#%s", yearMonth, userID)
-	transaction.GSI1SK = fmt.Sprintf("TX#%s#%s", raw.Date, txID)
+	transaction.GSI1SK = fmt.Sprintf("TRANSACTION#%d", parsedDate.Unix())
 	transaction.GSI2PK = fmt.Sprintf("CATEGORY#%s#%s", strings.ToUpper(raw.Category), userID)
-	transaction.GSI2SK = fmt.Sprintf("TX#%s#%s", raw.Date, txID)
+	transaction.GSI2SK = fmt.Sprintf("TRANSACTION#%d", parsedDate.Unix())
+	transaction.GSI3PK = fmt.Sprintf("TRANSACTION#%s", txID)
+	transaction.GSI3SK = fmt.Sprintf("USER#%s", userID)
 
 	return transaction
 }
@@ -7,22 +7,24 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 	"time"
 
-	"github.com/google/uuid"
 	"backend/internal/database"
+	"backend/internal/importer"
 	"backend/internal/models"
 	"backend/internal/repository"
 )
 
 // JSONTransaction represents the structure in the JSON file
 type JSONTransaction struct {
-	ID          string  `json:"id"`
-	Date        string  `json:"date"`
-	Amount      float64 `json:"amount"`
-	Description string  `json:"description"`
-	Category    string  `json:"category"`
-	Type        string  `json:"type"`
+	ID          string       `json:"id"`
+	Date        string       `json:"date"`
+	Amount      models.Money `json:"amount"` // parsed exactly from the JSON decimal
+	Currency    string       `json:"currency,omitempty"` // defaults to MXN
+	Description string       `json:"description"`
+	Category    string       `json:"category"`
+	Type        string       `json:"type"`
 }
 
 // SeedData contains the structure from JSON file
@@ -32,6 +34,7 @@ type SeedData struct {
 
 var (
 	filePath    = flag.String("file", "./data/mock_expense_and_income.json", "Path to JSON seed file")
+	ofxPath     = flag.String("ofx", "", "Path to an OFX/QFX bank statement to load instead of the JSON file")
 	version     = flag.String("version", "v1", "Seed version for tracking")
 	userID      = flag.String("user", "default-user", "User ID to assign transactions")
 	dryRun      = flag.Bool("dry-run", false, "Preview transactions without inserting")
@@ -44,7 +47,11 @@ func main() {
 	flag.Parse()
 
 	log.Printf("Starting seed process...")
-	log.Printf("File: %s", *filePath)
+	if *ofxPath != "" {
+		log.Printf("OFX statement: %s", *ofxPath)
+	} else {
+		log.Printf("File: %s", *filePath)
+	}
 	log.Printf("Version: %s", *version)
 	log.Printf("User ID: %s", *userID)
 	log.Printf("Environment: %s", *environment)
@@ -69,13 +76,18 @@ func main() {
 	// Initialize repository
 	repo := repository.NewDynamoDBRepository(dbClient.Client, dbClient.Config.TablePrefix+"-transactions")
 
-	// Load and validate JSON data
-	transactions, err := loadTransactionsFromJSON(*filePath, *userID)
+	// Load and validate the seed data
+	var transactions []models.Transaction
+	if *ofxPath != "" {
+		transactions, err = loadTransactionsFromOFX(*ofxPath, *userID)
+	} else {
+		transactions, err = loadTransactionsFromJSON(*filePath, *userID)
+	}
 	if err != nil {
 		log.Fatalf("Failed to load transactions: %v", err)
 	}
 
-	log.Printf("Loaded %d transactions from JSON", len(transactions))
+	log.Printf("Loaded %d transactions", len(transactions))
 
 	if *dryRun {
 		fmt.Println("\n=== DRY RUN - Preview of transactions to be seeded ===")
@@ -118,6 +130,7 @@ func loadTransactionsFromJSON(filePath, userID string) ([]models.Transaction, er
 	}
 
 	var transactions []models.Transaction
+	occurrences := make(map[string]int)
 	for i, jsonTx := range jsonTransactions {
 		// Parse date
 		date, err := time.Parse("2006-01-02", jsonTx.Date)
@@ -126,18 +139,31 @@ func loadTransactionsFromJSON(filePath, userID string) ([]models.Transaction, er
 			continue
 		}
 
-		// Generate ID if not provided
+		// Without an ID, derive one from the content so seeding the same file
+		// again rewrites its transactions instead of duplicating them.
+		// Identical entries are told apart by their occurrence.
 		id := jsonTx.ID
 		if id == "" {
-			id = uuid.New().String()
+			content := strings.Join([]string{jsonTx.Date, jsonTx.Amount.String(), jsonTx.Currency, jsonTx.Type, jsonTx.Description, jsonTx.Category}, "#")
+			occurrences[content]++
+			id = importer.ExternalTransactionID(userID, "seed", fmt.Sprintf("%s#%d", content, occurrences[content]))
 		}
 
 		// Validate required fields
-		if jsonTx.Amount == 0 {
+		if jsonTx.Amount.IsZero() {
 			log.Printf("Warning: Skipping transaction %d with zero amount", i)
 			continue
 		}
 
+		if jsonTx.Currency != "" {
+			currency, err := models.NormalizeCurrency(jsonTx.Currency)
+			if err != nil {
+				log.Printf("Warning: Skipping transaction %d: %v", i, err)
+				continue
+			}
+			jsonTx.Amount.Currency = currency
+		}
+
 		if jsonTx.Type != "income" && jsonTx.Type != "expense" {
 			log.Printf("Warning: Invalid type '%s' for transaction %d, defaulting to 'expense'", jsonTx.Type, i)
 			jsonTx.Type = "expense"
@@ -164,6 +190,32 @@ func loadTransactionsFromJSON(filePath, userID string) ([]models.Transaction, er
 	return transactions, nil
 }
 
+// loadTransactionsFromOFX reads a bank statement. Transaction IDs come from the
+// statement's FITIDs, so seeding the same statement twice does not duplicate it.
+func loadTransactionsFromOFX(filePath, userID string) ([]models.Transaction, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file %s: %w", filePath, err)
+	}
+	defer file.Close()
+
+	rows, err := importer.ParseOFX(file, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse OFX: %w", err)
+	}
+
+	var transactions []models.Transaction
+	for _, row := range rows {
+		if row.Transaction == nil {
+			log.Printf("Warning: Skipping transaction on line %d: %s", row.Line, strings.Join(row.Errors, "; "))
+			continue
+		}
+		transactions = append(transactions, *row.Transaction)
+	}
+
+	return transactions, nil
+}
+
 func previewTransactions(transactions []models.Transaction) {
 	fmt.Printf("\nTotal transactions: %d\n", len(transactions))
 	
@@ -173,29 +225,30 @@ func previewTransactions(transactions []models.Transaction) {
 		if i >= 5 {
 			break
 		}
-		fmt.Printf("  %d. %s | %s | %s | %.2f | %s\n", 
+		fmt.Printf("  %d. %s | %s | %s | %s | %s\n", 
 			i+1, tx.Date.Format("2006-01-02"), tx.Type, tx.Category, tx.Amount, tx.Description)
 	}
 
 	// Summary statistics
-	var totalIncome, totalExpense float64
+	totalIncome := models.ZeroMoney(models.DefaultCurrency)
+	totalExpense := models.ZeroMoney(models.DefaultCurrency)
 	categoryCount := make(map[string]int)
 	typeCount := make(map[string]int)
 
 	for _, tx := range transactions {
 		if tx.Type == "income" {
-			totalIncome += tx.Amount
+			totalIncome = totalIncome.Add(tx.Amount)
 		} else {
-			totalExpense += tx.Amount
+			totalExpense = totalExpense.Add(tx.Amount)
 		}
 		categoryCount[tx.Category]++
 		typeCount[tx.Type]++
 	}
 
 	fmt.Printf("\nSummary:")
-	fmt.Printf("  Total Income: $%.2f\n", totalIncome)
-	fmt.Printf("  Total Expense: $%.2f\n", totalExpense)
-	fmt.Printf("  Net Balance: $%.2f\n", totalIncome-totalExpense)
+	fmt.Printf("  Total Income: $%s\n", totalIncome)
+	fmt.Printf("  Total Expense: $%s\n", totalExpense)
+	fmt.Printf("  Net Balance: $%s\n", totalIncome.Add(totalExpense))
 	
 	fmt.Printf("\nBy Type:")
 	for txType, count := range typeCount {
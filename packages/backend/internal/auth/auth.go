@@ -0,0 +1,273 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"backend/internal/config"
+)
+
+var (
+	ErrMissingToken  = errors.New("missing bearer token")
+	ErrInvalidToken  = errors.New("invalid token")
+	ErrExpiredToken  = errors.New("token has expired")
+	ErrNotConfigured = errors.New("authentication is not configured")
+)
+
+// clockSkew is the tolerance applied to exp/nbf checks
+const clockSkew = 30 * time.Second
+
+// Claims holds the registered JWT claims the API relies on
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Issuer    string   `json:"iss,omitempty"`
+	Audience  Audience `json:"aud,omitempty"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+	IssuedAt  int64    `json:"iat,omitempty"`
+}
+
+// Audience accepts both the string and array forms of the "aud" claim
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*a = Audience{single}
+		return nil
+	}
+
+	var multiple []string
+	if err := json.Unmarshal(data, &multiple); err != nil {
+		return fmt.Errorf("aud must be a string or an array of strings")
+	}
+	*a = multiple
+	return nil
+}
+
+func (a Audience) contains(audience string) bool {
+	for _, value := range a {
+		if value == audience {
+			return true
+		}
+	}
+	return false
+}
+
+type header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// Verifier validates HS256 and RS256 bearer tokens
+type Verifier struct {
+	secret   []byte
+	keys     map[string]*rsa.PublicKey
+	issuer   string
+	audience string
+	now      func() time.Time
+}
+
+// NewVerifier builds a verifier from the HS256 secret and/or JWKS file in the config
+func NewVerifier(cfg *config.Config) (*Verifier, error) {
+	if cfg.JWTSecret == "" && cfg.JWKSFile == "" {
+		return nil, ErrNotConfigured
+	}
+
+	v := &Verifier{
+		issuer:   cfg.JWTIssuer,
+		audience: cfg.JWTAudience,
+		now:      time.Now,
+	}
+	if cfg.JWTSecret != "" {
+		v.secret = []byte(cfg.JWTSecret)
+	}
+	if cfg.JWKSFile != "" {
+		keys, err := LoadJWKS(cfg.JWKSFile)
+		if err != nil {
+			return nil, err
+		}
+		v.keys = keys
+	}
+
+	return v, nil
+}
+
+// Verify checks the token signature and registered claims and returns its claims
+func (v *Verifier) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: malformed token", ErrInvalidToken)
+	}
+
+	var hdr header
+	if err := decodeSegment(parts[0], &hdr); err != nil {
+		return nil, fmt.Errorf("%w: bad header: %v", ErrInvalidToken, err)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: bad signature encoding", ErrInvalidToken)
+	}
+
+	signingInput := parts[0] + "." + parts[1]
+	if err := v.verifySignature(hdr, signingInput, signature); err != nil {
+		return nil, err
+	}
+
+	var claims Claims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("%w: bad claims: %v", ErrInvalidToken, err)
+	}
+
+	if err := v.validateClaims(&claims); err != nil {
+		return nil, err
+	}
+
+	return &claims, nil
+}
+
+func (v *Verifier) verifySignature(hdr header, signingInput string, signature []byte) error {
+	switch hdr.Algorithm {
+	case "HS256":
+		if len(v.secret) == 0 {
+			return fmt.Errorf("%w: HS256 tokens are not accepted", ErrInvalidToken)
+		}
+		mac := hmac.New(sha256.New, v.secret)
+		mac.Write([]byte(signingInput))
+		if !hmac.Equal(signature, mac.Sum(nil)) {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		return nil
+	case "RS256":
+		key, err := v.publicKey(hdr.KeyID)
+		if err != nil {
+			return err
+		}
+		digest := sha256.Sum256([]byte(signingInput))
+		if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+			return fmt.Errorf("%w: signature mismatch", ErrInvalidToken)
+		}
+		return nil
+	default:
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, hdr.Algorithm)
+	}
+}
+
+func (v *Verifier) publicKey(keyID string) (*rsa.PublicKey, error) {
+	if len(v.keys) == 0 {
+		return nil, fmt.Errorf("%w: RS256 tokens are not accepted", ErrInvalidToken)
+	}
+	if keyID == "" {
+		// Tokens without a kid are only unambiguous when a single key is configured
+		if len(v.keys) == 1 {
+			for _, key := range v.keys {
+				return key, nil
+			}
+		}
+		return nil, fmt.Errorf("%w: token has no kid", ErrInvalidToken)
+	}
+
+	key, ok := v.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown signing key %q", ErrInvalidToken, keyID)
+	}
+	return key, nil
+}
+
+func (v *Verifier) validateClaims(claims *Claims) error {
+	now := v.now()
+
+	if claims.Subject == "" {
+		return fmt.Errorf("%w: sub claim is required", ErrInvalidToken)
+	}
+	if claims.ExpiresAt == 0 {
+		return fmt.Errorf("%w: exp claim is required", ErrInvalidToken)
+	}
+	if now.After(time.Unix(claims.ExpiresAt, 0).Add(clockSkew)) {
+		return ErrExpiredToken
+	}
+	if claims.NotBefore != 0 && now.Add(clockSkew).Before(time.Unix(claims.NotBefore, 0)) {
+		return fmt.Errorf("%w: token is not valid yet", ErrInvalidToken)
+	}
+	if v.issuer != "" && claims.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer", ErrInvalidToken)
+	}
+	if v.audience != "" && !claims.Audience.contains(v.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+// BearerToken extracts the token from an Authorization header value
+func BearerToken(authorization string) (string, error) {
+	if authorization == "" {
+		return "", ErrMissingToken
+	}
+
+	scheme, token, found := strings.Cut(strings.TrimSpace(authorization), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") || strings.TrimSpace(token) == "" {
+		return "", fmt.Errorf("%w: expected 'Bearer <token>'", ErrInvalidToken)
+	}
+
+	return strings.TrimSpace(token), nil
+}
+
+// NewHS256Token signs the claims with the shared secret. It is meant for local
+// development and tests; production tokens come from the identity provider.
+func NewHS256Token(claims Claims, secret string) (string, error) {
+	hdr, err := encodeSegment(header{Algorithm: "HS256", Type: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := encodeSegment(claims)
+	if err != nil {
+		return "", err
+	}
+
+	signingInput := hdr + "." + payload
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(signingInput))
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func decodeSegment(segment string, out interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, out)
+}
+
+func encodeSegment(in interface{}) (string, error) {
+	data, err := json.Marshal(in)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+type contextKey struct{}
+
+// WithUserID returns a copy of ctx carrying the authenticated user ID
+func WithUserID(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, contextKey{}, userID)
+}
+
+// UserIDFromContext returns the authenticated user ID stored in ctx
+func UserIDFromContext(ctx context.Context) (string, bool) {
+	userID, ok := ctx.Value(contextKey{}).(string)
+	return userID, ok && userID != ""
+}
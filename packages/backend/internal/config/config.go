@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -28,6 +29,21 @@ type Config struct {
 	AIModel         string
 	AIBaseURL       string
 	
+	// Authentication
+	JWTSecret   string // HS256 shared secret
+	JWKSFile    string // Path to a JWKS file with RS256 public keys
+	JWTIssuer   string
+	JWTAudience string
+	
+	// Pagination
+	CursorSecret string // HMAC key for page cursors, defaults to JWTSecret
+	
+	// Currency conversion
+	ExchangeRatesFile string // CSV or JSON file with daily exchange rates
+	
+	// Recurring transactions
+	RecurringInterval time.Duration // Time between in-process scheduler runs, 0 disables them
+	
 	// CORS
 	CORSOrigins []string
 	
@@ -50,6 +66,13 @@ func Load() (*Config, error) {
 		AIProvider:        getEnv("AI_PROVIDER", "groq"),
 		OpenAIAPIKeySSM:   getEnv("OPENAI_API_KEY_SSM", "/stori/dev/openai-api-key"),
 		GroqAPIKey:        getEnv("GROQ_API_KEY", ""),
+		JWTSecret:         getEnv("JWT_SECRET", ""),
+		JWKSFile:          getEnv("JWKS_FILE", ""),
+		JWTIssuer:         getEnv("JWT_ISSUER", ""),
+		JWTAudience:       getEnv("JWT_AUDIENCE", ""),
+		ExchangeRatesFile: getEnv("EXCHANGE_RATES_FILE", ""),
+		CursorSecret:      getEnv("CURSOR_SECRET", getEnv("JWT_SECRET", "")),
+		RecurringInterval: getDurationEnv("RECURRING_SCHEDULER_INTERVAL", time.Hour),
 		CORSOrigins:       []string{
 			getEnv("FRONTEND_URL", "http://localhost:3000"),
 		},
@@ -97,6 +120,21 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+// getDurationEnv parses a Go duration such as "15m", falling back to the
+// default when the variable is unset or invalid
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		fmt.Printf("Warning: invalid %s %q, using %s\n", key, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
 func getSSMParameter(cfg aws.Config, parameterName string) (string, error) {
 	ssmClient := ssm.NewFromConfig(cfg)
 	
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
@@ -18,6 +19,10 @@ const (
 	UsersTable        = "users"
 )
 
+// TimeToLiveAttribute holds the Unix time after which DynamoDB deletes an item
+// of the transactions table, such as an idempotency key
+const TimeToLiveAttribute = "expires_at"
+
 type DynamoDBClient struct {
 	Client *dynamodb.Client
 	Config Config
@@ -89,6 +94,11 @@ func (db *DynamoDBClient) CreateTablesIfNotExist(ctx context.Context) error {
 		return fmt.Errorf("failed to create transactions table: %w", err)
 	}
 
+	err = db.enableTimeToLive(ctx, db.getTableName(TransactionsTable))
+	if err != nil {
+		return fmt.Errorf("failed to enable TTL on transactions table: %w", err)
+	}
+
 	// Create users table
 	err = db.createUsersTable(ctx)
 	if err != nil {
@@ -148,6 +158,14 @@ func (db *DynamoDBClient) createTransactionsTable(ctx context.Context) error {
 				AttributeName: aws.String("GSI2SK"),
 				AttributeType: types.ScalarAttributeTypeS,
 			},
+			{
+				AttributeName: aws.String("GSI3PK"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
+			{
+				AttributeName: aws.String("GSI3SK"),
+				AttributeType: types.ScalarAttributeTypeS,
+			},
 		},
 		GlobalSecondaryIndexes: []types.GlobalSecondaryIndex{
 			{
@@ -190,6 +208,27 @@ func (db *DynamoDBClient) createTransactionsTable(ctx context.Context) error {
 					WriteCapacityUnits: aws.Int64(5),
 				},
 			},
+			{
+				// Single-transaction lookups by ID, scoped to the owner
+				IndexName: aws.String("GSI3"),
+				KeySchema: []types.KeySchemaElement{
+					{
+						AttributeName: aws.String("GSI3PK"),
+						KeyType:       types.KeyTypeHash,
+					},
+					{
+						AttributeName: aws.String("GSI3SK"),
+						KeyType:       types.KeyTypeRange,
+					},
+				},
+				Projection: &types.Projection{
+					ProjectionType: types.ProjectionTypeAll,
+				},
+				ProvisionedThroughput: &types.ProvisionedThroughput{
+					ReadCapacityUnits:  aws.Int64(5),
+					WriteCapacityUnits: aws.Int64(5),
+				},
+			},
 		},
 		ProvisionedThroughput: &types.ProvisionedThroughput{
 			ReadCapacityUnits:  aws.Int64(10),
@@ -206,6 +245,39 @@ func (db *DynamoDBClient) createTransactionsTable(ctx context.Context) error {
 	return nil
 }
 
+// enableTimeToLive turns on expiry by TimeToLiveAttribute once the table is
+// active, unless it is already on
+func (db *DynamoDBClient) enableTimeToLive(ctx context.Context, tableName string) error {
+	ttl, err := db.Client.DescribeTimeToLive(ctx, &dynamodb.DescribeTimeToLiveInput{
+		TableName: aws.String(tableName),
+	})
+	if err == nil && ttl.TimeToLiveDescription != nil {
+		switch ttl.TimeToLiveDescription.TimeToLiveStatus {
+		case types.TimeToLiveStatusEnabled, types.TimeToLiveStatusEnabling:
+			return nil
+		}
+	}
+
+	waiter := dynamodb.NewTableExistsWaiter(db.Client)
+	if err := waiter.Wait(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)}, 2*time.Minute); err != nil {
+		return fmt.Errorf("table %s is not active: %w", tableName, err)
+	}
+
+	_, err = db.Client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+		TableName: aws.String(tableName),
+		TimeToLiveSpecification: &types.TimeToLiveSpecification{
+			AttributeName: aws.String(TimeToLiveAttribute),
+			Enabled:       aws.Bool(true),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	log.Printf("TTL enabled on %s.%s", tableName, TimeToLiveAttribute)
+	return nil
+}
+
 func (db *DynamoDBClient) createUsersTable(ctx context.Context) error {
 	tableName := db.getTableName(UsersTable)
 
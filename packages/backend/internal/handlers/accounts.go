@@ -0,0 +1,244 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type AccountHandler struct {
+	service services.AccountService
+}
+
+func NewAccountHandler(service services.AccountService) *AccountHandler {
+	return &AccountHandler{
+		service: service,
+	}
+}
+
+// ListAccounts handles GET /accounts
+func (h *AccountHandler) ListAccounts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	accounts, err := h.service.ListAccounts(r.Context(), userID)
+	if err != nil {
+		respondAccountError(w, "Failed to list accounts", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(accounts, &models.APIMeta{Total: len(accounts)}))
+}
+
+// CreateAccount handles POST /accounts
+func (h *AccountHandler) CreateAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	account, ok := decodeAccount(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.service.CreateAccount(r.Context(), account); err != nil {
+		respondAccountError(w, "Failed to create account", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(account, nil))
+}
+
+// GetAccount handles GET /accounts/{id}
+func (h *AccountHandler) GetAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	account, err := h.service.GetAccount(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		respondAccountError(w, "Failed to get account", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(account, nil))
+}
+
+// UpdateAccount handles PUT /accounts/{id}
+func (h *AccountHandler) UpdateAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	account, ok := decodeAccount(w, r, userID)
+	if !ok {
+		return
+	}
+	account.ID = mux.Vars(r)["id"]
+
+	if err := h.service.UpdateAccount(r.Context(), account); err != nil {
+		respondAccountError(w, "Failed to update account", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(account, nil))
+}
+
+// DeleteAccount handles DELETE /accounts/{id}
+func (h *AccountHandler) DeleteAccount(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteAccount(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		respondAccountError(w, "Failed to delete account", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetLedger handles GET /accounts/{id}/ledger, the account's transactions in
+// the period with the running balance
+func (h *AccountHandler) GetLedger(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rng, ok := parsePeriod(w, r)
+	if !ok {
+		return
+	}
+
+	ledger, err := h.service.GetLedger(r.Context(), userID, mux.Vars(r)["id"], rng)
+	if err != nil {
+		respondAccountError(w, "Failed to get ledger", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    ledger,
+		"period":  newPeriodInfo(rng),
+	})
+}
+
+// GetCardStatements handles GET /accounts/{id}/statements?cycles=N, the
+// credit card's last billing cycles with the one in progress first
+func (h *AccountHandler) GetCardStatements(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	cycles := 0
+	if value := r.URL.Query().Get("cycles"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			RespondError(w, models.ErrorCodeValidation, "Invalid cycles", err.Error())
+			return
+		}
+		cycles = n
+	}
+
+	statements, err := h.service.GetCardStatements(r.Context(), userID, mux.Vars(r)["id"], cycles, time.Now())
+	if err != nil {
+		respondAccountError(w, "Failed to get statements", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(statements, nil))
+}
+
+// CreateTransfer handles POST /transfers
+func (h *AccountHandler) CreateTransfer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var in models.TransferInput
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	transfer, err := h.service.CreateTransfer(r.Context(), userID, in)
+	if err != nil {
+		respondAccountError(w, "Failed to create transfer", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(transfer, nil))
+}
+
+// DeleteTransfer handles DELETE /transfers/{id}, deleting both entries
+func (h *AccountHandler) DeleteTransfer(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteTransfer(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		respondAccountError(w, "Failed to delete transfer", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeAccount reads an account from the body and scopes it to the user
+func decodeAccount(w http.ResponseWriter, r *http.Request, userID string) (*models.Account, bool) {
+	account := &models.Account{}
+	if err := json.NewDecoder(r.Body).Decode(account); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid request body", err.Error())
+		return nil, false
+	}
+	if !checkUserID(w, userID, account.UserID) {
+		return nil, false
+	}
+	account.UserID = userID
+	return account, true
+}
+
+// respondAccountError maps invalid accounts and transfers, and statements of
+// accounts that are not credit cards, to 400, missing ones to 404 and
+// accounts in use and concurrent modifications to 409
+func respondAccountError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAccount), errors.Is(err, services.ErrInvalidTransfer),
+		errors.Is(err, services.ErrNotCreditCard):
+		RespondError(w, models.ErrorCodeValidation, message, err.Error())
+	case errors.Is(err, repository.ErrAccountNotFound), errors.Is(err, repository.ErrTransactionNotFound):
+		RespondError(w, models.ErrorCodeNotFound, message, err.Error())
+	case errors.Is(err, services.ErrAccountInUse), errors.Is(err, repository.ErrAccountConflict),
+		errors.Is(err, repository.ErrTransactionExists):
+		RespondError(w, models.ErrorCodeConflict, message, err.Error())
+	default:
+		RespondError(w, models.ErrorCodeInternalServer, message, err.Error())
+	}
+}
@@ -26,6 +26,10 @@ func (h *AIHandler) GetAdvice(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if _, ok := requireUserID(w, r); !ok {
+		return
+	}
+
 	var adviceRequest models.AIAdviceRequest
 	
 	if err := json.NewDecoder(r.Body).Decode(&adviceRequest); err != nil {
@@ -63,9 +67,8 @@ func (h *AIHandler) GetPersonalizedAdvice(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "user_id parameter is required", http.StatusBadRequest)
+	userID, ok := requireUserID(w, r)
+	if !ok {
 		return
 	}
 
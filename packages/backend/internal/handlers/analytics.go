@@ -2,9 +2,13 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+	"strconv"
 	"time"
 
+	"backend/internal/models"
+	"backend/internal/period"
 	"backend/internal/services"
 
 	"github.com/gorilla/mux"
@@ -21,9 +25,8 @@ func NewAnalyticsHandler(service services.AnalyticsService) *AnalyticsHandler {
 }
 
 func (h *AnalyticsHandler) GetSummary(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+	userID, ok := requireUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -63,9 +66,8 @@ func (h *AnalyticsHandler) GetMonthlyAnalytics(w http.ResponseWriter, r *http.Re
 	vars := mux.Vars(r)
 	month := vars["month"]
 	
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+	userID, ok := requireUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -74,20 +76,103 @@ func (h *AnalyticsHandler) GetMonthlyAnalytics(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	analytics, err := h.service.GetMonthlyAnalytics(r.Context(), userID, month)
+	point, err := h.service.GetMonthTrend(r.Context(), userID, month)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		respondTrendsError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    point,
+	})
+}
+
+// GetMonthlyTrends returns a month-by-month series over the months the period
+// parameters overlap, or over the last months ending in the current one.
+func (h *AnalyticsHandler) GetMonthlyTrends(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	from, to, ok := parseTrendMonths(w, r)
+	if !ok {
+		return
+	}
+
+	trends, err := h.service.GetMonthlyTrends(r.Context(), userID, from, to)
+	if err != nil {
+		respondTrendsError(w, err)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(analytics)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    trends,
+	})
+}
+
+// parseTrendMonths resolves the first and last month (YYYY-MM) of a trend
+// series. The period, from and to parameters go through the shared parser,
+// with from/to also accepted as whole months and to defaulting to today;
+// without them the series covers the last `months` (12 by default).
+func parseTrendMonths(w http.ResponseWriter, r *http.Request) (string, string, bool) {
+	query := r.URL.Query()
+	now := time.Now().UTC()
+
+	name, from, to := query.Get("period"), query.Get("from"), query.Get("to")
+	if name == "" && from == "" && to == "" {
+		months := 12
+		if monthsStr := query.Get("months"); monthsStr != "" {
+			m, err := strconv.Atoi(monthsStr)
+			if err != nil || m < 1 || m > services.MaxTrendMonths {
+				RespondError(w, models.ErrorCodeValidation, "Invalid months", "months must be between 1 and "+strconv.Itoa(services.MaxTrendMonths))
+				return "", "", false
+			}
+			months = m
+		}
+		end := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return end.AddDate(0, -(months - 1), 0).Format("2006-01"), end.Format("2006-01"), true
+	}
+	if query.Get("months") != "" {
+		RespondError(w, models.ErrorCodeValidation, "Invalid months", "months cannot be combined with period, from or to")
+		return "", "", false
+	}
+
+	if from != "" && to == "" {
+		to = now.Format("2006-01-02")
+	}
+	from, to = period.WholeMonths(from, to)
+	rng, err := period.Parse(name, from, to, now)
+	if err != nil {
+		RespondError(w, models.ErrorCodeValidation, "Invalid period", err.Error())
+		return "", "", false
+	}
+	months := rng.Months()
+	return months[0], months[len(months)-1], true
+}
+
+func respondTrendsError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrInvalidRange) {
+		RespondError(w, models.ErrorCodeValidation, "Invalid month range", err.Error())
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
 }
 
 func (h *AnalyticsHandler) GetCategoryBreakdown(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	// Labels come from the user's category catalog in the requested language
+	language := r.URL.Query().Get("lang")
+	if !models.ValidLanguage(language) {
+		RespondError(w, models.ErrorCodeValidation, "Invalid lang", "lang must be es or en")
 		return
 	}
 
@@ -95,7 +180,7 @@ func (h *AnalyticsHandler) GetCategoryBreakdown(w http.ResponseWriter, r *http.R
 	simple := r.URL.Query().Get("simple")
 	if simple == "true" {
 		// Return category options with label and value
-		categoryOptions, err := h.service.GetCategoryOptions(r.Context(), userID)
+		categoryOptions, err := h.service.GetCategoryOptions(r.Context(), userID, language)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
@@ -110,12 +195,21 @@ func (h *AnalyticsHandler) GetCategoryBreakdown(w http.ResponseWriter, r *http.R
 	}
 
 	// Return full breakdown with amounts and percentages
-	period := r.URL.Query().Get("period")
-	if period == "" {
-		period = "month"
+	rng, ok := parsePeriod(w, r)
+	if !ok {
+		return
 	}
 
-	breakdown, err := h.service.GetCategoryBreakdown(r.Context(), userID, period)
+	rollUp, err := formBool(r, "rollup", false)
+	if err != nil {
+		RespondError(w, models.ErrorCodeValidation, "Invalid rollup", err.Error())
+		return
+	}
+
+	breakdown, err := h.service.GetCategoryBreakdown(r.Context(), userID, rng, services.BreakdownOptions{
+		RollUp:   rollUp,
+		Language: language,
+	})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
@@ -125,14 +219,68 @@ func (h *AnalyticsHandler) GetCategoryBreakdown(w http.ResponseWriter, r *http.R
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
 		"data":    breakdown,
+		"period":  newPeriodInfo(rng),
 	})
 }
 
+// GetTagBreakdown handles GET /analytics/tags with the same period
+// parameters as the category breakdown
+func (h *AnalyticsHandler) GetTagBreakdown(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rng, ok := parsePeriod(w, r)
+	if !ok {
+		return
+	}
+
+	breakdown, err := h.service.GetTagBreakdown(r.Context(), userID, rng)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    breakdown,
+		"period":  newPeriodInfo(rng),
+	})
+}
+
+// periodInfo echoes the resolved period; both dates are inclusive
+type periodInfo struct {
+	Name string `json:"name"`
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func newPeriodInfo(rng period.Range) periodInfo {
+	return periodInfo{
+		Name: rng.Name,
+		From: rng.Start.Format("2006-01-02"),
+		To:   rng.Last().Format("2006-01-02"),
+	}
+}
+
+// parsePeriod resolves the period, from and to query parameters shared by the
+// analytics endpoints, writing a 400 response when they are invalid
+func parsePeriod(w http.ResponseWriter, r *http.Request) (period.Range, bool) {
+	query := r.URL.Query()
+	rng, err := period.Parse(query.Get("period"), query.Get("from"), query.Get("to"), time.Now())
+	if err != nil {
+		RespondError(w, models.ErrorCodeValidation, "Invalid period", err.Error())
+		return period.Range{}, false
+	}
+	return rng, true
+}
+
 // GetFinancialSummary returns comprehensive financial summary for dashboard
 func (h *AnalyticsHandler) GetFinancialSummary(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+	userID, ok := requireUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -149,11 +297,125 @@ func (h *AnalyticsHandler) GetFinancialSummary(w http.ResponseWriter, r *http.Re
 	})
 }
 
+// GetSubscriptions returns the recurring charges detected in the user's history
+func (h *AnalyticsHandler) GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	subscriptions, err := h.service.DetectSubscriptions(r.Context(), userID)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    subscriptions,
+	})
+}
+
+// GetCashFlowForecast handles GET /analytics/forecast?days=N, the projected
+// daily balance over the next days (30 by default, up to 90)
+func (h *AnalyticsHandler) GetCashFlowForecast(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	days, ok := parseCount(w, r, "days")
+	if !ok {
+		return
+	}
+
+	forecast, err := h.service.GetCashFlowForecast(r.Context(), userID, days, time.Now())
+	if err != nil {
+		respondForecastError(w, "Failed to forecast cash flow", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(forecast, nil))
+}
+
+// BacktestForecast handles GET /analytics/forecast/backtest?months=N, the
+// forecast of each of the last complete months scored against what happened
+func (h *AnalyticsHandler) BacktestForecast(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	months, ok := parseCount(w, r, "months")
+	if !ok {
+		return
+	}
+
+	backtest, err := h.service.BacktestForecast(r.Context(), userID, months, time.Now())
+	if err != nil {
+		respondForecastError(w, "Failed to backtest forecast", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(backtest, nil))
+}
+
+// parseCount reads an optional integer query parameter, 0 when absent
+func parseCount(w http.ResponseWriter, r *http.Request, name string) (int, bool) {
+	value := r.URL.Query().Get(name)
+	if value == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		RespondError(w, models.ErrorCodeValidation, "Invalid "+name, err.Error())
+		return 0, false
+	}
+	return n, true
+}
+
+func respondForecastError(w http.ResponseWriter, message string, err error) {
+	if errors.Is(err, services.ErrInvalidForecast) {
+		RespondError(w, models.ErrorCodeValidation, message, err.Error())
+		return
+	}
+	RespondError(w, models.ErrorCodeInternalServer, message, err.Error())
+}
+
+// GetAnomalies flags unusually large expenses and category spikes in the
+// last months, the current one included
+func (h *AnalyticsHandler) GetAnomalies(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	months, ok := parseCount(w, r, "months")
+	if !ok {
+		return
+	}
+
+	anomalies, err := h.service.DetectAnomalies(r.Context(), userID, months, time.Now())
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidRange) {
+			RespondError(w, models.ErrorCodeValidation, "Invalid month range", err.Error())
+			return
+		}
+		RespondError(w, models.ErrorCodeInternalServer, "Failed to detect anomalies", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(anomalies, nil))
+}
+
 // GetMonthsWithTransactions returns list of months that have transactions
 func (h *AnalyticsHandler) GetMonthsWithTransactions(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+	userID, ok := requireUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -0,0 +1,34 @@
+package handlers
+
+import (
+	"net/http"
+
+	"backend/internal/auth"
+	"backend/internal/models"
+)
+
+// requireUserID returns the authenticated user for the request. A user_id query
+// parameter is still accepted for backward compatibility, but it must match the
+// token subject.
+func requireUserID(w http.ResponseWriter, r *http.Request) (string, bool) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		RespondError(w, models.ErrorCodeUnauthorized, "Authentication required", "")
+		return "", false
+	}
+
+	if !checkUserID(w, userID, r.URL.Query().Get("user_id")) {
+		return "", false
+	}
+
+	return userID, true
+}
+
+// checkUserID rejects a client-supplied user ID that differs from the authenticated one
+func checkUserID(w http.ResponseWriter, userID, requested string) bool {
+	if requested != "" && requested != userID {
+		RespondError(w, models.ErrorCodeForbidden, "Access to another user's data is not allowed", "")
+		return false
+	}
+	return true
+}
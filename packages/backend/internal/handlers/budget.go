@@ -2,10 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
+	"time"
 
+	"backend/internal/models"
+	"backend/internal/repository"
 	"backend/internal/services"
 
 	"github.com/gorilla/mux"
@@ -23,15 +27,17 @@ func NewBudgetHandler(budgetService *services.BudgetService) *BudgetHandler {
 
 // CreateOrUpdateBudgetRequest represents the request body for budget creation/update
 type CreateOrUpdateBudgetRequest struct {
-	Month    string  `json:"month"`    // Format: YYYY-MM
-	Category string  `json:"category"`
-	Amount   float64 `json:"amount"`
+	Month    string       `json:"month"`    // Format: YYYY-MM
+	Category string       `json:"category"`
+	Amount   models.Money `json:"amount"`
 }
 
 // CreateOrUpdateBudget handles POST /budgets requests
 func (h *BudgetHandler) CreateOrUpdateBudget(w http.ResponseWriter, r *http.Request) {
-	// For this demo, we'll use a hardcoded user ID
-	userID := "user-123"
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
 
 	var req CreateOrUpdateBudgetRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -49,7 +55,7 @@ func (h *BudgetHandler) CreateOrUpdateBudget(w http.ResponseWriter, r *http.Requ
 		http.Error(w, "Category is required", http.StatusBadRequest)
 		return
 	}
-	if req.Amount < 0 {
+	if req.Amount.IsNegative() {
 		http.Error(w, "Amount cannot be negative", http.StatusBadRequest)
 		return
 	}
@@ -58,7 +64,7 @@ func (h *BudgetHandler) CreateOrUpdateBudget(w http.ResponseWriter, r *http.Requ
 	err := h.budgetService.CreateOrUpdateBudget(r.Context(), userID, req.Month, req.Category, req.Amount)
 	if err != nil {
 		log.Printf("Error creating/updating budget: %v", err)
-		http.Error(w, fmt.Sprintf("Failed to create/update budget: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to create/update budget: %v", err), budgetErrorStatus(err))
 		return
 	}
 
@@ -78,7 +84,10 @@ func (h *BudgetHandler) CreateOrUpdateBudget(w http.ResponseWriter, r *http.Requ
 
 // GetBudgetsByMonth handles GET /budgets/{month} requests
 func (h *BudgetHandler) GetBudgetsByMonth(w http.ResponseWriter, r *http.Request) {
-	userID := "user-123"
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
 	
 	vars := mux.Vars(r)
 	month := vars["month"]
@@ -91,7 +100,7 @@ func (h *BudgetHandler) GetBudgetsByMonth(w http.ResponseWriter, r *http.Request
 	budgets, err := h.budgetService.GetBudgetsByMonth(r.Context(), userID, month)
 	if err != nil {
 		log.Printf("Error getting budgets for month %s: %v", month, err)
-		http.Error(w, fmt.Sprintf("Failed to get budgets: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to get budgets: %v", err), budgetErrorStatus(err))
 		return
 	}
 
@@ -99,9 +108,12 @@ func (h *BudgetHandler) GetBudgetsByMonth(w http.ResponseWriter, r *http.Request
 	json.NewEncoder(w).Encode(budgets)
 }
 
-// GetBudget handles GET /budgets/{month}/{category} requests
+// GetBudget handles GET /budgets/{month}/categories/{category} requests
 func (h *BudgetHandler) GetBudget(w http.ResponseWriter, r *http.Request) {
-	userID := "user-123"
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
 	
 	vars := mux.Vars(r)
 	month := vars["month"]
@@ -115,7 +127,7 @@ func (h *BudgetHandler) GetBudget(w http.ResponseWriter, r *http.Request) {
 	budget, err := h.budgetService.GetBudget(r.Context(), userID, month, category)
 	if err != nil {
 		log.Printf("Error getting budget for month %s, category %s: %v", month, category, err)
-		http.Error(w, fmt.Sprintf("Failed to get budget: %v", err), http.StatusNotFound)
+		http.Error(w, fmt.Sprintf("Failed to get budget: %v", err), budgetErrorStatus(err))
 		return
 	}
 
@@ -123,9 +135,12 @@ func (h *BudgetHandler) GetBudget(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(budget)
 }
 
-// DeleteBudget handles DELETE /budgets/{month}/{category} requests
+// DeleteBudget handles DELETE /budgets/{month}/categories/{category} requests
 func (h *BudgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
-	userID := "user-123"
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
 	
 	vars := mux.Vars(r)
 	month := vars["month"]
@@ -139,7 +154,7 @@ func (h *BudgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
 	err := h.budgetService.DeleteBudget(r.Context(), userID, month, category)
 	if err != nil {
 		log.Printf("Error deleting budget for month %s, category %s: %v", month, category, err)
-		http.Error(w, fmt.Sprintf("Failed to delete budget: %v", err), http.StatusNotFound)
+		http.Error(w, fmt.Sprintf("Failed to delete budget: %v", err), budgetErrorStatus(err))
 		return
 	}
 
@@ -155,7 +170,10 @@ func (h *BudgetHandler) DeleteBudget(w http.ResponseWriter, r *http.Request) {
 
 // GetBudgetUtilization handles GET /budgets/{month}/utilization requests
 func (h *BudgetHandler) GetBudgetUtilization(w http.ResponseWriter, r *http.Request) {
-	userID := "user-123"
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
 	
 	vars := mux.Vars(r)
 	month := vars["month"]
@@ -168,10 +186,112 @@ func (h *BudgetHandler) GetBudgetUtilization(w http.ResponseWriter, r *http.Requ
 	utilization, err := h.budgetService.GetBudgetUtilization(r.Context(), userID, month)
 	if err != nil {
 		log.Printf("Error getting budget utilization for month %s: %v", month, err)
-		http.Error(w, fmt.Sprintf("Failed to get budget utilization: %v", err), http.StatusInternalServerError)
+		http.Error(w, fmt.Sprintf("Failed to get budget utilization: %v", err), budgetErrorStatus(err))
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(utilization)
 }
+
+// GetBudgetPacing handles GET /budgets/{month}/pacing requests
+func (h *BudgetHandler) GetBudgetPacing(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	month := mux.Vars(r)["month"]
+	if month == "" {
+		http.Error(w, "Month parameter is required", http.StatusBadRequest)
+		return
+	}
+
+	pacing, err := h.budgetService.GetBudgetPacing(r.Context(), userID, month, time.Now())
+	if err != nil {
+		log.Printf("Error getting budget pacing for month %s: %v", month, err)
+		http.Error(w, fmt.Sprintf("Failed to get budget pacing: %v", err), budgetErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(pacing)
+}
+
+// ListBudgets handles GET /budgets?from=YYYY-MM&to=YYYY-MM requests. Both
+// bounds are inclusive; "to" defaults to "from" and "from" to the current month.
+func (h *BudgetHandler) ListBudgets(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	from := r.URL.Query().Get("from")
+	if from == "" {
+		from = time.Now().Format("2006-01")
+	}
+	to := r.URL.Query().Get("to")
+	if to == "" {
+		to = from
+	}
+
+	budgets, err := h.budgetService.GetBudgetsInRange(r.Context(), userID, from, to)
+	if err != nil {
+		log.Printf("Error getting budgets from %s to %s: %v", from, to, err)
+		http.Error(w, fmt.Sprintf("Failed to get budgets: %v", err), budgetErrorStatus(err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(budgets)
+}
+
+// BulkUpsertBudgetsRequest represents the request body for bulk budget creation/update
+type BulkUpsertBudgetsRequest struct {
+	Budgets []models.BudgetInput `json:"budgets"`
+}
+
+// BulkUpsertBudgets handles POST /budgets/bulk requests
+func (h *BudgetHandler) BulkUpsertBudgets(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var req BulkUpsertBudgetsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		log.Printf("Error decoding request body: %v", err)
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	budgets, err := h.budgetService.BulkUpsertBudgets(r.Context(), userID, req.Budgets)
+	if err != nil {
+		log.Printf("Error bulk upserting budgets: %v", err)
+		http.Error(w, fmt.Sprintf("Failed to create/update budgets: %v", err), budgetErrorStatus(err))
+		return
+	}
+
+	response := map[string]interface{}{
+		"message": "Budgets created/updated successfully",
+		"user_id": userID,
+		"count":   len(budgets),
+		"budgets": budgets,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(response)
+}
+
+// budgetErrorStatus maps validation errors to 400, missing budgets to 404 and anything else to 500
+func budgetErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, services.ErrInvalidBudget):
+		return http.StatusBadRequest
+	case errors.Is(err, repository.ErrBudgetNotFound):
+		return http.StatusNotFound
+	default:
+		return http.StatusInternalServerError
+	}
+}
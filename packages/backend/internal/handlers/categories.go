@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type CategoryHandler struct {
+	service services.CategoryService
+}
+
+func NewCategoryHandler(service services.CategoryService) *CategoryHandler {
+	return &CategoryHandler{
+		service: service,
+	}
+}
+
+// ListCategories handles GET /categories
+func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	categories, err := h.service.ListCategories(r.Context(), userID)
+	if err != nil {
+		respondCategoryError(w, "Failed to list categories", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(categories, &models.APIMeta{Total: len(categories)}))
+}
+
+// CreateCategory handles POST /categories
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	category, ok := decodeCategory(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.service.CreateCategory(r.Context(), category); err != nil {
+		respondCategoryError(w, "Failed to create category", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(category, nil))
+}
+
+// GetCategory handles GET /categories/{id}
+func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	category, err := h.service.GetCategory(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		respondCategoryError(w, "Failed to get category", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(category, nil))
+}
+
+// UpdateCategory handles PUT /categories/{id}
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	category, ok := decodeCategory(w, r, userID)
+	if !ok {
+		return
+	}
+	category.ID = mux.Vars(r)["id"]
+
+	if err := h.service.UpdateCategory(r.Context(), category); err != nil {
+		respondCategoryError(w, "Failed to update category", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(category, nil))
+}
+
+// DeleteCategory handles DELETE /categories/{id}
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteCategory(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		respondCategoryError(w, "Failed to delete category", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// RenameCategory handles POST /categories/{id}/rename with {"new_id": "..."},
+// moving the category's transactions to the new ID
+func (h *CategoryHandler) RenameCategory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		NewID string `json:"new_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid request body", err.Error())
+		return
+	}
+
+	change, err := h.service.RenameCategory(r.Context(), userID, mux.Vars(r)["id"], body.NewID)
+	if err != nil {
+		respondCategoryError(w, "Failed to rename category", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(change, nil))
+}
+
+// MergeCategory handles POST /categories/{id}/merge with {"into": "..."},
+// moving the category's transactions to another category and deleting it
+func (h *CategoryHandler) MergeCategory(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var body struct {
+		Into string `json:"into"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if body.Into == "" {
+		RespondError(w, models.ErrorCodeValidation, "Failed to merge category", "into is required")
+		return
+	}
+
+	change, err := h.service.MergeCategory(r.Context(), userID, mux.Vars(r)["id"], body.Into)
+	if err != nil {
+		respondCategoryError(w, "Failed to merge category", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(change, nil))
+}
+
+// decodeCategory reads a category from the body and scopes it to the user
+func decodeCategory(w http.ResponseWriter, r *http.Request, userID string) (*models.Category, bool) {
+	category := &models.Category{}
+	if err := json.NewDecoder(r.Body).Decode(category); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid request body", err.Error())
+		return nil, false
+	}
+	if !checkUserID(w, userID, category.UserID) {
+		return nil, false
+	}
+	category.UserID = userID
+	return category, true
+}
+
+// respondCategoryError maps invalid categories to 400, missing ones to 404 and
+// existing IDs and concurrent modifications to 409
+func respondCategoryError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCategory):
+		RespondError(w, models.ErrorCodeValidation, message, err.Error())
+	case errors.Is(err, repository.ErrCategoryNotFound):
+		RespondError(w, models.ErrorCodeNotFound, message, err.Error())
+	case errors.Is(err, repository.ErrCategoryExists), errors.Is(err, repository.ErrCategoryConflict),
+		errors.Is(err, repository.ErrRuleConflict), errors.Is(err, repository.ErrRecurringConflict):
+		RespondError(w, models.ErrorCodeConflict, message, err.Error())
+	default:
+		RespondError(w, models.ErrorCodeInternalServer, message, err.Error())
+	}
+}
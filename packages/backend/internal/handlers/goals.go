@@ -0,0 +1,225 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type GoalHandler struct {
+	service services.GoalService
+}
+
+func NewGoalHandler(service services.GoalService) *GoalHandler {
+	return &GoalHandler{
+		service: service,
+	}
+}
+
+// ListGoals handles GET /goals
+func (h *GoalHandler) ListGoals(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	goals, err := h.service.ListGoals(r.Context(), userID)
+	if err != nil {
+		respondGoalError(w, "Failed to list goals", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(goals, &models.APIMeta{Total: len(goals)}))
+}
+
+// CreateGoal handles POST /goals
+func (h *GoalHandler) CreateGoal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	goal, ok := decodeGoal(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.service.CreateGoal(r.Context(), goal); err != nil {
+		respondGoalError(w, "Failed to create goal", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(goal, nil))
+}
+
+// GetGoal handles GET /goals/{id}
+func (h *GoalHandler) GetGoal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	goal, err := h.service.GetGoal(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		respondGoalError(w, "Failed to get goal", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(goal, nil))
+}
+
+// UpdateGoal handles PUT /goals/{id}
+func (h *GoalHandler) UpdateGoal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	goal, ok := decodeGoal(w, r, userID)
+	if !ok {
+		return
+	}
+	goal.ID = mux.Vars(r)["id"]
+
+	if err := h.service.UpdateGoal(r.Context(), goal); err != nil {
+		respondGoalError(w, "Failed to update goal", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(goal, nil))
+}
+
+// DeleteGoal handles DELETE /goals/{id}, deleting its contributions too
+func (h *GoalHandler) DeleteGoal(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteGoal(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		respondGoalError(w, "Failed to delete goal", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GetProgress handles GET /goals/{id}/progress
+func (h *GoalHandler) GetProgress(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	progress, err := h.service.GetProgress(r.Context(), userID, mux.Vars(r)["id"], time.Now())
+	if err != nil {
+		respondGoalError(w, "Failed to get goal progress", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(progress, nil))
+}
+
+// ListContributions handles GET /goals/{id}/contributions
+func (h *GoalHandler) ListContributions(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	contributions, err := h.service.ListContributions(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		respondGoalError(w, "Failed to list contributions", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(contributions, &models.APIMeta{Total: len(contributions)}))
+}
+
+// AddContribution handles POST /goals/{id}/contributions
+func (h *GoalHandler) AddContribution(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	contribution := &models.GoalContribution{}
+	if err := json.NewDecoder(r.Body).Decode(contribution); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if !checkUserID(w, userID, contribution.UserID) {
+		return
+	}
+	contribution.UserID = userID
+	contribution.GoalID = mux.Vars(r)["id"]
+
+	if err := h.service.AddContribution(r.Context(), contribution); err != nil {
+		respondGoalError(w, "Failed to add contribution", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(contribution, nil))
+}
+
+// DeleteContribution handles DELETE /goals/{id}/contributions/{contributionId}
+func (h *GoalHandler) DeleteContribution(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	vars := mux.Vars(r)
+	if err := h.service.DeleteContribution(r.Context(), userID, vars["id"], vars["contributionId"]); err != nil {
+		respondGoalError(w, "Failed to delete contribution", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeGoal reads a goal from the body and scopes it to the user
+func decodeGoal(w http.ResponseWriter, r *http.Request, userID string) (*models.Goal, bool) {
+	goal := &models.Goal{}
+	if err := json.NewDecoder(r.Body).Decode(goal); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid request body", err.Error())
+		return nil, false
+	}
+	if !checkUserID(w, userID, goal.UserID) {
+		return nil, false
+	}
+	goal.UserID = userID
+	return goal, true
+}
+
+// respondGoalError maps invalid goals to 400, missing goals, contributions
+// and linked accounts to 404 and concurrent modifications to 409
+func respondGoalError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidGoal):
+		RespondError(w, models.ErrorCodeValidation, message, err.Error())
+	case errors.Is(err, repository.ErrGoalNotFound), errors.Is(err, repository.ErrGoalContributionNotFound),
+		errors.Is(err, repository.ErrAccountNotFound):
+		RespondError(w, models.ErrorCodeNotFound, message, err.Error())
+	case errors.Is(err, repository.ErrGoalConflict):
+		RespondError(w, models.ErrorCodeConflict, message, err.Error())
+	default:
+		RespondError(w, models.ErrorCodeInternalServer, message, err.Error())
+	}
+}
@@ -0,0 +1,346 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+// maxImportFileSize bounds uploaded import files; the rest of the form gets
+// another megabyte
+const maxImportFileSize = 5 << 20
+
+type ImportHandler struct {
+	service services.ImportService
+}
+
+func NewImportHandler(service services.ImportService) *ImportHandler {
+	return &ImportHandler{
+		service: service,
+	}
+}
+
+// ImportCSV handles POST /imports/csv. The multipart form carries the file
+// and either an inline "mapping" (JSON) or the "mapping_name" of a saved one.
+// Imports are dry runs unless dry_run=false.
+func (h *ImportHandler) ImportCSV(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	file, ok := uploadedFile(w, r)
+	if !ok {
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	defer file.Close()
+
+	opts, ok := importOptions(w, r)
+	if !ok {
+		return
+	}
+
+	mapping, ok := h.formMapping(w, r, userID)
+	if !ok {
+		return
+	}
+
+	save, err := formBool(r, "save_mapping", false)
+	if err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid save_mapping", err.Error())
+		return
+	}
+	if save {
+		if err := h.service.SaveMapping(r.Context(), mapping); err != nil {
+			respondImportError(w, "Failed to save import mapping", err)
+			return
+		}
+	}
+
+	result, err := h.service.ImportCSV(r.Context(), userID, file, *mapping, opts)
+	if err != nil {
+		respondImportError(w, "Failed to import CSV", err)
+		return
+	}
+
+	respondImportResult(w, result)
+}
+
+// ImportOFX handles POST /imports/ofx with an OFX or QFX statement in the
+// "file" field. Imports are dry runs unless dry_run=false.
+func (h *ImportHandler) ImportOFX(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	file, ok := uploadedFile(w, r)
+	if !ok {
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+	defer file.Close()
+
+	opts, ok := importOptions(w, r)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.ImportOFX(r.Context(), userID, file, opts)
+	if err != nil {
+		respondImportError(w, "Failed to import OFX", err)
+		return
+	}
+
+	respondImportResult(w, result)
+}
+
+// ImportCFDI handles POST /imports/cfdi with one or more CFDI XML invoices in
+// "file" fields. A single invoice can be attached to a given expense with
+// transaction_id; otherwise each invoice is matched to an expense of the same
+// amount or a new expense in "category" is created for it. Imports are dry
+// runs unless dry_run=false.
+func (h *ImportHandler) ImportCFDI(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if !parseUpload(w, r) {
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	headers := r.MultipartForm.File["file"]
+	if len(headers) == 0 {
+		RespondError(w, models.ErrorCodeBadRequest, "At least one file is required in the 'file' field", "")
+		return
+	}
+
+	dryRun, err := formBool(r, "dry_run", true)
+	if err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid dry_run", err.Error())
+		return
+	}
+	opts := services.InvoiceOptions{
+		DryRun:        dryRun,
+		TransactionID: r.FormValue("transaction_id"),
+		Category:      r.FormValue("category"),
+	}
+
+	files := make([]services.InvoiceFile, 0, len(headers))
+	for _, header := range headers {
+		file, err := header.Open()
+		if err != nil {
+			RespondError(w, models.ErrorCodeBadRequest, "Failed to read uploaded file", err.Error())
+			return
+		}
+		defer file.Close()
+		files = append(files, services.InvoiceFile{Name: header.Filename, Content: file})
+	}
+
+	result, err := h.service.ImportCFDI(r.Context(), userID, files, opts)
+	if err != nil {
+		respondImportError(w, "Failed to import CFDI", err)
+		return
+	}
+
+	respondImport(w, result, result.DryRun)
+}
+
+// ListMappings handles GET /imports/mappings
+func (h *ImportHandler) ListMappings(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	mappings, err := h.service.ListMappings(r.Context(), userID)
+	if err != nil {
+		respondImportError(w, "Failed to list import mappings", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(mappings, &models.APIMeta{Total: len(mappings)}))
+}
+
+// GetMapping handles GET /imports/mappings/{name}
+func (h *ImportHandler) GetMapping(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	mapping, err := h.service.GetMapping(r.Context(), userID, mux.Vars(r)["name"])
+	if err != nil {
+		respondImportError(w, "Failed to get import mapping", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(mapping, nil))
+}
+
+// SaveMapping handles PUT /imports/mappings/{name}
+func (h *ImportHandler) SaveMapping(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var mapping models.ImportMapping
+	if err := json.NewDecoder(r.Body).Decode(&mapping); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	mapping.UserID = userID
+	mapping.Name = mux.Vars(r)["name"]
+
+	if err := h.service.SaveMapping(r.Context(), &mapping); err != nil {
+		respondImportError(w, "Failed to save import mapping", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(mapping, nil))
+}
+
+// DeleteMapping handles DELETE /imports/mappings/{name}
+func (h *ImportHandler) DeleteMapping(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteMapping(r.Context(), userID, mux.Vars(r)["name"]); err != nil {
+		respondImportError(w, "Failed to delete import mapping", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// parseUpload parses the multipart form of an upload. The caller removes the
+// form's temporary files.
+func parseUpload(w http.ResponseWriter, r *http.Request) bool {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportFileSize+1<<20)
+	if err := r.ParseMultipartForm(maxImportFileSize); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid multipart form", err.Error())
+		return false
+	}
+	return true
+}
+
+// uploadedFile parses the multipart form of an upload and opens its "file"
+// field. The caller removes the form's temporary files.
+func uploadedFile(w http.ResponseWriter, r *http.Request) (multipart.File, bool) {
+	if !parseUpload(w, r) {
+		return nil, false
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		r.MultipartForm.RemoveAll()
+		RespondError(w, models.ErrorCodeBadRequest, "A file is required in the 'file' field", err.Error())
+		return nil, false
+	}
+	return file, true
+}
+
+// respondImportResult answers 201 for committed imports and 200 for previews
+func respondImportResult(w http.ResponseWriter, result *models.ImportResult) {
+	respondImport(w, result, result.DryRun)
+}
+
+func respondImport(w http.ResponseWriter, result interface{}, dryRun bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if !dryRun {
+		w.WriteHeader(http.StatusCreated)
+	}
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(result, nil))
+}
+
+// formMapping reads the inline mapping of an upload, or loads the saved one
+// named by mapping_name
+func (h *ImportHandler) formMapping(w http.ResponseWriter, r *http.Request, userID string) (*models.ImportMapping, bool) {
+	name := r.FormValue("mapping_name")
+
+	if raw := r.FormValue("mapping"); raw != "" {
+		var mapping models.ImportMapping
+		if err := json.Unmarshal([]byte(raw), &mapping); err != nil {
+			RespondError(w, models.ErrorCodeBadRequest, "Invalid mapping", err.Error())
+			return nil, false
+		}
+		mapping.UserID = userID
+		if name != "" {
+			mapping.Name = name
+		}
+		return &mapping, true
+	}
+
+	if name == "" {
+		RespondError(w, models.ErrorCodeBadRequest, "A mapping or mapping_name is required", "")
+		return nil, false
+	}
+
+	mapping, err := h.service.GetMapping(r.Context(), userID, name)
+	if err != nil {
+		respondImportError(w, "Failed to get import mapping", err)
+		return nil, false
+	}
+	return mapping, true
+}
+
+// importOptions reads dry_run (default true), skip_invalid (default false)
+// and the duplicates policy (default flag)
+func importOptions(w http.ResponseWriter, r *http.Request) (services.ImportOptions, bool) {
+	opts := services.ImportOptions{Duplicates: r.FormValue("duplicates")}
+	var err error
+
+	if opts.DryRun, err = formBool(r, "dry_run", true); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid dry_run", err.Error())
+		return opts, false
+	}
+	if opts.SkipInvalid, err = formBool(r, "skip_invalid", false); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid skip_invalid", err.Error())
+		return opts, false
+	}
+	return opts, true
+}
+
+// formBool reads a boolean form or query value
+func formBool(r *http.Request, name string, fallback bool) (bool, error) {
+	value := r.FormValue(name)
+	if value == "" {
+		return fallback, nil
+	}
+	parsed, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be true or false", name)
+	}
+	return parsed, nil
+}
+
+// respondImportError maps invalid files and mappings to 400 and unknown
+// mappings and transactions to 404
+func respondImportError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidImport):
+		RespondError(w, models.ErrorCodeValidation, message, err.Error())
+	case errors.Is(err, repository.ErrImportMappingNotFound), errors.Is(err, repository.ErrTransactionNotFound):
+		RespondError(w, models.ErrorCodeNotFound, message, err.Error())
+	default:
+		RespondError(w, models.ErrorCodeInternalServer, message, err.Error())
+	}
+}
@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type RecurringHandler struct {
+	service services.RecurringService
+}
+
+func NewRecurringHandler(service services.RecurringService) *RecurringHandler {
+	return &RecurringHandler{
+		service: service,
+	}
+}
+
+// CreateRecurring handles POST /recurring-transactions
+func (h *RecurringHandler) CreateRecurring(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	recurring, ok := decodeRecurring(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.service.CreateRecurring(r.Context(), recurring); err != nil {
+		respondRecurringError(w, "Failed to create recurring transaction", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(recurring, nil))
+}
+
+// ListRecurring handles GET /recurring-transactions
+func (h *RecurringHandler) ListRecurring(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := h.service.ListRecurring(r.Context(), userID)
+	if err != nil {
+		respondRecurringError(w, "Failed to list recurring transactions", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(rules, &models.APIMeta{Total: len(rules)}))
+}
+
+// GetRecurring handles GET /recurring-transactions/{id}
+func (h *RecurringHandler) GetRecurring(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	recurring, err := h.service.GetRecurring(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		respondRecurringError(w, "Failed to get recurring transaction", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(recurring, nil))
+}
+
+// UpdateRecurring handles PUT /recurring-transactions/{id}
+func (h *RecurringHandler) UpdateRecurring(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	recurring, ok := decodeRecurring(w, r, userID)
+	if !ok {
+		return
+	}
+	recurring.ID = mux.Vars(r)["id"]
+
+	if err := h.service.UpdateRecurring(r.Context(), recurring); err != nil {
+		respondRecurringError(w, "Failed to update recurring transaction", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(recurring, nil))
+}
+
+// DeleteRecurring handles DELETE /recurring-transactions/{id}
+func (h *RecurringHandler) DeleteRecurring(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRecurring(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		respondRecurringError(w, "Failed to delete recurring transaction", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// decodeRecurring reads a rule from the body and scopes it to the user. Rules
+// are active unless the body says otherwise.
+func decodeRecurring(w http.ResponseWriter, r *http.Request, userID string) (*models.RecurringTransaction, bool) {
+	recurring := &models.RecurringTransaction{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(recurring); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid request body", err.Error())
+		return nil, false
+	}
+	if !checkUserID(w, userID, recurring.UserID) {
+		return nil, false
+	}
+	recurring.UserID = userID
+	return recurring, true
+}
+
+// respondRecurringError maps validation errors to 400, missing rules to 404 and
+// concurrent modifications to 409
+func respondRecurringError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRecurring):
+		RespondError(w, models.ErrorCodeValidation, message, err.Error())
+	case errors.Is(err, repository.ErrRecurringNotFound):
+		RespondError(w, models.ErrorCodeNotFound, message, err.Error())
+	case errors.Is(err, repository.ErrRecurringConflict):
+		RespondError(w, models.ErrorCodeConflict, message, err.Error())
+	default:
+		RespondError(w, models.ErrorCodeInternalServer, message, err.Error())
+	}
+}
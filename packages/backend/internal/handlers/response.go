@@ -0,0 +1,15 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"backend/internal/models"
+)
+
+// RespondError writes a standard error envelope using the HTTP status mapped from the error code
+func RespondError(w http.ResponseWriter, code, message, details string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(models.HTTPStatusFromErrorCode(code))
+	json.NewEncoder(w).Encode(models.NewErrorResponse(code, message, details))
+}
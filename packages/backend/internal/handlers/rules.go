@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+
+	"github.com/gorilla/mux"
+)
+
+type RuleHandler struct {
+	service services.RuleService
+}
+
+func NewRuleHandler(service services.RuleService) *RuleHandler {
+	return &RuleHandler{
+		service: service,
+	}
+}
+
+// CreateRule handles POST /rules
+func (h *RuleHandler) CreateRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rule, ok := decodeRule(w, r, userID)
+	if !ok {
+		return
+	}
+
+	if err := h.service.CreateRule(r.Context(), rule); err != nil {
+		respondRuleError(w, "Failed to create rule", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(rule, nil))
+}
+
+// ListRules handles GET /rules, listing the rules in the order they run
+func (h *RuleHandler) ListRules(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := h.service.ListRules(r.Context(), userID)
+	if err != nil {
+		respondRuleError(w, "Failed to list rules", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(rules, &models.APIMeta{Total: len(rules)}))
+}
+
+// GetRule handles GET /rules/{id}
+func (h *RuleHandler) GetRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rule, err := h.service.GetRule(r.Context(), userID, mux.Vars(r)["id"])
+	if err != nil {
+		respondRuleError(w, "Failed to get rule", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(rule, nil))
+}
+
+// UpdateRule handles PUT /rules/{id}
+func (h *RuleHandler) UpdateRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rule, ok := decodeRule(w, r, userID)
+	if !ok {
+		return
+	}
+	rule.ID = mux.Vars(r)["id"]
+
+	if err := h.service.UpdateRule(r.Context(), rule); err != nil {
+		respondRuleError(w, "Failed to update rule", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(rule, nil))
+}
+
+// DeleteRule handles DELETE /rules/{id}
+func (h *RuleHandler) DeleteRule(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteRule(r.Context(), userID, mux.Vars(r)["id"]); err != nil {
+		respondRuleError(w, "Failed to delete rule", err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ApplyRules handles POST /rules/apply, running the active rules over the
+// transactions of the period. With dry_run=true it only reports the changes.
+func (h *RuleHandler) ApplyRules(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	rng, ok := parsePeriod(w, r)
+	if !ok {
+		return
+	}
+	dryRun, err := formBool(r, "dry_run", false)
+	if err != nil {
+		RespondError(w, models.ErrorCodeValidation, "Invalid dry_run", err.Error())
+		return
+	}
+
+	result, err := h.service.ApplyRules(r.Context(), userID, rng, dryRun)
+	if err != nil {
+		respondRuleError(w, "Failed to apply rules", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    result,
+		"period":  newPeriodInfo(rng),
+	})
+}
+
+// decodeRule reads a rule from the body and scopes it to the user. Rules are
+// active unless the body says otherwise.
+func decodeRule(w http.ResponseWriter, r *http.Request, userID string) (*models.CategoryRule, bool) {
+	rule := &models.CategoryRule{Active: true}
+	if err := json.NewDecoder(r.Body).Decode(rule); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid request body", err.Error())
+		return nil, false
+	}
+	if !checkUserID(w, userID, rule.UserID) {
+		return nil, false
+	}
+	rule.UserID = userID
+	return rule, true
+}
+
+// respondRuleError maps invalid rules to 400, missing rules to 404 and
+// concurrent modifications to 409
+func respondRuleError(w http.ResponseWriter, message string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidRule):
+		RespondError(w, models.ErrorCodeValidation, message, err.Error())
+	case errors.Is(err, repository.ErrRuleNotFound):
+		RespondError(w, models.ErrorCodeNotFound, message, err.Error())
+	case errors.Is(err, repository.ErrRuleConflict):
+		RespondError(w, models.ErrorCodeConflict, message, err.Error())
+	default:
+		RespondError(w, models.ErrorCodeInternalServer, message, err.Error())
+	}
+}
@@ -2,11 +2,14 @@ package handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
 
 	"backend/internal/models"
+	"backend/internal/pagination"
+	"backend/internal/repository"
 	"backend/internal/services"
 
 	"github.com/gorilla/mux"
@@ -29,61 +32,88 @@ func (h *TransactionHandler) CreateTransaction(w http.ResponseWriter, r *http.Re
 		return
 	}
 
-	// If user_id is not in body, try to get it from query parameter
-	if transaction.UserID == "" {
-		userID := r.URL.Query().Get("user_id")
-		if userID == "" {
-			http.Error(w, "user_id is required either in body or as query parameter", http.StatusBadRequest)
-			return
-		}
-		transaction.UserID = userID
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	if !checkUserID(w, userID, transaction.UserID) {
+		return
 	}
+	transaction.UserID = userID
 
-	if err := h.service.CreateTransaction(r.Context(), &transaction); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	opts := services.CreateOptions{
+		IdempotencyKey: r.Header.Get("Idempotency-Key"),
+		Duplicates:     r.URL.Query().Get("duplicates"),
+	}
+
+	result, err := h.service.CreateTransaction(r.Context(), &transaction, opts)
+	if err != nil {
+		respondCreateError(w, err)
 		return
 	}
 
+	// A skipped duplicate answers 200 with the stored transaction; a replay
+	// answers like the original request did
 	w.Header().Set("Content-Type", "application/json")
-	w.WriteHeader(http.StatusCreated)
+	if result.Replayed {
+		w.Header().Set("Idempotent-Replayed", "true")
+	}
+	if !result.Skipped {
+		w.WriteHeader(http.StatusCreated)
+	}
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
-		"data":    transaction,
+		"data":    result.Transaction,
+		"skipped": result.Skipped,
 	})
 }
 
-func (h *TransactionHandler) GetTransactionsByUser(w http.ResponseWriter, r *http.Request) {
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+// FindDuplicates handles GET /transactions/duplicates, listing the pairs of
+// transactions in the period that look like the same movement entered twice
+func (h *TransactionHandler) FindDuplicates(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
 		return
 	}
 
-	limitStr := r.URL.Query().Get("limit")
-	limit := 50
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
+	rng, ok := parsePeriod(w, r)
+	if !ok {
+		return
 	}
 
-	transactions, err := h.service.GetTransactionsByUser(r.Context(), userID, limit)
+	pairs, err := h.service.FindDuplicates(r.Context(), userID, rng)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		RespondError(w, models.ErrorCodeInternalServer, "Failed to find duplicate transactions", err.Error())
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(transactions)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"data":    pairs,
+		"meta":    &models.APIMeta{Total: len(pairs)},
+		"period":  newPeriodInfo(rng),
+	})
+}
+
+func (h *TransactionHandler) GetTransactionsByUser(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	limit, cursor := pageParams(r)
+
+	page, err := h.service.GetTransactionsByUser(r.Context(), userID, limit, cursor, listFilter(r))
+	respondTransactionPage(w, page, err)
 }
 
 func (h *TransactionHandler) GetTransaction(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	transactionID := vars["id"]
 	
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+	userID, ok := requireUserID(w, r)
+	if !ok {
 		return
 	}
 
@@ -104,11 +134,20 @@ func (h *TransactionHandler) UpdateTransaction(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+	if !checkUserID(w, userID, transaction.UserID) {
+		return
+	}
+	transaction.UserID = userID
+
 	vars := mux.Vars(r)
 	transaction.ID = vars["id"]
 
 	if err := h.service.UpdateTransaction(r.Context(), &transaction); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), transactionErrorStatus(err))
 		return
 	}
 
@@ -120,14 +159,13 @@ func (h *TransactionHandler) DeleteTransaction(w http.ResponseWriter, r *http.Re
 	vars := mux.Vars(r)
 	transactionID := vars["id"]
 	
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+	userID, ok := requireUserID(w, r)
+	if !ok {
 		return
 	}
 
 	if err := h.service.DeleteTransaction(r.Context(), userID, transactionID); err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), transactionErrorStatus(err))
 		return
 	}
 
@@ -138,54 +176,89 @@ func (h *TransactionHandler) GetTransactionsByMonth(w http.ResponseWriter, r *ht
 	vars := mux.Vars(r)
 	month := vars["month"]
 	
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+	userID, ok := requireUserID(w, r)
+	if !ok {
 		return
 	}
 
-	limitStr := r.URL.Query().Get("limit")
-	limit := 50
-	if limitStr != "" {
-		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
-			limit = l
-		}
-	}
+	limit, cursor := pageParams(r)
 
-	transactions, err := h.service.GetTransactionsByMonth(r.Context(), userID, month, limit)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(transactions)
+	page, err := h.service.GetTransactionsByMonth(r.Context(), userID, month, limit, cursor, listFilter(r))
+	respondTransactionPage(w, page, err)
 }
 
 func (h *TransactionHandler) GetTransactionsByCategory(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	category := vars["category"]
 	
-	userID := r.URL.Query().Get("user_id")
-	if userID == "" {
-		http.Error(w, "user_id is required", http.StatusBadRequest)
+	userID, ok := requireUserID(w, r)
+	if !ok {
 		return
 	}
 
-	limitStr := r.URL.Query().Get("limit")
+	limit, cursor := pageParams(r)
+
+	page, err := h.service.GetTransactionsByCategory(r.Context(), userID, category, limit, cursor, listFilter(r))
+	respondTransactionPage(w, page, err)
+}
+
+// respondCreateError maps invalid options and idempotency key reuse to 400 and
+// a request still in progress with the same key to 409
+func respondCreateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCreateOptions), errors.Is(err, services.ErrIdempotencyKeyMismatch),
+		errors.Is(err, services.ErrInvalidAccount):
+		RespondError(w, models.ErrorCodeValidation, "Invalid request", err.Error())
+	case errors.Is(err, services.ErrIdempotencyKeyInProgress):
+		RespondError(w, models.ErrorCodeConflict, "Request in progress", err.Error())
+	default:
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// transactionErrorStatus maps a missing transaction to 404, an unknown account
+// to 400, a transfer entry to 409 and anything else to 500
+func transactionErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, repository.ErrTransactionNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, services.ErrInvalidAccount):
+		return http.StatusBadRequest
+	case errors.Is(err, services.ErrTransferEntry):
+		return http.StatusConflict
+	}
+	return http.StatusInternalServerError
+}
+
+// pageParams reads the page size and the opaque cursor returned by the previous page
+func pageParams(r *http.Request) (int, string) {
 	limit := 50
-	if limitStr != "" {
+	if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
 		if l, err := strconv.Atoi(limitStr); err == nil && l > 0 {
 			limit = l
 		}
 	}
+	return limit, r.URL.Query().Get("cursor")
+}
 
-	transactions, err := h.service.GetTransactionsByCategory(r.Context(), userID, category, limit)
+// listFilter reads the optional ?tag= filter of the list endpoints
+func listFilter(r *http.Request) services.TransactionFilter {
+	return services.TransactionFilter{Tag: r.URL.Query().Get("tag")}
+}
+
+func respondTransactionPage(w http.ResponseWriter, page *models.TransactionPage, err error) {
+	if errors.Is(err, pagination.ErrInvalidCursor) {
+		RespondError(w, models.ErrorCodeValidation, "Invalid cursor", err.Error())
+		return
+	}
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(transactions)
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(page.Transactions, &models.APIMeta{
+		HasMore:    page.HasMore,
+		NextCursor: page.NextCursor,
+	}))
 }
@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"backend/internal/models"
+	"backend/internal/services"
+)
+
+type UserHandler struct {
+	service services.UserService
+}
+
+func NewUserHandler(service services.UserService) *UserHandler {
+	return &UserHandler{
+		service: service,
+	}
+}
+
+// GetProfile handles GET /users/me
+func (h *UserHandler) GetProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	user, err := h.service.GetProfile(r.Context(), userID)
+	if err != nil {
+		RespondError(w, models.ErrorCodeInternalServer, "Failed to get profile", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(user, nil))
+}
+
+// UpdateProfile handles PUT /users/me
+func (h *UserHandler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
+	userID, ok := requireUserID(w, r)
+	if !ok {
+		return
+	}
+
+	var update models.UserProfileUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		RespondError(w, models.ErrorCodeBadRequest, "Invalid request body", err.Error())
+		return
+	}
+	if update.BaseCurrency != nil {
+		if _, err := models.NormalizeCurrency(*update.BaseCurrency); err != nil {
+			RespondError(w, models.ErrorCodeValidation, "Invalid base currency", err.Error())
+			return
+		}
+	}
+
+	user, err := h.service.UpdateProfile(r.Context(), userID, &update)
+	if err != nil {
+		RespondError(w, models.ErrorCodeInternalServer, "Failed to update profile", err.Error())
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(models.NewSuccessResponse(user, nil))
+}
@@ -0,0 +1,189 @@
+package importer
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+)
+
+// FormatCFDI identifies CFDI invoice imports
+const FormatCFDI = "cfdi"
+
+// cfdiIVA is the SAT code of the value added tax in tax breakdowns
+const cfdiIVA = "002"
+
+// cfdiComprobante maps the CFDI 4.0 (and 3.3) XML. Elements are matched by
+// local name, so the cfdi: and tfd: prefixes need no namespace handling.
+type cfdiComprobante struct {
+	XMLName           xml.Name
+	Version           string `xml:"Version,attr"`
+	Serie             string `xml:"Serie,attr"`
+	Folio             string `xml:"Folio,attr"`
+	Fecha             string `xml:"Fecha,attr"`
+	FormaPago         string `xml:"FormaPago,attr"`
+	MetodoPago        string `xml:"MetodoPago,attr"`
+	Moneda            string `xml:"Moneda,attr"`
+	TipoDeComprobante string `xml:"TipoDeComprobante,attr"`
+	SubTotal          string `xml:"SubTotal,attr"`
+	Descuento         string `xml:"Descuento,attr"`
+	Total             string `xml:"Total,attr"`
+
+	Emisor struct {
+		Rfc    string `xml:"Rfc,attr"`
+		Nombre string `xml:"Nombre,attr"`
+	} `xml:"Emisor"`
+
+	Receptor struct {
+		Rfc     string `xml:"Rfc,attr"`
+		Nombre  string `xml:"Nombre,attr"`
+		UsoCFDI string `xml:"UsoCFDI,attr"`
+	} `xml:"Receptor"`
+
+	Conceptos []struct {
+		ClaveProdServ string `xml:"ClaveProdServ,attr"`
+		Cantidad      string `xml:"Cantidad,attr"`
+		Unidad        string `xml:"Unidad,attr"`
+		Descripcion   string `xml:"Descripcion,attr"`
+		ValorUnitario string `xml:"ValorUnitario,attr"`
+		Importe       string `xml:"Importe,attr"`
+	} `xml:"Conceptos>Concepto"`
+
+	Impuestos struct {
+		TotalImpuestosTrasladados string `xml:"TotalImpuestosTrasladados,attr"`
+		TotalImpuestosRetenidos   string `xml:"TotalImpuestosRetenidos,attr"`
+		Traslados                 []struct {
+			Impuesto string `xml:"Impuesto,attr"`
+			Importe  string `xml:"Importe,attr"`
+		} `xml:"Traslados>Traslado"`
+	} `xml:"Impuestos"`
+
+	Timbre struct {
+		UUID string `xml:"UUID,attr"`
+	} `xml:"Complemento>TimbreFiscalDigital"`
+}
+
+// ParseCFDI reads a stamped CFDI invoice. Only income invoices (type I) are
+// accepted: they are the ones a user receives for a purchase.
+func ParseCFDI(r io.Reader) (*models.Invoice, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	var doc cfdiComprobante
+	if err := xml.Unmarshal(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")), &doc); err != nil {
+		return nil, fmt.Errorf("%w: not a valid XML document: %v", ErrInvalidFile, err)
+	}
+	if doc.XMLName.Local != "Comprobante" {
+		return nil, fmt.Errorf("%w: not a CFDI, root element is %s", ErrInvalidFile, doc.XMLName.Local)
+	}
+
+	if err := doc.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	invoice, err := doc.invoice()
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	return invoice, nil
+}
+
+func (doc *cfdiComprobante) validate() error {
+	if doc.Version != "4.0" && doc.Version != "3.3" {
+		return fmt.Errorf("unsupported CFDI version %q", doc.Version)
+	}
+	if doc.TipoDeComprobante != "I" {
+		return fmt.Errorf("CFDI of type %q cannot be imported as an expense, only type I invoices", doc.TipoDeComprobante)
+	}
+	if doc.Timbre.UUID == "" {
+		return fmt.Errorf("CFDI is not stamped, TimbreFiscalDigital is missing")
+	}
+	if doc.Emisor.Rfc == "" {
+		return fmt.Errorf("emitter RFC is missing")
+	}
+	return nil
+}
+
+func (doc *cfdiComprobante) invoice() (*models.Invoice, error) {
+	currency, err := models.NormalizeCurrency(doc.Moneda)
+	if err != nil {
+		return nil, err
+	}
+
+	// Fecha is the local time of issue in Mexico, without a zone
+	issued, err := time.Parse("2006-01-02T15:04:05", doc.Fecha)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Fecha %q", doc.Fecha)
+	}
+
+	amount := func(name, value string) models.Money {
+		if err != nil || value == "" {
+			return models.ZeroMoney(currency)
+		}
+		var parsed models.Money
+		if parsed, err = models.ParseMoney(value, currency); err != nil {
+			err = fmt.Errorf("invalid %s %q", name, value)
+		}
+		return parsed
+	}
+
+	invoice := &models.Invoice{
+		UUID:          strings.ToUpper(doc.Timbre.UUID),
+		Version:       doc.Version,
+		Series:        doc.Serie,
+		Folio:         doc.Folio,
+		IssuedAt:      issued,
+		PaymentDate:   time.Date(issued.Year(), issued.Month(), issued.Day(), 0, 0, 0, 0, time.UTC),
+		PaymentMethod: doc.MetodoPago,
+		PaymentForm:   doc.FormaPago,
+		EmitterRFC:    strings.ToUpper(doc.Emisor.Rfc),
+		EmitterName:   strings.TrimSpace(doc.Emisor.Nombre),
+		ReceiverRFC:   strings.ToUpper(doc.Receptor.Rfc),
+		ReceiverName:  strings.TrimSpace(doc.Receptor.Nombre),
+		ReceiverUse:   doc.Receptor.UsoCFDI,
+		Currency:      currency,
+		Subtotal:      amount("SubTotal", doc.SubTotal),
+		Discount:      amount("Descuento", doc.Descuento),
+		TransferTaxes: amount("TotalImpuestosTrasladados", doc.Impuestos.TotalImpuestosTrasladados),
+		WithheldTaxes: amount("TotalImpuestosRetenidos", doc.Impuestos.TotalImpuestosRetenidos),
+		Total:         amount("Total", doc.Total),
+		IVA:           models.ZeroMoney(currency),
+		Concepts:      []models.InvoiceConcept{},
+	}
+
+	for _, traslado := range doc.Impuestos.Traslados {
+		if traslado.Impuesto == cfdiIVA {
+			invoice.IVA = invoice.IVA.Add(amount("Traslado Importe", traslado.Importe))
+		}
+	}
+
+	for _, concepto := range doc.Conceptos {
+		quantity, parseErr := strconv.ParseFloat(concepto.Cantidad, 64)
+		if parseErr != nil && err == nil {
+			err = fmt.Errorf("invalid Cantidad %q", concepto.Cantidad)
+		}
+		invoice.Concepts = append(invoice.Concepts, models.InvoiceConcept{
+			ProductCode: concepto.ClaveProdServ,
+			Description: strings.TrimSpace(concepto.Descripcion),
+			Quantity:    quantity,
+			Unit:        concepto.Unidad,
+			UnitPrice:   amount("ValorUnitario", concepto.ValorUnitario),
+			Amount:      amount("Importe", concepto.Importe),
+		})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+	if !invoice.Total.IsPositive() {
+		return nil, fmt.Errorf("total must be positive")
+	}
+	return invoice, nil
+}
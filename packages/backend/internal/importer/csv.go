@@ -0,0 +1,270 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+)
+
+// FormatCSV identifies CSV imports
+const FormatCSV = "csv"
+
+// dateTokens translates the date notation used by most banks to Go layouts
+var dateTokens = strings.NewReplacer("YYYY", "2006", "YY", "06", "MM", "01", "DD", "02")
+
+// csvColumns holds the resolved zero-based positions of the mapped columns; -1
+// means the column is not mapped
+type csvColumns struct {
+	date, description, category, currency, amount, debit, credit int
+}
+
+// ParseCSV reads a CSV export with the mapping and returns one row per
+// non-empty data line. The error is only set when the file itself is unusable.
+func ParseCSV(r io.Reader, mapping models.ImportMapping, userID string) ([]models.ImportRow, error) {
+	if err := mapping.Validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	reader := csv.NewReader(r)
+	if mapping.Delimiter != "" {
+		reader.Comma = []rune(mapping.Delimiter)[0]
+	}
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+
+	var header []string
+	if !mapping.NoHeader {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return nil, fmt.Errorf("%w: file is empty", ErrInvalidFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		header = record
+	}
+
+	columns, err := resolveColumns(mapping, header)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+
+	layout := dateLayout(mapping.DateFormat)
+	rows := []models.ImportRow{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+		}
+		if blankRecord(record) {
+			continue
+		}
+		if len(rows) == MaxRows {
+			return nil, fmt.Errorf("%w: more than %d rows", ErrInvalidFile, MaxRows)
+		}
+
+		line, _ := reader.FieldPos(0)
+		rows = append(rows, parseRecord(record, line, columns, mapping, layout, userID))
+	}
+
+	return rows, nil
+}
+
+func resolveColumns(mapping models.ImportMapping, header []string) (csvColumns, error) {
+	var err error
+	resolve := func(name string) int {
+		if name == "" || err != nil {
+			return -1
+		}
+		var index int
+		index, err = columnIndex(name, header)
+		return index
+	}
+
+	columns := csvColumns{
+		date:        resolve(mapping.DateColumn),
+		description: resolve(mapping.DescriptionColumn),
+		category:    resolve(mapping.CategoryColumn),
+		currency:    resolve(mapping.CurrencyColumn),
+		amount:      resolve(mapping.AmountColumn),
+		debit:       resolve(mapping.DebitColumn),
+		credit:      resolve(mapping.CreditColumn),
+	}
+	return columns, err
+}
+
+// columnIndex finds a column by header name, falling back to a 1-based position
+func columnIndex(name string, header []string) (int, error) {
+	for i, column := range header {
+		// Excel prefixes UTF-8 exports with a byte order mark
+		column = strings.TrimPrefix(column, "\ufeff")
+		if strings.EqualFold(strings.TrimSpace(column), strings.TrimSpace(name)) {
+			return i, nil
+		}
+	}
+	if position, err := strconv.Atoi(strings.TrimSpace(name)); err == nil && position > 0 {
+		return position - 1, nil
+	}
+	if header == nil {
+		return -1, fmt.Errorf("column %q must be a 1-based position for files without a header", name)
+	}
+	return -1, fmt.Errorf("column %q not found in header", name)
+}
+
+func dateLayout(format string) string {
+	if format == "" {
+		return "2006-01-02"
+	}
+	return dateTokens.Replace(format)
+}
+
+func blankRecord(record []string) bool {
+	for _, field := range record {
+		if strings.TrimSpace(field) != "" {
+			return false
+		}
+	}
+	return true
+}
+
+func field(record []string, index int) string {
+	if index < 0 || index >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[index])
+}
+
+func parseRecord(record []string, line int, columns csvColumns, mapping models.ImportMapping, layout, userID string) models.ImportRow {
+	row := models.ImportRow{Line: line}
+
+	currency := mapping.Currency
+	if value := field(record, columns.currency); value != "" {
+		currency = value
+	}
+	if currency == "" {
+		currency = models.DefaultCurrency
+	}
+	currency, err := models.NormalizeCurrency(currency)
+	if err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	}
+
+	date, err := time.Parse(layout, field(record, columns.date))
+	if err != nil {
+		row.Errors = append(row.Errors, fmt.Sprintf("invalid date %q, expected format %s", field(record, columns.date), layout))
+	}
+
+	amount, err := recordAmount(record, columns, mapping, currency)
+	if err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	}
+
+	if len(row.Errors) > 0 {
+		return row
+	}
+
+	transactionType := models.TransactionTypeIncome
+	if amount.IsNegative() {
+		transactionType = models.TransactionTypeExpense
+	}
+
+	category := strings.ToLower(field(record, columns.category))
+	if category == "" {
+		category = strings.ToLower(strings.TrimSpace(mapping.DefaultCategory))
+	}
+	if category == "" {
+		category = models.DefaultImportCategory
+	}
+
+	tx := models.NewTransaction(userID, transactionType, category, field(record, columns.description), amount, date)
+	if err := tx.Validate(); err != nil {
+		row.Errors = append(row.Errors, err.Error())
+		return row
+	}
+
+	row.Transaction = tx
+	return row
+}
+
+// recordAmount returns the signed amount of a row, negative for expenses
+func recordAmount(record []string, columns csvColumns, mapping models.ImportMapping, currency string) (models.Money, error) {
+	if columns.amount >= 0 {
+		raw := field(record, columns.amount)
+		if raw == "" {
+			return models.Money{}, fmt.Errorf("amount is missing")
+		}
+		amount, err := parseAmount(raw, mapping.DecimalComma, currency)
+		if err != nil {
+			return models.Money{}, err
+		}
+		if mapping.AmountSign == models.AmountSignInverted {
+			amount = amount.Neg()
+		}
+		return amount, nil
+	}
+
+	// Debit and credit columns hold unsigned amounts; a debit is an expense
+	if raw := field(record, columns.debit); raw != "" {
+		amount, err := parseAmount(raw, mapping.DecimalComma, currency)
+		if err != nil {
+			return models.Money{}, err
+		}
+		if !amount.IsZero() {
+			return amount.Abs().Neg(), nil
+		}
+	}
+	if raw := field(record, columns.credit); raw != "" {
+		amount, err := parseAmount(raw, mapping.DecimalComma, currency)
+		if err != nil {
+			return models.Money{}, err
+		}
+		if !amount.IsZero() {
+			return amount.Abs(), nil
+		}
+	}
+	return models.Money{}, fmt.Errorf("amount is missing")
+}
+
+// parseAmount reads amounts as banks print them: with currency symbols,
+// thousands separators, a trailing minus or parentheses for negatives
+func parseAmount(raw string, decimalComma bool, currency string) (models.Money, error) {
+	value := raw
+	negative := false
+	if strings.HasPrefix(value, "(") && strings.HasSuffix(value, ")") {
+		negative = true
+		value = value[1 : len(value)-1]
+	}
+	if strings.HasSuffix(value, "-") {
+		negative = true
+		value = strings.TrimSuffix(value, "-")
+	}
+
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9', r == '-', r == '+':
+			b.WriteRune(r)
+		case r == '.' && !decimalComma, r == ',' && decimalComma:
+			b.WriteRune('.')
+		}
+	}
+	cleaned := b.String()
+	if negative && !strings.HasPrefix(cleaned, "-") {
+		cleaned = "-" + cleaned
+	}
+
+	amount, err := models.ParseMoney(cleaned, currency)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("invalid amount %q", raw)
+	}
+	return amount, nil
+}
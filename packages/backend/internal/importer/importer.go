@@ -0,0 +1,29 @@
+// Package importer parses bank statements and invoices for import. Statement
+// parsers never fail on a bad row: each row carries either a valid transaction
+// or the reasons it was rejected, so callers can preview an import before
+// committing it.
+package importer
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidFile is returned when a file cannot be read at all, or not with
+// the given mapping
+var ErrInvalidFile = errors.New("invalid import file")
+
+// MaxRows bounds the number of rows of one import
+const MaxRows = 5000
+
+// externalNamespace derives transaction IDs from the IDs of imported records
+var externalNamespace = uuid.MustParse("0b6f3d4e-58a1-4c2f-8e7d-93a5c1f2b640")
+
+// ExternalTransactionID derives the ID of the transaction imported from a
+// record of a source (a bank transaction, an invoice), so importing the same
+// record again targets the same transaction
+func ExternalTransactionID(userID, source, externalID string) string {
+	return uuid.NewSHA1(externalNamespace, []byte(strings.Join([]string{userID, source, externalID}, "#"))).String()
+}
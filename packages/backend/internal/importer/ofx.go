@@ -0,0 +1,208 @@
+package importer
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+)
+
+// FormatOFX identifies OFX and QFX imports
+const FormatOFX = "ofx"
+
+// OFX transaction types that are always money in or money out. Transfers and
+// OTHER follow the sign of the amount.
+var (
+	ofxIncomeTypes  = map[string]bool{"CREDIT": true, "DEP": true, "INT": true, "DIV": true, "DIRECTDEP": true}
+	ofxExpenseTypes = map[string]bool{
+		"DEBIT": true, "FEE": true, "SRVCHG": true, "ATM": true, "POS": true, "CHECK": true,
+		"PAYMENT": true, "CASH": true, "DIRECTDEBIT": true, "REPEATPMT": true,
+	}
+)
+
+var ofxEntities = strings.NewReplacer("&amp;", "&", "&lt;", "<", "&gt;", ">", "&quot;", `"`, "&apos;", "'", "&nbsp;", " ")
+
+// ofxTransaction holds the elements of one STMTTRN aggregate
+type ofxTransaction struct {
+	line     int
+	account  string
+	currency string
+	fields   map[string]string
+}
+
+// ParseOFX reads the bank and credit card statements of an OFX or QFX file,
+// either SGML (OFX 1.x) or XML (OFX 2.x). Each transaction's ID is derived
+// from its account and FITID, so importing a statement again yields the same
+// transactions instead of new ones.
+func ParseOFX(r io.Reader, userID string) ([]models.ImportRow, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidFile, err)
+	}
+	content := string(data)
+
+	start := strings.Index(strings.ToUpper(content), "<OFX>")
+	if start < 0 {
+		return nil, fmt.Errorf("%w: no <OFX> element found", ErrInvalidFile)
+	}
+
+	line := 1 + strings.Count(content[:start], "\n")
+	currency := models.DefaultCurrency
+	account := ""
+	var current *ofxTransaction
+	var parsed []ofxTransaction
+
+	rest := content[start:]
+	for {
+		open := strings.IndexByte(rest, '<')
+		if open < 0 {
+			break
+		}
+		line += strings.Count(rest[:open], "\n")
+		closing := strings.IndexByte(rest[open:], '>')
+		if closing < 0 {
+			return nil, fmt.Errorf("%w: unterminated tag on line %d", ErrInvalidFile, line)
+		}
+		tag := strings.ToUpper(strings.TrimSpace(rest[open+1 : open+closing]))
+		rest = rest[open+closing+1:]
+
+		// In SGML files elements are not closed: their value runs to the next tag
+		value := rest
+		if next := strings.IndexByte(rest, '<'); next >= 0 {
+			value = rest[:next]
+		}
+		value = strings.TrimSpace(ofxEntities.Replace(value))
+
+		switch {
+		case strings.HasPrefix(tag, "!"), strings.HasPrefix(tag, "?"), strings.HasSuffix(tag, "/"):
+			// Comments, processing instructions and empty elements
+		case tag == "STMTTRN":
+			current = &ofxTransaction{line: line, account: account, currency: currency, fields: map[string]string{}}
+		case tag == "/STMTTRN":
+			if current != nil {
+				parsed = append(parsed, *current)
+				current = nil
+			}
+		case strings.HasPrefix(tag, "/"):
+		case current != nil:
+			// The first occurrence wins, so a payee aggregate's NAME does not
+			// replace the transaction's own
+			if _, ok := current.fields[tag]; !ok && value != "" {
+				current.fields[tag] = value
+			}
+		case tag == "CURDEF":
+			currency = value
+		case tag == "ACCTID":
+			account = value
+		}
+	}
+
+	if len(parsed) > MaxRows {
+		return nil, fmt.Errorf("%w: more than %d transactions", ErrInvalidFile, MaxRows)
+	}
+
+	rows := make([]models.ImportRow, 0, len(parsed))
+	seen := make(map[string]bool)
+	for _, t := range parsed {
+		rows = append(rows, t.row(userID, seen))
+	}
+	return rows, nil
+}
+
+func (t ofxTransaction) row(userID string, seen map[string]bool) models.ImportRow {
+	row := models.ImportRow{Line: t.line}
+
+	fitID := t.fields["FITID"]
+	if fitID == "" {
+		row.Errors = append(row.Errors, "FITID is missing")
+	} else if key := t.account + "#" + fitID; seen[key] {
+		row.Errors = append(row.Errors, fmt.Sprintf("duplicate FITID %q", fitID))
+	} else {
+		seen[key] = true
+	}
+
+	currency, err := models.NormalizeCurrency(t.currency)
+	if err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	}
+
+	posted := t.fields["DTPOSTED"]
+	if posted == "" {
+		posted = t.fields["DTUSER"]
+	}
+	date, err := parseOFXDate(posted)
+	if err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	}
+
+	amount, err := parseOFXAmount(t.fields["TRNAMT"], currency)
+	if err != nil {
+		row.Errors = append(row.Errors, err.Error())
+	}
+
+	if len(row.Errors) > 0 {
+		return row
+	}
+
+	transactionType := models.TransactionTypeIncome
+	switch trnType := strings.ToUpper(t.fields["TRNTYPE"]); {
+	case ofxExpenseTypes[trnType]:
+		transactionType = models.TransactionTypeExpense
+	case ofxIncomeTypes[trnType]:
+	case amount.IsNegative():
+		transactionType = models.TransactionTypeExpense
+	}
+	// Some banks export debits as positive amounts; expenses are stored negative
+	amount = amount.Abs()
+	if transactionType == models.TransactionTypeExpense {
+		amount = amount.Neg()
+	}
+
+	description := t.fields["NAME"]
+	if description == "" {
+		description = t.fields["MEMO"]
+	}
+
+	tx := models.NewTransaction(userID, transactionType, models.DefaultImportCategory, description, amount, date)
+	tx.ID = ExternalTransactionID(userID, FormatOFX, t.account+"#"+fitID)
+	tx.ExternalID = fitID
+	tx.GenerateKeys()
+	if err := tx.Validate(); err != nil {
+		row.Errors = append(row.Errors, err.Error())
+		return row
+	}
+
+	row.Transaction = tx
+	return row
+}
+
+// parseOFXDate reads the calendar date of an OFX datetime such as
+// 20250115120000.000[-6:CST]. The date is kept as the bank reported it,
+// whatever its time zone.
+func parseOFXDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+func parseOFXAmount(value, currency string) (models.Money, error) {
+	if value == "" {
+		return models.Money{}, fmt.Errorf("amount is missing")
+	}
+	// Some European banks use a decimal comma
+	if !strings.Contains(value, ".") {
+		value = strings.Replace(value, ",", ".", 1)
+	}
+	amount, err := models.ParseMoney(value, currency)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+	return amount, nil
+}
@@ -0,0 +1,235 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// Account types
+const (
+	AccountTypeChecking   = "checking"
+	AccountTypeSavings    = "savings"
+	AccountTypeCreditCard = "credit_card"
+	AccountTypeCash       = "cash"
+)
+
+// Limits of the user's accounts
+const (
+	MaxAccountsPerUser   = 50
+	MaxAccountNameLength = 60
+)
+
+// CategoryTransfer is the category of both entries of a transfer
+const CategoryTransfer = "transfer"
+
+// transferNamespace derives the IDs of a transfer's entries from the transfer ID
+var transferNamespace = uuid.MustParse("0b7f6c1e-3a8d-4e52-9c4f-6d2b8a1e5f37")
+
+// Account is a bank account, credit card or cash wallet the user's
+// transactions are paid from or into. Its balance is the opening balance plus
+// every transaction linked to it, in the account's currency.
+type Account struct {
+	ID             string `json:"id" dynamodbav:"id"`
+	UserID         string `json:"user_id" dynamodbav:"user_id"`
+	Name           string `json:"name" dynamodbav:"name"`
+	Type           string `json:"type" dynamodbav:"type"` // checking, savings, credit_card or cash
+	Currency       string `json:"currency" dynamodbav:"currency"`
+	OpeningBalance Money  `json:"opening_balance" dynamodbav:"opening_balance"` // negative for a credit card's debt
+
+	// Billing terms of a credit card; its statements need them
+	CreditCard *CreditCardTerms `json:"credit_card,omitempty" dynamodbav:"credit_card,omitempty"`
+
+	// DynamoDB keys for single-table design
+	PK string `json:"-" dynamodbav:"PK"` // USER#{userID}
+	SK string `json:"-" dynamodbav:"SK"` // ACCOUNT#{id}
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" dynamodbav:"updated_at"`
+	Version   int       `json:"version" dynamodbav:"version"`
+}
+
+// GenerateKeys generates the DynamoDB keys for the account
+func (a *Account) GenerateKeys() {
+	a.PK = fmt.Sprintf("USER#%s", a.UserID)
+	a.SK = fmt.Sprintf("ACCOUNT#%s", a.ID)
+}
+
+// ToDynamoDBItem converts the account to a DynamoDB item
+func (a *Account) ToDynamoDBItem() (map[string]types.AttributeValue, error) {
+	a.GenerateKeys()
+	return attributevalue.MarshalMap(a)
+}
+
+// FromDynamoDBItem creates the account from a DynamoDB item
+func (a *Account) FromDynamoDBItem(item map[string]types.AttributeValue) error {
+	return attributevalue.UnmarshalMap(item, a)
+}
+
+// ValidAccountType reports whether t is a known account type
+func ValidAccountType(t string) bool {
+	switch t {
+	case AccountTypeChecking, AccountTypeSavings, AccountTypeCreditCard, AccountTypeCash:
+		return true
+	}
+	return false
+}
+
+// Validate normalizes and validates the account. The opening balance is in
+// the account's currency, the default one when none is given.
+func (a *Account) Validate() error {
+	if a.UserID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+
+	a.Name = strings.TrimSpace(a.Name)
+	if a.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(a.Name) > MaxAccountNameLength {
+		return fmt.Errorf("name is longer than %d characters", MaxAccountNameLength)
+	}
+
+	a.Type = strings.ToLower(strings.TrimSpace(a.Type))
+	if !ValidAccountType(a.Type) {
+		return fmt.Errorf("type must be %s, %s, %s or %s", AccountTypeChecking, AccountTypeSavings, AccountTypeCreditCard, AccountTypeCash)
+	}
+
+	if a.Currency == "" {
+		a.Currency = DefaultCurrency
+	}
+	currency, err := NormalizeCurrency(a.Currency)
+	if err != nil {
+		return err
+	}
+	a.Currency = currency
+	a.OpeningBalance.Currency = currency
+
+	if a.CreditCard != nil {
+		if a.Type != AccountTypeCreditCard {
+			return fmt.Errorf("credit_card terms are only for %s accounts", AccountTypeCreditCard)
+		}
+		if err := a.CreditCard.Validate(currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// IsTransfer reports whether the transaction is one entry of a transfer
+// between accounts. Transfers are not income or expenses.
+func (t *Transaction) IsTransfer() bool {
+	return t.Type == TransactionTypeTransfer
+}
+
+// TransferInput moves money between two of the user's accounts
+type TransferInput struct {
+	FromAccountID string    `json:"from_account_id"`
+	ToAccountID   string    `json:"to_account_id"`
+	Amount        Money     `json:"amount"`              // leaving the source account, in its currency
+	ToAmount      *Money    `json:"to_amount,omitempty"` // arriving in the destination account, when its currency differs
+	Description   string    `json:"description"`
+	Date          time.Time `json:"date"`
+}
+
+// UnmarshalJSON accepts the date as YYYY-MM-DD or RFC3339, defaulting to now
+// like Transaction
+func (in *TransferInput) UnmarshalJSON(data []byte) error {
+	type Alias TransferInput
+	aux := &struct {
+		Date string `json:"date"`
+		*Alias
+	}{
+		Alias: (*Alias)(in),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.Date == "" {
+		in.Date = time.Now()
+		return nil
+	}
+	date, err := parseDate(aux.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date: %w", err)
+	}
+	in.Date = date
+	return nil
+}
+
+// Transfer is a movement between accounts, stored as two transactions of type
+// transfer: the amount leaving one account and the amount arriving in the other
+type Transfer struct {
+	ID   string      `json:"id"`
+	From Transaction `json:"from"`
+	To   Transaction `json:"to"`
+}
+
+// TransferEntryIDs returns the IDs of the entries of a transfer. They are
+// derived from the transfer ID, so a transfer is found and deleted by its ID.
+func TransferEntryIDs(transferID string) (from, to string) {
+	from = uuid.NewSHA1(transferNamespace, []byte(transferID+"#from")).String()
+	to = uuid.NewSHA1(transferNamespace, []byte(transferID+"#to")).String()
+	return from, to
+}
+
+// NewTransfer builds the entries of a transfer: fromAmount (positive) leaves
+// the source account and toAmount arrives in the destination account
+func NewTransfer(userID string, in TransferInput, fromAmount, toAmount Money) *Transfer {
+	id := uuid.New().String()
+	fromID, toID := TransferEntryIDs(id)
+	description := strings.TrimSpace(in.Description)
+	if description == "" {
+		description = "Transferencia"
+	}
+
+	entry := func(entryID, accountID string, amount Money) Transaction {
+		t := NewTransaction(userID, TransactionTypeTransfer, CategoryTransfer, description, amount, in.Date)
+		t.ID = entryID
+		t.AccountID = accountID
+		t.TransferID = id
+		t.GenerateKeys()
+		return *t
+	}
+
+	return &Transfer{
+		ID:   id,
+		From: entry(fromID, in.FromAccountID, fromAmount.Abs().Neg()),
+		To:   entry(toID, in.ToAccountID, toAmount.Abs()),
+	}
+}
+
+// AccountBalance is an account with its current balance
+type AccountBalance struct {
+	Account
+	Balance          Money `json:"balance"`
+	TransactionCount int   `json:"transaction_count"`
+}
+
+// LedgerEntry is one transaction of an account with the balance after it
+type LedgerEntry struct {
+	TransactionID string    `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	Type          string    `json:"type"`
+	Category      string    `json:"category"`
+	Description   string    `json:"description"`
+	TransferID    string    `json:"transfer_id,omitempty"`
+	Amount        Money     `json:"amount"`  // in the account's currency
+	Balance       Money     `json:"balance"` // running balance after the transaction
+}
+
+// AccountLedger is the running balance of an account over a period
+type AccountLedger struct {
+	Account         Account       `json:"account"`
+	StartingBalance Money         `json:"starting_balance"` // before the first entry of the period
+	EndingBalance   Money         `json:"ending_balance"`
+	Entries         []LedgerEntry `json:"entries"` // oldest first
+}
@@ -0,0 +1,60 @@
+package models
+
+import "time"
+
+// Months examined for anomalies, each against the AnomalyBaselineMonths
+// before it
+const (
+	DefaultAnomalyMonths  = 3
+	MaxAnomalyMonths      = 24
+	AnomalyBaselineMonths = 6
+)
+
+// Thresholds of the anomaly detection. Scores are modified z-scores: the
+// distance from the median in units of the median absolute deviation, so a
+// few outliers in the history do not hide new ones.
+const (
+	AnomalyScoreThreshold = 3.5
+	MinAnomalySamples     = 5 // expenses of a category or merchant needed to score a transaction
+	MinSpikeMonths        = 3 // baseline months with spending in the category needed to score a month
+)
+
+// What a transaction is unusually large for
+const (
+	AnomalyScopeCategory = "category"
+	AnomalyScopeMerchant = "merchant"
+)
+
+// SpendingAnomalies are the unusual expenses and category spikes in the
+// examined months. Amounts are positive and in the user's base currency.
+type SpendingAnomalies struct {
+	Currency       string               `json:"currency"`
+	From           string               `json:"from"`            // first examined month, YYYY-MM
+	To             string               `json:"to"`              // last examined month, YYYY-MM
+	Transactions   []TransactionAnomaly `json:"transactions"`    // highest score first
+	CategorySpikes []CategorySpike      `json:"category_spikes"` // newest month first, then highest score
+}
+
+// TransactionAnomaly is an expense far larger than the category's or the
+// merchant's usual ones in the months before it
+type TransactionAnomaly struct {
+	TransactionID string    `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	Description   string    `json:"description"`
+	Category      string    `json:"category"`
+	Amount        Money     `json:"amount"`
+	Scope         string    `json:"scope"`   // category or merchant, whichever scored higher
+	Typical       Money     `json:"typical"` // median expense of the scope
+	Score         float64   `json:"score"`
+}
+
+// CategorySpike is a month whose spending in a category is far above the
+// category's usual monthly total
+type CategorySpike struct {
+	Month           string   `json:"month"` // YYYY-MM
+	Category        string   `json:"category"`
+	Amount          Money    `json:"amount"`
+	Typical         Money    `json:"typical"` // median monthly total of the baseline months
+	Score           float64  `json:"score"`
+	IncreasePercent *float64 `json:"increase_percent,omitempty"` // over the typical total, absent when it is zero
+}
@@ -0,0 +1,47 @@
+package models
+
+import "time"
+
+// Months of spending a budget's intra-month curve is learned from. With fewer
+// of them having spending in the category, the curve is a straight line.
+const (
+	PacingHistoryMonths = 6
+	MinPacingMonths     = 3
+)
+
+// PaceTolerancePercent is how far from the expected spend to date, as a
+// percentage of the budget, a budget still counts as on track
+const PaceTolerancePercent = 5.0
+
+// Where a budget's intra-month spending curve comes from
+const (
+	PacingCurveHistory = "history" // the category's spending in the months before
+	PacingCurveLinear  = "linear"  // the same spend every day
+)
+
+// Pace of a budget's spending
+const (
+	PaceAhead   = "ahead"    // spending faster than usual for this point of the month
+	PaceOnTrack = "on_track" // within PaceTolerancePercent of the usual pace
+	PaceBehind  = "behind"   // spending slower than usual
+)
+
+// BudgetPacing is a budget's utilization up to a day of its month, compared
+// with how the category's spending usually builds up over a month and
+// projected to the month's end
+type BudgetPacing struct {
+	BudgetUtilization
+
+	Day         int    `json:"day"` // days of the month elapsed, 0 before it starts
+	DaysInMonth int    `json:"days_in_month"`
+	Curve       string `json:"curve"` // history or linear
+
+	// Share of a month's spending usually done by Day, and that share of the budget
+	ExpectedPercent float64 `json:"expected_percent"`
+	ExpectedSpent   Money   `json:"expected_spent"`
+	Pace            string  `json:"pace"`
+
+	ProjectedSpent     Money      `json:"projected_spent"`     // at the end of the month
+	ProjectedRemaining Money      `json:"projected_remaining"` // budget minus projected spend, negative when over
+	RunOutDate         *time.Time `json:"run_out_date,omitempty"`
+}
@@ -0,0 +1,249 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Limits of the category catalog
+const (
+	MaxCategoriesPerUser = 200
+	MaxCategoryIDLength  = 40
+	MaxCategoryIconSize  = 32
+)
+
+// Languages of category display names
+const (
+	LanguageSpanish = "es"
+	LanguageEnglish = "en"
+)
+
+var (
+	categoryIDPattern    = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]*$`)
+	categoryColorPattern = regexp.MustCompile(`^#[0-9A-F]{6}$`)
+)
+
+// Category is an entry of the user's category catalog. Transactions, budgets
+// and rules refer to it by ID. Categories nest one level: a category with a
+// parent cannot have children of its own.
+type Category struct {
+	ID     string `json:"id" dynamodbav:"id"` // the value stored in transactions' category
+	UserID string `json:"user_id" dynamodbav:"user_id"`
+	Parent string `json:"parent,omitempty" dynamodbav:"parent,omitempty"`
+	NameES string `json:"name_es" dynamodbav:"name_es"`
+	NameEN string `json:"name_en" dynamodbav:"name_en"`
+	Color  string `json:"color,omitempty" dynamodbav:"color,omitempty"` // #RRGGBB
+	Icon   string `json:"icon,omitempty" dynamodbav:"icon,omitempty"`   // icon name understood by the clients
+
+	// DynamoDB keys for single-table design
+	PK string `json:"-" dynamodbav:"PK"` // USER#{userID}
+	SK string `json:"-" dynamodbav:"SK"` // CATEGORY#{id}
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" dynamodbav:"updated_at"`
+	Version   int       `json:"version" dynamodbav:"version"`
+}
+
+// CategoryChange reports what renaming or merging a category rewrote
+type CategoryChange struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Transactions int    `json:"transactions"` // transactions moved to the new category
+	Rules        int    `json:"rules"`        // categorization rules that now assign it
+	Recurring    int    `json:"recurring"`    // recurring transactions that now generate it
+	Children     int    `json:"children"`     // subcategories moved under it
+	Budgets      int    `json:"budgets"`      // monthly budgets moved to it, or added to its own
+}
+
+// GenerateKeys generates the DynamoDB keys for the category
+func (c *Category) GenerateKeys() {
+	c.PK = fmt.Sprintf("USER#%s", c.UserID)
+	c.SK = fmt.Sprintf("CATEGORY#%s", c.ID)
+}
+
+// ToDynamoDBItem converts the category to a DynamoDB item
+func (c *Category) ToDynamoDBItem() (map[string]types.AttributeValue, error) {
+	c.GenerateKeys()
+	return attributevalue.MarshalMap(c)
+}
+
+// FromDynamoDBItem creates the category from a DynamoDB item
+func (c *Category) FromDynamoDBItem(item map[string]types.AttributeValue) error {
+	return attributevalue.UnmarshalMap(item, c)
+}
+
+// NormalizeCategoryID lowercases and trims a category, so "Food " and "food"
+// refer to the same catalog entry
+func NormalizeCategoryID(id string) string {
+	return strings.ToLower(strings.TrimSpace(id))
+}
+
+// ValidateCategoryID checks that the ID is a lowercase slug
+func ValidateCategoryID(id string) error {
+	if id == "" {
+		return fmt.Errorf("id is required")
+	}
+	if len(id) > MaxCategoryIDLength {
+		return fmt.Errorf("id must be at most %d characters", MaxCategoryIDLength)
+	}
+	if !categoryIDPattern.MatchString(id) {
+		return fmt.Errorf("id must contain only lowercase letters, digits, '-' and '_'")
+	}
+	return nil
+}
+
+// Validate normalizes the category and checks its fields. A missing display
+// name is filled with the other language's one.
+func (c *Category) Validate() error {
+	if c.UserID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+	c.ID = NormalizeCategoryID(c.ID)
+	if err := ValidateCategoryID(c.ID); err != nil {
+		return err
+	}
+	c.Parent = NormalizeCategoryID(c.Parent)
+	if c.Parent == c.ID {
+		return fmt.Errorf("a category cannot be its own parent")
+	}
+
+	c.NameES = strings.TrimSpace(c.NameES)
+	c.NameEN = strings.TrimSpace(c.NameEN)
+	if c.NameES == "" && c.NameEN == "" {
+		return fmt.Errorf("name_es or name_en is required")
+	}
+	if c.NameES == "" {
+		c.NameES = c.NameEN
+	}
+	if c.NameEN == "" {
+		c.NameEN = c.NameES
+	}
+
+	c.Color = strings.ToUpper(strings.TrimSpace(c.Color))
+	if c.Color != "" && !categoryColorPattern.MatchString(c.Color) {
+		return fmt.Errorf("color must be a hex color like #1E88E5")
+	}
+	c.Icon = strings.TrimSpace(c.Icon)
+	if len(c.Icon) > MaxCategoryIconSize {
+		return fmt.Errorf("icon must be at most %d characters", MaxCategoryIconSize)
+	}
+	return nil
+}
+
+// Name returns the display name in the language, Spanish by default
+func (c *Category) Name(language string) string {
+	if language == LanguageEnglish {
+		return c.NameEN
+	}
+	return c.NameES
+}
+
+// ValidLanguage reports whether display names exist in the language; empty
+// means the default
+func ValidLanguage(language string) bool {
+	return language == "" || language == LanguageSpanish || language == LanguageEnglish
+}
+
+// defaultCategory describes an entry of the catalog every user starts with
+type defaultCategory struct {
+	id, nameES, nameEN, color, icon string
+}
+
+var defaultCategories = []defaultCategory{
+	{CategorySalary, "Salario", "Salary", "#43A047", "briefcase"},
+	{CategoryRent, "Renta", "Rent", "#8E24AA", "home"},
+	{CategoryGroceries, "Supermercado", "Groceries", "#FB8C00", "shopping-cart"},
+	{CategoryUtilities, "Servicios", "Utilities", "#00ACC1", "bolt"},
+	{CategoryDining, "Restaurantes", "Dining", "#E53935", "utensils"},
+	{CategoryTransportation, "Transporte", "Transportation", "#1E88E5", "car"},
+	{CategoryEntertainment, "Entretenimiento", "Entertainment", "#D81B60", "film"},
+	{CategoryHealthcare, "Salud", "Healthcare", "#00897B", "heart-pulse"},
+	{CategoryShopping, "Compras", "Shopping", "#6D4C41", "bag"},
+	{CategoryOther, "Otros", "Other", "#757575", "dots"},
+	{DefaultImportCategory, "Sin categoría", "Uncategorized", "#BDBDBD", "question"},
+}
+
+// DefaultCategories returns the catalog a user has until they change it
+func DefaultCategories(userID string) []Category {
+	categories := make([]Category, 0, len(defaultCategories))
+	for _, d := range defaultCategories {
+		categories = append(categories, Category{
+			ID:      d.id,
+			UserID:  userID,
+			NameES:  d.nameES,
+			NameEN:  d.nameEN,
+			Color:   d.color,
+			Icon:    d.icon,
+			Version: 1,
+		})
+	}
+	return categories
+}
+
+// CategoryCatalog looks up the categories transactions refer to
+type CategoryCatalog map[string]Category
+
+// NewCategoryCatalog indexes the categories by ID
+func NewCategoryCatalog(categories []Category) CategoryCatalog {
+	catalog := make(CategoryCatalog, len(categories))
+	for _, category := range categories {
+		catalog[category.ID] = category
+	}
+	return catalog
+}
+
+// Lookup finds the catalog entry of a transaction's category, ignoring case
+func (c CategoryCatalog) Lookup(category string) (Category, bool) {
+	entry, ok := c[NormalizeCategoryID(category)]
+	return entry, ok
+}
+
+// RollUp returns the parent of the category, or the category itself when it
+// is top-level or not in the catalog
+func (c CategoryCatalog) RollUp(category string) string {
+	if entry, ok := c.Lookup(category); ok && entry.Parent != "" {
+		return entry.Parent
+	}
+	return category
+}
+
+// Label returns the display name of the category in the language. Categories
+// missing from the catalog are shown capitalized.
+func (c CategoryCatalog) Label(category, language string) string {
+	if entry, ok := c.Lookup(category); ok {
+		return entry.Name(language)
+	}
+	if category == "" {
+		return ""
+	}
+	return strings.ToUpper(category[:1]) + category[1:]
+}
+
+// Children returns the IDs of the subcategories of the category
+func (c CategoryCatalog) Children(id string) []string {
+	var children []string
+	for _, entry := range c {
+		if entry.Parent == id {
+			children = append(children, entry.ID)
+		}
+	}
+	sort.Strings(children)
+	return children
+}
+
+// Annotate sets the display fields of a breakdown entry from the catalog
+func (c CategoryCatalog) Annotate(breakdown *CategoryBreakdown, language string) {
+	breakdown.Label = c.Label(breakdown.Category, language)
+	if entry, ok := c.Lookup(breakdown.Category); ok {
+		breakdown.Color = entry.Color
+		breakdown.Icon = entry.Icon
+		breakdown.Parent = entry.Parent
+	}
+}
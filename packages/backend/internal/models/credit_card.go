@@ -0,0 +1,138 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Limits of a credit card's terms
+const (
+	MaxCardAPR        = 200.0 // percent
+	MaxCardStatements = 24
+)
+
+// Minimum payment of a statement: the larger of a share of the statement
+// balance and a share of the credit limit, as Mexican card issuers compute it
+const (
+	MinimumPaymentBalanceRate = 0.015
+	MinimumPaymentLimitRate   = 0.0125
+)
+
+// CreditCardTerms are the billing terms of a credit card account. A cycle
+// closes on ClosingDay and its statement is due on the next DueDay; days past
+// the end of a month fall on its last day.
+type CreditCardTerms struct {
+	ClosingDay  int     `json:"closing_day" dynamodbav:"closing_day"` // 1-31, cut-off day of each cycle
+	DueDay      int     `json:"due_day" dynamodbav:"due_day"`         // 1-31, payment due day after the cut-off
+	CreditLimit Money   `json:"credit_limit" dynamodbav:"credit_limit"`
+	APR         float64 `json:"apr" dynamodbav:"apr"` // annual interest rate in percent, e.g. 54.9
+}
+
+// Validate checks the terms of a card in the given currency
+func (c *CreditCardTerms) Validate(currency string) error {
+	if c.ClosingDay < 1 || c.ClosingDay > 31 {
+		return fmt.Errorf("closing_day must be between 1 and 31")
+	}
+	if c.DueDay < 1 || c.DueDay > 31 {
+		return fmt.Errorf("due_day must be between 1 and 31")
+	}
+	c.CreditLimit.Currency = currency
+	if !c.CreditLimit.IsPositive() {
+		return fmt.Errorf("credit_limit must be positive")
+	}
+	if c.APR < 0 || c.APR > MaxCardAPR {
+		return fmt.Errorf("apr must be between 0 and %g", MaxCardAPR)
+	}
+	return nil
+}
+
+// dayIn returns the given day of a month, or its last day when the month is shorter
+func dayIn(year int, month time.Month, day int) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	if day > last {
+		day = last
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// ClosingOn returns the closing date of the cycle the day t falls in
+func (c *CreditCardTerms) ClosingOn(t time.Time) time.Time {
+	t = t.UTC()
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	closing := dayIn(t.Year(), t.Month(), c.ClosingDay)
+	if day.After(closing) {
+		closing = dayIn(t.Year(), t.Month()+1, c.ClosingDay)
+	}
+	return closing
+}
+
+// PreviousClosing returns the closing date of the cycle before the one
+// closing on closing
+func (c *CreditCardTerms) PreviousClosing(closing time.Time) time.Time {
+	return dayIn(closing.Year(), closing.Month()-1, c.ClosingDay)
+}
+
+// DueDate returns the payment due date of the statement closing on closing:
+// the first DueDay after it
+func (c *CreditCardTerms) DueDate(closing time.Time) time.Time {
+	due := dayIn(closing.Year(), closing.Month(), c.DueDay)
+	if !due.After(closing) {
+		due = dayIn(closing.Year(), closing.Month()+1, c.DueDay)
+	}
+	return due
+}
+
+// MinimumPayment returns the minimum payment of a statement balance owed on
+// a card with the given limit, never more than the balance
+func MinimumPayment(balance, limit Money) Money {
+	if !balance.IsPositive() {
+		return ZeroMoney(balance.Currency)
+	}
+	minimum := balance.MulFloat(MinimumPaymentBalanceRate)
+	if floor := limit.MulFloat(MinimumPaymentLimitRate); floor.Cmp(minimum) > 0 {
+		minimum = NewMoney(floor.Minor, balance.Currency)
+	}
+	if minimum.Cmp(balance) > 0 {
+		return balance
+	}
+	return minimum
+}
+
+// CardStatement is one billing cycle of a credit card. Balances are what the
+// user owes, so they are positive while there is debt.
+type CardStatement struct {
+	PeriodStart       time.Time `json:"period_start"`
+	ClosingDate       time.Time `json:"closing_date"`
+	DueDate           time.Time `json:"due_date"`
+	Closed            bool      `json:"closed"` // false for the cycle in progress, whose figures are so far
+	PreviousBalance   Money     `json:"previous_balance"`
+	Charges           Money     `json:"charges"`  // purchases and fees in the cycle
+	Payments          Money     `json:"payments"` // payments, refunds and transfers into the card in the cycle
+	StatementBalance  Money     `json:"statement_balance"`
+	MinimumPayment    Money     `json:"minimum_payment"`
+	PaidSinceClosing  Money     `json:"paid_since_closing"` // payments between the closing and due dates
+	AmountDue         Money     `json:"amount_due"`         // left to pay by the due date to avoid interest
+	ProjectedInterest Money     `json:"projected_interest"` // charged over the next cycle if the amount due is left unpaid
+	Utilization       float64   `json:"utilization"`        // statement balance as a percentage of the credit limit
+}
+
+// CreditCardStatements is the state of a credit card account with its most
+// recent statements, newest first
+type CreditCardStatements struct {
+	Account         Account         `json:"account"`
+	CurrentBalance  Money           `json:"current_balance"` // owed today
+	AvailableCredit Money           `json:"available_credit"`
+	Utilization     float64         `json:"utilization"` // current balance as a percentage of the credit limit
+	Statements      []CardStatement `json:"statements"`
+}
+
+// PaymentDue is an upcoming credit card payment
+type PaymentDue struct {
+	AccountID        string    `json:"account_id"`
+	AccountName      string    `json:"account_name"`
+	DueDate          time.Time `json:"due_date"`
+	DaysUntilDue     int       `json:"days_until_due"`
+	StatementBalance Money     `json:"statement_balance"`
+	MinimumPayment   Money     `json:"minimum_payment"`
+	AmountDue        Money     `json:"amount_due"`
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"strings"
+	"time"
+	"unicode"
+)
+
+// How creating or importing a transaction treats a likely duplicate of a
+// stored one
+const (
+	DuplicatePolicyFlag = "flag" // create it with duplicate_of pointing to the stored one
+	DuplicatePolicySkip = "skip" // do not create it
+)
+
+// DuplicateWindowDays is how many days apart two transactions may be dated
+// and still be taken for the same movement entered twice
+const DuplicateWindowDays = 1
+
+var accentFolder = strings.NewReplacer(
+	"á", "a", "à", "a", "ä", "a", "â", "a",
+	"é", "e", "è", "e", "ë", "e", "ê", "e",
+	"í", "i", "ì", "i", "ï", "i", "î", "i",
+	"ó", "o", "ò", "o", "ö", "o", "ô", "o",
+	"ú", "u", "ù", "u", "ü", "u", "û", "u",
+	"ñ", "n", "ç", "c",
+)
+
+// DuplicatePair is a transaction suspected to repeat an earlier one
+type DuplicatePair struct {
+	Original  Transaction `json:"original"`
+	Duplicate Transaction `json:"duplicate"`
+	DaysApart int         `json:"days_apart"`
+}
+
+// ValidDuplicatePolicy reports whether policy is empty or a known policy
+func ValidDuplicatePolicy(policy string) bool {
+	return policy == "" || policy == DuplicatePolicyFlag || policy == DuplicatePolicySkip
+}
+
+// FoldDescription reduces a description to lowercase words without accents
+// or punctuation, so "Café  OXXO #12" and "cafe oxxo 12" compare equal. Digits
+// are kept: duplicates and rules tell "OXXO 12" from "OXXO 31", unlike the
+// merchant key of subscription detection.
+func FoldDescription(description string) string {
+	folded := accentFolder.Replace(strings.ToLower(description))
+	words := strings.FieldsFunc(folded, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, " ")
+}
+
+// DaysApart returns how many calendar days separate the two transactions
+func (t *Transaction) DaysApart(other *Transaction) int {
+	days := int(calendarDay(t.Date).Sub(calendarDay(other.Date)).Hours() / 24)
+	if days < 0 {
+		return -days
+	}
+	return days
+}
+
+// IsLikelyDuplicate reports whether other looks like the same movement as t
+// entered twice: same type, amount and normalized description, dated at most
+// DuplicateWindowDays apart
+func (t *Transaction) IsLikelyDuplicate(other *Transaction) bool {
+	return t.ID != other.ID &&
+		t.Type == other.Type &&
+		t.Amount.Currency == other.Amount.Currency &&
+		t.Amount.Cmp(other.Amount) == 0 &&
+		t.DaysApart(other) <= DuplicateWindowDays &&
+		FoldDescription(t.Description) == FoldDescription(other.Description)
+}
+
+func calendarDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,95 @@
+package models
+
+import "time"
+
+// Limits of the cash-flow forecast and its backtest
+const (
+	DefaultForecastDays   = 30
+	MaxForecastDays       = 90
+	DefaultBacktestMonths = 3
+	MaxBacktestMonths     = 12
+)
+
+// ForecastLookbackDays is how many days of history the average daily spend
+// and income per category are taken from
+const ForecastLookbackDays = 90
+
+// Sources of the expected transactions in a forecast
+const (
+	ForecastSourceRecurring    = "recurring"    // an occurrence of a recurring transaction
+	ForecastSourceSubscription = "subscription" // the next charge of a detected subscription
+	ForecastSourceIncome       = "income"       // the next payment of income detected in the history
+)
+
+// CashFlowForecast projects the user's balance day by day. Amounts are in the
+// user's base currency; expenses are positive.
+type CashFlowForecast struct {
+	Currency         string    `json:"currency"`
+	AsOf             time.Time `json:"as_of"` // last day of known transactions
+	Days             int       `json:"days"`
+	StartingBalance  Money     `json:"starting_balance"` // balance of the accounts, or without accounts income minus expenses, up to as_of
+	EndingBalance    Money     `json:"ending_balance"`
+	ExpectedIncome   Money     `json:"expected_income"`
+	ExpectedExpenses Money     `json:"expected_expenses"`
+
+	LowestBalance     Money      `json:"lowest_balance"`
+	LowestBalanceDate time.Time  `json:"lowest_balance_date"`
+	OverdraftRisk     bool       `json:"overdraft_risk"`           // the balance is projected below zero
+	OverdraftDate     *time.Time `json:"overdraft_date,omitempty"` // first day below zero
+
+	DailySpending []CategorySpendRate `json:"daily_spending"` // spend not covered by recurring charges, largest first
+	DailyIncome   []CategorySpendRate `json:"daily_income"`   // income not covered by recurring or detected payments, largest first
+	Balances      []ForecastDay       `json:"balances"`
+}
+
+// CategorySpendRate is the average daily spend or income of a category
+// projected into every day of a forecast
+type CategorySpendRate struct {
+	Category    string `json:"category"`
+	DailyAmount Money  `json:"daily_amount"`
+}
+
+// ForecastDay is one projected day
+type ForecastDay struct {
+	Date     time.Time       `json:"date"`
+	Income   Money           `json:"income"`
+	Expenses Money           `json:"expenses"`
+	Balance  Money           `json:"balance"` // at the end of the day
+	Events   []ForecastEvent `json:"events,omitempty"`
+}
+
+// ForecastEvent is an expected recurring transaction, subscription charge or
+// income payment
+type ForecastEvent struct {
+	Source      string `json:"source"` // recurring, subscription or income
+	Description string `json:"description"`
+	Category    string `json:"category"`
+	Amount      Money  `json:"amount"` // signed like a transaction
+}
+
+// ForecastBacktest scores the forecast against past months, each forecast
+// from the history before it
+type ForecastBacktest struct {
+	Currency string          `json:"currency"`
+	Months   []BacktestMonth `json:"months"` // oldest first
+
+	// Mean of the months' error percentages, and 100 minus it (at least 0);
+	// absent when no month had transactions
+	MeanErrorPercent *float64 `json:"mean_error_percent,omitempty"`
+	Accuracy         *float64 `json:"accuracy,omitempty"`
+}
+
+// BacktestMonth compares the forecast of one month with what happened
+type BacktestMonth struct {
+	Month             string `json:"month"` // YYYY-MM
+	PredictedIncome   Money  `json:"predicted_income"`
+	ActualIncome      Money  `json:"actual_income"`
+	PredictedExpenses Money  `json:"predicted_expenses"`
+	ActualExpenses    Money  `json:"actual_expenses"`
+	PredictedNet      Money  `json:"predicted_net"`
+	ActualNet         Money  `json:"actual_net"`
+
+	// Income and expenses missed, as a percentage of the month's actual
+	// income plus expenses; absent for months without transactions
+	ErrorPercent *float64 `json:"error_percent,omitempty"`
+}
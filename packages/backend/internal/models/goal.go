@@ -0,0 +1,235 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Limits of the user's savings goals
+const (
+	MaxGoalsPerUser           = 50
+	MaxGoalNameLength         = 60
+	MaxContributionNoteLength = 200
+)
+
+// GoalSavingsMonths is how many complete months of net savings the goal
+// projections average
+const GoalSavingsMonths = 6
+
+// Goal is an amount the user is saving toward. Progress is the sum of the
+// goal's contributions plus, when linked, the balance of an account or the
+// transactions carrying a tag.
+type Goal struct {
+	ID           string     `json:"id" dynamodbav:"id"`
+	UserID       string     `json:"user_id" dynamodbav:"user_id"`
+	Name         string     `json:"name" dynamodbav:"name"`
+	Currency     string     `json:"currency" dynamodbav:"currency"`
+	TargetAmount Money      `json:"target_amount" dynamodbav:"target_amount"`
+	TargetDate   *time.Time `json:"target_date,omitempty" dynamodbav:"target_date,omitempty"`
+	AccountID    string     `json:"account_id,omitempty" dynamodbav:"account_id,omitempty"` // its balance counts as saved
+	Tag          string     `json:"tag,omitempty" dynamodbav:"tag,omitempty"`               // transactions carrying it count as saved
+
+	// DynamoDB keys for single-table design
+	PK string `json:"-" dynamodbav:"PK"` // USER#{userID}
+	SK string `json:"-" dynamodbav:"SK"` // GOAL#{id}
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" dynamodbav:"updated_at"`
+	Version   int       `json:"version" dynamodbav:"version"`
+}
+
+// UnmarshalJSON accepts the target date as YYYY-MM-DD or RFC3339
+func (g *Goal) UnmarshalJSON(data []byte) error {
+	type Alias Goal
+	aux := &struct {
+		TargetDate string `json:"target_date"`
+		*Alias
+	}{
+		Alias: (*Alias)(g),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	g.TargetDate = nil
+	if aux.TargetDate != "" {
+		date, err := parseDate(aux.TargetDate)
+		if err != nil {
+			return fmt.Errorf("invalid target_date: %w", err)
+		}
+		g.TargetDate = &date
+	}
+	return nil
+}
+
+// GenerateKeys generates the DynamoDB keys for the goal
+func (g *Goal) GenerateKeys() {
+	g.PK = fmt.Sprintf("USER#%s", g.UserID)
+	g.SK = fmt.Sprintf("GOAL#%s", g.ID)
+}
+
+// ToDynamoDBItem converts the goal to a DynamoDB item
+func (g *Goal) ToDynamoDBItem() (map[string]types.AttributeValue, error) {
+	g.GenerateKeys()
+	return attributevalue.MarshalMap(g)
+}
+
+// FromDynamoDBItem creates the goal from a DynamoDB item
+func (g *Goal) FromDynamoDBItem(item map[string]types.AttributeValue) error {
+	return attributevalue.UnmarshalMap(item, g)
+}
+
+// Validate normalizes and validates the goal. The target is in the goal's
+// currency, the default one when none is given.
+func (g *Goal) Validate() error {
+	if g.UserID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+
+	g.Name = strings.TrimSpace(g.Name)
+	if g.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if len(g.Name) > MaxGoalNameLength {
+		return fmt.Errorf("name is longer than %d characters", MaxGoalNameLength)
+	}
+
+	if g.Currency == "" {
+		g.Currency = DefaultCurrency
+	}
+	currency, err := NormalizeCurrency(g.Currency)
+	if err != nil {
+		return err
+	}
+	g.Currency = currency
+	g.TargetAmount.Currency = currency
+	if !g.TargetAmount.IsPositive() {
+		return fmt.Errorf("target_amount must be positive")
+	}
+
+	if g.TargetDate != nil {
+		date := g.TargetDate.UTC()
+		day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+		g.TargetDate = &day
+	}
+
+	g.AccountID = strings.TrimSpace(g.AccountID)
+	if tags := NormalizeTags([]string{g.Tag}); len(tags) > 0 {
+		g.Tag = tags[0]
+	} else {
+		g.Tag = ""
+	}
+	if g.AccountID != "" && g.Tag != "" {
+		return fmt.Errorf("a goal is linked to an account or a tag, not both")
+	}
+	if len(g.Tag) > MaxTagLength {
+		return fmt.Errorf("tag is longer than %d characters", MaxTagLength)
+	}
+	return nil
+}
+
+// GoalContribution is money the user put toward a goal, or took out of it
+// when negative
+type GoalContribution struct {
+	ID     string    `json:"id" dynamodbav:"id"`
+	UserID string    `json:"user_id" dynamodbav:"user_id"`
+	GoalID string    `json:"goal_id" dynamodbav:"goal_id"`
+	Amount Money     `json:"amount" dynamodbav:"amount"` // in the goal's currency
+	Date   time.Time `json:"date" dynamodbav:"date"`
+	Note   string    `json:"note,omitempty" dynamodbav:"note,omitempty"`
+
+	// DynamoDB keys for single-table design
+	PK string `json:"-" dynamodbav:"PK"` // USER#{userID}
+	SK string `json:"-" dynamodbav:"SK"` // GOALCONTRIBUTION#{goalID}#{id}
+
+	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
+}
+
+// UnmarshalJSON accepts the date as YYYY-MM-DD or RFC3339, defaulting to now
+func (c *GoalContribution) UnmarshalJSON(data []byte) error {
+	type Alias GoalContribution
+	aux := &struct {
+		Date string `json:"date"`
+		*Alias
+	}{
+		Alias: (*Alias)(c),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	if aux.Date == "" {
+		c.Date = time.Now()
+		return nil
+	}
+	date, err := parseDate(aux.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date: %w", err)
+	}
+	c.Date = date
+	return nil
+}
+
+// GenerateKeys generates the DynamoDB keys for the contribution, grouped
+// under its goal
+func (c *GoalContribution) GenerateKeys() {
+	c.PK = fmt.Sprintf("USER#%s", c.UserID)
+	c.SK = fmt.Sprintf("GOALCONTRIBUTION#%s#%s", c.GoalID, c.ID)
+}
+
+// ToDynamoDBItem converts the contribution to a DynamoDB item
+func (c *GoalContribution) ToDynamoDBItem() (map[string]types.AttributeValue, error) {
+	c.GenerateKeys()
+	return attributevalue.MarshalMap(c)
+}
+
+// FromDynamoDBItem creates the contribution from a DynamoDB item
+func (c *GoalContribution) FromDynamoDBItem(item map[string]types.AttributeValue) error {
+	return attributevalue.UnmarshalMap(item, c)
+}
+
+// Validate checks a contribution to a goal in the given currency
+func (c *GoalContribution) Validate(currency string) error {
+	c.Amount.Currency = currency
+	if c.Amount.IsZero() {
+		return fmt.Errorf("amount cannot be zero")
+	}
+	c.Note = strings.TrimSpace(c.Note)
+	if len(c.Note) > MaxContributionNoteLength {
+		return fmt.Errorf("note is longer than %d characters", MaxContributionNoteLength)
+	}
+	if c.Date.IsZero() {
+		c.Date = time.Now()
+	}
+	return nil
+}
+
+// GoalProgress is how far a goal is and when it will be reached at the
+// user's usual pace of saving
+type GoalProgress struct {
+	Goal          Goal    `json:"goal"`
+	Saved         Money   `json:"saved"`         // contributions plus the linked account or tag
+	Contributions Money   `json:"contributions"` // recorded toward the goal
+	Linked        Money   `json:"linked"`        // balance of the linked account or total of the linked tag
+	Remaining     Money   `json:"remaining"`
+	Percentage    float64 `json:"percentage"`
+	Complete      bool    `json:"complete"`
+
+	// Income minus expenses per month over the last complete months, and when
+	// the remaining amount is reached at that pace; absent when not saving
+	AverageMonthlySavings Money      `json:"average_monthly_savings"`
+	ProjectedCompletion   *time.Time `json:"projected_completion,omitempty"`
+
+	// Only for goals with a target date
+	MonthsLeft      int    `json:"months_left,omitempty"`
+	RequiredMonthly *Money `json:"required_monthly,omitempty"` // to reach the target by its date
+	OnTrack         *bool  `json:"on_track,omitempty"`         // projected completion by the target date
+}
@@ -0,0 +1,84 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// IdempotencyKeyTTL is how long the transaction created with an
+// Idempotency-Key is replayed for requests with the same key
+const IdempotencyKeyTTL = 24 * time.Hour
+
+// MaxIdempotencyKeyLength bounds client-chosen idempotency keys
+const MaxIdempotencyKeyLength = 255
+
+// IdempotencyKey remembers which transaction a POST /transactions request
+// with an Idempotency-Key header created, so a retry returns it instead of
+// creating another one
+type IdempotencyKey struct {
+	Key           string    `json:"key" dynamodbav:"key"`
+	UserID        string    `json:"user_id" dynamodbav:"user_id"`
+	TransactionID string    `json:"transaction_id" dynamodbav:"transaction_id"`
+	RequestHash   string    `json:"request_hash" dynamodbav:"request_hash"` // fingerprint of the request body
+	CreatedAt     time.Time `json:"created_at" dynamodbav:"created_at"`
+	ExpiresAt     int64     `json:"expires_at" dynamodbav:"expires_at"` // Unix seconds; the table's TTL attribute
+
+	// DynamoDB keys for single-table design
+	PK string `json:"-" dynamodbav:"PK"` // USER#{userID}
+	SK string `json:"-" dynamodbav:"SK"` // IDEMPOTENCY#{key}
+}
+
+// NewIdempotencyKey records that the request with the key and hash creates
+// the transaction, expiring IdempotencyKeyTTL after now
+func NewIdempotencyKey(userID, key, transactionID, requestHash string, now time.Time) *IdempotencyKey {
+	k := &IdempotencyKey{
+		Key:           key,
+		UserID:        userID,
+		TransactionID: transactionID,
+		RequestHash:   requestHash,
+		CreatedAt:     now,
+		ExpiresAt:     now.Add(IdempotencyKeyTTL).Unix(),
+	}
+	k.GenerateKeys()
+	return k
+}
+
+// GenerateKeys generates the DynamoDB keys for the idempotency key
+func (k *IdempotencyKey) GenerateKeys() {
+	k.PK = fmt.Sprintf("USER#%s", k.UserID)
+	k.SK = fmt.Sprintf("IDEMPOTENCY#%s", k.Key)
+}
+
+// Expired reports whether the key can be reused. DynamoDB deletes expired
+// items lazily, so they may still be read for a while.
+func (k *IdempotencyKey) Expired(now time.Time) bool {
+	return now.Unix() >= k.ExpiresAt
+}
+
+// ToDynamoDBItem converts the idempotency key to a DynamoDB item
+func (k *IdempotencyKey) ToDynamoDBItem() (map[string]types.AttributeValue, error) {
+	k.GenerateKeys()
+	return attributevalue.MarshalMap(k)
+}
+
+// FromDynamoDBItem creates the idempotency key from a DynamoDB item
+func (k *IdempotencyKey) FromDynamoDBItem(item map[string]types.AttributeValue) error {
+	return attributevalue.UnmarshalMap(item, k)
+}
+
+// ValidateIdempotencyKey checks that a client-chosen key is 1-255 visible
+// ASCII characters
+func ValidateIdempotencyKey(key string) error {
+	if key == "" || len(key) > MaxIdempotencyKeyLength {
+		return fmt.Errorf("idempotency key must be 1-%d characters", MaxIdempotencyKeyLength)
+	}
+	for _, r := range key {
+		if r < 0x21 || r > 0x7e {
+			return fmt.Errorf("idempotency key must contain only visible ASCII characters")
+		}
+	}
+	return nil
+}
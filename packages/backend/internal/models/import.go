@@ -0,0 +1,131 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Amount sign conventions of bank exports
+const (
+	AmountSignSigned   = "signed"   // negative amounts are expenses (default)
+	AmountSignInverted = "inverted" // positive amounts are expenses, as in most credit card exports
+)
+
+// DefaultImportCategory is used for imported rows without a category
+const DefaultImportCategory = "uncategorized"
+
+var mappingNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// ImportMapping describes how the columns of a bank CSV export map to
+// transaction fields. Columns are given by header name (case-insensitive) or,
+// for files without a header row, by 1-based position.
+type ImportMapping struct {
+	Name   string `json:"name" dynamodbav:"name"` // lowercase letters, digits, "-" and "_"
+	UserID string `json:"-" dynamodbav:"user_id"`
+
+	Delimiter string `json:"delimiter,omitempty" dynamodbav:"delimiter,omitempty"` // defaults to ","
+	NoHeader  bool   `json:"no_header,omitempty" dynamodbav:"no_header,omitempty"`
+
+	DateColumn        string `json:"date_column" dynamodbav:"date_column"`
+	DateFormat        string `json:"date_format,omitempty" dynamodbav:"date_format,omitempty"` // e.g. "DD/MM/YYYY" or a Go layout, defaults to YYYY-MM-DD
+	DescriptionColumn string `json:"description_column,omitempty" dynamodbav:"description_column,omitempty"`
+	CategoryColumn    string `json:"category_column,omitempty" dynamodbav:"category_column,omitempty"`
+	CurrencyColumn    string `json:"currency_column,omitempty" dynamodbav:"currency_column,omitempty"`
+
+	// Either a single signed amount column or separate debit/credit columns
+	AmountColumn string `json:"amount_column,omitempty" dynamodbav:"amount_column,omitempty"`
+	AmountSign   string `json:"amount_sign,omitempty" dynamodbav:"amount_sign,omitempty"` // signed or inverted
+	DebitColumn  string `json:"debit_column,omitempty" dynamodbav:"debit_column,omitempty"`
+	CreditColumn string `json:"credit_column,omitempty" dynamodbav:"credit_column,omitempty"`
+	DecimalComma bool   `json:"decimal_comma,omitempty" dynamodbav:"decimal_comma,omitempty"` // "1.234,56"
+
+	DefaultCategory string `json:"default_category,omitempty" dynamodbav:"default_category,omitempty"`
+	Currency        string `json:"currency,omitempty" dynamodbav:"currency,omitempty"` // when there is no currency column
+
+	// DynamoDB keys for single-table design
+	PK string `json:"-" dynamodbav:"PK"` // USER#{userID}
+	SK string `json:"-" dynamodbav:"SK"` // IMPORTMAPPING#{name}
+
+	CreatedAt time.Time `json:"created_at,omitempty" dynamodbav:"created_at"`
+	UpdatedAt time.Time `json:"updated_at,omitempty" dynamodbav:"updated_at"`
+}
+
+// GenerateKeys generates the DynamoDB keys for a saved mapping
+func (m *ImportMapping) GenerateKeys() {
+	m.PK = fmt.Sprintf("USER#%s", m.UserID)
+	m.SK = fmt.Sprintf("IMPORTMAPPING#%s", m.Name)
+}
+
+// ToDynamoDBItem converts the mapping to a DynamoDB item
+func (m *ImportMapping) ToDynamoDBItem() (map[string]types.AttributeValue, error) {
+	m.GenerateKeys()
+	return attributevalue.MarshalMap(m)
+}
+
+// FromDynamoDBItem creates the mapping from a DynamoDB item
+func (m *ImportMapping) FromDynamoDBItem(item map[string]types.AttributeValue) error {
+	return attributevalue.UnmarshalMap(item, m)
+}
+
+// ValidateName checks that the mapping can be saved under its name
+func (m *ImportMapping) ValidateName() error {
+	if !mappingNamePattern.MatchString(m.Name) {
+		return fmt.Errorf("mapping name must be 1-64 lowercase letters, digits, '-' or '_'")
+	}
+	return nil
+}
+
+// Validate checks that the mapping describes a date and an amount
+func (m *ImportMapping) Validate() error {
+	if len([]rune(m.Delimiter)) > 1 {
+		return fmt.Errorf("delimiter must be a single character")
+	}
+	if strings.TrimSpace(m.DateColumn) == "" {
+		return fmt.Errorf("date_column is required")
+	}
+	if m.AmountColumn == "" && m.DebitColumn == "" && m.CreditColumn == "" {
+		return fmt.Errorf("amount_column or debit_column/credit_column is required")
+	}
+	if m.AmountColumn != "" && (m.DebitColumn != "" || m.CreditColumn != "") {
+		return fmt.Errorf("use either amount_column or debit_column/credit_column, not both")
+	}
+	switch m.AmountSign {
+	case "", AmountSignSigned, AmountSignInverted:
+	default:
+		return fmt.Errorf("amount_sign must be %q or %q", AmountSignSigned, AmountSignInverted)
+	}
+	if m.Currency != "" {
+		if _, err := NormalizeCurrency(m.Currency); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ImportRow is one parsed row of an import file. Rows with errors carry no
+// transaction and are never written; duplicates of transactions imported
+// before are shown but skipped.
+type ImportRow struct {
+	Line        int          `json:"line"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+	Errors      []string     `json:"errors,omitempty"`
+	Duplicate   bool         `json:"duplicate,omitempty"`
+}
+
+// ImportResult reports a dry run or a committed import
+type ImportResult struct {
+	Format           string      `json:"format"`
+	DryRun           bool        `json:"dry_run"`
+	TotalRows        int         `json:"total_rows"`
+	ValidRows        int         `json:"valid_rows"`
+	InvalidRows      int         `json:"invalid_rows"`
+	Duplicates       int         `json:"duplicates"`        // valid rows already imported
+	LikelyDuplicates int         `json:"likely_duplicates"` // valid rows that likely repeat a stored transaction named by their duplicate_of
+	Imported         int         `json:"imported"`
+	Rows             []ImportRow `json:"rows"`
+}
@@ -0,0 +1,68 @@
+package models
+
+import "time"
+
+// What an invoice import did with the invoice
+const (
+	InvoiceActionCreated   = "created"   // a new expense was created for it
+	InvoiceActionAttached  = "attached"  // it was attached to an existing expense
+	InvoiceActionDuplicate = "duplicate" // it was already imported
+)
+
+// Invoice holds the fiscal data of a Mexican CFDI electronic invoice attached
+// to an expense. The UUID (folio fiscal) and the emitter's RFC identify it
+// before the SAT when reporting deductible expenses.
+type Invoice struct {
+	UUID          string    `json:"uuid" dynamodbav:"uuid"`
+	Version       string    `json:"version" dynamodbav:"version"` // CFDI version, 4.0 or 3.3
+	Series        string    `json:"series,omitempty" dynamodbav:"series,omitempty"`
+	Folio         string    `json:"folio,omitempty" dynamodbav:"folio,omitempty"`
+	IssuedAt      time.Time `json:"issued_at" dynamodbav:"issued_at"`
+	PaymentDate   time.Time `json:"payment_date" dynamodbav:"payment_date"`
+	PaymentMethod string    `json:"payment_method,omitempty" dynamodbav:"payment_method,omitempty"` // PUE (single payment) or PPD (deferred)
+	PaymentForm   string    `json:"payment_form,omitempty" dynamodbav:"payment_form,omitempty"`     // SAT catalog c_FormaPago, e.g. 04 credit card
+
+	EmitterRFC   string `json:"emitter_rfc" dynamodbav:"emitter_rfc"`
+	EmitterName  string `json:"emitter_name" dynamodbav:"emitter_name"`
+	ReceiverRFC  string `json:"receiver_rfc" dynamodbav:"receiver_rfc"`
+	ReceiverUse  string `json:"receiver_use,omitempty" dynamodbav:"receiver_use,omitempty"` // UsoCFDI, e.g. G03 general expenses
+	ReceiverName string `json:"receiver_name,omitempty" dynamodbav:"receiver_name,omitempty"`
+
+	Currency      string           `json:"currency" dynamodbav:"currency"` // of every amount below
+	Subtotal      Money            `json:"subtotal" dynamodbav:"subtotal"`
+	Discount      Money            `json:"discount" dynamodbav:"discount"`
+	IVA           Money            `json:"iva" dynamodbav:"iva"`                       // transferred value added tax
+	TransferTaxes Money            `json:"transfer_taxes" dynamodbav:"transfer_taxes"` // all transferred taxes, IVA and IEPS
+	WithheldTaxes Money            `json:"withheld_taxes" dynamodbav:"withheld_taxes"`
+	Total         Money            `json:"total" dynamodbav:"total"`
+	Concepts      []InvoiceConcept `json:"concepts" dynamodbav:"concepts"`
+}
+
+// InvoiceConcept is one line of an invoice
+type InvoiceConcept struct {
+	ProductCode string  `json:"product_code" dynamodbav:"product_code"` // SAT catalog c_ClaveProdServ
+	Description string  `json:"description" dynamodbav:"description"`
+	Quantity    float64 `json:"quantity" dynamodbav:"quantity"`
+	Unit        string  `json:"unit,omitempty" dynamodbav:"unit,omitempty"`
+	UnitPrice   Money   `json:"unit_price" dynamodbav:"unit_price"`
+	Amount      Money   `json:"amount" dynamodbav:"amount"`
+}
+
+// InvoiceImport reports what happened to one uploaded invoice file
+type InvoiceImport struct {
+	File        string       `json:"file"`
+	Action      string       `json:"action,omitempty"` // created, attached or duplicate; empty when invalid
+	Invoice     *Invoice     `json:"invoice,omitempty"`
+	Transaction *Transaction `json:"transaction,omitempty"`
+	Errors      []string     `json:"errors,omitempty"`
+}
+
+// InvoiceImportResult reports a dry run or a committed invoice import
+type InvoiceImportResult struct {
+	DryRun     bool            `json:"dry_run"`
+	Created    int             `json:"created"`
+	Attached   int             `json:"attached"`
+	Duplicates int             `json:"duplicates"`
+	Invalid    int             `json:"invalid"`
+	Invoices   []InvoiceImport `json:"invoices"`
+}
@@ -0,0 +1,277 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultCurrency is assumed for amounts that do not carry a currency code,
+// including every item written before amounts became fixed-point
+const DefaultCurrency = "MXN"
+
+// minorUnitsPerMajor is the scale of every supported currency (MXN, USD: 2 decimals)
+const minorUnitsPerMajor = 100
+
+// Money is a fixed-point monetary amount expressed in minor units (centavos/cents)
+// plus an ISO 4217 currency code. All arithmetic is exact integer math; convert to
+// float64 only for ratios such as percentages.
+//
+// JSON encodes Money as a plain decimal number (e.g. -25.50) so API clients keep
+// receiving numeric amounts. DynamoDB stores it as a map {minor, currency}; legacy
+// items holding a float number are still readable.
+type Money struct {
+	Minor    int64
+	Currency string
+}
+
+// NewMoney creates an amount from minor units
+func NewMoney(minor int64, currency string) Money {
+	return Money{Minor: minor, Currency: currency}
+}
+
+// ZeroMoney returns a zero amount in the given currency
+func ZeroMoney(currency string) Money {
+	return Money{Currency: currency}
+}
+
+// MoneyFromFloat converts a float amount, rounding half away from zero to the
+// nearest minor unit. Prefer ParseMoney when the decimal text is available.
+func MoneyFromFloat(amount float64, currency string) Money {
+	return Money{Minor: int64(math.Round(amount * minorUnitsPerMajor)), Currency: currency}
+}
+
+// ParseMoney parses a decimal string such as "-1234.5" exactly. Inputs with more
+// than two decimals (e.g. float artifacts like "0.30000000000000004") are rounded
+// half away from zero.
+func ParseMoney(value, currency string) (Money, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return Money{}, fmt.Errorf("amount is empty")
+	}
+
+	rat, ok := new(big.Rat).SetString(value)
+	if !ok {
+		return Money{}, fmt.Errorf("invalid amount %q", value)
+	}
+
+	scaled := new(big.Rat).Mul(rat, big.NewRat(minorUnitsPerMajor, 1))
+	num := new(big.Int).Abs(scaled.Num())
+	den := scaled.Denom()
+
+	// Round half away from zero: (2*num + den) / (2*den)
+	quotient := new(big.Int).Mul(num, big.NewInt(2))
+	quotient.Add(quotient, den)
+	quotient.Quo(quotient, new(big.Int).Mul(den, big.NewInt(2)))
+	if !quotient.IsInt64() {
+		return Money{}, fmt.Errorf("amount %q is out of range", value)
+	}
+
+	minor := quotient.Int64()
+	if scaled.Sign() < 0 {
+		minor = -minor
+	}
+
+	return Money{Minor: minor, Currency: currency}, nil
+}
+
+// NormalizeCurrency upper-cases and validates an ISO 4217 currency code
+func NormalizeCurrency(code string) (string, error) {
+	code = strings.ToUpper(strings.TrimSpace(code))
+	if len(code) != 3 {
+		return "", fmt.Errorf("invalid currency code %q, expected a 3-letter ISO 4217 code", code)
+	}
+	for _, r := range code {
+		if r < 'A' || r > 'Z' {
+			return "", fmt.Errorf("invalid currency code %q, expected a 3-letter ISO 4217 code", code)
+		}
+	}
+	return code, nil
+}
+
+// Add returns m + other. Both amounts must already be in the same currency;
+// a zero-value Money adopts the currency of the other operand.
+func (m Money) Add(other Money) Money {
+	return Money{Minor: m.Minor + other.Minor, Currency: m.currencyWith(other)}
+}
+
+// Sub returns m - other under the same currency rules as Add
+func (m Money) Sub(other Money) Money {
+	return Money{Minor: m.Minor - other.Minor, Currency: m.currencyWith(other)}
+}
+
+func (m Money) currencyWith(other Money) string {
+	if m.Currency != "" {
+		return m.Currency
+	}
+	return other.Currency
+}
+
+// Neg returns -m
+func (m Money) Neg() Money {
+	return Money{Minor: -m.Minor, Currency: m.Currency}
+}
+
+// Abs returns |m|
+func (m Money) Abs() Money {
+	if m.Minor < 0 {
+		return m.Neg()
+	}
+	return m
+}
+
+// IsZero reports whether the amount is zero
+func (m Money) IsZero() bool {
+	return m.Minor == 0
+}
+
+// IsNegative reports whether the amount is below zero
+func (m Money) IsNegative() bool {
+	return m.Minor < 0
+}
+
+// IsPositive reports whether the amount is above zero
+func (m Money) IsPositive() bool {
+	return m.Minor > 0
+}
+
+// Cmp compares the amounts, returning -1, 0 or +1
+func (m Money) Cmp(other Money) int {
+	switch {
+	case m.Minor < other.Minor:
+		return -1
+	case m.Minor > other.Minor:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Float64 returns the amount in major units. Use it for ratios and statistics only.
+func (m Money) Float64() float64 {
+	return float64(m.Minor) / minorUnitsPerMajor
+}
+
+// Ratio returns m / other as a float, or 0 when other is zero
+func (m Money) Ratio(other Money) float64 {
+	if other.Minor == 0 {
+		return 0
+	}
+	return float64(m.Minor) / float64(other.Minor)
+}
+
+// MulFloat scales the amount by factor, rounding half away from zero
+func (m Money) MulFloat(factor float64) Money {
+	return Money{Minor: int64(math.Round(float64(m.Minor) * factor)), Currency: m.Currency}
+}
+
+// DivInt splits the amount into n parts, rounding half away from zero
+func (m Money) DivInt(n int) Money {
+	if n == 0 {
+		return Money{Currency: m.Currency}
+	}
+	return Money{Minor: int64(math.Round(float64(m.Minor) / float64(n))), Currency: m.Currency}
+}
+
+// String formats the amount as a plain decimal, e.g. "-1234.50"
+func (m Money) String() string {
+	sign := ""
+	minor := m.Minor
+	if minor < 0 {
+		sign = "-"
+		minor = -minor
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, minor/minorUnitsPerMajor, minor%minorUnitsPerMajor)
+}
+
+// MarshalJSON encodes the amount as a decimal JSON number
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON accepts a JSON number or a quoted decimal string
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if bytes.Equal(data, []byte("null")) {
+		*m = Money{}
+		return nil
+	}
+
+	text := string(data)
+	if unquoted, err := strconv.Unquote(text); err == nil {
+		text = unquoted
+	}
+
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	parsed, err := ParseMoney(text, currency)
+	if err != nil {
+		return err
+	}
+	*m = parsed
+	return nil
+}
+
+// MarshalDynamoDBAttributeValue stores the amount as {minor: N, currency: S}
+func (m Money) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	currency := m.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+
+	return &types.AttributeValueMemberM{Value: map[string]types.AttributeValue{
+		"minor":    &types.AttributeValueMemberN{Value: strconv.FormatInt(m.Minor, 10)},
+		"currency": &types.AttributeValueMemberS{Value: currency},
+	}}, nil
+}
+
+// UnmarshalDynamoDBAttributeValue reads the map format as well as the legacy
+// float number written before amounts were fixed-point
+func (m *Money) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	switch value := av.(type) {
+	case *types.AttributeValueMemberN:
+		// Legacy float64 amount
+		parsed, err := ParseMoney(value.Value, DefaultCurrency)
+		if err != nil {
+			return fmt.Errorf("failed to read legacy amount: %w", err)
+		}
+		*m = parsed
+		return nil
+	case *types.AttributeValueMemberM:
+		minorAV, ok := value.Value["minor"].(*types.AttributeValueMemberN)
+		if !ok {
+			return fmt.Errorf("money attribute is missing minor units")
+		}
+		minor, err := strconv.ParseInt(minorAV.Value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid minor units %q: %w", minorAV.Value, err)
+		}
+
+		currency := DefaultCurrency
+		if currencyAV, ok := value.Value["currency"].(*types.AttributeValueMemberS); ok && currencyAV.Value != "" {
+			currency = currencyAV.Value
+		}
+
+		*m = Money{Minor: minor, Currency: currency}
+		return nil
+	case *types.AttributeValueMemberNULL:
+		*m = Money{}
+		return nil
+	default:
+		return fmt.Errorf("unsupported attribute type %T for money", av)
+	}
+}
+
+// IsLegacyAmount reports whether a stored amount attribute still uses the float format
+func IsLegacyAmount(av types.AttributeValue) bool {
+	_, ok := av.(*types.AttributeValueMemberN)
+	return ok
+}
@@ -0,0 +1,290 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/uuid"
+)
+
+// Recurrence frequencies, named after the RRULE FREQ values
+const (
+	FrequencyDaily   = "DAILY"
+	FrequencyWeekly  = "WEEKLY"
+	FrequencyMonthly = "MONTHLY"
+	FrequencyYearly  = "YEARLY"
+)
+
+// RecurringDuePartition is the GSI1 partition listing every active rule by its
+// next run, so the scheduler finds due rules of all users with one query
+const RecurringDuePartition = "RECURRING#DUE"
+
+// RecurringDueKey is the GSI1 sort key prefix of rules next run at t. The Unix
+// seconds are zero-padded so the keys sort by time when compared as strings.
+func RecurringDueKey(t time.Time) string {
+	return fmt.Sprintf("NEXT#%020d", t.Unix())
+}
+
+// recurringNamespace derives deterministic IDs for generated occurrences
+var recurringNamespace = uuid.MustParse("6f1c4a52-7d0e-4b8e-9a43-2f5d8c1e0b7a")
+
+// RecurringTransaction is a template that the scheduler materializes into a
+// Transaction on every occurrence of an RRULE-style schedule
+type RecurringTransaction struct {
+	ID     string `json:"id" dynamodbav:"id"`
+	UserID string `json:"user_id" dynamodbav:"user_id"`
+
+	// Template of the generated transactions
+	Type        string `json:"type" dynamodbav:"type"` // "income" or "expense"
+	Category    string `json:"category" dynamodbav:"category"`
+	Description string `json:"description" dynamodbav:"description"`
+	Amount      Money  `json:"amount" dynamodbav:"amount"`
+
+	// Schedule (FREQ, INTERVAL, DTSTART, UNTIL and COUNT in RRULE terms)
+	Frequency string     `json:"frequency" dynamodbav:"frequency"`
+	Interval  int        `json:"interval" dynamodbav:"interval"` // every N days/weeks/months/years
+	StartDate time.Time  `json:"start_date" dynamodbav:"start_date"`
+	EndDate   *time.Time `json:"end_date,omitempty" dynamodbav:"end_date,omitempty"` // inclusive
+	Count     int        `json:"count,omitempty" dynamodbav:"count,omitempty"`       // 0 means no limit
+	Active    bool       `json:"active" dynamodbav:"active"`
+
+	// Scheduler state
+	Generated int        `json:"generated" dynamodbav:"generated"` // occurrences materialized so far
+	LastRun   *time.Time `json:"last_run,omitempty" dynamodbav:"last_run,omitempty"`
+	NextRun   *time.Time `json:"next_run,omitempty" dynamodbav:"next_run,omitempty"` // nil once the schedule is exhausted
+
+	// DynamoDB keys for single-table design
+	PK     string `json:"-" dynamodbav:"PK"`               // USER#{userID}
+	SK     string `json:"-" dynamodbav:"SK"`               // RECURRING#{id}
+	GSI1PK string `json:"-" dynamodbav:"GSI1PK,omitempty"` // RECURRING#DUE while active and scheduled
+	GSI1SK string `json:"-" dynamodbav:"GSI1SK,omitempty"` // NEXT#{zero-padded next run timestamp}#{id}
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" dynamodbav:"updated_at"`
+	Version   int       `json:"version" dynamodbav:"version"`
+}
+
+// GenerateKeys generates the DynamoDB keys. Paused and exhausted rules are left
+// out of the due index.
+func (r *RecurringTransaction) GenerateKeys() {
+	r.PK = fmt.Sprintf("USER#%s", r.UserID)
+	r.SK = fmt.Sprintf("RECURRING#%s", r.ID)
+
+	r.GSI1PK, r.GSI1SK = "", ""
+	if r.Active && r.NextRun != nil {
+		r.GSI1PK = RecurringDuePartition
+		r.GSI1SK = RecurringDueKey(*r.NextRun) + "#" + r.ID
+	}
+}
+
+// ToDynamoDBItem converts the rule to a DynamoDB item
+func (r *RecurringTransaction) ToDynamoDBItem() (map[string]types.AttributeValue, error) {
+	r.GenerateKeys()
+	return attributevalue.MarshalMap(r)
+}
+
+// FromDynamoDBItem creates the rule from a DynamoDB item
+func (r *RecurringTransaction) FromDynamoDBItem(item map[string]types.AttributeValue) error {
+	return attributevalue.UnmarshalMap(item, r)
+}
+
+// MarshalJSON exposes the amount's currency as "currency" and the start and
+// end as plain dates, like Transaction
+func (r *RecurringTransaction) MarshalJSON() ([]byte, error) {
+	type Alias RecurringTransaction
+	currency := r.Amount.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
+	var endDate string
+	if r.EndDate != nil {
+		endDate = r.EndDate.Format("2006-01-02")
+	}
+	return json.Marshal(&struct {
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date,omitempty"`
+		Currency  string `json:"currency"`
+		*Alias
+	}{
+		StartDate: r.StartDate.Format("2006-01-02"),
+		EndDate:   endDate,
+		Currency:  currency,
+		Alias:     (*Alias)(r),
+	})
+}
+
+// UnmarshalJSON accepts start and end dates as YYYY-MM-DD or RFC3339
+func (r *RecurringTransaction) UnmarshalJSON(data []byte) error {
+	type Alias RecurringTransaction
+	aux := &struct {
+		StartDate string `json:"start_date"`
+		EndDate   string `json:"end_date"`
+		Currency  string `json:"currency"`
+		*Alias
+	}{
+		Alias: (*Alias)(r),
+	}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	// Amounts without an explicit currency are in the default currency
+	r.Amount.Currency = DefaultCurrency
+	if aux.Currency != "" {
+		currency, err := NormalizeCurrency(aux.Currency)
+		if err != nil {
+			return err
+		}
+		r.Amount.Currency = currency
+	}
+
+	r.StartDate = time.Time{}
+	if aux.StartDate != "" {
+		start, err := parseDate(aux.StartDate)
+		if err != nil {
+			return fmt.Errorf("invalid start_date: %w", err)
+		}
+		r.StartDate = start
+	}
+
+	r.EndDate = nil
+	if aux.EndDate != "" {
+		end, err := parseDate(aux.EndDate)
+		if err != nil {
+			return fmt.Errorf("invalid end_date: %w", err)
+		}
+		r.EndDate = &end
+	}
+
+	return nil
+}
+
+func parseDate(value string) (time.Time, error) {
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+	return time.Parse("2006-01-02", value)
+}
+
+// Validate validates the template and the schedule
+func (r *RecurringTransaction) Validate() error {
+	if r.UserID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+	if r.Type != TransactionTypeIncome && r.Type != TransactionTypeExpense {
+		return fmt.Errorf("type must be 'income' or 'expense'")
+	}
+	if r.Category == "" {
+		return fmt.Errorf("category is required")
+	}
+	if r.Description == "" {
+		return fmt.Errorf("description is required")
+	}
+	if r.Amount.IsZero() {
+		return fmt.Errorf("amount must be non-zero")
+	}
+	if _, err := NormalizeCurrency(r.Amount.Currency); err != nil {
+		return err
+	}
+	switch r.Frequency {
+	case FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly:
+	default:
+		return fmt.Errorf("frequency must be one of %s, %s, %s or %s", FrequencyDaily, FrequencyWeekly, FrequencyMonthly, FrequencyYearly)
+	}
+	if r.Interval < 1 {
+		return fmt.Errorf("interval must be at least 1")
+	}
+	if r.StartDate.IsZero() {
+		return fmt.Errorf("start_date is required")
+	}
+	if r.EndDate != nil && r.EndDate.Before(r.StartDate) {
+		return fmt.Errorf("end_date must not be before start_date")
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("count must not be negative")
+	}
+	return nil
+}
+
+// Occurrence returns the n-th (zero-based) date of the schedule, ignoring its
+// end. Monthly and yearly rules starting on a day the target month lacks (the
+// 31st, February 29th) fall on that month's last day.
+func (r *RecurringTransaction) Occurrence(n int) time.Time {
+	step := n * r.Interval
+	switch r.Frequency {
+	case FrequencyDaily:
+		return r.StartDate.AddDate(0, 0, step)
+	case FrequencyWeekly:
+		return r.StartDate.AddDate(0, 0, 7*step)
+	case FrequencyYearly:
+		return addMonthsClamped(r.StartDate, 12*step)
+	default:
+		return addMonthsClamped(r.StartDate, step)
+	}
+}
+
+func addMonthsClamped(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	lastDay := first.AddDate(0, 1, -1).Day()
+	day := t.Day()
+	if day > lastDay {
+		day = lastDay
+	}
+	return first.AddDate(0, 0, day-1)
+}
+
+// Schedule sets NextRun to the first occurrence strictly after the given time
+// (or the first occurrence when after is nil), or to nil when the rule has
+// reached its end date or count
+func (r *RecurringTransaction) Schedule(after *time.Time) {
+	r.NextRun = nil
+	if r.Count > 0 && r.Generated >= r.Count {
+		return
+	}
+	for n := 0; ; n++ {
+		occurrence := r.Occurrence(n)
+		// The end date is inclusive whatever the time of day of the occurrences
+		if r.EndDate != nil && !occurrence.Before(r.EndDate.AddDate(0, 0, 1)) {
+			return
+		}
+		if after == nil || occurrence.After(*after) {
+			r.NextRun = &occurrence
+			return
+		}
+	}
+}
+
+// NewOccurrence builds the transaction for one occurrence. Its ID is derived
+// from the rule and the date, so generating the same occurrence twice yields
+// the same item.
+func (r *RecurringTransaction) NewOccurrence(date time.Time) *Transaction {
+	now := time.Now()
+	t := &Transaction{
+		ID:          uuid.NewSHA1(recurringNamespace, []byte(r.ID+"#"+date.UTC().Format(time.RFC3339))).String(),
+		UserID:      r.UserID,
+		Type:        r.Type,
+		Category:    r.Category,
+		Description: r.Description,
+		Amount:      r.Amount,
+		Date:        date,
+		RecurringID: r.ID,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+		Version:     1,
+	}
+	t.GenerateKeys()
+	return t
+}
+
+// RecurringRunResult summarizes one scheduler run
+type RecurringRunResult struct {
+	Rules      int `json:"rules"`      // due rules processed
+	Generated  int `json:"generated"`  // transactions created
+	Duplicates int `json:"duplicates"` // occurrences that already existed
+	Failed     int `json:"failed"`     // rules left for the next run after an error
+}
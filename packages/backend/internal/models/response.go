@@ -40,6 +40,7 @@ const (
 	ErrorCodeForbidden       = "FORBIDDEN"
 	ErrorCodeBadRequest      = "BAD_REQUEST"
 	ErrorCodeTooManyRequests = "TOO_MANY_REQUESTS"
+	ErrorCodeConflict        = "CONFLICT"
 )
 
 // NewSuccessResponse creates a successful API response
@@ -78,6 +79,8 @@ func HTTPStatusFromErrorCode(code string) int {
 		return http.StatusForbidden
 	case ErrorCodeTooManyRequests:
 		return http.StatusTooManyRequests
+	case ErrorCodeConflict:
+		return http.StatusConflict
 	case ErrorCodeInternalServer:
 		return http.StatusInternalServerError
 	default:
@@ -0,0 +1,277 @@
+package models
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// Limits of categorization rules
+const (
+	MaxRulesPerUser  = 200
+	MaxRuleTags      = 10
+	MaxTagLength     = 32
+	MaxRuleRegexSize = 256
+)
+
+// CategoryRule categorizes and tags the transactions it matches. Every
+// condition that is set must hold; a rule without conditions matches nothing.
+type CategoryRule struct {
+	ID       string `json:"id" dynamodbav:"id"`
+	UserID   string `json:"user_id" dynamodbav:"user_id"`
+	Name     string `json:"name" dynamodbav:"name"`
+	Priority int    `json:"priority" dynamodbav:"priority"` // lower runs first
+	Active   bool   `json:"active" dynamodbav:"active"`
+
+	// Conditions
+	DescriptionContains string `json:"description_contains,omitempty" dynamodbav:"description_contains,omitempty"` // ignores case, accents and punctuation
+	DescriptionRegex    string `json:"description_regex,omitempty" dynamodbav:"description_regex,omitempty"`       // RE2 syntax, case-insensitive
+	Merchant            string `json:"merchant,omitempty" dynamodbav:"merchant,omitempty"`                         // invoice emitter RFC or name, or the description's leading words
+	Type                string `json:"type,omitempty" dynamodbav:"type,omitempty"`
+	MinAmount           *Money `json:"min_amount,omitempty" dynamodbav:"min_amount,omitempty"` // inclusive, compared with the absolute amount
+	MaxAmount           *Money `json:"max_amount,omitempty" dynamodbav:"max_amount,omitempty"` // inclusive, compared with the absolute amount
+
+	// Actions
+	Category string   `json:"category,omitempty" dynamodbav:"category,omitempty"`
+	Tags     []string `json:"tags,omitempty" dynamodbav:"tags,omitempty"`
+	Override bool     `json:"override" dynamodbav:"override"` // replace categories the user chose, not only missing ones
+
+	// DynamoDB keys for single-table design
+	PK string `json:"-" dynamodbav:"PK"` // USER#{userID}
+	SK string `json:"-" dynamodbav:"SK"` // RULE#{id}
+
+	// Metadata
+	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" dynamodbav:"updated_at"`
+	Version   int       `json:"version" dynamodbav:"version"`
+
+	regex *regexp.Regexp
+}
+
+// RuleChange reports what the rules changed in one transaction
+type RuleChange struct {
+	TransactionID string    `json:"transaction_id"`
+	Date          time.Time `json:"date"`
+	Description   string    `json:"description"`
+	OldCategory   string    `json:"old_category"`
+	NewCategory   string    `json:"new_category"`
+	AddedTags     []string  `json:"added_tags,omitempty"`
+	RuleIDs       []string  `json:"rule_ids"`
+}
+
+// RuleRunResult reports a retroactive run of the rules over a date range
+type RuleRunResult struct {
+	DryRun  bool         `json:"dry_run"`
+	Scanned int          `json:"scanned"`
+	Changed int          `json:"changed"`
+	Changes []RuleChange `json:"changes"`
+}
+
+// GenerateKeys generates the DynamoDB keys for the rule
+func (r *CategoryRule) GenerateKeys() {
+	r.PK = fmt.Sprintf("USER#%s", r.UserID)
+	r.SK = fmt.Sprintf("RULE#%s", r.ID)
+}
+
+// ToDynamoDBItem converts the rule to a DynamoDB item
+func (r *CategoryRule) ToDynamoDBItem() (map[string]types.AttributeValue, error) {
+	r.GenerateKeys()
+	return attributevalue.MarshalMap(r)
+}
+
+// FromDynamoDBItem creates the rule from a DynamoDB item
+func (r *CategoryRule) FromDynamoDBItem(item map[string]types.AttributeValue) error {
+	return attributevalue.UnmarshalMap(item, r)
+}
+
+// Validate checks that the rule has a condition and an action, normalizing its
+// category and tags
+func (r *CategoryRule) Validate() error {
+	if r.UserID == "" {
+		return fmt.Errorf("user_id is required")
+	}
+	r.Name = strings.TrimSpace(r.Name)
+	if r.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+
+	if r.DescriptionContains == "" && r.DescriptionRegex == "" && r.Merchant == "" &&
+		r.Type == "" && r.MinAmount == nil && r.MaxAmount == nil {
+		return fmt.Errorf("at least one condition is required")
+	}
+	if r.DescriptionContains != "" && FoldDescription(r.DescriptionContains) == "" {
+		return fmt.Errorf("description_contains must contain letters or digits")
+	}
+	if r.DescriptionRegex != "" {
+		if len(r.DescriptionRegex) > MaxRuleRegexSize {
+			return fmt.Errorf("description_regex must be at most %d characters", MaxRuleRegexSize)
+		}
+		regex, err := regexp.Compile("(?i)" + r.DescriptionRegex)
+		if err != nil {
+			return fmt.Errorf("invalid description_regex: %v", err)
+		}
+		r.regex = regex
+	}
+	if r.Type != "" && r.Type != TransactionTypeIncome && r.Type != TransactionTypeExpense {
+		return fmt.Errorf("type must be 'income' or 'expense'")
+	}
+	if (r.MinAmount != nil && r.MinAmount.IsNegative()) || (r.MaxAmount != nil && r.MaxAmount.IsNegative()) {
+		return fmt.Errorf("min_amount and max_amount must not be negative")
+	}
+	if r.MinAmount != nil && r.MaxAmount != nil && r.MinAmount.Cmp(*r.MaxAmount) > 0 {
+		return fmt.Errorf("min_amount must not exceed max_amount")
+	}
+
+	r.Category = strings.TrimSpace(r.Category)
+	r.Tags = NormalizeTags(r.Tags)
+	if r.Category == "" && len(r.Tags) == 0 {
+		return fmt.Errorf("a category or tags are required")
+	}
+	if len(r.Tags) > MaxRuleTags {
+		return fmt.Errorf("at most %d tags are allowed", MaxRuleTags)
+	}
+	for _, tag := range r.Tags {
+		if len(tag) > MaxTagLength {
+			return fmt.Errorf("tag %q is longer than %d characters", tag, MaxTagLength)
+		}
+	}
+	return nil
+}
+
+// Matches reports whether the transaction meets every condition of the rule
+func (r *CategoryRule) Matches(t *Transaction) bool {
+	if r.Type != "" && r.Type != t.Type {
+		return false
+	}
+
+	amount := t.Amount.Abs()
+	if r.MinAmount != nil && amount.Cmp(*r.MinAmount) < 0 {
+		return false
+	}
+	if r.MaxAmount != nil && amount.Cmp(*r.MaxAmount) > 0 {
+		return false
+	}
+
+	description := FoldDescription(t.Description)
+	if r.DescriptionContains != "" && !strings.Contains(description, FoldDescription(r.DescriptionContains)) {
+		return false
+	}
+	if r.DescriptionRegex != "" {
+		if r.regex == nil {
+			regex, err := regexp.Compile("(?i)" + r.DescriptionRegex)
+			if err != nil {
+				return false
+			}
+			r.regex = regex
+		}
+		if !r.regex.MatchString(t.Description) {
+			return false
+		}
+	}
+	if r.Merchant != "" && !r.matchesMerchant(t, description) {
+		return false
+	}
+
+	return r.DescriptionContains != "" || r.DescriptionRegex != "" || r.Merchant != "" ||
+		r.Type != "" || r.MinAmount != nil || r.MaxAmount != nil
+}
+
+// matchesMerchant compares the merchant with the invoice's emitter or with the
+// first words of the description, so "oxxo" matches "OXXO SUC 123" but not
+// "PAGO OXXO"
+func (r *CategoryRule) matchesMerchant(t *Transaction, description string) bool {
+	merchant := FoldDescription(r.Merchant)
+	if t.Invoice != nil {
+		if strings.EqualFold(t.Invoice.EmitterRFC, strings.TrimSpace(r.Merchant)) ||
+			hasLeadingWords(FoldDescription(t.Invoice.EmitterName), merchant) {
+			return true
+		}
+	}
+	return hasLeadingWords(description, merchant)
+}
+
+func hasLeadingWords(text, words string) bool {
+	return words != "" && (text == words || strings.HasPrefix(text, words+" "))
+}
+
+// SortRules orders rules by priority, then by creation
+func SortRules(rules []CategoryRule) {
+	sort.SliceStable(rules, func(i, j int) bool {
+		if rules[i].Priority != rules[j].Priority {
+			return rules[i].Priority < rules[j].Priority
+		}
+		return rules[i].CreatedAt.Before(rules[j].CreatedAt)
+	})
+}
+
+// ApplyRules runs the active rules, in the order given, on the transaction.
+// The first matching rule with a category sets it when the transaction is
+// uncategorized, or always when the rule overrides; every matching rule adds
+// its tags until the transaction has MaxTransactionTags. Transfers are left as
+// they are. It returns the IDs of the rules that changed the transaction.
+func ApplyRules(rules []CategoryRule, t *Transaction) []string {
+	if t.IsTransfer() {
+		return nil
+	}
+	var applied []string
+	categorized := false
+	for i := range rules {
+		rule := &rules[i]
+		if !rule.Active || !rule.Matches(t) {
+			continue
+		}
+
+		changed := false
+		if rule.Category != "" && !categorized {
+			categorized = true
+			uncategorized := t.Category == "" || t.Category == DefaultImportCategory
+			if (uncategorized || rule.Override) && t.Category != rule.Category {
+				t.Category = rule.Category
+				changed = true
+			}
+		}
+		for _, tag := range rule.Tags {
+			if len(t.Tags) >= MaxTransactionTags {
+				break
+			}
+			if !t.HasTag(tag) {
+				t.Tags = append(t.Tags, tag)
+				changed = true
+			}
+		}
+		if changed {
+			applied = append(applied, rule.ID)
+		}
+	}
+	return applied
+}
+
+// NormalizeTags lowercases and trims tags, dropping empty and repeated ones
+func NormalizeTags(tags []string) []string {
+	var normalized []string
+	seen := make(map[string]bool)
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+// HasTag reports whether the transaction carries the tag
+func (t *Transaction) HasTag(tag string) bool {
+	for _, existing := range t.Tags {
+		if existing == tag {
+			return true
+		}
+	}
+	return false
+}
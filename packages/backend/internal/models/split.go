@@ -0,0 +1,128 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Limits of a split transaction
+const (
+	MaxSplitsPerTransaction = 20
+	MaxSplitNoteLength      = 200
+)
+
+// Split is the part of a transaction's amount that belongs to one category,
+// e.g. the household items on a supermarket receipt. Amounts carry the sign
+// of the transaction (negative for expenses).
+type Split struct {
+	Category string `json:"category" dynamodbav:"category"`
+	Amount   Money  `json:"amount" dynamodbav:"amount"`
+	Note     string `json:"note,omitempty" dynamodbav:"note,omitempty"`
+}
+
+// Allocations returns the amounts the transaction contributes to each
+// category: its splits, or its whole amount under its category
+func (t *Transaction) Allocations() []Split {
+	if len(t.Splits) == 0 {
+		return []Split{{Category: t.Category, Amount: t.Amount}}
+	}
+	return t.Splits
+}
+
+// ValidateSplits checks that the splits add up to the transaction amount.
+// Each split needs a category of its own and the sign of the transaction.
+// A split transaction sent without a category takes the one of its largest
+// split.
+func (t *Transaction) ValidateSplits() error {
+	if len(t.Splits) == 0 {
+		t.Splits = nil
+		return nil
+	}
+	if len(t.Splits) < 2 {
+		return fmt.Errorf("a split transaction needs at least 2 splits")
+	}
+	if len(t.Splits) > MaxSplitsPerTransaction {
+		return fmt.Errorf("at most %d splits are allowed", MaxSplitsPerTransaction)
+	}
+
+	total := ZeroMoney(t.Amount.Currency)
+	largest := 0
+	seen := make(map[string]bool)
+	for i := range t.Splits {
+		split := &t.Splits[i]
+		split.Category = strings.TrimSpace(split.Category)
+		split.Note = strings.TrimSpace(split.Note)
+		if split.Category == "" {
+			return fmt.Errorf("split %d: category is required", i+1)
+		}
+		key := strings.ToLower(split.Category)
+		if seen[key] {
+			return fmt.Errorf("split %d: category %s is already split", i+1, split.Category)
+		}
+		seen[key] = true
+		if split.Amount.Currency != t.Amount.Currency {
+			return fmt.Errorf("split %d: amount must be in %s", i+1, t.Amount.Currency)
+		}
+		if split.Amount.IsZero() || split.Amount.IsNegative() != t.Amount.IsNegative() {
+			return fmt.Errorf("split %d: amount must be non-zero with the sign of the transaction amount", i+1)
+		}
+		if len(split.Note) > MaxSplitNoteLength {
+			return fmt.Errorf("split %d: note is longer than %d characters", i+1, MaxSplitNoteLength)
+		}
+		if split.Amount.Abs().Cmp(t.Splits[largest].Amount.Abs()) > 0 {
+			largest = i
+		}
+		total = total.Add(split.Amount)
+	}
+	if total.Cmp(t.Amount) != 0 {
+		return fmt.Errorf("splits add up to %s but the amount is %s", total, t.Amount)
+	}
+
+	if strings.TrimSpace(t.Category) == "" {
+		t.Category = t.Splits[largest].Category
+	}
+	return nil
+}
+
+// RenameCategory moves the transaction and its splits from one category of
+// the catalog to another, joining a split with the split already in
+// the new category. It reports whether anything changed.
+func (t *Transaction) RenameCategory(from, to string) bool {
+	from = NormalizeCategoryID(from)
+	changed := false
+	if NormalizeCategoryID(t.Category) == from {
+		t.Category = to
+		changed = true
+	}
+
+	var splits []Split
+	index := make(map[string]int)
+	for _, split := range t.Splits {
+		if NormalizeCategoryID(split.Category) == from {
+			split.Category = to
+			changed = true
+		}
+		key := NormalizeCategoryID(split.Category)
+		if i, ok := index[key]; ok {
+			splits[i].Amount = splits[i].Amount.Add(split.Amount)
+			switch {
+			case splits[i].Note == "":
+				splits[i].Note = split.Note
+			case split.Note != "":
+				splits[i].Note += "; " + split.Note
+			}
+			continue
+		}
+		index[key] = len(splits)
+		splits = append(splits, split)
+	}
+
+	// Joining the last two splits leaves a plain transaction
+	if len(splits) == 1 {
+		splits = nil
+	}
+	if changed {
+		t.Splits = splits
+	}
+	return changed
+}
@@ -15,11 +15,19 @@ import (
 type Transaction struct {
 	ID          string    `json:"id" dynamodbav:"id"`
 	Date        time.Time `json:"date" dynamodbav:"date"`
-	Amount      float64   `json:"amount" dynamodbav:"amount"`
+	Amount      Money     `json:"amount" dynamodbav:"amount"` // carries the currency; exposed as "currency" in JSON
 	Description string    `json:"description" dynamodbav:"description"`
 	Category    string    `json:"category" dynamodbav:"category"`
-	Type        string    `json:"type" dynamodbav:"type"` // "income" or "expense"
+	Splits      []Split   `json:"splits,omitempty" dynamodbav:"splits,omitempty"` // per-category parts of the amount
+	Type        string    `json:"type" dynamodbav:"type"` // "income", "expense" or "transfer"
+	Tags        []string  `json:"tags,omitempty" dynamodbav:"tags,omitempty"`
 	UserID      string    `json:"user_id" dynamodbav:"user_id"`
+	RecurringID string    `json:"recurring_id,omitempty" dynamodbav:"recurring_id,omitempty"` // rule that generated it
+	ExternalID  string    `json:"external_id,omitempty" dynamodbav:"external_id,omitempty"`   // bank's ID of an imported transaction (OFX FITID)
+	Invoice     *Invoice  `json:"invoice,omitempty" dynamodbav:"invoice,omitempty"`           // CFDI backing an expense
+	DuplicateOf string    `json:"duplicate_of,omitempty" dynamodbav:"duplicate_of,omitempty"` // stored transaction it likely repeats
+	AccountID   string    `json:"account_id,omitempty" dynamodbav:"account_id,omitempty"`     // account it was paid from or into
+	TransferID  string    `json:"transfer_id,omitempty" dynamodbav:"transfer_id,omitempty"`   // transfer it is an entry of
 	
 	// DynamoDB keys for single-table design
 	PK     string `json:"-" dynamodbav:"PK"`     // USER#{userID}
@@ -28,6 +36,8 @@ type Transaction struct {
 	GSI1SK string `json:"-" dynamodbav:"GSI1SK"` // TRANSACTION#{timestamp}
 	GSI2PK string `json:"-" dynamodbav:"GSI2PK"` // CATEGORY#{category}#{userID}
 	GSI2SK string `json:"-" dynamodbav:"GSI2SK"` // TRANSACTION#{timestamp}
+	GSI3PK string `json:"-" dynamodbav:"GSI3PK"` // TRANSACTION#{id}
+	GSI3SK string `json:"-" dynamodbav:"GSI3SK"` // USER#{userID}
 	
 	// Metadata
 	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
@@ -36,7 +46,7 @@ type Transaction struct {
 }
 
 // NewTransaction creates a new transaction with generated ID
-func NewTransaction(userID, transactionType, category, description string, amount float64, date time.Time) *Transaction {
+func NewTransaction(userID, transactionType, category, description string, amount Money, date time.Time) *Transaction {
 	now := time.Now()
 	t := &Transaction{
 		ID:          uuid.New().String(),
@@ -67,6 +77,20 @@ func (t *Transaction) GenerateKeys() {
 	// GSI2: Category access pattern - Query transactions by category
 	t.GSI2PK = fmt.Sprintf("CATEGORY#%s#%s", strings.ToUpper(t.Category), t.UserID)
 	t.GSI2SK = fmt.Sprintf("TRANSACTION#%d", t.Date.Unix())
+	
+	// GSI3: ID access pattern - Get, update or delete a transaction by ID
+	t.GSI3PK = fmt.Sprintf("TRANSACTION#%s", t.ID)
+	t.GSI3SK = fmt.Sprintf("USER#%s", t.UserID)
+}
+
+// TagKeys returns the sort keys of the transaction's tag items, one per tag:
+// copies of the transaction in the user's partition that are queried by tag
+func (t *Transaction) TagKeys() []string {
+	keys := make([]string, 0, len(t.Tags))
+	for _, tag := range t.Tags {
+		keys = append(keys, fmt.Sprintf("TAG#%s#%d#%s", tag, t.Date.Unix(), t.ID))
+	}
+	return keys
 }
 
 // ToDynamoDBItem converts transaction to DynamoDB item
@@ -85,11 +109,14 @@ func (t *Transaction) Validate() error {
 	if t.UserID == "" {
 		return fmt.Errorf("user_id is required")
 	}
-	if t.Amount == 0 {
+	if t.Amount.IsZero() {
 		return fmt.Errorf("amount must be non-zero")
 	}
-	if t.Type != "income" && t.Type != "expense" {
-		return fmt.Errorf("type must be 'income' or 'expense'")
+	if _, err := NormalizeCurrency(t.Amount.Currency); err != nil {
+		return err
+	}
+	if t.Type != "income" && t.Type != "expense" && t.Type != "transfer" {
+		return fmt.Errorf("type must be 'income', 'expense' or 'transfer'")
 	}
 	if t.Category == "" {
 		return fmt.Errorf("category is required")
@@ -102,68 +129,93 @@ func (t *Transaction) Validate() error {
 
 // User represents a user in the system
 type User struct {
-	ID        string    `json:"id" dynamodbav:"id"`
-	Email     string    `json:"email" dynamodbav:"email"`
-	Name      string    `json:"name" dynamodbav:"name"`
-	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
-	UpdatedAt time.Time `json:"updated_at" dynamodbav:"updated_at"`
+	ID           string    `json:"id" dynamodbav:"id"`
+	Email        string    `json:"email" dynamodbav:"email"`
+	Name         string    `json:"name" dynamodbav:"name"`
+	BaseCurrency string    `json:"base_currency" dynamodbav:"base_currency"` // currency analytics and budgets are reported in
+	CreatedAt    time.Time `json:"created_at" dynamodbav:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" dynamodbav:"updated_at"`
 	
 	// DynamoDB keys
 	PK string `json:"-" dynamodbav:"PK"` // USER#{id}
 	SK string `json:"-" dynamodbav:"SK"` // PROFILE
 }
 
+// UserProfileUpdate holds the profile fields a user may change; nil fields are left as-is
+type UserProfileUpdate struct {
+	Name         *string `json:"name,omitempty"`
+	Email        *string `json:"email,omitempty"`
+	BaseCurrency *string `json:"base_currency,omitempty"`
+}
+
 // NewUser creates a new user
 func NewUser(email, name string) *User {
 	now := time.Now()
 	u := &User{
-		ID:        uuid.New().String(),
-		Email:     email,
-		Name:      name,
-		CreatedAt: now,
-		UpdatedAt: now,
+		ID:           uuid.New().String(),
+		Email:        email,
+		Name:         name,
+		BaseCurrency: DefaultCurrency,
+		CreatedAt:    now,
+		UpdatedAt:    now,
 	}
 	u.GenerateKeys()
 	return u
 }
 
+// Currency returns the user's base currency, defaulting for profiles created before it existed
+func (u *User) Currency() string {
+	if u == nil || u.BaseCurrency == "" {
+		return DefaultCurrency
+	}
+	return u.BaseCurrency
+}
+
 // GenerateKeys generates DynamoDB keys for a user
 func (u *User) GenerateKeys() {
 	u.PK = fmt.Sprintf("USER#%s", u.ID)
 	u.SK = "PROFILE"
 }
 
+// BudgetInput is one entry of a bulk budget upsert
+type BudgetInput struct {
+	Month    string `json:"month"` // YYYY-MM format
+	Category string `json:"category"`
+	Amount   Money  `json:"amount"`
+}
+
 // BudgetUtilization represents budget vs spending analysis
 type BudgetUtilization struct {
 	Category     string  `json:"category"`
-	BudgetAmount float64 `json:"budget_amount"`
-	SpentAmount  float64 `json:"spent_amount"`
-	Remaining    float64 `json:"remaining"`
+	BudgetAmount Money   `json:"budget_amount"`
+	SpentAmount  Money   `json:"spent_amount"`
+	Remaining    Money   `json:"remaining"`
 	Percentage   float64 `json:"percentage"`
 }
 type TransactionSummary struct {
-	TotalIncome  float64 `json:"total_income"`
-	TotalExpense float64 `json:"total_expense"`
-	Balance      float64 `json:"balance"`
+	TotalIncome  Money   `json:"total_income"`
+	TotalExpense Money   `json:"total_expense"`
+	Balance      Money   `json:"balance"`
 	Count        int     `json:"count"`
 }
 
 type MonthlyAnalytics struct {
 	Month             string                     `json:"month"`
-	TotalIncome       float64                    `json:"total_income"`
-	TotalExpense      float64                    `json:"total_expense"`
-	Balance           float64                    `json:"balance"`
-	CategoryBreakdown map[string]float64         `json:"category_breakdown"`
+	Currency          string                     `json:"currency"`
+	TotalIncome       Money                      `json:"total_income"`
+	TotalExpense      Money                      `json:"total_expense"`
+	Balance           Money                      `json:"balance"`
+	CategoryBreakdown map[string]Money           `json:"category_breakdown"`
 	TransactionCount  int                        `json:"transaction_count"`
 	Transactions      []Transaction              `json:"transactions,omitempty"`
 }
 
 type CategorySummary struct {
 	Category     string  `json:"category"`
-	TotalAmount  float64 `json:"total_amount"`
+	TotalAmount  Money   `json:"total_amount"`
 	Count        int     `json:"count"`
 	Percentage   float64 `json:"percentage"`
-	AvgAmount    float64 `json:"avg_amount"`
+	AvgAmount    Money   `json:"avg_amount"`
 }
 
 // Query parameters for DynamoDB operations
@@ -177,6 +229,14 @@ type QueryParams struct {
 	LastKey    map[string]types.AttributeValue
 }
 
+// TransactionPage is one page of a transaction listing. NextCursor is an opaque
+// token for the following page and is empty on the last one.
+type TransactionPage struct {
+	Transactions []Transaction `json:"transactions"`
+	NextCursor   string        `json:"next_cursor,omitempty"`
+	HasMore      bool          `json:"has_more"`
+}
+
 // PaginationResponse represents paginated response
 type PaginationResponse struct {
 	Items   interface{}                     `json:"items"`
@@ -200,8 +260,9 @@ type AIAdviceResponse struct {
 }
 
 type FinancialContext struct {
-	MonthlyIncome    float64            `json:"monthly_income"`
-	MonthlyExpense   float64            `json:"monthly_expense"`
+	Currency         string             `json:"currency"`
+	MonthlyIncome    Money              `json:"monthly_income"`
+	MonthlyExpense   Money              `json:"monthly_expense"`
 	SavingsRate      float64            `json:"savings_rate"`
 	TopCategories    []*CategorySummary `json:"top_categories"`
 	SpendingTrends   []string           `json:"spending_trends"`
@@ -209,10 +270,14 @@ type FinancialContext struct {
 
 // Constants
 const (
-	TransactionTypeIncome  = "income"
-	TransactionTypeExpense = "expense"
+	TransactionTypeIncome   = "income"
+	TransactionTypeExpense  = "expense"
+	TransactionTypeTransfer = "transfer" // an entry of a transfer between accounts
 )
 
+// MaxTransactionTags caps a transaction's tags, each of which is stored as a tag item
+const MaxTransactionTags = 20
+
 const (
 	CategorySalary         = "salary"
 	CategoryRent           = "rent"
@@ -229,19 +294,26 @@ const (
 // Custom JSON marshaling to handle time formatting
 func (t *Transaction) MarshalJSON() ([]byte, error) {
 	type Alias Transaction
+	currency := t.Amount.Currency
+	if currency == "" {
+		currency = DefaultCurrency
+	}
 	return json.Marshal(&struct {
-		Date string `json:"date"`
+		Date     string `json:"date"`
+		Currency string `json:"currency"`
 		*Alias
 	}{
-		Date:  t.Date.Format("2006-01-02"),
-		Alias: (*Alias)(t),
+		Date:     t.Date.Format("2006-01-02"),
+		Currency: currency,
+		Alias:    (*Alias)(t),
 	})
 }
 
 func (t *Transaction) UnmarshalJSON(data []byte) error {
 	type Alias Transaction
 	aux := &struct {
-		Date string `json:"date"`
+		Date     string `json:"date"`
+		Currency string `json:"currency"`
 		*Alias
 	}{
 		Alias: (*Alias)(t),
@@ -251,6 +323,20 @@ func (t *Transaction) UnmarshalJSON(data []byte) error {
 		return err
 	}
 	
+	// Amounts without an explicit currency are in the default currency
+	t.Amount.Currency = DefaultCurrency
+	if aux.Currency != "" {
+		currency, err := NormalizeCurrency(aux.Currency)
+		if err != nil {
+			return err
+		}
+		t.Amount.Currency = currency
+	}
+	// Splits are in the transaction's currency
+	for i := range t.Splits {
+		t.Splits[i].Amount.Currency = t.Amount.Currency
+	}
+	
 	// If date is empty, use current time
 	if aux.Date == "" {
 		t.Date = time.Now()
@@ -271,38 +357,118 @@ func (t *Transaction) UnmarshalJSON(data []byte) error {
 
 // FinancialSummary represents an overall financial summary for a user
 type FinancialSummary struct {
-	TotalBalance      float64             `json:"total_balance"`
-	MonthlyIncome     float64             `json:"monthly_income"`
-	MonthlyExpenses   float64             `json:"monthly_expenses"`
-	AvailableMoney    float64             `json:"available_money"`
+	Currency          string              `json:"currency"` // user's base currency
+	TotalBalance      Money               `json:"total_balance"`
+	MonthlyIncome     Money               `json:"monthly_income"`
+	MonthlyExpenses   Money               `json:"monthly_expenses"`
+	AvailableMoney    Money               `json:"available_money"`
 	SavingsRate       float64             `json:"savings_rate"` // percentage of income saved
 	CategoryBreakdown []CategoryBreakdown `json:"category_breakdown"` // Changed from map to slice
-	MonthlyTrend      float64             `json:"monthly_trend"` // percentage change from last month
+	MonthlyTrend      float64             `json:"monthly_trend"` // percentage change in expenses from last month
+	UpcomingPayments  []PaymentDue        `json:"upcoming_payments"` // unpaid credit card statements, soonest first
 	Timestamp         time.Time           `json:"timestamp"`
 }
 
+// MonthlyTrends is a month-by-month time series over a range of months
+type MonthlyTrends struct {
+	From     string              `json:"from"` // YYYY-MM
+	To       string              `json:"to"`   // YYYY-MM
+	Currency string              `json:"currency"`
+	Months   []MonthlyTrendPoint `json:"months"`
+}
+
+// MonthlyTrendPoint holds one month of a trends series. Expenses are positive;
+// category totals keep the transaction sign (expenses negative).
+type MonthlyTrendPoint struct {
+	Month            string           `json:"month"`
+	TotalIncome      Money            `json:"total_income"`
+	TotalExpenses    Money            `json:"total_expenses"`
+	NetBalance       Money            `json:"net_balance"`
+	TransactionCount int              `json:"transaction_count"`
+	Categories       map[string]Money `json:"categories"`
+	MonthOverMonth   *TrendDelta      `json:"month_over_month,omitempty"`
+	YearOverYear     *TrendDelta      `json:"year_over_year,omitempty"`
+}
+
+// TrendDelta compares a month with an earlier one. Percentages are nil when
+// the earlier value is zero.
+type TrendDelta struct {
+	Compared          string   `json:"compared"` // YYYY-MM of the earlier month
+	Income            Money    `json:"income"`
+	Expenses          Money    `json:"expenses"`
+	NetBalance        Money    `json:"net_balance"`
+	IncomePercent     *float64 `json:"income_percent,omitempty"`
+	ExpensesPercent   *float64 `json:"expenses_percent,omitempty"`
+	NetBalancePercent *float64 `json:"net_balance_percent,omitempty"`
+}
+
+// Subscription cadences detected from transaction history
+const (
+	CadenceWeekly  = "weekly"
+	CadenceMonthly = "monthly"
+	CadenceAnnual  = "annual"
+)
+
+// Subscription is a recurring charge detected in a user's expenses. Amounts
+// are positive and in the user's base currency.
+type Subscription struct {
+	Name           string    `json:"name"` // description of the latest charge
+	Category       string    `json:"category"`
+	Cadence        string    `json:"cadence"` // weekly, monthly or annual
+	Occurrences    int       `json:"occurrences"`
+	AverageAmount  Money     `json:"average_amount"`
+	LastAmount     Money     `json:"last_amount"`
+	AnnualizedCost Money     `json:"annualized_cost"` // last amount times charges per year
+	FirstCharge    time.Time `json:"first_charge"`
+	LastCharge     time.Time `json:"last_charge"`
+	NextExpected   time.Time `json:"next_expected"`
+	Active         bool      `json:"active"` // false once a charge has been missed
+
+	// Set when the latest charge costs more than the one before it
+	PriceIncrease      bool     `json:"price_increase"`
+	PreviousAmount     *Money   `json:"previous_amount,omitempty"`
+	PriceChangePercent *float64 `json:"price_change_percent,omitempty"`
+}
+
 // CategoryBreakdown represents spending breakdown by category
 type CategoryBreakdown struct {
 	Category    string  `json:"category"`
-	Amount      float64 `json:"amount"`
+	Amount      Money   `json:"amount"`
 	Percentage  float64 `json:"percentage"`
 	Count       int     `json:"transaction_count"`
 	Color       string  `json:"color,omitempty"` // For chart visualization
+	Label       string  `json:"label,omitempty"` // Display name from the user's category catalog
+	Icon        string  `json:"icon,omitempty"`
+	Parent      string  `json:"parent,omitempty"`
+	Subcategories []CategoryBreakdown `json:"subcategories,omitempty"` // When rolled up to parent categories
+}
+
+// TagBreakdown represents the expenses carrying one tag. A transaction with
+// several tags counts under each of them, so percentages can add up to more
+// than 100.
+type TagBreakdown struct {
+	Tag        string  `json:"tag"`
+	Amount     Money   `json:"amount"`
+	Percentage float64 `json:"percentage"` // of all expenses in the period
+	Count      int     `json:"transaction_count"`
 }
 
 // MonthSummary represents summary data for a specific month
 type MonthSummary struct {
 	Month        string  `json:"month"`
-	Income       float64 `json:"income"`
-	Expenses     float64 `json:"expenses"`
-	Balance      float64 `json:"balance"`
+	Income       Money   `json:"income"`
+	Expenses     Money   `json:"expenses"`
+	Balance      Money   `json:"balance"`
 	HasTransactions bool `json:"has_transactions"`
 }
 
 // CategoryOption represents a category option for UI dropdowns/selects
 type CategoryOption struct {
-	Label string `json:"label"`
-	Value string `json:"value"`
+	Label  string `json:"label"`
+	Value  string `json:"value"`
+	Parent string `json:"parent,omitempty"`
+	Color  string `json:"color,omitempty"`
+	Icon   string `json:"icon,omitempty"`
 }
 
 // Budget represents a budget for a specific category and month
@@ -311,7 +477,7 @@ type Budget struct {
 	UserID   string    `json:"user_id" dynamodbav:"user_id"`
 	Category string    `json:"category" dynamodbav:"category"`
 	Month    string    `json:"month" dynamodbav:"month"` // YYYY-MM format
-	Amount   float64   `json:"amount" dynamodbav:"amount"`
+	Amount   Money     `json:"amount" dynamodbav:"amount"`
 	CreatedAt time.Time `json:"created_at" dynamodbav:"created_at"`
 	UpdatedAt time.Time `json:"updated_at" dynamodbav:"updated_at"`
 	
@@ -340,17 +506,18 @@ func (b *Budget) FromDynamoDBItem(item map[string]types.AttributeValue) error {
 // CategoryBudgetBreakdown represents spending breakdown with budget info
 type CategoryBudgetBreakdown struct {
 	Category  string  `json:"category"`
-	Amount    float64 `json:"amount"`
-	Budget    float64 `json:"budget"`
-	Remaining float64 `json:"remaining"`
+	Amount    Money   `json:"amount"`
+	Budget    Money   `json:"budget"`
+	Remaining Money   `json:"remaining"`
 }
 
 // MonthlyAnalyticsWithBudget extends MonthlyAnalytics with budget information
 type MonthlyAnalyticsWithBudget struct {
 	Month             string                     `json:"month"`
-	TotalIncome       float64                    `json:"total_income"`
-	TotalExpense      float64                    `json:"total_expense"`
-	Balance           float64                    `json:"balance"`
+	Currency          string                     `json:"currency"`
+	TotalIncome       Money                      `json:"total_income"`
+	TotalExpense      Money                      `json:"total_expense"`
+	Balance           Money                      `json:"balance"`
 	CategoryBreakdown []CategoryBudgetBreakdown  `json:"category_breakdown"` // Changed from map
 	TransactionCount  int                        `json:"transaction_count"`
 }
@@ -0,0 +1,134 @@
+package period
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var ErrInvalidPeriod = errors.New("invalid period")
+
+// Supported period names
+const (
+	Week    = "week"    // rolling 7 days ending today
+	Days30  = "30d"     // rolling 30 days ending today
+	Month   = "month"   // current calendar month
+	Quarter = "quarter" // current calendar quarter
+	Year    = "year"    // year to date
+	Custom  = "custom"  // explicit from/to dates
+)
+
+// MaxCustomDays bounds explicit from/to ranges
+const MaxCustomDays = 366 * 5
+
+const (
+	dateLayout  = "2006-01-02"
+	monthLayout = "2006-01"
+)
+
+// Range is a span of whole days in UTC. Start is inclusive and End exclusive,
+// so a range covering one day has End == Start + 24h.
+type Range struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+// Parse resolves a period name, or an explicit from/to date pair
+// (YYYY-MM-DD, both inclusive), relative to now. An empty name with no dates
+// defaults to the current month; dates without a name imply Custom.
+func Parse(name, from, to string, now time.Time) (Range, error) {
+	if name == "" {
+		name = Month
+		if from != "" || to != "" {
+			name = Custom
+		}
+	}
+	if name != Custom && (from != "" || to != "") {
+		return Range{}, fmt.Errorf("%w: from/to can only be used with the %s period", ErrInvalidPeriod, Custom)
+	}
+
+	today := startOfDay(now)
+	tomorrow := today.AddDate(0, 0, 1)
+
+	switch name {
+	case Week:
+		return Range{Name: name, Start: today.AddDate(0, 0, -6), End: tomorrow}, nil
+	case Days30:
+		return Range{Name: name, Start: today.AddDate(0, 0, -29), End: tomorrow}, nil
+	case Month:
+		start := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return Range{Name: name, Start: start, End: start.AddDate(0, 1, 0)}, nil
+	case Quarter:
+		firstMonth := time.Month((int(today.Month())-1)/3*3 + 1)
+		start := time.Date(today.Year(), firstMonth, 1, 0, 0, 0, 0, time.UTC)
+		return Range{Name: name, Start: start, End: start.AddDate(0, 3, 0)}, nil
+	case Year:
+		return Range{Name: name, Start: time.Date(today.Year(), 1, 1, 0, 0, 0, 0, time.UTC), End: tomorrow}, nil
+	case Custom:
+		return parseCustom(from, to)
+	default:
+		return Range{}, fmt.Errorf("%w: unknown period %q, expected one of %s, %s, %s, %s, %s or %s",
+			ErrInvalidPeriod, name, Week, Days30, Month, Quarter, Year, Custom)
+	}
+}
+
+// WholeMonths widens from/to values given as whole months (YYYY-MM) to the
+// dates Parse expects: the first day of the from month and the last day of
+// the to month. Other values are returned unchanged.
+func WholeMonths(from, to string) (string, string) {
+	if month, err := time.Parse(monthLayout, from); err == nil {
+		from = month.Format(dateLayout)
+	}
+	if month, err := time.Parse(monthLayout, to); err == nil {
+		to = month.AddDate(0, 1, -1).Format(dateLayout)
+	}
+	return from, to
+}
+
+func parseCustom(from, to string) (Range, error) {
+	if from == "" || to == "" {
+		return Range{}, fmt.Errorf("%w: from and to are required for a %s period", ErrInvalidPeriod, Custom)
+	}
+	start, err := time.Parse(dateLayout, from)
+	if err != nil {
+		return Range{}, fmt.Errorf("%w: from date %q, expected YYYY-MM-DD", ErrInvalidPeriod, from)
+	}
+	end, err := time.Parse(dateLayout, to)
+	if err != nil {
+		return Range{}, fmt.Errorf("%w: to date %q, expected YYYY-MM-DD", ErrInvalidPeriod, to)
+	}
+	if end.Before(start) {
+		return Range{}, fmt.Errorf("%w: from date must not be after to date", ErrInvalidPeriod)
+	}
+	end = end.AddDate(0, 0, 1)
+	if days := int(end.Sub(start).Hours() / 24); days > MaxCustomDays {
+		return Range{}, fmt.Errorf("%w: range spans %d days, at most %d allowed", ErrInvalidPeriod, days, MaxCustomDays)
+	}
+	return Range{Name: Custom, Start: start, End: end}, nil
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// Contains reports whether t falls on one of the range's days
+func (r Range) Contains(t time.Time) bool {
+	t = t.UTC()
+	return !t.Before(r.Start) && t.Before(r.End)
+}
+
+// Last returns the final day included in the range
+func (r Range) Last() time.Time {
+	return r.End.AddDate(0, 0, -1)
+}
+
+// Months lists the calendar months (YYYY-MM) the range overlaps, in order
+func (r Range) Months() []string {
+	var months []string
+	last := r.Last()
+	for month := time.Date(r.Start.Year(), r.Start.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(last); month = month.AddDate(0, 1, 0) {
+		months = append(months, month.Format("2006-01"))
+	}
+	return months
+}
@@ -0,0 +1,196 @@
+package rates
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"backend/internal/models"
+)
+
+var ErrRateNotFound = errors.New("exchange rate not found")
+
+const dateLayout = "2006-01-02"
+
+// Rate is the value of one unit of Base expressed in Quote on a given day
+type Rate struct {
+	Date  string  `json:"date"` // YYYY-MM-DD
+	Base  string  `json:"base"`
+	Quote string  `json:"quote"`
+	Rate  float64 `json:"rate"`
+}
+
+type datedRate struct {
+	day  time.Time
+	rate float64
+}
+
+// Store holds daily exchange rates indexed by currency pair
+type Store struct {
+	pairs map[string][]datedRate // "USD/MXN" -> rates sorted by day
+}
+
+// NewStore creates an empty store; it only converts between identical currencies
+func NewStore() *Store {
+	return &Store{pairs: make(map[string][]datedRate)}
+}
+
+// LoadFile reads rates from a .csv (date,base,quote,rate) or .json ([]Rate) file
+func LoadFile(path string) (*Store, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rates file %s: %w", path, err)
+	}
+	defer file.Close()
+
+	var rates []Rate
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".csv":
+		rates, err = readCSV(file)
+	case ".json":
+		err = json.NewDecoder(file).Decode(&rates)
+	default:
+		return nil, fmt.Errorf("unsupported rates file format %q, expected .csv or .json", filepath.Ext(path))
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse rates file %s: %w", path, err)
+	}
+
+	store := NewStore()
+	for i, rate := range rates {
+		if err := store.AddRate(rate); err != nil {
+			return nil, fmt.Errorf("rates file %s, entry %d: %w", path, i+1, err)
+		}
+	}
+
+	return store, nil
+}
+
+// LoadOptional loads the rates file when a path is configured and returns an
+// empty store otherwise
+func LoadOptional(path string) (*Store, error) {
+	if path == "" {
+		return NewStore(), nil
+	}
+	return LoadFile(path)
+}
+
+func readCSV(r io.Reader) ([]Rate, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	var rates []Rate
+	for i, record := range records {
+		if len(record) != 4 {
+			return nil, fmt.Errorf("line %d: expected 4 columns (date,base,quote,rate), got %d", i+1, len(record))
+		}
+		// Skip an optional header row
+		if i == 0 && strings.EqualFold(record[0], "date") {
+			continue
+		}
+
+		value, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid rate %q", i+1, record[3])
+		}
+		rates = append(rates, Rate{Date: record[0], Base: record[1], Quote: record[2], Rate: value})
+	}
+
+	return rates, nil
+}
+
+// AddRate validates and stores a single rate
+func (s *Store) AddRate(rate Rate) error {
+	day, err := time.Parse(dateLayout, rate.Date)
+	if err != nil {
+		return fmt.Errorf("invalid date %q, expected YYYY-MM-DD", rate.Date)
+	}
+	base, err := models.NormalizeCurrency(rate.Base)
+	if err != nil {
+		return err
+	}
+	quote, err := models.NormalizeCurrency(rate.Quote)
+	if err != nil {
+		return err
+	}
+	if rate.Rate <= 0 {
+		return fmt.Errorf("rate for %s/%s on %s must be positive", base, quote, rate.Date)
+	}
+
+	key := pairKey(base, quote)
+	entries := append(s.pairs[key], datedRate{day: day, rate: rate.Rate})
+	sort.Slice(entries, func(i, j int) bool { return entries[i].day.Before(entries[j].day) })
+	s.pairs[key] = entries
+	return nil
+}
+
+// Rate returns how many units of quote one unit of base is worth on the given
+// day. It uses the latest rate published on or before that day (markets close on
+// weekends) and falls back to the earliest known rate for older dates. Inverse
+// pairs are derived automatically.
+func (s *Store) Rate(base, quote string, on time.Time) (float64, error) {
+	if base == quote {
+		return 1, nil
+	}
+
+	if rate, ok := s.lookup(pairKey(base, quote), on); ok {
+		return rate, nil
+	}
+	if rate, ok := s.lookup(pairKey(quote, base), on); ok {
+		return 1 / rate, nil
+	}
+
+	return 0, fmt.Errorf("%w: %s/%s on %s", ErrRateNotFound, base, quote, on.Format(dateLayout))
+}
+
+func (s *Store) lookup(key string, on time.Time) (float64, bool) {
+	entries := s.pairs[key]
+	if len(entries) == 0 {
+		return 0, false
+	}
+
+	day := time.Date(on.Year(), on.Month(), on.Day(), 0, 0, 0, 0, time.UTC)
+	// First entry strictly after the day; the one before it is the applicable rate
+	i := sort.Search(len(entries), func(i int) bool { return entries[i].day.After(day) })
+	if i == 0 {
+		return entries[0].rate, true
+	}
+	return entries[i-1].rate, true
+}
+
+// Convert expresses amount in the target currency using the rate of the given day
+func (s *Store) Convert(amount models.Money, to string, on time.Time) (models.Money, error) {
+	from := amount.Currency
+	if from == "" {
+		from = models.DefaultCurrency
+	}
+	if from == to {
+		return models.NewMoney(amount.Minor, to), nil
+	}
+
+	rate, err := s.Rate(from, to, on)
+	if err != nil {
+		return models.Money{}, err
+	}
+
+	converted := amount.MulFloat(rate)
+	converted.Currency = to
+	return converted, nil
+}
+
+func pairKey(base, quote string) string {
+	return base + "/" + quote
+}
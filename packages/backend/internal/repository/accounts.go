@@ -0,0 +1,237 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"backend/internal/models"
+)
+
+// ErrAccountNotFound is returned when no account with the ID belongs to the user
+var ErrAccountNotFound = errors.New("account not found")
+
+// ErrAccountConflict is returned when an account changed or was deleted since it was read
+var ErrAccountConflict = errors.New("account was modified concurrently")
+
+func accountKey(userID, accountID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+		"SK": &types.AttributeValueMemberS{Value: fmt.Sprintf("ACCOUNT#%s", accountID)},
+	}
+}
+
+// CreateAccount stores a new account
+func (r *DynamoDBRepository) CreateAccount(ctx context.Context, account *models.Account) error {
+	item, err := account.ToDynamoDBItem()
+	if err != nil {
+		return fmt.Errorf("failed to marshal account: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return fmt.Errorf("account %s already exists", account.ID)
+		}
+		return fmt.Errorf("failed to create account: %w", err)
+	}
+
+	return nil
+}
+
+// GetAccount retrieves one of the user's accounts
+func (r *DynamoDBRepository) GetAccount(ctx context.Context, userID, accountID string) (*models.Account, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       accountKey(userID, accountID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get account: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, ErrAccountNotFound
+	}
+
+	var account models.Account
+	if err := account.FromDynamoDBItem(result.Item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal account: %w", err)
+	}
+
+	return &account, nil
+}
+
+// ListAccounts retrieves all of the user's accounts
+func (r *DynamoDBRepository) ListAccounts(ctx context.Context, userID string) ([]models.Account, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk_prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":        &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+			":sk_prefix": &types.AttributeValueMemberS{Value: "ACCOUNT#"},
+		},
+	}
+
+	var accounts []models.Account
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query accounts: %w", err)
+		}
+
+		for _, item := range result.Items {
+			var account models.Account
+			if err := account.FromDynamoDBItem(item); err != nil {
+				log.Printf("Failed to unmarshal account: %v", err)
+				continue
+			}
+			accounts = append(accounts, account)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return accounts, nil
+}
+
+// UpdateAccount replaces an account using optimistic locking, like
+// UpdateRecurringTransaction
+func (r *DynamoDBRepository) UpdateAccount(ctx context.Context, account *models.Account) error {
+	expected := account.Version
+	account.Version++
+	account.UpdatedAt = time.Now()
+
+	item, err := account.ToDynamoDBItem()
+	if err != nil {
+		account.Version = expected
+		return fmt.Errorf("failed to marshal account: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(PK) AND version = :version"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: strconv.Itoa(expected)},
+		},
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		account.Version = expected
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrAccountConflict
+		}
+		return fmt.Errorf("failed to update account: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteAccount removes an account
+func (r *DynamoDBRepository) DeleteAccount(ctx context.Context, userID, accountID string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName:           aws.String(r.tableName),
+		Key:                 accountKey(userID, accountID),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	}
+
+	if _, err := r.client.DeleteItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrAccountNotFound
+		}
+		return fmt.Errorf("failed to delete account: %w", err)
+	}
+
+	return nil
+}
+
+// GetTransactionsByAccount retrieves the transactions linked to the account.
+// It reads the user's partition and filters in DynamoDB, so a page can hold
+// fewer than limit transactions while more remain.
+func (r *DynamoDBRepository) GetTransactionsByAccount(ctx context.Context, userID string, accountID string, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		FilterExpression:       aws.String("account_id = :account_id"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":         &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+			":sk":         &types.AttributeValueMemberS{Value: "TRANSACTION#"},
+			":account_id": &types.AttributeValueMemberS{Value: accountID},
+		},
+		ScanIndexForward: aws.Bool(false), // Most recent first
+		Limit:            aws.Int32(int32(limit)),
+	}
+
+	if lastKey != nil {
+		input.ExclusiveStartKey = lastKey
+	}
+
+	result, err := r.client.Query(ctx, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query transactions by account: %w", err)
+	}
+
+	var transactions []models.Transaction
+	for _, item := range result.Items {
+		var transaction models.Transaction
+		if err := transaction.FromDynamoDBItem(item); err != nil {
+			log.Printf("Failed to unmarshal transaction: %v", err)
+			continue
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, result.LastEvaluatedKey, nil
+}
+
+// CreateTransfer stores both entries of a transfer in a single transaction,
+// so a transfer is never left with only one side
+func (r *DynamoDBRepository) CreateTransfer(ctx context.Context, transfer *models.Transfer) error {
+	var items []types.TransactWriteItem
+	for _, entry := range []*models.Transaction{&transfer.From, &transfer.To} {
+		if err := entry.Validate(); err != nil {
+			return fmt.Errorf("validation failed: %w", err)
+		}
+		item, err := entry.ToDynamoDBItem()
+		if err != nil {
+			return fmt.Errorf("failed to marshal transaction: %w", err)
+		}
+		items = append(items, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName:           aws.String(r.tableName),
+				Item:                item,
+				ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+			},
+		})
+	}
+
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		var txErr *types.TransactionCanceledException
+		if errors.As(err, &txErr) {
+			return ErrTransactionExists
+		}
+		return fmt.Errorf("failed to create transfer: %w", err)
+	}
+
+	log.Printf("Transfer created: %s for user %s", transfer.ID, transfer.From.UserID)
+	return nil
+}
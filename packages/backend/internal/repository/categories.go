@@ -0,0 +1,168 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"backend/internal/models"
+)
+
+// ErrCategoryNotFound is returned when the category is not in the user's catalog
+var ErrCategoryNotFound = errors.New("category not found")
+
+// ErrCategoryExists is returned when creating a category whose ID is already in the catalog
+var ErrCategoryExists = errors.New("category already exists")
+
+// ErrCategoryConflict is returned when a category changed or was deleted since it was read
+var ErrCategoryConflict = errors.New("category was modified concurrently")
+
+func categoryKey(userID, categoryID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+		"SK": &types.AttributeValueMemberS{Value: fmt.Sprintf("CATEGORY#%s", categoryID)},
+	}
+}
+
+// CreateCategory adds a category to the user's catalog
+func (r *DynamoDBRepository) CreateCategory(ctx context.Context, category *models.Category) error {
+	item, err := category.ToDynamoDBItem()
+	if err != nil {
+		return fmt.Errorf("failed to marshal category: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrCategoryExists
+		}
+		return fmt.Errorf("failed to create category: %w", err)
+	}
+
+	return nil
+}
+
+// GetCategory retrieves one category of the user's catalog
+func (r *DynamoDBRepository) GetCategory(ctx context.Context, userID, categoryID string) (*models.Category, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       categoryKey(userID, categoryID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, ErrCategoryNotFound
+	}
+
+	var category models.Category
+	if err := category.FromDynamoDBItem(result.Item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal category: %w", err)
+	}
+
+	return &category, nil
+}
+
+// ListCategories retrieves the user's stored catalog
+func (r *DynamoDBRepository) ListCategories(ctx context.Context, userID string) ([]models.Category, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk_prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":        &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+			":sk_prefix": &types.AttributeValueMemberS{Value: "CATEGORY#"},
+		},
+	}
+
+	var categories []models.Category
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query categories: %w", err)
+		}
+
+		for _, item := range result.Items {
+			var category models.Category
+			if err := category.FromDynamoDBItem(item); err != nil {
+				log.Printf("Failed to unmarshal category: %v", err)
+				continue
+			}
+			categories = append(categories, category)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return categories, nil
+}
+
+// UpdateCategory replaces a category using optimistic locking, like
+// UpdateRecurringTransaction
+func (r *DynamoDBRepository) UpdateCategory(ctx context.Context, category *models.Category) error {
+	expected := category.Version
+	category.Version++
+	category.UpdatedAt = time.Now()
+
+	item, err := category.ToDynamoDBItem()
+	if err != nil {
+		category.Version = expected
+		return fmt.Errorf("failed to marshal category: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(PK) AND version = :version"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: strconv.Itoa(expected)},
+		},
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		category.Version = expected
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrCategoryConflict
+		}
+		return fmt.Errorf("failed to update category: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteCategory removes a category from the catalog. Transactions keep the
+// category they have.
+func (r *DynamoDBRepository) DeleteCategory(ctx context.Context, userID, categoryID string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName:           aws.String(r.tableName),
+		Key:                 categoryKey(userID, categoryID),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	}
+
+	if _, err := r.client.DeleteItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrCategoryNotFound
+		}
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+
+	return nil
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"log"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -18,6 +19,18 @@ import (
 	"backend/internal/models"
 )
 
+// ErrUserNotFound is returned when a user has no stored profile
+var ErrUserNotFound = errors.New("user not found")
+
+// ErrBudgetNotFound is returned when the user has no budget for the month and category
+var ErrBudgetNotFound = errors.New("budget not found")
+
+// ErrTransactionNotFound is returned when no transaction with the ID belongs to the user
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// ErrTransactionExists is returned when creating a transaction whose key is already stored
+var ErrTransactionExists = errors.New("transaction already exists")
+
 type Repository interface {
 	// Transaction operations
 	CreateTransaction(ctx context.Context, transaction *models.Transaction) error
@@ -28,7 +41,9 @@ type Repository interface {
 	// Query operations with optimized access patterns
 	GetTransactionsByUser(ctx context.Context, userID string, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error)
 	GetTransactionsByMonth(ctx context.Context, userID string, month string, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error)
+	GetTransactionsByMonthBetween(ctx context.Context, userID string, month string, from, to time.Time, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error)
 	GetTransactionsByCategory(ctx context.Context, userID string, category string, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error)
+	GetTransactionsByTag(ctx context.Context, userID string, tag string, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error)
 	
 	// Batch operations
 	BatchCreateTransactions(ctx context.Context, transactions []models.Transaction) error
@@ -38,13 +53,69 @@ type Repository interface {
 	
 	// Budget operations
 	CreateOrUpdateBudget(ctx context.Context, budget *models.Budget) error
+	BatchUpsertBudgets(ctx context.Context, budgets []models.Budget) error
 	GetBudgetsByMonth(ctx context.Context, userID, month string) ([]models.Budget, error)
+	GetBudgetsInRange(ctx context.Context, userID, fromMonth, toMonth string) ([]models.Budget, error)
+	ListBudgets(ctx context.Context, userID string) ([]models.Budget, error)
 	GetBudget(ctx context.Context, userID, month, category string) (*models.Budget, error)
 	DeleteBudget(ctx context.Context, userID, month, category string) error
 	
+	// Recurring transaction operations
+	CreateRecurringTransaction(ctx context.Context, recurring *models.RecurringTransaction) error
+	GetRecurringTransaction(ctx context.Context, userID, recurringID string) (*models.RecurringTransaction, error)
+	ListRecurringTransactions(ctx context.Context, userID string) ([]models.RecurringTransaction, error)
+	UpdateRecurringTransaction(ctx context.Context, recurring *models.RecurringTransaction) error
+	DeleteRecurringTransaction(ctx context.Context, userID, recurringID string) error
+	GetDueRecurringTransactions(ctx context.Context, before time.Time) ([]models.RecurringTransaction, error)
+	
+	// Import mapping operations
+	SaveImportMapping(ctx context.Context, mapping *models.ImportMapping) error
+	GetImportMapping(ctx context.Context, userID, name string) (*models.ImportMapping, error)
+	ListImportMappings(ctx context.Context, userID string) ([]models.ImportMapping, error)
+	DeleteImportMapping(ctx context.Context, userID, name string) error
+	
+	// Account operations
+	CreateAccount(ctx context.Context, account *models.Account) error
+	GetAccount(ctx context.Context, userID, accountID string) (*models.Account, error)
+	ListAccounts(ctx context.Context, userID string) ([]models.Account, error)
+	UpdateAccount(ctx context.Context, account *models.Account) error
+	DeleteAccount(ctx context.Context, userID, accountID string) error
+	GetTransactionsByAccount(ctx context.Context, userID string, accountID string, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error)
+	CreateTransfer(ctx context.Context, transfer *models.Transfer) error
+	
+	// Savings goal operations
+	CreateGoal(ctx context.Context, goal *models.Goal) error
+	GetGoal(ctx context.Context, userID, goalID string) (*models.Goal, error)
+	ListGoals(ctx context.Context, userID string) ([]models.Goal, error)
+	UpdateGoal(ctx context.Context, goal *models.Goal) error
+	DeleteGoal(ctx context.Context, userID, goalID string) error
+	CreateGoalContribution(ctx context.Context, contribution *models.GoalContribution) error
+	ListGoalContributions(ctx context.Context, userID, goalID string) ([]models.GoalContribution, error)
+	DeleteGoalContribution(ctx context.Context, userID, goalID, contributionID string) error
+	
+	// Category catalog operations
+	CreateCategory(ctx context.Context, category *models.Category) error
+	GetCategory(ctx context.Context, userID, categoryID string) (*models.Category, error)
+	ListCategories(ctx context.Context, userID string) ([]models.Category, error)
+	UpdateCategory(ctx context.Context, category *models.Category) error
+	DeleteCategory(ctx context.Context, userID, categoryID string) error
+	
+	// Categorization rule operations
+	CreateRule(ctx context.Context, rule *models.CategoryRule) error
+	GetRule(ctx context.Context, userID, ruleID string) (*models.CategoryRule, error)
+	ListRules(ctx context.Context, userID string) ([]models.CategoryRule, error)
+	UpdateRule(ctx context.Context, rule *models.CategoryRule) error
+	DeleteRule(ctx context.Context, userID, ruleID string) error
+	
+	// Idempotency key operations
+	CreateIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error
+	GetIdempotencyKey(ctx context.Context, userID, key string) (*models.IdempotencyKey, error)
+	DeleteIdempotencyKey(ctx context.Context, userID, key string) error
+	
 	// User operations
 	CreateUser(ctx context.Context, user *models.User) error
 	GetUser(ctx context.Context, userID string) (*models.User, error)
+	UpdateUser(ctx context.Context, user *models.User) error
 }
 
 type DynamoDBRepository struct {
@@ -66,15 +137,23 @@ func (r *DynamoDBRepository) CreateTransaction(ctx context.Context, transaction
 		return fmt.Errorf("failed to marshal transaction: %w", err)
 	}
 
-	input := &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      item,
-		// Prevent overwriting existing transactions
-		ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+	writes := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName: aws.String(r.tableName),
+				Item:      item,
+				// Prevent overwriting existing transactions
+				ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+			},
+		},
 	}
+	writes = append(writes, r.putTagItems(transaction, item)...)
 
-	_, err = r.client.PutItem(ctx, input)
+	err = r.writeItems(ctx, writes)
 	if err != nil {
+		if conditionFailed(err, 0) {
+			return ErrTransactionExists
+		}
 		return fmt.Errorf("failed to create transaction: %w", err)
 	}
 
@@ -89,7 +168,7 @@ func (r *DynamoDBRepository) GetTransactionsByUser(ctx context.Context, userID s
 		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":pk": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
-			":sk": &types.AttributeValueMemberS{Value: "TX#"},
+			":sk": &types.AttributeValueMemberS{Value: "TRANSACTION#"},
 		},
 		ScanIndexForward: aws.Bool(false), // Most recent first
 		Limit:           aws.Int32(int32(limit)),
@@ -152,6 +231,57 @@ func (r *DynamoDBRepository) GetTransactionsByMonth(ctx context.Context, userID
 	return transactions, result.LastEvaluatedKey, nil
 }
 
+// GetTransactionsByMonthBetween retrieves the transactions of a month dated
+// from from to to (both inclusive, to the second) with a range condition on
+// the GSI1 sort key. The bounds are clamped to the month, so they have as many
+// digits as the timestamps of its transactions and compare as strings alike.
+func (r *DynamoDBRepository) GetTransactionsByMonthBetween(ctx context.Context, userID string, month string, from, to time.Time, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+	start, err := time.Parse("2006-01", month)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid month %q: %w", month, err)
+	}
+	if from.Before(start) {
+		from = start
+	}
+	if end := start.AddDate(0, 1, 0).Add(-time.Second); to.After(end) {
+		to = end
+	}
+
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("GSI1PK = :gsi1pk AND GSI1SK BETWEEN :from AND :to"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi1pk": &types.AttributeValueMemberS{Value: fmt.Sprintf("MONTH#%s#%s", month, userID)},
+			":from":   &types.AttributeValueMemberS{Value: fmt.Sprintf("TRANSACTION#%d", from.Unix())},
+			":to":     &types.AttributeValueMemberS{Value: fmt.Sprintf("TRANSACTION#%d", to.Unix())},
+		},
+		ScanIndexForward: aws.Bool(false), // Most recent first
+		Limit:            aws.Int32(int32(limit)),
+	}
+
+	if lastKey != nil {
+		input.ExclusiveStartKey = lastKey
+	}
+
+	result, err := r.client.Query(ctx, input)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query transactions by date: %w", err)
+	}
+
+	var transactions []models.Transaction
+	for _, item := range result.Items {
+		var transaction models.Transaction
+		if err := transaction.FromDynamoDBItem(item); err != nil {
+			log.Printf("Failed to unmarshal transaction: %v", err)
+			continue
+		}
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, result.LastEvaluatedKey, nil
+}
+
 // GetTransactionsByCategory retrieves transactions for a specific category using GSI2
 func (r *DynamoDBRepository) GetTransactionsByCategory(ctx context.Context, userID string, category string, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
 	input := &dynamodb.QueryInput{
@@ -187,33 +317,168 @@ func (r *DynamoDBRepository) GetTransactionsByCategory(ctx context.Context, user
 	return transactions, result.LastEvaluatedKey, nil
 }
 
-// GetMonthlyAnalytics calculates analytics for a specific month
-func (r *DynamoDBRepository) GetMonthlyAnalytics(ctx context.Context, userID string, month string) (*models.MonthlyAnalytics, error) {
-	transactions, _, err := r.GetTransactionsByMonth(ctx, userID, month, 1000, nil)
+// GetTransactionsByTag retrieves the user's transactions carrying the tag from
+// the tag items written next to each tagged transaction, most recent first
+func (r *DynamoDBRepository) GetTransactionsByTag(ctx context.Context, userID string, tag string, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+			":sk": &types.AttributeValueMemberS{Value: fmt.Sprintf("TAG#%s#", tag)},
+		},
+		ScanIndexForward: aws.Bool(false), // Most recent first
+		Limit:            aws.Int32(int32(limit)),
+	}
+
+	if lastKey != nil {
+		input.ExclusiveStartKey = lastKey
+	}
+
+	result, err := r.client.Query(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get transactions for analytics: %w", err)
+		return nil, nil, fmt.Errorf("failed to query transactions by tag: %w", err)
 	}
 
+	var transactions []models.Transaction
+	for _, item := range result.Items {
+		var transaction models.Transaction
+		if err := transaction.FromDynamoDBItem(item); err != nil {
+			log.Printf("Failed to unmarshal transaction: %v", err)
+			continue
+		}
+		// A tag containing "#" can share the prefix of another tag's items
+		if !transaction.HasTag(tag) {
+			continue
+		}
+		transaction.GenerateKeys()
+		transactions = append(transactions, transaction)
+	}
+
+	return transactions, result.LastEvaluatedKey, nil
+}
+
+// putTagItems returns the writes that store a copy of the transaction's item
+// under each of its tag keys. The copies leave out the index keys so they stay
+// out of GSI1-GSI3.
+func (r *DynamoDBRepository) putTagItems(transaction *models.Transaction, item map[string]types.AttributeValue) []types.TransactWriteItem {
+	var writes []types.TransactWriteItem
+	for _, sk := range transaction.TagKeys() {
+		tagItem := make(map[string]types.AttributeValue, len(item))
+		for name, value := range item {
+			switch name {
+			case "GSI1PK", "GSI1SK", "GSI2PK", "GSI2SK", "GSI3PK", "GSI3SK":
+				continue
+			}
+			tagItem[name] = value
+		}
+		tagItem["SK"] = &types.AttributeValueMemberS{Value: sk}
+		writes = append(writes, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(r.tableName),
+				Item:      tagItem,
+			},
+		})
+	}
+	return writes
+}
+
+// deleteTagItems returns the writes that delete the transaction's tag items,
+// except those under the kept keys
+func (r *DynamoDBRepository) deleteTagItems(transaction *models.Transaction, keep []string) []types.TransactWriteItem {
+	var writes []types.TransactWriteItem
+	for _, sk := range transaction.TagKeys() {
+		if slices.Contains(keep, sk) {
+			continue
+		}
+		writes = append(writes, types.TransactWriteItem{
+			Delete: &types.Delete{
+				TableName: aws.String(r.tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", transaction.UserID)},
+					"SK": &types.AttributeValueMemberS{Value: sk},
+				},
+			},
+		})
+	}
+	return writes
+}
+
+// writeItems applies the writes in a single DynamoDB transaction, or as a
+// plain put or delete when there is only one
+func (r *DynamoDBRepository) writeItems(ctx context.Context, writes []types.TransactWriteItem) error {
+	if len(writes) == 1 {
+		if put := writes[0].Put; put != nil {
+			_, err := r.client.PutItem(ctx, &dynamodb.PutItemInput{
+				TableName:                 put.TableName,
+				Item:                      put.Item,
+				ConditionExpression:       put.ConditionExpression,
+				ExpressionAttributeValues: put.ExpressionAttributeValues,
+			})
+			return err
+		}
+		if del := writes[0].Delete; del != nil {
+			_, err := r.client.DeleteItem(ctx, &dynamodb.DeleteItemInput{
+				TableName:                 del.TableName,
+				Key:                       del.Key,
+				ConditionExpression:       del.ConditionExpression,
+				ExpressionAttributeValues: del.ExpressionAttributeValues,
+			})
+			return err
+		}
+	}
+
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: writes})
+	return err
+}
+
+// conditionFailed reports whether a write failed on the condition of the item
+// at index: a single write fails with ConditionalCheckFailedException, a
+// transaction is cancelled with that reason for the item. Cancellations for
+// other causes, such as a conflicting transaction or throttling, are not.
+func conditionFailed(err error, index int) bool {
+	var condErr *types.ConditionalCheckFailedException
+	if errors.As(err, &condErr) {
+		return index == 0
+	}
+	var txErr *types.TransactionCanceledException
+	if !errors.As(err, &txErr) || index >= len(txErr.CancellationReasons) {
+		return false
+	}
+	return aws.ToString(txErr.CancellationReasons[index].Code) == "ConditionalCheckFailed"
+}
+
+// GetMonthlyAnalytics calculates analytics for a specific month
+func (r *DynamoDBRepository) GetMonthlyAnalytics(ctx context.Context, userID string, month string) (*models.MonthlyAnalytics, error) {
 	analytics := &models.MonthlyAnalytics{
 		Month:             month,
-		CategoryBreakdown: make(map[string]float64),
+		Currency:          models.DefaultCurrency,
+		TotalIncome:       models.ZeroMoney(models.DefaultCurrency),
+		TotalExpense:      models.ZeroMoney(models.DefaultCurrency),
+		CategoryBreakdown: make(map[string]models.Money),
 	}
 
-	for _, tx := range transactions {
+	err := ForEachTransaction(ctx, IterateTransactionsByMonth(r, userID, month), func(tx models.Transaction) error {
 		analytics.TransactionCount++
 		if tx.Type == models.TransactionTypeIncome {
-			analytics.TotalIncome += tx.Amount
-		} else {
-			analytics.TotalExpense += tx.Amount
+			analytics.TotalIncome = analytics.TotalIncome.Add(tx.Amount)
+		} else if tx.Type == models.TransactionTypeExpense {
+			analytics.TotalExpense = analytics.TotalExpense.Add(tx.Amount.Abs())
 		}
-		analytics.CategoryBreakdown[tx.Category] += tx.Amount
+		analytics.CategoryBreakdown[tx.Category] = analytics.CategoryBreakdown[tx.Category].Add(tx.Amount)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions for analytics: %w", err)
 	}
 
-	analytics.Balance = analytics.TotalIncome - analytics.TotalExpense
+	analytics.Balance = analytics.TotalIncome.Sub(analytics.TotalExpense)
 	return analytics, nil
 }
 
-// UpdateTransaction updates an existing transaction
+// UpdateTransaction updates an existing transaction. When the date changes the
+// sort key changes with it, so the item is moved: the new item is written and
+// the old one deleted in a single transaction, along with the tag items.
 func (r *DynamoDBRepository) UpdateTransaction(ctx context.Context, transaction *models.Transaction) error {
 	if err := transaction.Validate(); err != nil {
 		return fmt.Errorf("validation failed: %w", err)
@@ -222,7 +487,7 @@ func (r *DynamoDBRepository) UpdateTransaction(ctx context.Context, transaction
 	// First, get the current transaction to preserve the original timestamps
 	existing, err := r.GetTransaction(ctx, transaction.UserID, transaction.ID)
 	if err != nil {
-		return fmt.Errorf("transaction not found: %w", err)
+		return err
 	}
 
 	// Preserve original CreatedAt and keys
@@ -238,21 +503,53 @@ func (r *DynamoDBRepository) UpdateTransaction(ctx context.Context, transaction
 		return fmt.Errorf("failed to marshal transaction: %w", err)
 	}
 
-	input := &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      item,
-		// Use optimistic locking but handle version mismatch gracefully
-		ConditionExpression: aws.String("version = :oldVersion"),
-		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":oldVersion": &types.AttributeValueMemberN{Value: strconv.Itoa(existing.Version)},
-		},
+	oldVersion := map[string]types.AttributeValue{
+		":oldVersion": &types.AttributeValueMemberN{Value: strconv.Itoa(existing.Version)},
 	}
 
-	_, err = r.client.PutItem(ctx, input)
+	var writes []types.TransactWriteItem
+	if existing.SK == transaction.SK {
+		writes = append(writes, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(r.tableName),
+				Item:      item,
+				// Use optimistic locking but handle version mismatch gracefully
+				ConditionExpression:       aws.String("version = :oldVersion"),
+				ExpressionAttributeValues: oldVersion,
+			},
+		})
+	} else {
+		writes = append(writes,
+			types.TransactWriteItem{
+				Put: &types.Put{
+					TableName:           aws.String(r.tableName),
+					Item:                item,
+					ConditionExpression: aws.String("attribute_not_exists(PK)"),
+				},
+			},
+			types.TransactWriteItem{
+				Delete: &types.Delete{
+					TableName: aws.String(r.tableName),
+					Key: map[string]types.AttributeValue{
+						"PK": &types.AttributeValueMemberS{Value: existing.PK},
+						"SK": &types.AttributeValueMemberS{Value: existing.SK},
+					},
+					ConditionExpression:       aws.String("version = :oldVersion"),
+					ExpressionAttributeValues: oldVersion,
+				},
+			},
+		)
+	}
+	// Tag items follow the tags and the date: stale ones are deleted and the
+	// current ones rewritten with the new contents
+	writes = append(writes, r.deleteTagItems(existing, transaction.TagKeys())...)
+	writes = append(writes, r.putTagItems(transaction, item)...)
+
+	err = r.writeItems(ctx, writes)
 	if err != nil {
-		// Check if it's a condition failed error (version mismatch)
-		var condErr *types.ConditionalCheckFailedException
-		if errors.As(err, &condErr) {
+		// Check if it's a condition failed error (version mismatch); a moved
+		// transaction also checks the version when deleting the old item
+		if conditionFailed(err, 0) || conditionFailed(err, 1) {
 			return fmt.Errorf("transaction was modified by another process, please retry")
 		}
 		return fmt.Errorf("failed to update transaction: %w", err)
@@ -261,64 +558,69 @@ func (r *DynamoDBRepository) UpdateTransaction(ctx context.Context, transaction
 	return nil
 }
 
-// DeleteTransaction deletes a transaction by first finding it, then deleting
+// DeleteTransaction deletes a transaction and its tag items by first finding
+// its key through the ID index
 func (r *DynamoDBRepository) DeleteTransaction(ctx context.Context, userID, transactionID string) error {
-	// First, find the transaction to get its full key
 	transaction, err := r.GetTransaction(ctx, userID, transactionID)
 	if err != nil {
-		return fmt.Errorf("transaction not found: %w", err)
-	}
-
-	// Extract the SK from the found transaction
-	input := &dynamodb.DeleteItemInput{
-		TableName: aws.String(r.tableName),
-		Key: map[string]types.AttributeValue{
-			"PK": &types.AttributeValueMemberS{Value: transaction.PK},
-			"SK": &types.AttributeValueMemberS{Value: transaction.SK},
+		return err
+	}
+
+	writes := []types.TransactWriteItem{
+		{
+			Delete: &types.Delete{
+				TableName: aws.String(r.tableName),
+				Key: map[string]types.AttributeValue{
+					"PK": &types.AttributeValueMemberS{Value: transaction.PK},
+					"SK": &types.AttributeValueMemberS{Value: transaction.SK},
+				},
+				// Ensure transaction exists before deletion
+				ConditionExpression: aws.String("attribute_exists(PK)"),
+			},
 		},
-		// Ensure transaction exists before deletion
-		ConditionExpression: aws.String("attribute_exists(PK)"),
 	}
+	writes = append(writes, r.deleteTagItems(transaction, nil)...)
 
-	_, err = r.client.DeleteItem(ctx, input)
+	err = r.writeItems(ctx, writes)
 	if err != nil {
+		if conditionFailed(err, 0) {
+			return ErrTransactionNotFound
+		}
 		return fmt.Errorf("failed to delete transaction: %w", err)
 	}
 
 	return nil
 }
 
-// GetTransaction retrieves a single transaction by ID using Query and client-side filtering
+// GetTransaction retrieves a single transaction by ID with one keyed query on GSI3.
+// The sort key scopes the lookup to the user, so IDs of other users are not found.
 func (r *DynamoDBRepository) GetTransaction(ctx context.Context, userID, transactionID string) (*models.Transaction, error) {
-	// Query for all transactions of the user
 	input := &dynamodb.QueryInput{
 		TableName:              aws.String(r.tableName),
-		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk_prefix)"),
+		IndexName:              aws.String("GSI3"),
+		KeyConditionExpression: aws.String("GSI3PK = :gsi3pk AND GSI3SK = :gsi3sk"),
 		ExpressionAttributeValues: map[string]types.AttributeValue{
-			":pk":        &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
-			":sk_prefix": &types.AttributeValueMemberS{Value: "TX#"},
+			":gsi3pk": &types.AttributeValueMemberS{Value: fmt.Sprintf("TRANSACTION#%s", transactionID)},
+			":gsi3sk": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
 		},
+		Limit: aws.Int32(1),
 	}
 
 	result, err := r.client.Query(ctx, input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query transactions: %w", err)
+		return nil, fmt.Errorf("failed to query transaction: %w", err)
 	}
 
-	// Filter transactions by ID on the client side
-	for _, item := range result.Items {
-		var transaction models.Transaction
-		if err := transaction.FromDynamoDBItem(item); err != nil {
-			log.Printf("Failed to unmarshal transaction: %v", err)
-			continue
-		}
-		
-		if transaction.ID == transactionID {
-			return &transaction, nil
-		}
+	if len(result.Items) == 0 {
+		return nil, ErrTransactionNotFound
+	}
+
+	var transaction models.Transaction
+	if err := transaction.FromDynamoDBItem(result.Items[0]); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal transaction: %w", err)
 	}
 
-	return nil, fmt.Errorf("transaction not found")
+	return &transaction, nil
 }
 
 // BatchCreateTransactions creates multiple transactions in batches
@@ -358,8 +660,26 @@ func (r *DynamoDBRepository) batchWriteTransactions(ctx context.Context, transac
 		writeRequests = append(writeRequests, types.WriteRequest{
 			PutRequest: &types.PutRequest{Item: item},
 		})
+		for _, write := range r.putTagItems(&tx, item) {
+			writeRequests = append(writeRequests, types.WriteRequest{
+				PutRequest: &types.PutRequest{Item: write.Put.Item},
+			})
+		}
 	}
 
+	// Tag items can take the batch past the 25 requests of a batch write
+	const batchSize = 25
+	for i := 0; i < len(writeRequests); i += batchSize {
+		end := min(i+batchSize, len(writeRequests))
+		if err := r.batchWrite(ctx, writeRequests[i:end]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchWrite sends up to 25 write requests, retrying unprocessed items
+func (r *DynamoDBRepository) batchWrite(ctx context.Context, writeRequests []types.WriteRequest) error {
 	input := &dynamodb.BatchWriteItemInput{
 		RequestItems: map[string][]types.WriteRequest{
 			r.tableName: writeRequests,
@@ -430,7 +750,7 @@ func (r *DynamoDBRepository) GetUser(ctx context.Context, userID string) (*model
 	}
 
 	if result.Item == nil {
-		return nil, fmt.Errorf("user not found")
+		return nil, ErrUserNotFound
 	}
 
 	var user models.User
@@ -441,6 +761,32 @@ func (r *DynamoDBRepository) GetUser(ctx context.Context, userID string) (*model
 	return &user, nil
 }
 
+// UpdateUser creates or replaces the user's profile
+func (r *DynamoDBRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	user.GenerateKeys()
+	if user.CreatedAt.IsZero() {
+		user.CreatedAt = time.Now()
+	}
+	user.UpdatedAt = time.Now()
+
+	item, err := attributevalue.MarshalMap(user)
+	if err != nil {
+		return fmt.Errorf("failed to marshal user: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	}
+
+	_, err = r.client.PutItem(ctx, input)
+	if err != nil {
+		return fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return nil
+}
+
 // Budget operations
 
 // CreateOrUpdateBudget creates or updates a budget for a specific category and month
@@ -504,6 +850,126 @@ func (r *DynamoDBRepository) GetBudgetsByMonth(ctx context.Context, userID, mont
 	return budgets, nil
 }
 
+// GetBudgetsInRange retrieves all budgets for a user between two months (YYYY-MM, inclusive)
+func (r *DynamoDBRepository) GetBudgetsInRange(ctx context.Context, userID, fromMonth, toMonth string) ([]models.Budget, error) {
+	var budgets []models.Budget
+	var lastKey map[string]types.AttributeValue
+
+	for {
+		input := &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk AND SK BETWEEN :from AND :to"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":   &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+				":from": &types.AttributeValueMemberS{Value: fmt.Sprintf("BUDGET#%s#", fromMonth)},
+				// "$" sorts right after "#", so every category of the last month is included
+				":to": &types.AttributeValueMemberS{Value: fmt.Sprintf("BUDGET#%s$", toMonth)},
+			},
+			ExclusiveStartKey: lastKey,
+		}
+
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query budgets: %w", err)
+		}
+
+		for _, item := range result.Items {
+			var budget models.Budget
+			if err := budget.FromDynamoDBItem(item); err != nil {
+				log.Printf("Failed to unmarshal budget: %v", err)
+				continue
+			}
+			budgets = append(budgets, budget)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = result.LastEvaluatedKey
+	}
+
+	return budgets, nil
+}
+
+// ListBudgets retrieves every budget of the user, of all months
+func (r *DynamoDBRepository) ListBudgets(ctx context.Context, userID string) ([]models.Budget, error) {
+	var budgets []models.Budget
+	var lastKey map[string]types.AttributeValue
+
+	for {
+		input := &dynamodb.QueryInput{
+			TableName:              aws.String(r.tableName),
+			KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk_prefix)"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":pk":        &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+				":sk_prefix": &types.AttributeValueMemberS{Value: "BUDGET#"},
+			},
+			ExclusiveStartKey: lastKey,
+		}
+
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query budgets: %w", err)
+		}
+
+		for _, item := range result.Items {
+			var budget models.Budget
+			if err := budget.FromDynamoDBItem(item); err != nil {
+				log.Printf("Failed to unmarshal budget: %v", err)
+				continue
+			}
+			budgets = append(budgets, budget)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		lastKey = result.LastEvaluatedKey
+	}
+
+	return budgets, nil
+}
+
+// BatchUpsertBudgets creates or replaces several budgets in batches of 25
+func (r *DynamoDBRepository) BatchUpsertBudgets(ctx context.Context, budgets []models.Budget) error {
+	const batchSize = 25 // DynamoDB batch limit
+
+	now := time.Now()
+	for i := 0; i < len(budgets); i += batchSize {
+		end := i + batchSize
+		if end > len(budgets) {
+			end = len(budgets)
+		}
+
+		var writeRequests []types.WriteRequest
+		for j := i; j < end; j++ {
+			budget := &budgets[j]
+			if budget.ID == "" {
+				budget.ID = uuid.New().String()
+			}
+			if budget.CreatedAt.IsZero() {
+				budget.CreatedAt = now
+			}
+			budget.UpdatedAt = now
+			budget.GenerateKeys()
+
+			item, err := budget.ToDynamoDBItem()
+			if err != nil {
+				return fmt.Errorf("failed to marshal budget %s/%s: %w", budget.Month, budget.Category, err)
+			}
+			writeRequests = append(writeRequests, types.WriteRequest{
+				PutRequest: &types.PutRequest{Item: item},
+			})
+		}
+
+		if err := r.batchWrite(ctx, writeRequests); err != nil {
+			return fmt.Errorf("failed to write budgets %d-%d: %w", i, end-1, err)
+		}
+	}
+
+	return nil
+}
+
 // GetBudget retrieves a specific budget by category and month
 func (r *DynamoDBRepository) GetBudget(ctx context.Context, userID, month, category string) (*models.Budget, error) {
 	input := &dynamodb.GetItemInput{
@@ -520,7 +986,7 @@ func (r *DynamoDBRepository) GetBudget(ctx context.Context, userID, month, categ
 	}
 
 	if result.Item == nil {
-		return nil, fmt.Errorf("budget not found")
+		return nil, ErrBudgetNotFound
 	}
 
 	var budget models.Budget
@@ -544,6 +1010,10 @@ func (r *DynamoDBRepository) DeleteBudget(ctx context.Context, userID, month, ca
 
 	_, err := r.client.DeleteItem(ctx, input)
 	if err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrBudgetNotFound
+		}
 		return fmt.Errorf("failed to delete budget: %w", err)
 	}
 
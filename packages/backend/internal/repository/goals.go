@@ -0,0 +1,248 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"backend/internal/models"
+)
+
+// ErrGoalNotFound is returned when no goal with the ID belongs to the user
+var ErrGoalNotFound = errors.New("goal not found")
+
+// ErrGoalConflict is returned when a goal changed or was deleted since it was read
+var ErrGoalConflict = errors.New("goal was modified concurrently")
+
+// ErrGoalContributionNotFound is returned when the goal has no contribution with the ID
+var ErrGoalContributionNotFound = errors.New("goal contribution not found")
+
+func goalKey(userID, goalID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+		"SK": &types.AttributeValueMemberS{Value: fmt.Sprintf("GOAL#%s", goalID)},
+	}
+}
+
+// CreateGoal stores a new goal
+func (r *DynamoDBRepository) CreateGoal(ctx context.Context, goal *models.Goal) error {
+	item, err := goal.ToDynamoDBItem()
+	if err != nil {
+		return fmt.Errorf("failed to marshal goal: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return fmt.Errorf("goal %s already exists", goal.ID)
+		}
+		return fmt.Errorf("failed to create goal: %w", err)
+	}
+
+	return nil
+}
+
+// GetGoal retrieves one of the user's goals
+func (r *DynamoDBRepository) GetGoal(ctx context.Context, userID, goalID string) (*models.Goal, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       goalKey(userID, goalID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get goal: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, ErrGoalNotFound
+	}
+
+	var goal models.Goal
+	if err := goal.FromDynamoDBItem(result.Item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal goal: %w", err)
+	}
+
+	return &goal, nil
+}
+
+// ListGoals retrieves all of the user's goals
+func (r *DynamoDBRepository) ListGoals(ctx context.Context, userID string) ([]models.Goal, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk_prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":        &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+			":sk_prefix": &types.AttributeValueMemberS{Value: "GOAL#"},
+		},
+	}
+
+	var goals []models.Goal
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query goals: %w", err)
+		}
+
+		for _, item := range result.Items {
+			var goal models.Goal
+			if err := goal.FromDynamoDBItem(item); err != nil {
+				log.Printf("Failed to unmarshal goal: %v", err)
+				continue
+			}
+			goals = append(goals, goal)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return goals, nil
+}
+
+// UpdateGoal replaces a goal using optimistic locking, like UpdateAccount
+func (r *DynamoDBRepository) UpdateGoal(ctx context.Context, goal *models.Goal) error {
+	expected := goal.Version
+	goal.Version++
+	goal.UpdatedAt = time.Now()
+
+	item, err := goal.ToDynamoDBItem()
+	if err != nil {
+		goal.Version = expected
+		return fmt.Errorf("failed to marshal goal: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(PK) AND version = :version"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: strconv.Itoa(expected)},
+		},
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		goal.Version = expected
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrGoalConflict
+		}
+		return fmt.Errorf("failed to update goal: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteGoal removes a goal
+func (r *DynamoDBRepository) DeleteGoal(ctx context.Context, userID, goalID string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName:           aws.String(r.tableName),
+		Key:                 goalKey(userID, goalID),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	}
+
+	if _, err := r.client.DeleteItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrGoalNotFound
+		}
+		return fmt.Errorf("failed to delete goal: %w", err)
+	}
+
+	return nil
+}
+
+// CreateGoalContribution stores a contribution to a goal
+func (r *DynamoDBRepository) CreateGoalContribution(ctx context.Context, contribution *models.GoalContribution) error {
+	item, err := contribution.ToDynamoDBItem()
+	if err != nil {
+		return fmt.Errorf("failed to marshal goal contribution: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return fmt.Errorf("goal contribution %s already exists", contribution.ID)
+		}
+		return fmt.Errorf("failed to create goal contribution: %w", err)
+	}
+
+	return nil
+}
+
+// ListGoalContributions retrieves all contributions to one of the user's goals
+func (r *DynamoDBRepository) ListGoalContributions(ctx context.Context, userID, goalID string) ([]models.GoalContribution, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk_prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":        &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+			":sk_prefix": &types.AttributeValueMemberS{Value: fmt.Sprintf("GOALCONTRIBUTION#%s#", goalID)},
+		},
+	}
+
+	var contributions []models.GoalContribution
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query goal contributions: %w", err)
+		}
+
+		for _, item := range result.Items {
+			var contribution models.GoalContribution
+			if err := contribution.FromDynamoDBItem(item); err != nil {
+				log.Printf("Failed to unmarshal goal contribution: %v", err)
+				continue
+			}
+			contributions = append(contributions, contribution)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return contributions, nil
+}
+
+// DeleteGoalContribution removes a contribution from a goal
+func (r *DynamoDBRepository) DeleteGoalContribution(ctx context.Context, userID, goalID, contributionID string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+			"SK": &types.AttributeValueMemberS{Value: fmt.Sprintf("GOALCONTRIBUTION#%s#%s", goalID, contributionID)},
+		},
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	}
+
+	if _, err := r.client.DeleteItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrGoalContributionNotFound
+		}
+		return fmt.Errorf("failed to delete goal contribution: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"backend/internal/models"
+)
+
+// ErrIdempotencyKeyExists is returned when claiming a key that is in use and not expired
+var ErrIdempotencyKeyExists = errors.New("idempotency key already used")
+
+// ErrIdempotencyKeyNotFound is returned when the user has no unexpired key with the value
+var ErrIdempotencyKeyNotFound = errors.New("idempotency key not found")
+
+func idempotencyKey(userID, key string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+		"SK": &types.AttributeValueMemberS{Value: fmt.Sprintf("IDEMPOTENCY#%s", key)},
+	}
+}
+
+// CreateIdempotencyKey claims an idempotency key. A key whose TTL passed can
+// be claimed again even if DynamoDB has not deleted it yet.
+func (r *DynamoDBRepository) CreateIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	item, err := key.ToDynamoDBItem()
+	if err != nil {
+		return fmt.Errorf("failed to marshal idempotency key: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) OR expires_at <= :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":now": &types.AttributeValueMemberN{Value: strconv.FormatInt(time.Now().Unix(), 10)},
+		},
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrIdempotencyKeyExists
+		}
+		return fmt.Errorf("failed to create idempotency key: %w", err)
+	}
+
+	return nil
+}
+
+// GetIdempotencyKey retrieves an unexpired idempotency key of the user
+func (r *DynamoDBRepository) GetIdempotencyKey(ctx context.Context, userID, key string) (*models.IdempotencyKey, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName:      aws.String(r.tableName),
+		Key:            idempotencyKey(userID, key),
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, ErrIdempotencyKeyNotFound
+	}
+
+	var stored models.IdempotencyKey
+	if err := stored.FromDynamoDBItem(result.Item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal idempotency key: %w", err)
+	}
+	if stored.Expired(time.Now()) {
+		return nil, ErrIdempotencyKeyNotFound
+	}
+
+	return &stored, nil
+}
+
+// DeleteIdempotencyKey releases an idempotency key
+func (r *DynamoDBRepository) DeleteIdempotencyKey(ctx context.Context, userID, key string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       idempotencyKey(userID, key),
+	}
+
+	if _, err := r.client.DeleteItem(ctx, input); err != nil {
+		return fmt.Errorf("failed to delete idempotency key: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,120 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"backend/internal/models"
+)
+
+// ErrImportMappingNotFound is returned when the user has no saved mapping with the name
+var ErrImportMappingNotFound = errors.New("import mapping not found")
+
+func importMappingKey(userID, name string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+		"SK": &types.AttributeValueMemberS{Value: fmt.Sprintf("IMPORTMAPPING#%s", name)},
+	}
+}
+
+// SaveImportMapping creates or replaces a saved import mapping
+func (r *DynamoDBRepository) SaveImportMapping(ctx context.Context, mapping *models.ImportMapping) error {
+	item, err := mapping.ToDynamoDBItem()
+	if err != nil {
+		return fmt.Errorf("failed to marshal import mapping: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName: aws.String(r.tableName),
+		Item:      item,
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		return fmt.Errorf("failed to save import mapping: %w", err)
+	}
+
+	return nil
+}
+
+// GetImportMapping retrieves a saved import mapping of the user
+func (r *DynamoDBRepository) GetImportMapping(ctx context.Context, userID, name string) (*models.ImportMapping, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       importMappingKey(userID, name),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get import mapping: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, ErrImportMappingNotFound
+	}
+
+	var mapping models.ImportMapping
+	if err := mapping.FromDynamoDBItem(result.Item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal import mapping: %w", err)
+	}
+
+	return &mapping, nil
+}
+
+// ListImportMappings retrieves every saved import mapping of the user
+func (r *DynamoDBRepository) ListImportMappings(ctx context.Context, userID string) ([]models.ImportMapping, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk_prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":        &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+			":sk_prefix": &types.AttributeValueMemberS{Value: "IMPORTMAPPING#"},
+		},
+	}
+
+	var mappings []models.ImportMapping
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query import mappings: %w", err)
+		}
+
+		for _, item := range result.Items {
+			var mapping models.ImportMapping
+			if err := mapping.FromDynamoDBItem(item); err != nil {
+				log.Printf("Failed to unmarshal import mapping: %v", err)
+				continue
+			}
+			mappings = append(mappings, mapping)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return mappings, nil
+}
+
+// DeleteImportMapping deletes a saved import mapping
+func (r *DynamoDBRepository) DeleteImportMapping(ctx context.Context, userID, name string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName:           aws.String(r.tableName),
+		Key:                 importMappingKey(userID, name),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	}
+
+	if _, err := r.client.DeleteItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrImportMappingNotFound
+		}
+		return fmt.Errorf("failed to delete import mapping: %w", err)
+	}
+
+	return nil
+}
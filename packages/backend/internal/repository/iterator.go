@@ -0,0 +1,144 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"backend/internal/models"
+)
+
+// IteratorPageSize is the page size used when walking a complete listing
+const IteratorPageSize = 1000
+
+// PageFetcher returns one page of a listing starting after lastKey, together
+// with the key to resume from (empty on the last page)
+type PageFetcher func(ctx context.Context, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error)
+
+// TransactionIterator streams every transaction of a listing, fetching the
+// next page from DynamoDB only when the current one is exhausted. Usage:
+//
+//	it := repository.IterateTransactionsByUser(repo, userID)
+//	for it.Next(ctx) {
+//		tx := it.Transaction()
+//	}
+//	if err := it.Err(); err != nil { ... }
+type TransactionIterator struct {
+	fetch    PageFetcher
+	pageSize int
+
+	page    []models.Transaction
+	pos     int
+	lastKey map[string]types.AttributeValue
+	started bool
+	done    bool
+	err     error
+}
+
+// NewTransactionIterator creates an iterator over the pages returned by fetch
+func NewTransactionIterator(fetch PageFetcher, pageSize int) *TransactionIterator {
+	if pageSize <= 0 {
+		pageSize = IteratorPageSize
+	}
+	return &TransactionIterator{
+		fetch:    fetch,
+		pageSize: pageSize,
+	}
+}
+
+// IterateTransactionsByUser walks the user's full transaction history
+func IterateTransactionsByUser(repo Repository, userID string) *TransactionIterator {
+	return NewTransactionIterator(func(ctx context.Context, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+		return repo.GetTransactionsByUser(ctx, userID, limit, lastKey)
+	}, IteratorPageSize)
+}
+
+// IterateTransactionsByMonth walks every transaction of the month (YYYY-MM)
+func IterateTransactionsByMonth(repo Repository, userID, month string) *TransactionIterator {
+	return NewTransactionIterator(func(ctx context.Context, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+		return repo.GetTransactionsByMonth(ctx, userID, month, limit, lastKey)
+	}, IteratorPageSize)
+}
+
+// IterateTransactionsByMonthBetween walks the transactions of the month
+// (YYYY-MM) dated from from to to, both inclusive
+func IterateTransactionsByMonthBetween(repo Repository, userID, month string, from, to time.Time) *TransactionIterator {
+	return NewTransactionIterator(func(ctx context.Context, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+		return repo.GetTransactionsByMonthBetween(ctx, userID, month, from, to, limit, lastKey)
+	}, IteratorPageSize)
+}
+
+// IterateTransactionsByAccount walks every transaction linked to the account
+func IterateTransactionsByAccount(repo Repository, userID, accountID string) *TransactionIterator {
+	return NewTransactionIterator(func(ctx context.Context, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+		return repo.GetTransactionsByAccount(ctx, userID, accountID, limit, lastKey)
+	}, IteratorPageSize)
+}
+
+// IterateTransactionsByTag walks every transaction carrying the tag
+func IterateTransactionsByTag(repo Repository, userID, tag string) *TransactionIterator {
+	return NewTransactionIterator(func(ctx context.Context, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+		return repo.GetTransactionsByTag(ctx, userID, tag, limit, lastKey)
+	}, IteratorPageSize)
+}
+
+// Next advances to the next transaction and reports whether there is one.
+// It returns false at the end of the listing or on the first error.
+func (it *TransactionIterator) Next(ctx context.Context) bool {
+	for it.pos >= len(it.page) {
+		if it.done || it.err != nil {
+			return false
+		}
+		// A page may come back empty (e.g. filtered items) while more remain
+		if it.started && len(it.lastKey) == 0 {
+			it.done = true
+			return false
+		}
+
+		page, nextKey, err := it.fetch(ctx, it.pageSize, it.lastKey)
+		if err != nil {
+			it.err = err
+			return false
+		}
+		it.started = true
+		it.page, it.pos, it.lastKey = page, 0, nextKey
+	}
+
+	it.pos++
+	return true
+}
+
+// Transaction returns the transaction the iterator is positioned on
+func (it *TransactionIterator) Transaction() models.Transaction {
+	return it.page[it.pos-1]
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *TransactionIterator) Err() error {
+	return it.err
+}
+
+// ForEachTransaction calls fn for every transaction of the listing, stopping
+// at the first error returned by the repository or by fn
+func ForEachTransaction(ctx context.Context, it *TransactionIterator, fn func(models.Transaction) error) error {
+	for it.Next(ctx) {
+		if err := fn(it.Transaction()); err != nil {
+			return err
+		}
+	}
+	return it.Err()
+}
+
+// CollectTransactions loads the complete listing into memory
+func CollectTransactions(ctx context.Context, it *TransactionIterator) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	err := ForEachTransaction(ctx, it, func(tx models.Transaction) error {
+		transactions = append(transactions, tx)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transactions, nil
+}
@@ -0,0 +1,184 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"backend/internal/models"
+)
+
+// ErrRecurringNotFound is returned when no recurring transaction with the ID belongs to the user
+var ErrRecurringNotFound = errors.New("recurring transaction not found")
+
+// ErrRecurringConflict is returned when a recurring transaction changed or was
+// deleted since it was read
+var ErrRecurringConflict = errors.New("recurring transaction was modified concurrently")
+
+// CreateRecurringTransaction stores a new recurring transaction
+func (r *DynamoDBRepository) CreateRecurringTransaction(ctx context.Context, recurring *models.RecurringTransaction) error {
+	item, err := recurring.ToDynamoDBItem()
+	if err != nil {
+		return fmt.Errorf("failed to marshal recurring transaction: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		return fmt.Errorf("failed to create recurring transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetRecurringTransaction retrieves one recurring transaction of the user
+func (r *DynamoDBRepository) GetRecurringTransaction(ctx context.Context, userID, recurringID string) (*models.RecurringTransaction, error) {
+	input := &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+			"SK": &types.AttributeValueMemberS{Value: fmt.Sprintf("RECURRING#%s", recurringID)},
+		},
+	}
+
+	result, err := r.client.GetItem(ctx, input)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recurring transaction: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, ErrRecurringNotFound
+	}
+
+	var recurring models.RecurringTransaction
+	if err := recurring.FromDynamoDBItem(result.Item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal recurring transaction: %w", err)
+	}
+
+	return &recurring, nil
+}
+
+// ListRecurringTransactions retrieves every recurring transaction of the user
+func (r *DynamoDBRepository) ListRecurringTransactions(ctx context.Context, userID string) ([]models.RecurringTransaction, error) {
+	return r.queryRecurring(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk_prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":        &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+			":sk_prefix": &types.AttributeValueMemberS{Value: "RECURRING#"},
+		},
+	})
+}
+
+// UpdateRecurringTransaction replaces a recurring transaction using optimistic
+// locking: the write only succeeds if the stored version is still the one that
+// was read, and the version is then incremented
+func (r *DynamoDBRepository) UpdateRecurringTransaction(ctx context.Context, recurring *models.RecurringTransaction) error {
+	expected := recurring.Version
+	recurring.Version++
+	recurring.UpdatedAt = time.Now()
+
+	item, err := recurring.ToDynamoDBItem()
+	if err != nil {
+		recurring.Version = expected
+		return fmt.Errorf("failed to marshal recurring transaction: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(PK) AND version = :version"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: strconv.Itoa(expected)},
+		},
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		recurring.Version = expected
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrRecurringConflict
+		}
+		return fmt.Errorf("failed to update recurring transaction: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRecurringTransaction deletes a recurring transaction. Transactions it
+// already generated are kept.
+func (r *DynamoDBRepository) DeleteRecurringTransaction(ctx context.Context, userID, recurringID string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+			"SK": &types.AttributeValueMemberS{Value: fmt.Sprintf("RECURRING#%s", recurringID)},
+		},
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	}
+
+	if _, err := r.client.DeleteItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrRecurringNotFound
+		}
+		return fmt.Errorf("failed to delete recurring transaction: %w", err)
+	}
+
+	return nil
+}
+
+// GetDueRecurringTransactions retrieves the active rules of every user whose
+// next run is at or before the given time, from the sparse due index on GSI1
+func (r *DynamoDBRepository) GetDueRecurringTransactions(ctx context.Context, before time.Time) ([]models.RecurringTransaction, error) {
+	return r.queryRecurring(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String("GSI1"),
+		KeyConditionExpression: aws.String("GSI1PK = :gsi1pk AND GSI1SK BETWEEN :from AND :to"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":gsi1pk": &types.AttributeValueMemberS{Value: models.RecurringDuePartition},
+			":from":   &types.AttributeValueMemberS{Value: "NEXT#"},
+			// "$" sorts right after "#", so every rule due at that second is included
+			":to": &types.AttributeValueMemberS{Value: models.RecurringDueKey(before) + "$"},
+		},
+	})
+}
+
+// queryRecurring runs the query across all pages
+func (r *DynamoDBRepository) queryRecurring(ctx context.Context, input *dynamodb.QueryInput) ([]models.RecurringTransaction, error) {
+	var rules []models.RecurringTransaction
+
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query recurring transactions: %w", err)
+		}
+
+		for _, item := range result.Items {
+			var recurring models.RecurringTransaction
+			if err := recurring.FromDynamoDBItem(item); err != nil {
+				log.Printf("Failed to unmarshal recurring transaction: %v", err)
+				continue
+			}
+			rules = append(rules, recurring)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return rules, nil
+}
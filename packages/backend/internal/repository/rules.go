@@ -0,0 +1,161 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+
+	"backend/internal/models"
+)
+
+// ErrRuleNotFound is returned when no categorization rule with the ID belongs to the user
+var ErrRuleNotFound = errors.New("rule not found")
+
+// ErrRuleConflict is returned when a rule changed or was deleted since it was read
+var ErrRuleConflict = errors.New("rule was modified concurrently")
+
+func ruleKey(userID, ruleID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+		"SK": &types.AttributeValueMemberS{Value: fmt.Sprintf("RULE#%s", ruleID)},
+	}
+}
+
+// CreateRule stores a new categorization rule
+func (r *DynamoDBRepository) CreateRule(ctx context.Context, rule *models.CategoryRule) error {
+	item, err := rule.ToDynamoDBItem()
+	if err != nil {
+		return fmt.Errorf("failed to marshal rule: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_not_exists(PK) AND attribute_not_exists(SK)"),
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		return fmt.Errorf("failed to create rule: %w", err)
+	}
+
+	return nil
+}
+
+// GetRule retrieves one categorization rule of the user
+func (r *DynamoDBRepository) GetRule(ctx context.Context, userID, ruleID string) (*models.CategoryRule, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key:       ruleKey(userID, ruleID),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rule: %w", err)
+	}
+
+	if result.Item == nil {
+		return nil, ErrRuleNotFound
+	}
+
+	var rule models.CategoryRule
+	if err := rule.FromDynamoDBItem(result.Item); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rule: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// ListRules retrieves every categorization rule of the user
+func (r *DynamoDBRepository) ListRules(ctx context.Context, userID string) ([]models.CategoryRule, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		KeyConditionExpression: aws.String("PK = :pk AND begins_with(SK, :sk_prefix)"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pk":        &types.AttributeValueMemberS{Value: fmt.Sprintf("USER#%s", userID)},
+			":sk_prefix": &types.AttributeValueMemberS{Value: "RULE#"},
+		},
+	}
+
+	var rules []models.CategoryRule
+	for {
+		result, err := r.client.Query(ctx, input)
+		if err != nil {
+			return nil, fmt.Errorf("failed to query rules: %w", err)
+		}
+
+		for _, item := range result.Items {
+			var rule models.CategoryRule
+			if err := rule.FromDynamoDBItem(item); err != nil {
+				log.Printf("Failed to unmarshal rule: %v", err)
+				continue
+			}
+			rules = append(rules, rule)
+		}
+
+		if len(result.LastEvaluatedKey) == 0 {
+			break
+		}
+		input.ExclusiveStartKey = result.LastEvaluatedKey
+	}
+
+	return rules, nil
+}
+
+// UpdateRule replaces a categorization rule using optimistic locking, like
+// UpdateRecurringTransaction
+func (r *DynamoDBRepository) UpdateRule(ctx context.Context, rule *models.CategoryRule) error {
+	expected := rule.Version
+	rule.Version++
+	rule.UpdatedAt = time.Now()
+
+	item, err := rule.ToDynamoDBItem()
+	if err != nil {
+		rule.Version = expected
+		return fmt.Errorf("failed to marshal rule: %w", err)
+	}
+
+	input := &dynamodb.PutItemInput{
+		TableName:           aws.String(r.tableName),
+		Item:                item,
+		ConditionExpression: aws.String("attribute_exists(PK) AND version = :version"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":version": &types.AttributeValueMemberN{Value: strconv.Itoa(expected)},
+		},
+	}
+
+	if _, err := r.client.PutItem(ctx, input); err != nil {
+		rule.Version = expected
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrRuleConflict
+		}
+		return fmt.Errorf("failed to update rule: %w", err)
+	}
+
+	return nil
+}
+
+// DeleteRule deletes a categorization rule. Transactions it already
+// categorized keep their category and tags.
+func (r *DynamoDBRepository) DeleteRule(ctx context.Context, userID, ruleID string) error {
+	input := &dynamodb.DeleteItemInput{
+		TableName:           aws.String(r.tableName),
+		Key:                 ruleKey(userID, ruleID),
+		ConditionExpression: aws.String("attribute_exists(PK)"),
+	}
+
+	if _, err := r.client.DeleteItem(ctx, input); err != nil {
+		var condErr *types.ConditionalCheckFailedException
+		if errors.As(err, &condErr) {
+			return ErrRuleNotFound
+		}
+		return fmt.Errorf("failed to delete rule: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,366 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"backend/internal/models"
+	"backend/internal/period"
+	"backend/internal/rates"
+	"backend/internal/repository"
+)
+
+var (
+	// ErrInvalidAccount is returned for accounts that fail validation
+	ErrInvalidAccount = errors.New("invalid account")
+
+	// ErrAccountInUse is returned when deleting an account transactions are linked to
+	ErrAccountInUse = errors.New("account has transactions")
+
+	// ErrInvalidTransfer is returned for transfers that fail validation
+	ErrInvalidTransfer = errors.New("invalid transfer")
+
+	// ErrTransferEntry is returned when editing or deleting one entry of a
+	// transfer as a plain transaction
+	ErrTransferEntry = errors.New("transaction is an entry of a transfer")
+)
+
+type AccountService interface {
+	ListAccounts(ctx context.Context, userID string) ([]models.AccountBalance, error)
+	GetAccount(ctx context.Context, userID, accountID string) (*models.AccountBalance, error)
+	CreateAccount(ctx context.Context, account *models.Account) error
+	UpdateAccount(ctx context.Context, account *models.Account) error
+	DeleteAccount(ctx context.Context, userID, accountID string) error
+	GetLedger(ctx context.Context, userID, accountID string, r period.Range) (*models.AccountLedger, error)
+	CreateTransfer(ctx context.Context, userID string, in models.TransferInput) (*models.Transfer, error)
+	DeleteTransfer(ctx context.Context, userID, transferID string) error
+	GetCardStatements(ctx context.Context, userID, accountID string, cycles int, asOf time.Time) (*models.CreditCardStatements, error)
+	UpcomingPayments(ctx context.Context, userID string, asOf time.Time) ([]models.PaymentDue, error)
+}
+
+type accountService struct {
+	repo      repository.Repository
+	converter *CurrencyConverter
+}
+
+func NewAccountService(repo repository.Repository) AccountService {
+	return NewAccountServiceWithRates(repo, nil)
+}
+
+// NewAccountServiceWithRates creates an account service that converts
+// transactions in other currencies to each account's currency
+func NewAccountServiceWithRates(repo repository.Repository, store *rates.Store) AccountService {
+	return &accountService{
+		repo:      repo,
+		converter: NewCurrencyConverter(repo, store),
+	}
+}
+
+// ListAccounts returns the user's accounts by name, with their balances
+func (s *accountService) ListAccounts(ctx context.Context, userID string) ([]models.AccountBalance, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	accounts, err := s.repo.ListAccounts(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]models.AccountBalance, len(accounts))
+	byID := make(map[string]*models.AccountBalance, len(accounts))
+	for i, account := range accounts {
+		balances[i] = models.AccountBalance{Account: account, Balance: account.OpeningBalance}
+		byID[account.ID] = &balances[i]
+	}
+
+	if len(accounts) > 0 {
+		err = repository.ForEachTransaction(ctx, repository.IterateTransactionsByUser(s.repo, userID), func(tx models.Transaction) error {
+			balance, ok := byID[tx.AccountID]
+			if !ok {
+				return nil
+			}
+			return s.addToBalance(balance, tx)
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get user transactions: %w", err)
+		}
+	}
+
+	sort.Slice(balances, func(i, j int) bool {
+		return balances[i].Name < balances[j].Name
+	})
+	return balances, nil
+}
+
+// GetAccount returns one account with its balance
+func (s *accountService) GetAccount(ctx context.Context, userID, accountID string) (*models.AccountBalance, error) {
+	if userID == "" || accountID == "" {
+		return nil, fmt.Errorf("userID and accountID are required")
+	}
+
+	account, err := s.repo.GetAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	balance := &models.AccountBalance{Account: *account, Balance: account.OpeningBalance}
+	err = repository.ForEachTransaction(ctx, repository.IterateTransactionsByAccount(s.repo, userID, accountID), func(tx models.Transaction) error {
+		return s.addToBalance(balance, tx)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions of account %s: %w", accountID, err)
+	}
+	return balance, nil
+}
+
+func (s *accountService) addToBalance(balance *models.AccountBalance, tx models.Transaction) error {
+	amount, err := s.inAccountCurrency(&balance.Account, tx)
+	if err != nil {
+		return err
+	}
+	balance.Balance = balance.Balance.Add(amount)
+	balance.TransactionCount++
+	return nil
+}
+
+// inAccountCurrency expresses the transaction amount in the account's
+// currency, using the rate on the transaction date
+func (s *accountService) inAccountCurrency(account *models.Account, tx models.Transaction) (models.Money, error) {
+	if tx.Amount.Currency == account.Currency {
+		return tx.Amount, nil
+	}
+	amount, err := s.converter.Convert(tx.Amount, account.Currency, tx.Date)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("transaction %s: %w", tx.ID, err)
+	}
+	return amount, nil
+}
+
+// CreateAccount stores a new account with a generated ID
+func (s *accountService) CreateAccount(ctx context.Context, account *models.Account) error {
+	if account == nil {
+		return fmt.Errorf("%w: account cannot be nil", ErrInvalidAccount)
+	}
+	if err := account.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAccount, err)
+	}
+
+	existing, err := s.repo.ListAccounts(ctx, account.UserID)
+	if err != nil {
+		return err
+	}
+	if len(existing) >= models.MaxAccountsPerUser {
+		return fmt.Errorf("%w: at most %d accounts are allowed", ErrInvalidAccount, models.MaxAccountsPerUser)
+	}
+
+	now := time.Now()
+	account.ID = uuid.New().String()
+	account.CreatedAt = now
+	account.UpdatedAt = now
+	account.Version = 1
+
+	return s.repo.CreateAccount(ctx, account)
+}
+
+// UpdateAccount replaces the name, type and opening balance of an account.
+// The currency cannot change, since the balance is kept in it.
+func (s *accountService) UpdateAccount(ctx context.Context, account *models.Account) error {
+	if account == nil {
+		return fmt.Errorf("%w: account cannot be nil", ErrInvalidAccount)
+	}
+
+	existing, err := s.repo.GetAccount(ctx, account.UserID, account.ID)
+	if err != nil {
+		return err
+	}
+	if account.Currency == "" {
+		account.Currency = existing.Currency
+	}
+	if err := account.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidAccount, err)
+	}
+	if account.Currency != existing.Currency {
+		return fmt.Errorf("%w: the currency of an account cannot change", ErrInvalidAccount)
+	}
+
+	account.CreatedAt = existing.CreatedAt
+	account.Version = existing.Version
+
+	return s.repo.UpdateAccount(ctx, account)
+}
+
+// DeleteAccount removes an account no transaction is linked to
+func (s *accountService) DeleteAccount(ctx context.Context, userID, accountID string) error {
+	if userID == "" || accountID == "" {
+		return fmt.Errorf("userID and accountID are required")
+	}
+
+	if _, err := s.repo.GetAccount(ctx, userID, accountID); err != nil {
+		return err
+	}
+
+	it := repository.IterateTransactionsByAccount(s.repo, userID, accountID)
+	if it.Next(ctx) {
+		return fmt.Errorf("%w: move or delete its transactions first", ErrAccountInUse)
+	}
+	if err := it.Err(); err != nil {
+		return fmt.Errorf("failed to get transactions of account %s: %w", accountID, err)
+	}
+
+	return s.repo.DeleteAccount(ctx, userID, accountID)
+}
+
+// GetLedger lists the account's transactions within the period, oldest
+// first, with the balance after each one. The starting balance includes the
+// opening balance and every earlier transaction.
+func (s *accountService) GetLedger(ctx context.Context, userID, accountID string, r period.Range) (*models.AccountLedger, error) {
+	if userID == "" || accountID == "" {
+		return nil, fmt.Errorf("userID and accountID are required")
+	}
+
+	account, err := s.repo.GetAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := accountTransactions(ctx, s.repo, s.converter, account)
+	if err != nil {
+		return nil, err
+	}
+
+	ledger := &models.AccountLedger{
+		Account:         *account,
+		StartingBalance: account.OpeningBalance,
+		Entries:         []models.LedgerEntry{},
+	}
+	balance := account.OpeningBalance
+	for _, tx := range transactions {
+		if !tx.Date.Before(r.End) {
+			break
+		}
+		balance = balance.Add(tx.Amount)
+
+		if tx.Date.Before(r.Start) {
+			ledger.StartingBalance = balance
+			continue
+		}
+		ledger.Entries = append(ledger.Entries, models.LedgerEntry{
+			TransactionID: tx.ID,
+			Date:          tx.Date,
+			Type:          tx.Type,
+			Category:      tx.Category,
+			Description:   tx.Description,
+			TransferID:    tx.TransferID,
+			Amount:        tx.Amount,
+			Balance:       balance,
+		})
+	}
+	ledger.EndingBalance = balance
+
+	return ledger, nil
+}
+
+// accountTransactions returns the account's transactions oldest first, with
+// their amounts in the account's currency
+func accountTransactions(ctx context.Context, repo repository.Repository, converter *CurrencyConverter, account *models.Account) ([]models.Transaction, error) {
+	transactions, err := repository.CollectTransactions(ctx, repository.IterateTransactionsByAccount(repo, account.UserID, account.ID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions of account %s: %w", account.ID, err)
+	}
+	transactions, err = converter.ConvertTransactions(transactions, account.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(transactions, func(i, j int) bool {
+		if !transactions[i].Date.Equal(transactions[j].Date) {
+			return transactions[i].Date.Before(transactions[j].Date)
+		}
+		return transactions[i].CreatedAt.Before(transactions[j].CreatedAt)
+	})
+	return transactions, nil
+}
+
+// CreateTransfer moves money between two of the user's accounts. Between
+// accounts in different currencies the arriving amount is to_amount, or the
+// amount converted at the rate of the transfer date.
+func (s *accountService) CreateTransfer(ctx context.Context, userID string, in models.TransferInput) (*models.Transfer, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+	if in.FromAccountID == "" || in.ToAccountID == "" {
+		return nil, fmt.Errorf("%w: from_account_id and to_account_id are required", ErrInvalidTransfer)
+	}
+	if in.FromAccountID == in.ToAccountID {
+		return nil, fmt.Errorf("%w: the accounts must be different", ErrInvalidTransfer)
+	}
+	if !in.Amount.IsPositive() {
+		return nil, fmt.Errorf("%w: amount must be positive", ErrInvalidTransfer)
+	}
+	if in.Date.IsZero() {
+		in.Date = time.Now()
+	}
+
+	from, err := s.repo.GetAccount(ctx, userID, in.FromAccountID)
+	if err != nil {
+		return nil, err
+	}
+	to, err := s.repo.GetAccount(ctx, userID, in.ToAccountID)
+	if err != nil {
+		return nil, err
+	}
+
+	fromAmount := models.NewMoney(in.Amount.Minor, from.Currency)
+	var toAmount models.Money
+	switch {
+	case in.ToAmount != nil:
+		if !in.ToAmount.IsPositive() {
+			return nil, fmt.Errorf("%w: to_amount must be positive", ErrInvalidTransfer)
+		}
+		toAmount = models.NewMoney(in.ToAmount.Minor, to.Currency)
+	case from.Currency == to.Currency:
+		toAmount = models.NewMoney(in.Amount.Minor, to.Currency)
+	default:
+		toAmount, err = s.converter.Convert(fromAmount, to.Currency, in.Date)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTransfer, err)
+		}
+	}
+
+	transfer := models.NewTransfer(userID, in, fromAmount, toAmount)
+	if err := s.repo.CreateTransfer(ctx, transfer); err != nil {
+		return nil, err
+	}
+	return transfer, nil
+}
+
+// DeleteTransfer deletes both entries of a transfer
+func (s *accountService) DeleteTransfer(ctx context.Context, userID, transferID string) error {
+	if userID == "" || transferID == "" {
+		return fmt.Errorf("userID and transferID are required")
+	}
+
+	fromID, toID := models.TransferEntryIDs(transferID)
+	found := false
+	for _, id := range []string{fromID, toID} {
+		err := s.repo.DeleteTransaction(ctx, userID, id)
+		if errors.Is(err, repository.ErrTransactionNotFound) {
+			// Left by an earlier delete that failed halfway
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		found = true
+	}
+	if !found {
+		return repository.ErrTransactionNotFound
+	}
+	return nil
+}
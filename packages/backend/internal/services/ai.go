@@ -3,13 +3,14 @@ package services
 import (
 	"context"
 	"fmt"
-	"math"
 	"sort"
 	"strings"
 	"time"
 
+	"backend/internal/auth"
 	"backend/internal/config"
 	"backend/internal/models"
+	"backend/internal/rates"
 	"backend/internal/repository"
 
 	openai "github.com/sashabaranov/go-openai"
@@ -21,15 +22,26 @@ type AIService interface {
 	BuildFinancialContext(ctx context.Context, userID string) (*models.FinancialContext, error)
 }
 
+// maxAnomalyInsights bounds the unusual expenses and the category spikes
+// mentioned in the financial context
+const maxAnomalyInsights = 3
+
 type aiService struct {
 	client         *openai.Client
 	repo           repository.Repository
 	analyticsService AnalyticsService
+	converter      *CurrencyConverter
 	config         *config.Config
 	isGroq         bool
 }
 
 func NewAIService(cfg *config.Config, repo repository.Repository) (AIService, error) {
+	return NewAIServiceWithRates(cfg, repo, nil)
+}
+
+// NewAIServiceWithRates creates an AI service whose financial context is
+// expressed in each user's base currency
+func NewAIServiceWithRates(cfg *config.Config, repo repository.Repository, store *rates.Store) (AIService, error) {
 	if cfg.OpenAIAPIKey == "" {
 		return nil, fmt.Errorf("AI API key is required")
 	}
@@ -44,12 +56,13 @@ func NewAIService(cfg *config.Config, repo repository.Repository) (AIService, er
 	}
 	
 	client := openai.NewClientWithConfig(clientConfig)
-	analyticsService := NewAnalyticsService(repo)
+	analyticsService := NewAnalyticsServiceWithRates(repo, store)
 	
 	return &aiService{
 		client:           client,
 		repo:             repo,
 		analyticsService: analyticsService,
+		converter:        NewCurrencyConverter(repo, store),
 		config:           cfg,
 		isGroq:           isGroq,
 	}, nil
@@ -164,47 +177,67 @@ func (s *aiService) GeneratePersonalizedAdvice(ctx context.Context, userContext
 }
 
 func (s *aiService) BuildFinancialContext(ctx context.Context, userID string) (*models.FinancialContext, error) {
-	// Obtener TODAS las transacciones históricas del usuario
-	allTransactions, _, err := s.repo.GetTransactionsByUser(ctx, userID, 1000, nil) // Aumentar límite para obtener historial completo
+	// Expresar todos los montos en la moneda base del usuario
+	base, err := s.converter.BaseCurrency(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user transactions: %w", err)
+		return nil, err
 	}
 	
 	// Calcular métricas financieras totales
-	totalIncome := 0.0
-	totalExpenses := 0.0
-	categoryTotals := make(map[string]float64)
+	totalIncome := models.ZeroMoney(base)
+	totalExpenses := models.ZeroMoney(base)
+	categoryTotals := make(map[string]models.Money)
 	categoryCounts := make(map[string]int)
 	
-	// Procesar todas las transacciones históricas
-	for _, transaction := range allTransactions {
+	// Gastos de los últimos meses para detectar anomalías, con su línea base
+	now := time.Now().UTC()
+	lastMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	firstMonth := lastMonth.AddDate(0, -(models.DefaultAnomalyMonths - 1), 0)
+	baselineStart := firstMonth.AddDate(0, -models.AnomalyBaselineMonths, 0)
+	var recent []models.Transaction
+	
+	// Recorrer TODAS las transacciones históricas del usuario, página por página
+	err = s.converter.ForEachInBase(ctx, repository.IterateTransactionsByUser(s.repo, userID), base, func(transaction models.Transaction) error {
+		if transaction.IsTransfer() {
+			// Las transferencias entre cuentas no son ingresos ni gastos
+			return nil
+		}
 		if transaction.Type == "income" {
-			totalIncome += transaction.Amount
+			totalIncome = totalIncome.Add(transaction.Amount)
 		} else if transaction.Type == "expense" {
-			totalExpenses += math.Abs(transaction.Amount) // Convertir a positivo para cálculos
+			totalExpenses = totalExpenses.Add(transaction.Amount.Abs()) // Convertir a positivo para cálculos
+			if !transaction.Date.Before(baselineStart) {
+				recent = append(recent, transaction)
+			}
 		}
 		
 		// Acumular por categoría (mantenemos los montos originales para el contexto)
-		categoryTotals[transaction.Category] += transaction.Amount
-		categoryCounts[transaction.Category]++
+		for _, split := range transaction.Allocations() {
+			categoryTotals[split.Category] = categoryTotals[split.Category].Add(split.Amount)
+			categoryCounts[split.Category]++
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user transactions: %w", err)
 	}
 	
 	// Calcular saldo actual total (ingresos - gastos)
-	currentBalance := totalIncome - totalExpenses
+	currentBalance := totalIncome.Sub(totalExpenses)
 	
 	// Calcular savings rate: (saldo actual / ingresos totales) * 100
 	savingsRate := 0.0
-	if totalIncome > 0 {
-		savingsRate = (currentBalance / totalIncome) * 100
+	if totalIncome.IsPositive() {
+		savingsRate = currentBalance.Ratio(totalIncome) * 100
 	}
 	
 	// Crear categorías principales ordenadas por monto (solo gastos para el breakdown)
 	var topCategories []*models.CategorySummary
 	for category, amount := range categoryTotals {
-		if amount < 0 { // Solo gastos para el breakdown de categorías
+		if amount.IsNegative() { // Solo gastos para el breakdown de categorías
 			percentage := 0.0
-			if totalExpenses > 0 {
-				percentage = (math.Abs(amount) / totalExpenses) * 100
+			if totalExpenses.IsPositive() {
+				percentage = amount.Abs().Ratio(totalExpenses) * 100
 			}
 			
 			topCategories = append(topCategories, &models.CategorySummary{
@@ -218,15 +251,17 @@ func (s *aiService) BuildFinancialContext(ctx context.Context, userID string) (*
 	
 	// Ordenar categorías por monto total (mayor gasto primero)
 	sort.Slice(topCategories, func(i, j int) bool {
-		return math.Abs(topCategories[i].TotalAmount) > math.Abs(topCategories[j].TotalAmount)
+		return topCategories[i].TotalAmount.Abs().Cmp(topCategories[j].TotalAmount.Abs()) > 0
 	})
 	
 	// Generar insights basados en datos históricos
-	spendingTrends := s.generateHistoricalInsights(totalIncome, totalExpenses, currentBalance, topCategories)
+	anomalies := detectAnomalies(recent, base, firstMonth, lastMonth)
+	spendingTrends := s.generateHistoricalInsights(totalIncome, totalExpenses, currentBalance, topCategories, anomalies)
 	
 	return &models.FinancialContext{
+		Currency:       base,
 		MonthlyIncome:  totalIncome,  // Ahora es ingresos totales históricos
-		MonthlyExpense: -totalExpenses, // Mantener negativo para consistencia
+		MonthlyExpense: totalExpenses.Neg(), // Mantener negativo para consistencia
 		SavingsRate:    savingsRate,
 		TopCategories:  topCategories,
 		SpendingTrends: spendingTrends,
@@ -234,8 +269,12 @@ func (s *aiService) BuildFinancialContext(ctx context.Context, userID string) (*
 }
 
 func (s *aiService) buildFinancialContext(ctx context.Context) (*models.FinancialContext, error) {
-	// Default user for backward compatibility
-	return s.BuildFinancialContext(ctx, "user-123")
+	// The advice request is always scoped to the authenticated caller
+	userID, ok := auth.UserIDFromContext(ctx)
+	if !ok {
+		return nil, fmt.Errorf("authenticated user is required")
+	}
+	return s.BuildFinancialContext(ctx, userID)
 }
 
 func (s *aiService) getSystemPrompt() string {
@@ -276,12 +315,13 @@ func (s *aiService) buildAdvicePrompt(question string, context *models.Financial
 	
 	prompt.WriteString(fmt.Sprintf("Pregunta del Usuario: %s\n\n", question))
 	prompt.WriteString("Contexto Financiero Histórico Completo:\n")
-	prompt.WriteString(fmt.Sprintf("- Ingresos Totales Históricos: $%.2f\n", context.MonthlyIncome))
-	prompt.WriteString(fmt.Sprintf("- Gastos Totales Históricos: $%.2f\n", math.Abs(context.MonthlyExpense)))
+	prompt.WriteString(fmt.Sprintf("- Moneda: %s\n", context.Currency))
+	prompt.WriteString(fmt.Sprintf("- Ingresos Totales Históricos: $%s\n", context.MonthlyIncome))
+	prompt.WriteString(fmt.Sprintf("- Gastos Totales Históricos: $%s\n", context.MonthlyExpense.Abs()))
 	
 	// Calcular y mostrar balance actual
-	currentBalance := context.MonthlyIncome + context.MonthlyExpense // MonthlyExpense ya es negativo
-	prompt.WriteString(fmt.Sprintf("- Balance Actual Total: $%.2f\n", currentBalance))
+	currentBalance := context.MonthlyIncome.Add(context.MonthlyExpense) // MonthlyExpense ya es negativo
+	prompt.WriteString(fmt.Sprintf("- Balance Actual Total: $%s\n", currentBalance))
 	prompt.WriteString(fmt.Sprintf("- Tasa de Ahorro: %.1f%%\n", context.SavingsRate))
 	
 	if len(context.TopCategories) > 0 {
@@ -290,8 +330,8 @@ func (s *aiService) buildAdvicePrompt(question string, context *models.Financial
 			if i >= 10 { // Limitar a top 10 para no sobrecargar el prompt
 				break
 			}
-			prompt.WriteString(fmt.Sprintf("- %s: $%.2f (%.1f%% del total, %d transacciones)\n", 
-				category.Category, math.Abs(category.TotalAmount), category.Percentage, category.Count))
+			prompt.WriteString(fmt.Sprintf("- %s: $%s (%.1f%% del total, %d transacciones)\n", 
+				category.Category, category.TotalAmount.Abs(), category.Percentage, category.Count))
 		}
 	}
 	
@@ -311,19 +351,20 @@ func (s *aiService) buildPersonalizedPrompt(context *models.FinancialContext) st
 	var prompt strings.Builder
 	
 	prompt.WriteString("Genera consejos financieros personalizados basados en el perfil histórico completo de este usuario:\n\n")
-	prompt.WriteString(fmt.Sprintf("Ingresos Totales Históricos: $%.2f\n", context.MonthlyIncome))
-	prompt.WriteString(fmt.Sprintf("Gastos Totales Históricos: $%.2f\n", math.Abs(context.MonthlyExpense)))
+	prompt.WriteString(fmt.Sprintf("Moneda: %s\n", context.Currency))
+	prompt.WriteString(fmt.Sprintf("Ingresos Totales Históricos: $%s\n", context.MonthlyIncome))
+	prompt.WriteString(fmt.Sprintf("Gastos Totales Históricos: $%s\n", context.MonthlyExpense.Abs()))
 	
 	// Calcular balance actual
-	currentBalance := context.MonthlyIncome + context.MonthlyExpense
-	prompt.WriteString(fmt.Sprintf("Balance Actual: $%.2f\n", currentBalance))
+	currentBalance := context.MonthlyIncome.Add(context.MonthlyExpense)
+	prompt.WriteString(fmt.Sprintf("Balance Actual: $%s\n", currentBalance))
 	prompt.WriteString(fmt.Sprintf("Tasa de Ahorro: %.1f%%\n", context.SavingsRate))
 	
 	if len(context.TopCategories) > 0 {
 		prompt.WriteString("\nDesglose de Gastos Históricos:\n")
 		for _, category := range context.TopCategories {
-			prompt.WriteString(fmt.Sprintf("- %s: $%.2f (%.1f%%, %d transacciones)\n", 
-				category.Category, math.Abs(category.TotalAmount), category.Percentage, category.Count))
+			prompt.WriteString(fmt.Sprintf("- %s: $%s (%.1f%%, %d transacciones)\n", 
+				category.Category, category.TotalAmount.Abs(), category.Percentage, category.Count))
 		}
 	}
 	
@@ -365,21 +406,21 @@ func (s *aiService) extractSuggestions(advice string) []string {
 	return suggestions
 }
 
-func (s *aiService) generateHistoricalInsights(totalIncome, totalExpenses, currentBalance float64, topCategories []*models.CategorySummary) []string {
+func (s *aiService) generateHistoricalInsights(totalIncome, totalExpenses, currentBalance models.Money, topCategories []*models.CategorySummary, anomalies *models.SpendingAnomalies) []string {
 	var insights []string
 	
 	// Insight sobre el balance general
-	if currentBalance > 0 {
-		insights = append(insights, fmt.Sprintf("¡Excelente! Has ahorrado $%.2f en total", currentBalance))
+	if currentBalance.IsPositive() {
+		insights = append(insights, fmt.Sprintf("¡Excelente! Has ahorrado $%s en total", currentBalance))
 	} else {
-		insights = append(insights, fmt.Sprintf("Tienes un balance negativo de $%.2f - necesitas revisar tus gastos", math.Abs(currentBalance)))
+		insights = append(insights, fmt.Sprintf("Tienes un balance negativo de $%s - necesitas revisar tus gastos", currentBalance.Abs()))
 	}
 	
 	// Insight sobre la categoría de mayor gasto
 	if len(topCategories) > 0 {
 		topCategory := topCategories[0]
-		insights = append(insights, fmt.Sprintf("Tu categoría de mayor gasto es %s con $%.2f (%.1f%% del total)", 
-			topCategory.Category, math.Abs(topCategory.TotalAmount), topCategory.Percentage))
+		insights = append(insights, fmt.Sprintf("Tu categoría de mayor gasto es %s con $%s (%.1f%% del total)", 
+			topCategory.Category, topCategory.TotalAmount.Abs(), topCategory.Percentage))
 	}
 	
 	// Insight sobre diversificación de gastos
@@ -390,8 +431,8 @@ func (s *aiService) generateHistoricalInsights(totalIncome, totalExpenses, curre
 	}
 	
 	// Insight sobre el total de ingresos vs gastos
-	if totalIncome > 0 {
-		expenseRatio := (totalExpenses / totalIncome) * 100
+	if totalIncome.IsPositive() {
+		expenseRatio := totalExpenses.Ratio(totalIncome) * 100
 		if expenseRatio > 80 {
 			insights = append(insights, fmt.Sprintf("Estás gastando %.1f%% de tus ingresos - considera reducir gastos", expenseRatio))
 		} else if expenseRatio < 50 {
@@ -399,5 +440,23 @@ func (s *aiService) generateHistoricalInsights(totalIncome, totalExpenses, curre
 		}
 	}
 	
+	// Insights sobre gastos inusuales de los últimos meses
+	if anomalies != nil {
+		for i, anomaly := range anomalies.Transactions {
+			if i == maxAnomalyInsights {
+				break
+			}
+			insights = append(insights, fmt.Sprintf("Gasto inusual de $%s en %s (%s) el %s, cuando lo típico es $%s",
+				anomaly.Amount, anomaly.Description, anomaly.Category, anomaly.Date.Format("2006-01-02"), anomaly.Typical))
+		}
+		for i, spike := range anomalies.CategorySpikes {
+			if i == maxAnomalyInsights {
+				break
+			}
+			insights = append(insights, fmt.Sprintf("Tu gasto en %s subió a $%s en %s, frente a $%s en un mes típico",
+				spike.Category, spike.Amount, spike.Month, spike.Typical))
+		}
+	}
+	
 	return insights
 }
@@ -8,6 +8,8 @@ import (
 	"time"
 
 	"backend/internal/models"
+	"backend/internal/period"
+	"backend/internal/rates"
 	"backend/internal/repository"
 )
 
@@ -16,22 +18,56 @@ type AnalyticsService interface {
 	GetFinancialInsights(ctx context.Context, userID, month string) ([]string, error)
 	GetFinancialSummary(ctx context.Context, userID string) (*models.FinancialSummary, error)
 	GetFinancialSummaryWithBudgets(ctx context.Context, userID, month string) (*models.MonthlyAnalyticsWithBudget, error)
-	GetCategoryBreakdown(ctx context.Context, userID string, period string) ([]models.CategoryBreakdown, error)
+	GetCategoryBreakdown(ctx context.Context, userID string, r period.Range, opts BreakdownOptions) ([]models.CategoryBreakdown, error)
+	GetTagBreakdown(ctx context.Context, userID string, r period.Range) ([]models.TagBreakdown, error)
 	GetUniqueCategories(ctx context.Context, userID string) ([]string, error)
-	GetCategoryOptions(ctx context.Context, userID string) ([]models.CategoryOption, error)
+	GetCategoryOptions(ctx context.Context, userID, language string) ([]models.CategoryOption, error)
 	GetMonthsWithTransactions(ctx context.Context, userID string) ([]string, error)
+	GetMonthlyTrends(ctx context.Context, userID, fromMonth, toMonth string) (*models.MonthlyTrends, error)
+	GetMonthTrend(ctx context.Context, userID, month string) (*models.MonthlyTrendPoint, error)
+	DetectSubscriptions(ctx context.Context, userID string) ([]models.Subscription, error)
+	GetCashFlowForecast(ctx context.Context, userID string, days int, asOf time.Time) (*models.CashFlowForecast, error)
+	BacktestForecast(ctx context.Context, userID string, months int, asOf time.Time) (*models.ForecastBacktest, error)
+	DetectAnomalies(ctx context.Context, userID string, months int, asOf time.Time) (*models.SpendingAnomalies, error)
+}
+
+// BreakdownOptions controls how the category breakdown is grouped and labelled
+type BreakdownOptions struct {
+	RollUp   bool   // report subcategories within their parent category
+	Language string // language of the labels, models.LanguageSpanish by default
 }
 
 type analyticsService struct {
 	repo          repository.Repository
 	budgetService *BudgetService
+	converter     *CurrencyConverter
 }
 
 func NewAnalyticsService(repo repository.Repository) AnalyticsService {
+	return NewAnalyticsServiceWithRates(repo, nil)
+}
+
+// NewAnalyticsServiceWithRates creates an analytics service that reports
+// multi-currency transactions in each user's base currency
+func NewAnalyticsServiceWithRates(repo repository.Repository, store *rates.Store) AnalyticsService {
 	return &analyticsService{
 		repo:          repo,
-		budgetService: NewBudgetService(repo),
+		budgetService: NewBudgetServiceWithRates(repo, store),
+		converter:     NewCurrencyConverter(repo, store),
+	}
+}
+
+// transactionsInBaseCurrency converts the transactions to the user's base currency
+func (s *analyticsService) transactionsInBaseCurrency(ctx context.Context, userID string, transactions []models.Transaction) ([]models.Transaction, string, error) {
+	base, err := s.converter.BaseCurrency(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	converted, err := s.converter.ConvertTransactions(transactions, base)
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to convert to %s: %w", base, err)
 	}
+	return converted, base, nil
 }
 
 func (s *analyticsService) GetMonthlyAnalytics(ctx context.Context, userID, month string) (*models.MonthlyAnalytics, error) {
@@ -39,11 +75,41 @@ func (s *analyticsService) GetMonthlyAnalytics(ctx context.Context, userID, mont
 		return nil, fmt.Errorf("userID and month are required")
 	}
 	
-	analytics, err := s.repo.GetMonthlyAnalytics(ctx, userID, month)
+	transactions, err := repository.CollectTransactions(ctx, repository.IterateTransactionsByMonth(s.repo, userID, month))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get monthly analytics: %w", err)
 	}
 	
+	transactions, base, err := s.transactionsInBaseCurrency(ctx, userID, transactions)
+	if err != nil {
+		return nil, err
+	}
+	
+	analytics := &models.MonthlyAnalytics{
+		Month:             month,
+		Currency:          base,
+		TotalIncome:       models.ZeroMoney(base),
+		TotalExpense:      models.ZeroMoney(base),
+		CategoryBreakdown: make(map[string]models.Money),
+	}
+	
+	for _, tx := range transactions {
+		if tx.IsTransfer() {
+			// Moving money between accounts is neither income nor spending
+			continue
+		}
+		analytics.TransactionCount++
+		if tx.Type == models.TransactionTypeIncome {
+			analytics.TotalIncome = analytics.TotalIncome.Add(tx.Amount)
+		} else if tx.Type == models.TransactionTypeExpense {
+			analytics.TotalExpense = analytics.TotalExpense.Add(tx.Amount.Abs())
+		}
+		for _, split := range tx.Allocations() {
+			analytics.CategoryBreakdown[split.Category] = analytics.CategoryBreakdown[split.Category].Add(split.Amount)
+		}
+	}
+	
+	analytics.Balance = analytics.TotalIncome.Sub(analytics.TotalExpense)
 	return analytics, nil
 }
 
@@ -52,69 +118,89 @@ func (s *analyticsService) GetFinancialSummary(ctx context.Context, userID strin
 		return nil, fmt.Errorf("userID is required")
 	}
 	
-	// Get ALL historical transactions for user (not just current month)
-	transactions, _, err := s.repo.GetTransactionsByUser(ctx, userID, 1000, nil) 
+	base, err := s.converter.BaseCurrency(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user transactions: %w", err)
+		return nil, err
 	}
 	
 	// Calculate historical totals (same logic as AI service)
-	totalIncome := 0.0
-	totalExpenses := 0.0
+	totalIncome := models.ZeroMoney(base)
+	totalExpenses := models.ZeroMoney(base)
 	categoryMap := make(map[string]models.CategoryBreakdown)
 	
-	for _, transaction := range transactions {
+	// The current and previous month feed the monthly trend
+	now := time.Now()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	previousMonth := currentMonth.AddDate(0, -1, 0)
+	trend := newTrendBuilder(base, previousMonth, currentMonth)
+	
+	// Stream ALL historical transactions for user (not just current month)
+	err = s.converter.ForEachInBase(ctx, repository.IterateTransactionsByUser(s.repo, userID), base, func(transaction models.Transaction) error {
+		trend.add(transaction)
 		if transaction.Type == models.TransactionTypeIncome {
-			totalIncome += transaction.Amount
+			totalIncome = totalIncome.Add(transaction.Amount)
 		} else if transaction.Type == models.TransactionTypeExpense {
-			totalExpenses += -transaction.Amount // Convert to positive for calculations
-			
-			breakdown, exists := categoryMap[transaction.Category]
-			if !exists {
-				breakdown = models.CategoryBreakdown{
-					Category: transaction.Category,
-					Amount:   0,
-					Count:    0,
-				}
-			}
-			// Sum absolute values of all transactions in this category
-			breakdown.Amount += -transaction.Amount  // Convert negative expense to positive
-			breakdown.Count++
-			categoryMap[transaction.Category] = breakdown
+			totalExpenses = totalExpenses.Sub(transaction.Amount) // Convert to positive for calculations
+			addToBreakdown(categoryMap, transaction, base)
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user transactions: %w", err)
 	}
 	
 	// Calculate total balance: income - expenses
-	totalBalance := totalIncome - totalExpenses
+	totalBalance := totalIncome.Sub(totalExpenses)
 	
 	// Calculate savings rate: (total balance / total income) * 100
 	savingsRate := 0.0
-	if totalIncome > 0 {
-		savingsRate = (totalBalance / totalIncome) * 100
+	if totalIncome.IsPositive() {
+		savingsRate = totalBalance.Ratio(totalIncome) * 100
+	}
+	
+	// Percentage change in expenses from last month
+	monthlyTrend := 0.0
+	if change := percentChange(trend.point(previousMonth).TotalExpenses, trend.point(currentMonth).TotalExpenses); change != nil {
+		monthlyTrend = *change
 	}
 	
 	// Calculate percentages for categories and convert to slice
 	var categoryBreakdown []models.CategoryBreakdown
 	for _, breakdown := range categoryMap {
-		if totalExpenses > 0 {
-			breakdown.Percentage = (breakdown.Amount / totalExpenses) * 100
+		if totalExpenses.IsPositive() {
+			breakdown.Percentage = breakdown.Amount.Ratio(totalExpenses) * 100
 		}
 		categoryBreakdown = append(categoryBreakdown, breakdown)
 	}
 	
+	catalog, err := loadCatalog(ctx, s.repo, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range categoryBreakdown {
+		catalog.Annotate(&categoryBreakdown[i], models.LanguageSpanish)
+	}
+	
 	// Sort category breakdown by category name
 	sort.Slice(categoryBreakdown, func(i, j int) bool {
 		return categoryBreakdown[i].Category < categoryBreakdown[j].Category
 	})
 	
+	payments, err := upcomingPayments(ctx, s.repo, s.converter, userID, now)
+	if err != nil {
+		return nil, err
+	}
+	
 	return &models.FinancialSummary{
+		Currency:          base,
 		TotalBalance:      totalBalance,
 		MonthlyIncome:     totalIncome,        // Now historical total income
-		MonthlyExpenses:   -totalExpenses,     // Keep negative for consistency
+		MonthlyExpenses:   totalExpenses.Neg(), // Keep negative for consistency
 		AvailableMoney:    totalBalance,
 		SavingsRate:       savingsRate,        // New field
 		CategoryBreakdown: categoryBreakdown,
-		MonthlyTrend:      0, // TODO: Calculate trend
+		MonthlyTrend:      monthlyTrend,
+		UpcomingPayments:  payments,
 		Timestamp:         time.Now(),
 	}, nil
 }
@@ -126,22 +212,29 @@ func (s *analyticsService) GetFinancialSummaryWithBudgets(ctx context.Context, u
 	}
 
 	// Get transactions for the specific month
-	transactions, _, err := s.repo.GetTransactionsByMonth(ctx, userID, month, 1000, nil)
+	transactions, err := repository.CollectTransactions(ctx, repository.IterateTransactionsByMonth(s.repo, userID, month))
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions for month: %w", err)
 	}
 
+	transactions, base, err := s.transactionsInBaseCurrency(ctx, userID, transactions)
+	if err != nil {
+		return nil, err
+	}
+
 	// Calculate monthly totals and category breakdown
-	monthlyIncome := 0.0
-	monthlyExpenses := 0.0
-	categorySpending := make(map[string]float64)
+	monthlyIncome := models.ZeroMoney(base)
+	monthlyExpenses := models.ZeroMoney(base)
+	categorySpending := make(map[string]models.Money)
 
 	for _, transaction := range transactions {
 		if transaction.Type == models.TransactionTypeIncome {
-			monthlyIncome += transaction.Amount
+			monthlyIncome = monthlyIncome.Add(transaction.Amount)
 		} else if transaction.Type == models.TransactionTypeExpense {
-			monthlyExpenses += -transaction.Amount // Convert to positive
-			categorySpending[transaction.Category] += -transaction.Amount
+			monthlyExpenses = monthlyExpenses.Sub(transaction.Amount) // Convert to positive
+			for _, split := range transaction.Allocations() {
+				categorySpending[split.Category] = categorySpending[split.Category].Sub(split.Amount)
+			}
 		}
 	}
 
@@ -150,6 +243,10 @@ func (s *analyticsService) GetFinancialSummaryWithBudgets(ctx context.Context, u
 	if err != nil {
 		return nil, fmt.Errorf("failed to get budgets: %w", err)
 	}
+	budgets, err = s.budgetService.budgetsInCurrency(budgets, base)
+	if err != nil {
+		return nil, err
+	}
 
 	// Create budget map for quick lookup
 	budgetMap := make(map[string]models.Budget)
@@ -173,8 +270,8 @@ func (s *analyticsService) GetFinancialSummaryWithBudgets(ctx context.Context, u
 
 	// Build the breakdown for each category
 	for category := range allCategories {
-		spent := categorySpending[category]
-		budgetAmount := 0.0
+		spent := models.ZeroMoney(base).Add(categorySpending[category])
+		budgetAmount := models.ZeroMoney(base)
 
 		if budget, hasBudget := budgetMap[category]; hasBudget {
 			budgetAmount = budget.Amount
@@ -184,7 +281,7 @@ func (s *analyticsService) GetFinancialSummaryWithBudgets(ctx context.Context, u
 			Category:  category,
 			Amount:    spent,
 			Budget:    budgetAmount,
-			Remaining: budgetAmount - spent,
+			Remaining: budgetAmount.Sub(spent),
 		}
 
 		categoryBreakdown = append(categoryBreakdown, breakdown)
@@ -200,75 +297,200 @@ func (s *analyticsService) GetFinancialSummaryWithBudgets(ctx context.Context, u
 
 	return &models.MonthlyAnalyticsWithBudget{
 		Month:             month,
+		Currency:          base,
 		TotalIncome:       monthlyIncome,
 		TotalExpense:      monthlyExpenses,
-		Balance:           monthlyIncome - monthlyExpenses,
+		Balance:           monthlyIncome.Sub(monthlyExpenses),
 		CategoryBreakdown: categoryBreakdown,
 		TransactionCount:  transactionCount,
 	}, nil
 }
 
-func (s *analyticsService) GetCategoryBreakdown(ctx context.Context, userID string, period string) ([]models.CategoryBreakdown, error) {
+// GetCategoryBreakdown returns the expenses per category within the period,
+// labelled and colored from the user's catalog. Rolled up, each parent
+// category includes its subcategories, which are listed under it.
+func (s *analyticsService) GetCategoryBreakdown(ctx context.Context, userID string, r period.Range, opts BreakdownOptions) ([]models.CategoryBreakdown, error) {
 	if userID == "" {
 		return nil, fmt.Errorf("userID is required")
 	}
 	
-	// For now, get current month's data
-	currentMonth := time.Now().Format("2006-01")
-	
-	transactions, _, err := s.repo.GetTransactionsByMonth(ctx, userID, currentMonth, 1000, nil)
+	transactions, err := s.transactionsInPeriod(ctx, userID, r)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get transactions for period: %w", err)
 	}
 	
+	transactions, base, err := s.transactionsInBaseCurrency(ctx, userID, transactions)
+	if err != nil {
+		return nil, err
+	}
+	
 	categoryMap := make(map[string]models.CategoryBreakdown)
-	totalExpenses := 0.0
+	totalExpenses := models.ZeroMoney(base)
 	
 	for _, transaction := range transactions {
 		if transaction.Type == models.TransactionTypeExpense {
-			totalExpenses += -transaction.Amount
-			
-			breakdown, exists := categoryMap[transaction.Category]
-			if !exists {
-				breakdown = models.CategoryBreakdown{
-					Category: transaction.Category,
-					Amount:   0,
-					Count:    0,
-				}
-			}
-			breakdown.Amount += -transaction.Amount
-			breakdown.Count++
-			categoryMap[transaction.Category] = breakdown
+			totalExpenses = totalExpenses.Sub(transaction.Amount)
+			addToBreakdown(categoryMap, transaction, base)
 		}
 	}
 	
+	catalog, err := loadCatalog(ctx, s.repo, userID)
+	if err != nil {
+		return nil, err
+	}
+	
 	// Convert to slice and calculate percentages
 	var result []models.CategoryBreakdown
 	for _, breakdown := range categoryMap {
-		if totalExpenses > 0 {
-			breakdown.Percentage = (breakdown.Amount / totalExpenses) * 100
+		if totalExpenses.IsPositive() {
+			breakdown.Percentage = breakdown.Amount.Ratio(totalExpenses) * 100
 		}
+		catalog.Annotate(&breakdown, opts.Language)
 		result = append(result, breakdown)
 	}
 	
+	if opts.RollUp {
+		result = rollUpBreakdown(catalog, result, base, totalExpenses, opts.Language)
+	}
+	
 	return result, nil
 }
 
-// GetUniqueCategories returns just the unique category names for a user
-func (s *analyticsService) GetUniqueCategories(ctx context.Context, userID string) ([]string, error) {
+// GetTagBreakdown returns the expenses per tag within the period, largest
+// first. Untagged expenses count toward the total the percentages refer to.
+func (s *analyticsService) GetTagBreakdown(ctx context.Context, userID string, r period.Range) ([]models.TagBreakdown, error) {
 	if userID == "" {
 		return nil, fmt.Errorf("userID is required")
 	}
 	
-	// Get all transactions for user to find all categories they've used
-	transactions, _, err := s.repo.GetTransactionsByUser(ctx, userID, 1000, nil)
+	transactions, err := s.transactionsInPeriod(ctx, userID, r)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user transactions: %w", err)
+		return nil, fmt.Errorf("failed to get transactions for period: %w", err)
 	}
 	
-	categorySet := make(map[string]bool)
+	transactions, base, err := s.transactionsInBaseCurrency(ctx, userID, transactions)
+	if err != nil {
+		return nil, err
+	}
+	
+	tagMap := make(map[string]models.TagBreakdown)
+	totalExpenses := models.ZeroMoney(base)
+	
 	for _, transaction := range transactions {
-		categorySet[transaction.Category] = true
+		if transaction.Type != models.TransactionTypeExpense {
+			continue
+		}
+		totalExpenses = totalExpenses.Sub(transaction.Amount)
+		
+		for _, tag := range models.NormalizeTags(transaction.Tags) {
+			breakdown, exists := tagMap[tag]
+			if !exists {
+				breakdown = models.TagBreakdown{Tag: tag, Amount: models.ZeroMoney(base)}
+			}
+			breakdown.Amount = breakdown.Amount.Sub(transaction.Amount)
+			breakdown.Count++
+			tagMap[tag] = breakdown
+		}
+	}
+	
+	result := []models.TagBreakdown{}
+	for _, breakdown := range tagMap {
+		if totalExpenses.IsPositive() {
+			breakdown.Percentage = breakdown.Amount.Ratio(totalExpenses) * 100
+		}
+		result = append(result, breakdown)
+	}
+	
+	sort.Slice(result, func(i, j int) bool {
+		if c := result[i].Amount.Cmp(result[j].Amount); c != 0 {
+			return c > 0
+		}
+		return result[i].Tag < result[j].Tag
+	})
+	
+	return result, nil
+}
+
+// addToBreakdown adds an expense to the breakdown of each of its categories,
+// as a positive amount. A split transaction counts once in every category of
+// its splits.
+func addToBreakdown(categoryMap map[string]models.CategoryBreakdown, transaction models.Transaction, base string) {
+	for _, split := range transaction.Allocations() {
+		breakdown, exists := categoryMap[split.Category]
+		if !exists {
+			breakdown = models.CategoryBreakdown{
+				Category: split.Category,
+				Amount:   models.ZeroMoney(base),
+			}
+		}
+		breakdown.Amount = breakdown.Amount.Sub(split.Amount)
+		breakdown.Count++
+		categoryMap[split.Category] = breakdown
+	}
+}
+
+// rollUpBreakdown merges each subcategory into its parent's entry, keeping it
+// in the parent's Subcategories
+func rollUpBreakdown(catalog models.CategoryCatalog, breakdown []models.CategoryBreakdown, base string, total models.Money, language string) []models.CategoryBreakdown {
+	parents := make(map[string]*models.CategoryBreakdown)
+	var order []string
+	for _, entry := range breakdown {
+		id := catalog.RollUp(entry.Category)
+		parent, exists := parents[id]
+		if !exists {
+			parent = &models.CategoryBreakdown{Category: id, Amount: models.ZeroMoney(base)}
+			catalog.Annotate(parent, language)
+			parents[id] = parent
+			order = append(order, id)
+		}
+		parent.Amount = parent.Amount.Add(entry.Amount)
+		parent.Count += entry.Count
+		if id != entry.Category {
+			parent.Subcategories = append(parent.Subcategories, entry)
+		}
+	}
+	
+	result := make([]models.CategoryBreakdown, 0, len(order))
+	for _, id := range order {
+		parent := parents[id]
+		if total.IsPositive() {
+			parent.Percentage = parent.Amount.Ratio(total) * 100
+		}
+		sort.Slice(parent.Subcategories, func(i, j int) bool {
+			return parent.Subcategories[i].Amount.Cmp(parent.Subcategories[j].Amount) > 0
+		})
+		result = append(result, *parent)
+	}
+	return result
+}
+
+// transactionsInPeriod queries each month the period overlaps and keeps the
+// transactions dated within it
+func (s *analyticsService) transactionsInPeriod(ctx context.Context, userID string, r period.Range) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	for _, month := range r.Months() {
+		err := repository.ForEachTransaction(ctx, repository.IterateTransactionsByMonth(s.repo, userID, month), func(tx models.Transaction) error {
+			if r.Contains(tx.Date) {
+				transactions = append(transactions, tx)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return transactions, nil
+}
+
+// GetUniqueCategories returns just the unique category names for a user
+func (s *analyticsService) GetUniqueCategories(ctx context.Context, userID string) ([]string, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+	
+	categorySet, err := s.usedCategories(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
 	
 	var categories []string
@@ -279,31 +501,52 @@ func (s *analyticsService) GetUniqueCategories(ctx context.Context, userID strin
 	return categories, nil
 }
 
-// GetCategoryOptions returns category options with label and value for UI components
-func (s *analyticsService) GetCategoryOptions(ctx context.Context, userID string) ([]models.CategoryOption, error) {
+// GetCategoryOptions returns category options with label and value for UI
+// components: the user's catalog plus the categories their transactions use
+// that are not in it, sorted by label
+func (s *analyticsService) GetCategoryOptions(ctx context.Context, userID, language string) ([]models.CategoryOption, error) {
 	if userID == "" {
 		return nil, fmt.Errorf("userID is required")
 	}
 	
-	// Get all transactions for user to find all categories they've used
-	transactions, _, err := s.repo.GetTransactionsByUser(ctx, userID, 1000, nil)
+	categorySet, err := s.usedCategories(ctx, userID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get user transactions: %w", err)
+		return nil, err
+	}
+	catalog, err := loadCatalog(ctx, s.repo, userID)
+	if err != nil {
+		return nil, err
 	}
 	
-	categorySet := make(map[string]bool)
-	for _, transaction := range transactions {
-		categorySet[transaction.Category] = true
+	for id := range catalog {
+		categorySet[id] = true
 	}
 	
+	seen := make(map[string]bool)
 	var categoryOptions []models.CategoryOption
 	for category := range categorySet {
+		value := category
+		entry, cataloged := catalog.Lookup(category)
+		if cataloged {
+			value = entry.ID
+		}
+		if category == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		
 		categoryOptions = append(categoryOptions, models.CategoryOption{
-			Label: strings.ToUpper(string(category[0])) + category[1:],
-			Value: category,
+			Label:  catalog.Label(value, language),
+			Value:  value,
+			Parent: entry.Parent,
+			Color:  entry.Color,
+			Icon:   entry.Icon,
 		})
 	}
 	
+	sort.Slice(categoryOptions, func(i, j int) bool {
+		return strings.ToLower(categoryOptions[i].Label) < strings.ToLower(categoryOptions[j].Label)
+	})
 	return categoryOptions, nil
 }
 
@@ -312,18 +555,16 @@ func (s *analyticsService) GetMonthsWithTransactions(ctx context.Context, userID
 		return nil, fmt.Errorf("userID is required")
 	}
 	
-	// Get all transactions for user
-	transactions, _, err := s.repo.GetTransactionsByUser(ctx, userID, 1000, nil)
+	// Walk all transactions for user
+	monthSet := make(map[string]bool)
+	err := repository.ForEachTransaction(ctx, repository.IterateTransactionsByUser(s.repo, userID), func(transaction models.Transaction) error {
+		monthSet[transaction.Date.Format("2006-01")] = true
+		return nil
+	})
 	if err != nil {
 		return nil, fmt.Errorf("failed to get user transactions: %w", err)
 	}
 	
-	monthSet := make(map[string]bool)
-	for _, transaction := range transactions {
-		month := transaction.Date.Format("2006-01")
-		monthSet[month] = true
-	}
-	
 	var months []string
 	for month := range monthSet {
 		months = append(months, month)
@@ -332,6 +573,24 @@ func (s *analyticsService) GetMonthsWithTransactions(ctx context.Context, userID
 	return months, nil
 }
 
+// usedCategories walks the user's full history to find every category they've used
+func (s *analyticsService) usedCategories(ctx context.Context, userID string) (map[string]bool, error) {
+	categorySet := make(map[string]bool)
+	err := repository.ForEachTransaction(ctx, repository.IterateTransactionsByUser(s.repo, userID), func(transaction models.Transaction) error {
+		if transaction.IsTransfer() {
+			return nil
+		}
+		for _, split := range transaction.Allocations() {
+			categorySet[split.Category] = true
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user transactions: %w", err)
+	}
+	return categorySet, nil
+}
+
 func (s *analyticsService) GetFinancialInsights(ctx context.Context, userID, month string) ([]string, error) {
 	analytics, err := s.GetMonthlyAnalytics(ctx, userID, month)
 	if err != nil {
@@ -341,24 +600,24 @@ func (s *analyticsService) GetFinancialInsights(ctx context.Context, userID, mon
 	var insights []string
 	
 	// Generate basic insights
-	if analytics.Balance > 0 {
-		insights = append(insights, fmt.Sprintf("Great! You saved $%.2f this month", analytics.Balance))
+	if analytics.Balance.IsPositive() {
+		insights = append(insights, fmt.Sprintf("Great! You saved $%s this month", analytics.Balance))
 	} else {
-		insights = append(insights, fmt.Sprintf("You spent $%.2f more than you earned this month", -analytics.Balance))
+		insights = append(insights, fmt.Sprintf("You spent $%s more than you earned this month", analytics.Balance.Neg()))
 	}
 	
 	// Category insights
 	var topCategory string
-	var topAmount float64
+	var topAmount models.Money
 	for category, amount := range analytics.CategoryBreakdown {
-		if amount < 0 && -amount > topAmount { // Look for highest expense
+		if amount.IsNegative() && amount.Neg().Cmp(topAmount) > 0 { // Look for highest expense
 			topCategory = category
-			topAmount = -amount
+			topAmount = amount.Neg()
 		}
 	}
 	
 	if topCategory != "" {
-		insights = append(insights, fmt.Sprintf("Your highest spending category was %s with $%.2f", topCategory, topAmount))
+		insights = append(insights, fmt.Sprintf("Your highest spending category was %s with $%s", topCategory, topAmount))
 	}
 	
 	return insights, nil
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"backend/internal/models"
+)
+
+// DetectAnomalies flags the unusually large expenses and the category spikes
+// of the given number of months up to asOf's, each month scored against the
+// models.AnomalyBaselineMonths before it
+func (s *analyticsService) DetectAnomalies(ctx context.Context, userID string, months int, asOf time.Time) (*models.SpendingAnomalies, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+	if months == 0 {
+		months = models.DefaultAnomalyMonths
+	}
+	if months < 1 || months > models.MaxAnomalyMonths {
+		return nil, fmt.Errorf("%w: months must be between 1 and %d", ErrInvalidRange, models.MaxAnomalyMonths)
+	}
+
+	base, err := s.converter.BaseCurrency(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	asOf = asOf.UTC()
+	last := time.Date(asOf.Year(), asOf.Month(), 1, 0, 0, 0, 0, time.UTC)
+	first := last.AddDate(0, -(months - 1), 0)
+
+	transactions, err := transactionsBetween(ctx, s.repo, userID, first.AddDate(0, -models.AnomalyBaselineMonths, 0), last.AddDate(0, 1, 0))
+	if err != nil {
+		return nil, err
+	}
+	transactions, err = s.converter.ConvertTransactions(transactions, base)
+	if err != nil {
+		return nil, err
+	}
+
+	return detectAnomalies(transactions, base, first, last), nil
+}
+
+// detectAnomalies scores the expenses of the months from first to last
+// (inclusive) against the AnomalyBaselineMonths before each month. The
+// transactions are in the base currency and must cover the baseline.
+func detectAnomalies(transactions []models.Transaction, base string, first, last time.Time) *models.SpendingAnomalies {
+	result := &models.SpendingAnomalies{
+		Currency:       base,
+		From:           first.Format(monthLayout),
+		To:             last.Format(monthLayout),
+		Transactions:   []models.TransactionAnomaly{},
+		CategorySpikes: []models.CategorySpike{},
+	}
+
+	byCategory := make(map[string][]models.Transaction)
+	byMerchant := make(map[string][]models.Transaction)
+	monthly := make(map[string]map[string]int64) // category -> month -> total spent
+	for _, tx := range transactions {
+		if tx.Type != models.TransactionTypeExpense {
+			continue
+		}
+		byCategory[tx.Category] = append(byCategory[tx.Category], tx)
+		if merchant := normalizeDescription(tx.Description); merchant != "" {
+			byMerchant[merchant] = append(byMerchant[merchant], tx)
+		}
+		month := tx.Date.Format(monthLayout)
+		for _, split := range tx.Allocations() {
+			if monthly[split.Category] == nil {
+				monthly[split.Category] = make(map[string]int64)
+			}
+			monthly[split.Category][month] += split.Amount.Abs().Minor
+		}
+	}
+
+	for month := first; !month.After(last); month = month.AddDate(0, 1, 0) {
+		from := month.AddDate(0, -models.AnomalyBaselineMonths, 0)
+		end := month.AddDate(0, 1, 0)
+
+		for _, expenses := range byCategory {
+			for _, tx := range expenses {
+				if tx.Date.Before(month) || !tx.Date.Before(end) {
+					continue
+				}
+				if anomaly, ok := transactionAnomaly(tx, byCategory, byMerchant, from, month, base); ok {
+					result.Transactions = append(result.Transactions, anomaly)
+				}
+			}
+		}
+
+		for category, totals := range monthly {
+			if spike, ok := categorySpike(category, totals, month, base); ok {
+				result.CategorySpikes = append(result.CategorySpikes, spike)
+			}
+		}
+	}
+
+	sort.Slice(result.Transactions, func(i, j int) bool {
+		if result.Transactions[i].Score != result.Transactions[j].Score {
+			return result.Transactions[i].Score > result.Transactions[j].Score
+		}
+		return result.Transactions[i].TransactionID < result.Transactions[j].TransactionID
+	})
+	sort.Slice(result.CategorySpikes, func(i, j int) bool {
+		a, b := result.CategorySpikes[i], result.CategorySpikes[j]
+		if a.Month != b.Month {
+			return a.Month > b.Month
+		}
+		if a.Score != b.Score {
+			return a.Score > b.Score
+		}
+		return a.Category < b.Category
+	})
+	return result
+}
+
+// transactionAnomaly scores an expense against the expenses of its category
+// and of its merchant between from and before, keeping the higher score
+func transactionAnomaly(tx models.Transaction, byCategory, byMerchant map[string][]models.Transaction, from, before time.Time, base string) (models.TransactionAnomaly, bool) {
+	value := float64(tx.Amount.Abs().Minor)
+	anomaly := models.TransactionAnomaly{
+		TransactionID: tx.ID,
+		Date:          tx.Date,
+		Description:   tx.Description,
+		Category:      tx.Category,
+		Amount:        models.NewMoney(tx.Amount.Abs().Minor, base),
+	}
+
+	found := false
+	scopes := []struct {
+		name     string
+		expenses []models.Transaction
+	}{
+		{models.AnomalyScopeCategory, byCategory[tx.Category]},
+		{models.AnomalyScopeMerchant, byMerchant[normalizeDescription(tx.Description)]},
+	}
+	for _, scope := range scopes {
+		var baseline []float64
+		for _, expense := range scope.expenses {
+			if !expense.Date.Before(from) && expense.Date.Before(before) {
+				baseline = append(baseline, float64(expense.Amount.Abs().Minor))
+			}
+		}
+		if len(baseline) < models.MinAnomalySamples {
+			continue
+		}
+		score, typical, ok := anomalyScore(value, baseline)
+		if !ok || score < models.AnomalyScoreThreshold || (found && score <= anomaly.Score) {
+			continue
+		}
+		anomaly.Scope = scope.name
+		anomaly.Score = score
+		anomaly.Typical = models.NewMoney(int64(math.Round(typical)), base)
+		found = true
+	}
+	return anomaly, found
+}
+
+// categorySpike scores the category's total of the month against its totals
+// of the baseline months before it, months without spending counting as zero
+func categorySpike(category string, totals map[string]int64, month time.Time, base string) (models.CategorySpike, bool) {
+	amount := totals[month.Format(monthLayout)]
+	if amount == 0 {
+		return models.CategorySpike{}, false
+	}
+
+	baseline := make([]float64, 0, models.AnomalyBaselineMonths)
+	active := 0
+	for i := models.AnomalyBaselineMonths; i >= 1; i-- {
+		total := totals[month.AddDate(0, -i, 0).Format(monthLayout)]
+		if total > 0 {
+			active++
+		}
+		baseline = append(baseline, float64(total))
+	}
+	if active < models.MinSpikeMonths {
+		return models.CategorySpike{}, false
+	}
+
+	score, typical, ok := anomalyScore(float64(amount), baseline)
+	if !ok || score < models.AnomalyScoreThreshold {
+		return models.CategorySpike{}, false
+	}
+
+	spike := models.CategorySpike{
+		Month:    month.Format(monthLayout),
+		Category: category,
+		Amount:   models.NewMoney(amount, base),
+		Typical:  models.NewMoney(int64(math.Round(typical)), base),
+		Score:    score,
+	}
+	spike.IncreasePercent = percentChange(spike.Typical, spike.Amount)
+	return spike, true
+}
+
+// anomalyScore returns the modified z-score of value against the baseline and
+// the baseline's median. When over half the baseline equals the median, the
+// mean absolute deviation stands in for the median one; a baseline without
+// any spread cannot be scored.
+func anomalyScore(value float64, baseline []float64) (float64, float64, bool) {
+	if len(baseline) == 0 {
+		return 0, 0, false
+	}
+	median := medianOf(baseline)
+
+	deviations := make([]float64, len(baseline))
+	sum := 0.0
+	for i, v := range baseline {
+		deviations[i] = math.Abs(v - median)
+		sum += deviations[i]
+	}
+	if mad := medianOf(deviations); mad > 0 {
+		return 0.6745 * (value - median) / mad, median, true
+	}
+	if meanAD := sum / float64(len(baseline)); meanAD > 0 {
+		return (value - median) / (1.253314 * meanAD), median, true
+	}
+	return 0, median, false
+}
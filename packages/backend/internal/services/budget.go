@@ -2,37 +2,58 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"backend/internal/models"
+	"backend/internal/rates"
 	"backend/internal/repository"
+)
+
+// ErrInvalidBudget wraps budget input rejected by validation
+var ErrInvalidBudget = errors.New("invalid budget")
 
-	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+const (
+	// MaxBudgetRangeMonths bounds the months returned by GetBudgetsInRange
+	MaxBudgetRangeMonths = 24
+	// MaxBulkBudgets bounds the budgets accepted by one BulkUpsertBudgets call
+	MaxBulkBudgets = 100
 )
 
 // BudgetService handles budget-related business logic
 type BudgetService struct {
-	repo repository.Repository
+	repo      repository.Repository
+	converter *CurrencyConverter
 }
 
 // NewBudgetService creates a new budget service
 func NewBudgetService(repo repository.Repository) *BudgetService {
+	return NewBudgetServiceWithRates(repo, nil)
+}
+
+// NewBudgetServiceWithRates creates a budget service that converts spending in
+// other currencies to the user's base currency
+func NewBudgetServiceWithRates(repo repository.Repository, store *rates.Store) *BudgetService {
 	return &BudgetService{
-		repo: repo,
+		repo:      repo,
+		converter: NewCurrencyConverter(repo, store),
 	}
 }
 
-// CreateOrUpdateBudget creates or updates a budget for a specific month and category
-func (s *BudgetService) CreateOrUpdateBudget(ctx context.Context, userID, month, category string, amount float64) error {
-	if amount < 0 {
-		return fmt.Errorf("budget amount cannot be negative")
+// CreateOrUpdateBudget creates or updates a budget for a specific month and category.
+// The amount is expressed in the user's base currency.
+func (s *BudgetService) CreateOrUpdateBudget(ctx context.Context, userID, month, category string, amount models.Money) error {
+	if err := validateBudgetInput(models.BudgetInput{Month: month, Category: category, Amount: amount}); err != nil {
+		return err
 	}
 
-	// Validate month format (YYYY-MM)
-	if _, err := time.Parse("2006-01", month); err != nil {
-		return fmt.Errorf("invalid month format, expected YYYY-MM: %w", err)
+	base, err := s.converter.BaseCurrency(ctx, userID)
+	if err != nil {
+		return err
 	}
+	amount.Currency = base
 
 	budget := &models.Budget{
 		PK:       fmt.Sprintf("USER#%s", userID),
@@ -48,11 +69,98 @@ func (s *BudgetService) CreateOrUpdateBudget(ctx context.Context, userID, month,
 	return s.repo.CreateOrUpdateBudget(ctx, budget)
 }
 
+// BulkUpsertBudgets creates or replaces several budgets at once. Every entry is
+// validated before anything is written, so invalid input changes nothing.
+func (s *BudgetService) BulkUpsertBudgets(ctx context.Context, userID string, inputs []models.BudgetInput) ([]models.Budget, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: at least one budget is required", ErrInvalidBudget)
+	}
+	if len(inputs) > MaxBulkBudgets {
+		return nil, fmt.Errorf("%w: at most %d budgets per request", ErrInvalidBudget, MaxBulkBudgets)
+	}
+
+	seen := make(map[string]int, len(inputs))
+	for i, input := range inputs {
+		if err := validateBudgetInput(input); err != nil {
+			return nil, fmt.Errorf("budget %d: %w", i+1, err)
+		}
+		// Budgets are keyed by month and upper-cased category
+		key := input.Month + "#" + strings.ToUpper(input.Category)
+		if first, dup := seen[key]; dup {
+			return nil, fmt.Errorf("%w: budgets %d and %d are both for %s %s", ErrInvalidBudget, first, i+1, input.Month, input.Category)
+		}
+		seen[key] = i + 1
+	}
+
+	base, err := s.converter.BaseCurrency(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	budgets := make([]models.Budget, len(inputs))
+	for i, input := range inputs {
+		amount := input.Amount
+		amount.Currency = base
+		budgets[i] = models.Budget{
+			UserID:   userID,
+			Month:    input.Month,
+			Category: input.Category,
+			Amount:   amount,
+		}
+	}
+
+	if err := s.repo.BatchUpsertBudgets(ctx, budgets); err != nil {
+		return nil, err
+	}
+	return budgets, nil
+}
+
+// GetBudgetsInRange retrieves the user's budgets from one month to another (inclusive)
+func (s *BudgetService) GetBudgetsInRange(ctx context.Context, userID, fromMonth, toMonth string) ([]models.Budget, error) {
+	from, err := time.Parse("2006-01", fromMonth)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid from month, expected YYYY-MM", ErrInvalidBudget)
+	}
+	to, err := time.Parse("2006-01", toMonth)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid to month, expected YYYY-MM", ErrInvalidBudget)
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: from month must not be after to month", ErrInvalidBudget)
+	}
+	if months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1; months > MaxBudgetRangeMonths {
+		return nil, fmt.Errorf("%w: range spans %d months, at most %d allowed", ErrInvalidBudget, months, MaxBudgetRangeMonths)
+	}
+
+	budgets, err := s.repo.GetBudgetsInRange(ctx, userID, fromMonth, toMonth)
+	if err != nil {
+		return nil, err
+	}
+	if budgets == nil {
+		budgets = []models.Budget{}
+	}
+	return budgets, nil
+}
+
+func validateBudgetInput(input models.BudgetInput) error {
+	if input.Amount.IsNegative() {
+		return fmt.Errorf("%w: budget amount cannot be negative", ErrInvalidBudget)
+	}
+	if strings.TrimSpace(input.Category) == "" {
+		return fmt.Errorf("%w: category is required", ErrInvalidBudget)
+	}
+
+	// Validate month format (YYYY-MM)
+	if _, err := time.Parse("2006-01", input.Month); err != nil {
+		return fmt.Errorf("%w: invalid month format, expected YYYY-MM", ErrInvalidBudget)
+	}
+	return nil
+}
+
 // GetBudgetsByMonth retrieves all budgets for a user in a specific month
 func (s *BudgetService) GetBudgetsByMonth(ctx context.Context, userID, month string) ([]models.Budget, error) {
 	// Validate month format
 	if _, err := time.Parse("2006-01", month); err != nil {
-		return nil, fmt.Errorf("invalid month format, expected YYYY-MM: %w", err)
+		return nil, fmt.Errorf("%w: invalid month format, expected YYYY-MM", ErrInvalidBudget)
 	}
 
 	return s.repo.GetBudgetsByMonth(ctx, userID, month)
@@ -62,7 +170,7 @@ func (s *BudgetService) GetBudgetsByMonth(ctx context.Context, userID, month str
 func (s *BudgetService) GetBudget(ctx context.Context, userID, month, category string) (*models.Budget, error) {
 	// Validate month format
 	if _, err := time.Parse("2006-01", month); err != nil {
-		return nil, fmt.Errorf("invalid month format, expected YYYY-MM: %w", err)
+		return nil, fmt.Errorf("%w: invalid month format, expected YYYY-MM", ErrInvalidBudget)
 	}
 
 	return s.repo.GetBudget(ctx, userID, month, category)
@@ -70,6 +178,11 @@ func (s *BudgetService) GetBudget(ctx context.Context, userID, month, category s
 
 // DeleteBudget removes a budget
 func (s *BudgetService) DeleteBudget(ctx context.Context, userID, month, category string) error {
+	// Validate month format
+	if _, err := time.Parse("2006-01", month); err != nil {
+		return fmt.Errorf("%w: invalid month format, expected YYYY-MM", ErrInvalidBudget)
+	}
+
 	return s.repo.DeleteBudget(ctx, userID, month, category)
 }
 
@@ -87,62 +200,85 @@ func (s *BudgetService) GetBudgetUtilization(ctx context.Context, userID, month
 		return nil, fmt.Errorf("invalid month format: %w", err)
 	}
 
-	// Use the GetTransactionsByUser method with pagination to get all transactions
-	var allTransactions []models.Transaction
-	var lastKey map[string]types.AttributeValue
-	limit := 1000 // Large limit to get most transactions
-
-	for {
-		transactions, nextKey, err := s.repo.GetTransactionsByUser(ctx, userID, limit, lastKey)
-		if err != nil {
-			return nil, fmt.Errorf("failed to get transactions: %w", err)
-		}
-
-		allTransactions = append(allTransactions, transactions...)
-
-		// If no more pages, break
-		if nextKey == nil {
-			break
-		}
-		lastKey = nextKey
+	base, err := s.converter.BaseCurrency(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	budgets, err = s.budgetsInCurrency(budgets, base)
+	if err != nil {
+		return nil, err
 	}
 
-	// Filter transactions by date range and calculate spending by category (only expenses)
-	categorySpending := make(map[string]float64)
-	for _, tx := range allTransactions {
+	// Walk every page of the user's transactions, filter by date range and
+	// calculate spending by category (only expenses)
+	categorySpending := make(map[string]models.Money)
+	err = repository.ForEachTransaction(ctx, repository.IterateTransactionsByUser(s.repo, userID), func(tx models.Transaction) error {
 		// Convert transaction date to string for comparison
 		txDateStr := tx.Date.Format("2006-01-02")
 		if tx.Type == "expense" && txDateStr >= startDate && txDateStr <= endDate {
-			// For expenses, amount is negative, so we use absolute value for spending
-			if tx.Amount < 0 {
-				categorySpending[tx.Category] += -tx.Amount
-			} else {
-				categorySpending[tx.Category] += tx.Amount
+			tx, err := s.converter.InBase(tx, base)
+			if err != nil {
+				return err
+			}
+			// For expenses, amount is negative, so we use absolute value for
+			// spending. Each split counts toward the budget of its category.
+			for _, split := range tx.Allocations() {
+				categorySpending[split.Category] = categorySpending[split.Category].Add(split.Amount.Abs())
 			}
 		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
 	}
 
 	// Create budget utilization map
 	utilization := make(map[string]models.BudgetUtilization)
 	for _, budget := range budgets {
-		spent := categorySpending[budget.Category]
-		percentage := 0.0
-		if budget.Amount > 0 {
-			percentage = (spent / budget.Amount) * 100
-		}
-
-		utilization[budget.Category] = models.BudgetUtilization{
-			Category:     budget.Category,
-			BudgetAmount: budget.Amount,
-			SpentAmount:  spent,
-			Remaining:    budget.Amount - spent,
-			Percentage:   percentage,
-		}
+		spent := models.ZeroMoney(base).Add(categorySpending[budget.Category])
+		utilization[budget.Category] = budgetUtilization(budget, spent)
 	}
 
 	return utilization, nil
 }
 
+// budgetUtilization compares a budget with what was spent in its category
+func budgetUtilization(budget models.Budget, spent models.Money) models.BudgetUtilization {
+	percentage := 0.0
+	if budget.Amount.IsPositive() {
+		percentage = spent.Ratio(budget.Amount) * 100
+	}
+
+	return models.BudgetUtilization{
+		Category:     budget.Category,
+		BudgetAmount: budget.Amount,
+		SpentAmount:  spent,
+		Remaining:    budget.Amount.Sub(spent),
+		Percentage:   percentage,
+	}
+}
+
+// budgetsInCurrency converts budgets set before a base currency change, using
+// the rate on the first day of the budget month
+func (s *BudgetService) budgetsInCurrency(budgets []models.Budget, currency string) ([]models.Budget, error) {
+	converted := make([]models.Budget, len(budgets))
+	for i, budget := range budgets {
+		if budget.Amount.Currency != currency {
+			monthStart, err := time.Parse("2006-01", budget.Month)
+			if err != nil {
+				return nil, fmt.Errorf("invalid budget month %q: %w", budget.Month, err)
+			}
+			amount, err := s.converter.Convert(budget.Amount, currency, monthStart)
+			if err != nil {
+				return nil, fmt.Errorf("budget %s/%s: %w", budget.Month, budget.Category, err)
+			}
+			budget.Amount = amount
+		}
+		converted[i] = budget
+	}
+	return converted, nil
+}
+
 // getMonthDateRange converts a month string (YYYY-MM) to start and end dates
 func getMonthDateRange(month string) (string, string, error) {
 	t, err := time.Parse("2006-01", month)
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"backend/internal/models"
+)
+
+// GetBudgetPacing compares the spending of each of the month's budgets up to
+// asOf's day with how the category's spending usually builds up over a month,
+// projects it to the month's end and estimates the day the budget runs out
+func (s *BudgetService) GetBudgetPacing(ctx context.Context, userID, month string, asOf time.Time) (map[string]models.BudgetPacing, error) {
+	budgets, err := s.GetBudgetsByMonth(ctx, userID, month)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get budgets: %w", err)
+	}
+	pacing := make(map[string]models.BudgetPacing, len(budgets))
+	if len(budgets) == 0 {
+		return pacing, nil
+	}
+
+	start, err := time.Parse(monthLayout, month)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid month format, expected YYYY-MM", ErrInvalidBudget)
+	}
+	end := start.AddDate(0, 1, 0)
+	daysInMonth := end.AddDate(0, 0, -1).Day()
+
+	asOf = asOf.UTC()
+	day := asOf.Day()
+	if asOf.Before(start) {
+		day = 0
+	} else if !asOf.Before(end) {
+		day = daysInMonth
+	}
+
+	base, err := s.converter.BaseCurrency(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	budgets, err = s.budgetsInCurrency(budgets, base)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := transactionsBetween(ctx, s.repo, userID, start.AddDate(0, -models.PacingHistoryMonths, 0), end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get transactions: %w", err)
+	}
+	transactions, err = s.converter.ConvertTransactions(transactions, base)
+	if err != nil {
+		return nil, err
+	}
+
+	// Spending per category, month and day of the month
+	daily := make(map[string]map[string][]int64)
+	for _, tx := range transactions {
+		if tx.Type != models.TransactionTypeExpense {
+			continue
+		}
+		txMonth := tx.Date.Format(monthLayout)
+		for _, split := range tx.Allocations() {
+			if daily[split.Category] == nil {
+				daily[split.Category] = make(map[string][]int64)
+			}
+			days := daily[split.Category][txMonth]
+			if days == nil {
+				days = make([]int64, tx.Date.AddDate(0, 1, -tx.Date.Day()).Day())
+				daily[split.Category][txMonth] = days
+			}
+			days[tx.Date.Day()-1] += split.Amount.Abs().Minor
+		}
+	}
+
+	for _, budget := range budgets {
+		pacing[budget.Category] = budgetPacing(budget, daily[budget.Category], start, day, base)
+	}
+	return pacing, nil
+}
+
+// budgetPacing paces a budget through the given day of the month starting at
+// start, from the category's daily spending per month
+func budgetPacing(budget models.Budget, spending map[string][]int64, start time.Time, day int, base string) models.BudgetPacing {
+	current := spending[start.Format(monthLayout)]
+	daysInMonth := start.AddDate(0, 1, -1).Day()
+
+	var spent int64
+	for d := 0; d < day && d < len(current); d++ {
+		spent += current[d]
+	}
+
+	curve, typical := pacingCurve(spending, start, daysInMonth)
+	pacing := models.BudgetPacing{
+		BudgetUtilization: budgetUtilization(budget, models.NewMoney(spent, base)),
+		Day:               day,
+		DaysInMonth:       daysInMonth,
+		Curve:             models.PacingCurveHistory,
+		ExpectedPercent:   curve[day] * 100,
+		ExpectedSpent:     models.NewMoney(int64(math.Round(float64(budget.Amount.Minor)*curve[day])), base),
+	}
+
+	// The usual month's spending still to come, or the pace so far when
+	// there is no usual month to go by
+	projected := float64(spent) + (1-curve[day])*typical
+	if typical == 0 {
+		pacing.Curve = models.PacingCurveLinear
+		projected = float64(spent)
+		if day > 0 {
+			projected = float64(spent) * float64(daysInMonth) / float64(day)
+		}
+	}
+	pacing.ProjectedSpent = models.NewMoney(int64(math.Round(projected)), base)
+	pacing.ProjectedRemaining = budget.Amount.Sub(pacing.ProjectedSpent)
+
+	tolerance := budget.Amount.MulFloat(models.PaceTolerancePercent / 100)
+	switch difference := pacing.SpentAmount.Sub(pacing.ExpectedSpent); {
+	case difference.Cmp(tolerance) > 0:
+		pacing.Pace = models.PaceAhead
+	case difference.Neg().Cmp(tolerance) > 0:
+		pacing.Pace = models.PaceBehind
+	default:
+		pacing.Pace = models.PaceOnTrack
+	}
+
+	// The day spending reaches the budget: already passed, or where the
+	// projected spending to come crosses it along the curve
+	limit := float64(budget.Amount.Minor)
+	cumulative := 0.0
+	for d := 1; d <= daysInMonth; d++ {
+		if d <= day {
+			if d <= len(current) {
+				cumulative += float64(current[d-1])
+			}
+		} else if rest := 1 - curve[day]; rest > 0 {
+			cumulative = float64(spent) + (projected-float64(spent))*(curve[d]-curve[day])/rest
+		}
+		if cumulative > 0 && cumulative >= limit {
+			date := start.AddDate(0, 0, d-1)
+			pacing.RunOutDate = &date
+			break
+		}
+	}
+	return pacing
+}
+
+// pacingCurve returns the share of a month's spending usually done by the end
+// of each day of a month of the given length starting at start (index 0 being
+// before the first day), learned from the PacingHistoryMonths before it, and
+// the average spend of those months that had any. Days of shorter or longer
+// months are scaled to the month's length. Without MinPacingMonths months of
+// spending the curve is linear and the average zero.
+func pacingCurve(spending map[string][]int64, start time.Time, days int) ([]float64, float64) {
+	curve := make([]float64, days+1)
+
+	var total int64
+	active := 0
+	for i := 1; i <= models.PacingHistoryMonths; i++ {
+		for _, amount := range spending[start.AddDate(0, -i, 0).Format(monthLayout)] {
+			if amount > 0 {
+				active++
+				break
+			}
+		}
+	}
+	if active < models.MinPacingMonths {
+		for d := range curve {
+			curve[d] = float64(d) / float64(days)
+		}
+		return curve, 0
+	}
+
+	cumulative := make([]int64, days+1)
+	for i := 1; i <= models.PacingHistoryMonths; i++ {
+		history := spending[start.AddDate(0, -i, 0).Format(monthLayout)]
+		if len(history) == 0 {
+			continue
+		}
+		sums := make([]int64, len(history)+1)
+		for d, amount := range history {
+			sums[d+1] = sums[d] + amount
+		}
+		total += sums[len(history)]
+		for d := range cumulative {
+			cumulative[d] += sums[int(math.Round(float64(d*len(history))/float64(days)))]
+		}
+	}
+	for d := range curve {
+		curve[d] = float64(cumulative[d]) / float64(total)
+	}
+	return curve, float64(total) / float64(active)
+}
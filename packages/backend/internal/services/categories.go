@@ -0,0 +1,437 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/rates"
+	"backend/internal/repository"
+)
+
+// ErrInvalidCategory is returned for malformed categories, parents that break
+// the two-level hierarchy and catalogs beyond the per-user limit
+var ErrInvalidCategory = errors.New("invalid category")
+
+type CategoryService interface {
+	ListCategories(ctx context.Context, userID string) ([]models.Category, error)
+	GetCategory(ctx context.Context, userID, categoryID string) (*models.Category, error)
+	CreateCategory(ctx context.Context, category *models.Category) error
+	UpdateCategory(ctx context.Context, category *models.Category) error
+	DeleteCategory(ctx context.Context, userID, categoryID string) error
+	RenameCategory(ctx context.Context, userID, categoryID, newID string) (*models.CategoryChange, error)
+	MergeCategory(ctx context.Context, userID, categoryID, intoID string) (*models.CategoryChange, error)
+}
+
+type categoryService struct {
+	repo      repository.Repository
+	converter *CurrencyConverter
+}
+
+func NewCategoryService(repo repository.Repository) CategoryService {
+	return NewCategoryServiceWithRates(repo, nil)
+}
+
+// NewCategoryServiceWithRates creates a category service that can merge
+// budgets set in different currencies
+func NewCategoryServiceWithRates(repo repository.Repository, store *rates.Store) CategoryService {
+	return &categoryService{
+		repo:      repo,
+		converter: NewCurrencyConverter(repo, store),
+	}
+}
+
+// userCategories returns the user's catalog: the stored categories, or the
+// default ones until the user changes any
+func userCategories(ctx context.Context, repo repository.Repository, userID string) ([]models.Category, error) {
+	categories, err := repo.ListCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	if len(categories) == 0 {
+		return models.DefaultCategories(userID), nil
+	}
+	return categories, nil
+}
+
+// loadCatalog indexes the user's catalog for labelling and rolling up
+func loadCatalog(ctx context.Context, repo repository.Repository, userID string) (models.CategoryCatalog, error) {
+	categories, err := userCategories(ctx, repo, userID)
+	if err != nil {
+		return nil, err
+	}
+	return models.NewCategoryCatalog(categories), nil
+}
+
+// ensureCatalog stores the default catalog before the user's first change, so
+// the defaults can be edited, renamed and deleted like their own categories
+func (s *categoryService) ensureCatalog(ctx context.Context, userID string) (models.CategoryCatalog, error) {
+	categories, err := s.repo.ListCategories(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categories: %w", err)
+	}
+	if len(categories) > 0 {
+		return models.NewCategoryCatalog(categories), nil
+	}
+
+	categories = models.DefaultCategories(userID)
+	now := time.Now()
+	for i := range categories {
+		categories[i].CreatedAt = now
+		categories[i].UpdatedAt = now
+		// A concurrent first change may have stored it already
+		if err := s.repo.CreateCategory(ctx, &categories[i]); err != nil && !errors.Is(err, repository.ErrCategoryExists) {
+			return nil, err
+		}
+	}
+	return models.NewCategoryCatalog(categories), nil
+}
+
+// ListCategories returns the catalog with each subcategory after its parent
+func (s *categoryService) ListCategories(ctx context.Context, userID string) ([]models.Category, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	categories, err := userCategories(ctx, s.repo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	group := func(c models.Category) string {
+		if c.Parent != "" {
+			return c.Parent
+		}
+		return c.ID
+	}
+	sort.Slice(categories, func(i, j int) bool {
+		gi, gj := group(categories[i]), group(categories[j])
+		if gi != gj {
+			return gi < gj
+		}
+		if (categories[i].Parent == "") != (categories[j].Parent == "") {
+			return categories[i].Parent == ""
+		}
+		return categories[i].ID < categories[j].ID
+	})
+	return categories, nil
+}
+
+func (s *categoryService) GetCategory(ctx context.Context, userID, categoryID string) (*models.Category, error) {
+	if userID == "" || categoryID == "" {
+		return nil, fmt.Errorf("userID and categoryID are required")
+	}
+
+	catalog, err := loadCatalog(ctx, s.repo, userID)
+	if err != nil {
+		return nil, err
+	}
+	category, ok := catalog.Lookup(categoryID)
+	if !ok {
+		return nil, repository.ErrCategoryNotFound
+	}
+	return &category, nil
+}
+
+// CreateCategory adds a category to the catalog
+func (s *categoryService) CreateCategory(ctx context.Context, category *models.Category) error {
+	if category == nil {
+		return fmt.Errorf("%w: category cannot be nil", ErrInvalidCategory)
+	}
+	if err := category.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCategory, err)
+	}
+
+	catalog, err := s.ensureCatalog(ctx, category.UserID)
+	if err != nil {
+		return err
+	}
+	if _, exists := catalog[category.ID]; exists {
+		return repository.ErrCategoryExists
+	}
+	if len(catalog) >= models.MaxCategoriesPerUser {
+		return fmt.Errorf("%w: at most %d categories are allowed", ErrInvalidCategory, models.MaxCategoriesPerUser)
+	}
+	if err := checkParent(catalog, category); err != nil {
+		return err
+	}
+
+	now := time.Now()
+	category.CreatedAt = now
+	category.UpdatedAt = now
+	category.Version = 1
+	category.GenerateKeys()
+
+	return s.repo.CreateCategory(ctx, category)
+}
+
+// UpdateCategory replaces the names, color, icon and parent of a category.
+// Its ID only changes through RenameCategory.
+func (s *categoryService) UpdateCategory(ctx context.Context, category *models.Category) error {
+	if category == nil {
+		return fmt.Errorf("%w: category cannot be nil", ErrInvalidCategory)
+	}
+	if err := category.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCategory, err)
+	}
+
+	catalog, err := s.ensureCatalog(ctx, category.UserID)
+	if err != nil {
+		return err
+	}
+	existing, ok := catalog[category.ID]
+	if !ok {
+		return repository.ErrCategoryNotFound
+	}
+	if err := checkParent(catalog, category); err != nil {
+		return err
+	}
+
+	category.CreatedAt = existing.CreatedAt
+	category.Version = existing.Version
+
+	return s.repo.UpdateCategory(ctx, category)
+}
+
+// DeleteCategory removes a category without subcategories from the catalog.
+// Transactions keep it and are reported under it as an uncataloged category.
+func (s *categoryService) DeleteCategory(ctx context.Context, userID, categoryID string) error {
+	if userID == "" || categoryID == "" {
+		return fmt.Errorf("userID and categoryID are required")
+	}
+
+	catalog, err := s.ensureCatalog(ctx, userID)
+	if err != nil {
+		return err
+	}
+	category, ok := catalog.Lookup(categoryID)
+	if !ok {
+		return repository.ErrCategoryNotFound
+	}
+	if len(catalog.Children(category.ID)) > 0 {
+		return fmt.Errorf("%w: move or delete the subcategories of %s first", ErrInvalidCategory, category.ID)
+	}
+
+	return s.repo.DeleteCategory(ctx, userID, category.ID)
+}
+
+// RenameCategory changes the ID of a category, moving its transactions,
+// subcategories, rules, recurring transactions and budgets to the new ID. A
+// rename that fails halfway is completed by merging the old ID into the new
+// one.
+func (s *categoryService) RenameCategory(ctx context.Context, userID, categoryID, newID string) (*models.CategoryChange, error) {
+	if userID == "" || categoryID == "" {
+		return nil, fmt.Errorf("userID and categoryID are required")
+	}
+	newID = models.NormalizeCategoryID(newID)
+	if err := models.ValidateCategoryID(newID); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidCategory, err)
+	}
+
+	catalog, err := s.ensureCatalog(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	category, ok := catalog.Lookup(categoryID)
+	if !ok {
+		return nil, repository.ErrCategoryNotFound
+	}
+	if _, exists := catalog[newID]; exists {
+		return nil, fmt.Errorf("%w: %s; merge into it instead", repository.ErrCategoryExists, newID)
+	}
+
+	renamed := category
+	now := time.Now()
+	renamed.ID = newID
+	renamed.CreatedAt = now
+	renamed.UpdatedAt = now
+	renamed.Version = 1
+	if err := s.repo.CreateCategory(ctx, &renamed); err != nil {
+		return nil, err
+	}
+	catalog[newID] = renamed
+
+	return s.moveCategory(ctx, userID, catalog, category.ID, newID)
+}
+
+// MergeCategory moves everything in a category to another one and removes it
+// from the catalog. A budget of the merged category is added to the other
+// category's budget of the same month.
+func (s *categoryService) MergeCategory(ctx context.Context, userID, categoryID, intoID string) (*models.CategoryChange, error) {
+	if userID == "" || categoryID == "" || intoID == "" {
+		return nil, fmt.Errorf("userID, categoryID and intoID are required")
+	}
+
+	catalog, err := s.ensureCatalog(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	source, ok := catalog.Lookup(categoryID)
+	if !ok {
+		return nil, repository.ErrCategoryNotFound
+	}
+	target, ok := catalog.Lookup(intoID)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", repository.ErrCategoryNotFound, models.NormalizeCategoryID(intoID))
+	}
+	if source.ID == target.ID {
+		return nil, fmt.Errorf("%w: a category cannot be merged into itself", ErrInvalidCategory)
+	}
+	if target.Parent != "" && target.Parent != source.ID && len(catalog.Children(source.ID)) > 0 {
+		return nil, fmt.Errorf("%w: a category with subcategories cannot be merged into a subcategory", ErrInvalidCategory)
+	}
+
+	return s.moveCategory(ctx, userID, catalog, source.ID, target.ID)
+}
+
+// moveCategory rewrites every reference to the category from to the category
+// to and deletes from. The whole history is walked rather than the category's
+// GSI2 partition, which only indexes a transaction's main category and not
+// the categories of its splits.
+func (s *categoryService) moveCategory(ctx context.Context, userID string, catalog models.CategoryCatalog, from, to string) (*models.CategoryChange, error) {
+	change := &models.CategoryChange{From: from, To: to}
+
+	for _, id := range catalog.Children(from) {
+		child := catalog[id]
+		if id == to {
+			// Merging a parent into one of its subcategories promotes it
+			child.Parent = catalog[from].Parent
+		} else {
+			child.Parent = to
+		}
+		if err := s.repo.UpdateCategory(ctx, &child); err != nil {
+			return nil, fmt.Errorf("failed to move subcategory %s: %w", id, err)
+		}
+		change.Children++
+	}
+
+	err := repository.ForEachTransaction(ctx, repository.IterateTransactionsByUser(s.repo, userID), func(tx models.Transaction) error {
+		// Transfer entries keep their own category
+		if tx.IsTransfer() || !tx.RenameCategory(from, to) {
+			return nil
+		}
+		if err := s.repo.UpdateTransaction(ctx, &tx); err != nil {
+			return fmt.Errorf("failed to update transaction %s: %w", tx.ID, err)
+		}
+		change.Transactions++
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rules, err := s.repo.ListRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range rules {
+		if rules[i].Category == "" || models.NormalizeCategoryID(rules[i].Category) != from {
+			continue
+		}
+		rules[i].Category = to
+		if err := s.repo.UpdateRule(ctx, &rules[i]); err != nil {
+			return nil, fmt.Errorf("failed to update rule %s: %w", rules[i].ID, err)
+		}
+		change.Rules++
+	}
+
+	recurring, err := s.repo.ListRecurringTransactions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range recurring {
+		if models.NormalizeCategoryID(recurring[i].Category) != from {
+			continue
+		}
+		recurring[i].Category = to
+		if err := s.repo.UpdateRecurringTransaction(ctx, &recurring[i]); err != nil {
+			return nil, fmt.Errorf("failed to update recurring transaction %s: %w", recurring[i].ID, err)
+		}
+		change.Recurring++
+	}
+
+	budgets, err := s.moveBudgets(ctx, userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	change.Budgets = budgets
+
+	if err := s.repo.DeleteCategory(ctx, userID, from); err != nil && !errors.Is(err, repository.ErrCategoryNotFound) {
+		return nil, err
+	}
+	return change, nil
+}
+
+// moveBudgets rewrites the budgets of the category from as budgets of the
+// category to, adding them to the budget to already has that month. The
+// added amount is converted to the other budget's currency at the rate of the
+// month's first day. Returns how many budgets were moved.
+func (s *categoryService) moveBudgets(ctx context.Context, userID, from, to string) (int, error) {
+	budgets, err := s.repo.ListBudgets(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	targets := make(map[string]models.Budget)
+	for _, budget := range budgets {
+		if models.NormalizeCategoryID(budget.Category) == to {
+			targets[budget.Month] = budget
+		}
+	}
+
+	moved := 0
+	for _, budget := range budgets {
+		if models.NormalizeCategoryID(budget.Category) != from {
+			continue
+		}
+
+		merged := budget
+		merged.Category = to
+		if target, ok := targets[budget.Month]; ok {
+			amount := budget.Amount
+			if amount.Currency != target.Amount.Currency {
+				monthStart, err := time.Parse(monthLayout, budget.Month)
+				if err != nil {
+					return moved, fmt.Errorf("invalid budget month %q: %w", budget.Month, err)
+				}
+				if amount, err = s.converter.Convert(amount, target.Amount.Currency, monthStart); err != nil {
+					return moved, fmt.Errorf("budget %s/%s: %w", budget.Month, budget.Category, err)
+				}
+			}
+			merged = target
+			merged.Amount = target.Amount.Add(amount)
+		}
+
+		if err := s.repo.CreateOrUpdateBudget(ctx, &merged); err != nil {
+			return moved, fmt.Errorf("failed to move budget %s/%s: %w", budget.Month, budget.Category, err)
+		}
+		targets[budget.Month] = merged
+		if err := s.repo.DeleteBudget(ctx, userID, budget.Month, budget.Category); err != nil && !errors.Is(err, repository.ErrBudgetNotFound) {
+			return moved, fmt.Errorf("failed to delete budget %s/%s: %w", budget.Month, budget.Category, err)
+		}
+		moved++
+	}
+	return moved, nil
+}
+
+// checkParent keeps the catalog two levels deep: the parent must be a
+// top-level category, and a category with subcategories cannot get a parent
+func checkParent(catalog models.CategoryCatalog, category *models.Category) error {
+	if category.Parent == "" {
+		return nil
+	}
+	parent, ok := catalog[category.Parent]
+	if !ok {
+		return fmt.Errorf("%w: parent %s is not in the catalog", ErrInvalidCategory, category.Parent)
+	}
+	if parent.Parent != "" {
+		return fmt.Errorf("%w: parent %s is itself a subcategory", ErrInvalidCategory, category.Parent)
+	}
+	if len(catalog.Children(category.ID)) > 0 {
+		return fmt.Errorf("%w: %s has subcategories and cannot have a parent", ErrInvalidCategory, category.ID)
+	}
+	return nil
+}
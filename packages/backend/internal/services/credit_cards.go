@@ -0,0 +1,201 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// ErrNotCreditCard is returned for the statements of an account without
+// credit card terms
+var ErrNotCreditCard = errors.New("account is not a credit card with billing terms")
+
+// DefaultCardStatements is the number of cycles returned when none is asked for
+const DefaultCardStatements = 6
+
+// GetCardStatements returns the credit card's last cycles as of the given
+// time, the cycle in progress first
+func (s *accountService) GetCardStatements(ctx context.Context, userID, accountID string, cycles int, asOf time.Time) (*models.CreditCardStatements, error) {
+	if userID == "" || accountID == "" {
+		return nil, fmt.Errorf("userID and accountID are required")
+	}
+	if cycles == 0 {
+		cycles = DefaultCardStatements
+	}
+	if cycles < 1 || cycles > models.MaxCardStatements {
+		return nil, fmt.Errorf("%w: cycles must be between 1 and %d", ErrInvalidAccount, models.MaxCardStatements)
+	}
+
+	account, err := s.repo.GetAccount(ctx, userID, accountID)
+	if err != nil {
+		return nil, err
+	}
+	if account.CreditCard == nil {
+		return nil, ErrNotCreditCard
+	}
+
+	transactions, err := accountTransactions(ctx, s.repo, s.converter, account)
+	if err != nil {
+		return nil, err
+	}
+	return buildCardStatements(account, transactions, asOf, cycles), nil
+}
+
+// UpcomingPayments returns the unpaid statements of the user's credit cards
+// due on or after the given day, soonest first
+func (s *accountService) UpcomingPayments(ctx context.Context, userID string, asOf time.Time) ([]models.PaymentDue, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+	return upcomingPayments(ctx, s.repo, s.converter, userID, asOf)
+}
+
+func upcomingPayments(ctx context.Context, repo repository.Repository, converter *CurrencyConverter, userID string, asOf time.Time) ([]models.PaymentDue, error) {
+	accounts, err := repo.ListAccounts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+
+	payments := []models.PaymentDue{}
+	for i := range accounts {
+		account := &accounts[i]
+		if account.CreditCard == nil {
+			continue
+		}
+		transactions, err := accountTransactions(ctx, repo, converter, account)
+		if err != nil {
+			return nil, err
+		}
+		// The cycle in progress and the last closed one, whose payment may be pending
+		card := buildCardStatements(account, transactions, asOf, 2)
+		if due := paymentDue(account, card.Statements[1], asOf); due != nil {
+			payments = append(payments, *due)
+		}
+	}
+
+	sort.Slice(payments, func(i, j int) bool {
+		if !payments[i].DueDate.Equal(payments[j].DueDate) {
+			return payments[i].DueDate.Before(payments[j].DueDate)
+		}
+		return payments[i].AccountName < payments[j].AccountName
+	})
+	return payments, nil
+}
+
+// paymentDue reports a closed statement still to be paid on or after asOf
+func paymentDue(account *models.Account, statement models.CardStatement, asOf time.Time) *models.PaymentDue {
+	today := startOfDay(asOf)
+	if !statement.Closed || statement.DueDate.Before(today) || !statement.AmountDue.IsPositive() {
+		return nil
+	}
+	return &models.PaymentDue{
+		AccountID:        account.ID,
+		AccountName:      account.Name,
+		DueDate:          statement.DueDate,
+		DaysUntilDue:     int(statement.DueDate.Sub(today).Hours() / 24),
+		StatementBalance: statement.StatementBalance,
+		MinimumPayment:   statement.MinimumPayment,
+		AmountDue:        statement.AmountDue,
+	}
+}
+
+func startOfDay(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// buildCardStatements computes the last cycles of a card, newest first, from
+// its transactions sorted oldest first and in the account's currency. Amounts
+// owed are positive: charges are the card's negative transactions and
+// payments its positive ones.
+func buildCardStatements(account *models.Account, transactions []models.Transaction, asOf time.Time, cycles int) *models.CreditCardStatements {
+	terms := account.CreditCard
+	currency := account.Currency
+	limit := models.NewMoney(terms.CreditLimit.Minor, currency)
+
+	// owedBefore is the debt at the start of the day
+	owedBefore := func(day time.Time) models.Money {
+		owed := account.OpeningBalance.Neg()
+		for _, tx := range transactions {
+			if !tx.Date.Before(day) {
+				break
+			}
+			owed = owed.Sub(tx.Amount)
+		}
+		return models.NewMoney(owed.Minor, currency)
+	}
+
+	result := &models.CreditCardStatements{
+		Account:    *account,
+		Statements: make([]models.CardStatement, 0, cycles),
+	}
+	owed := account.OpeningBalance.Neg()
+	for _, tx := range transactions {
+		owed = owed.Sub(tx.Amount)
+	}
+	result.CurrentBalance = models.NewMoney(owed.Minor, currency)
+	result.AvailableCredit = limit.Sub(result.CurrentBalance)
+	result.Utilization = result.CurrentBalance.Ratio(limit) * 100
+
+	today := startOfDay(asOf)
+	closing := terms.ClosingOn(today)
+	for i := 0; i < cycles; i++ {
+		previous := terms.PreviousClosing(closing)
+		start := previous.AddDate(0, 0, 1)
+		end := closing.AddDate(0, 0, 1)
+		due := terms.DueDate(closing)
+
+		statement := models.CardStatement{
+			PeriodStart:      start,
+			ClosingDate:      closing,
+			DueDate:          due,
+			Closed:           !today.Before(end),
+			PreviousBalance:  owedBefore(start),
+			Charges:          models.ZeroMoney(currency),
+			Payments:         models.ZeroMoney(currency),
+			PaidSinceClosing: models.ZeroMoney(currency),
+		}
+		for _, tx := range transactions {
+			switch {
+			case tx.Date.Before(start):
+				continue
+			case tx.Date.Before(end):
+				if tx.Amount.IsNegative() {
+					statement.Charges = statement.Charges.Sub(tx.Amount)
+				} else {
+					statement.Payments = statement.Payments.Add(tx.Amount)
+				}
+			case tx.Date.Before(due.AddDate(0, 0, 1)) && tx.Amount.IsPositive():
+				statement.PaidSinceClosing = statement.PaidSinceClosing.Add(tx.Amount)
+			}
+		}
+		statement.StatementBalance = statement.PreviousBalance.Add(statement.Charges).Sub(statement.Payments)
+		statement.MinimumPayment = models.MinimumPayment(statement.StatementBalance, limit)
+		statement.AmountDue = statement.StatementBalance.Sub(statement.PaidSinceClosing)
+		if !statement.AmountDue.IsPositive() {
+			statement.AmountDue = models.ZeroMoney(currency)
+		}
+		statement.ProjectedInterest = projectedInterest(statement.AmountDue, terms, closing)
+		statement.Utilization = statement.StatementBalance.Ratio(limit) * 100
+
+		result.Statements = append(result.Statements, statement)
+		closing = previous
+	}
+	return result
+}
+
+// projectedInterest estimates the interest an unpaid balance accrues over the
+// cycle after the one closing on closing, at the card's daily rate
+func projectedInterest(unpaid models.Money, terms *models.CreditCardTerms, closing time.Time) models.Money {
+	if !unpaid.IsPositive() || terms.APR == 0 {
+		return models.ZeroMoney(unpaid.Currency)
+	}
+	next := terms.ClosingOn(closing.AddDate(0, 0, 1))
+	days := next.Sub(closing).Hours() / 24
+	return unpaid.MulFloat(terms.APR / 100 / 365 * days)
+}
@@ -0,0 +1,113 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/rates"
+	"backend/internal/repository"
+)
+
+// CurrencyConverter expresses transaction amounts in a user's base currency
+type CurrencyConverter struct {
+	repo  repository.Repository
+	rates *rates.Store
+}
+
+// NewCurrencyConverter creates a converter backed by the given rate store
+func NewCurrencyConverter(repo repository.Repository, store *rates.Store) *CurrencyConverter {
+	if store == nil {
+		store = rates.NewStore()
+	}
+	return &CurrencyConverter{
+		repo:  repo,
+		rates: store,
+	}
+}
+
+// BaseCurrency returns the user's reporting currency. Users without a stored
+// profile report in the default currency; any other failure to read the
+// profile is returned rather than guessed at.
+func (c *CurrencyConverter) BaseCurrency(ctx context.Context, userID string) (string, error) {
+	user, err := c.repo.GetUser(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		return models.DefaultCurrency, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get base currency: %w", err)
+	}
+	return user.Currency(), nil
+}
+
+// Convert expresses amount in the base currency using the rate on the given date
+func (c *CurrencyConverter) Convert(amount models.Money, base string, on time.Time) (models.Money, error) {
+	converted, err := c.rates.Convert(amount, base, on)
+	if err != nil {
+		return models.Money{}, fmt.Errorf("failed to convert %s %s to %s: %w", amount, amount.Currency, base, err)
+	}
+	return converted, nil
+}
+
+// ToBase converts the transaction amount using the rate on the transaction date
+func (c *CurrencyConverter) ToBase(tx models.Transaction, base string) (models.Money, error) {
+	return c.Convert(tx.Amount, base, tx.Date)
+}
+
+// InBase returns a copy of the transaction with its amount and splits in the
+// base currency. The largest split absorbs the rounding, so the splits still
+// add up to the converted amount.
+func (c *CurrencyConverter) InBase(tx models.Transaction, base string) (models.Transaction, error) {
+	amount, err := c.ToBase(tx, base)
+	if err != nil {
+		return models.Transaction{}, fmt.Errorf("transaction %s: %w", tx.ID, err)
+	}
+
+	if len(tx.Splits) > 0 && tx.Amount.Currency != base {
+		splits := make([]models.Split, len(tx.Splits))
+		total := models.ZeroMoney(base)
+		largest := 0
+		for i, split := range tx.Splits {
+			split.Amount, err = c.Convert(split.Amount, base, tx.Date)
+			if err != nil {
+				return models.Transaction{}, fmt.Errorf("transaction %s: %w", tx.ID, err)
+			}
+			if split.Amount.Abs().Cmp(splits[largest].Amount.Abs()) > 0 {
+				largest = i
+			}
+			total = total.Add(split.Amount)
+			splits[i] = split
+		}
+		splits[largest].Amount = splits[largest].Amount.Add(amount.Sub(total))
+		tx.Splits = splits
+	}
+	tx.Amount = amount
+	return tx, nil
+}
+
+// ForEachInBase streams the iterator's transactions to fn with amounts already
+// converted to the base currency
+func (c *CurrencyConverter) ForEachInBase(ctx context.Context, it *repository.TransactionIterator, base string, fn func(models.Transaction) error) error {
+	return repository.ForEachTransaction(ctx, it, func(tx models.Transaction) error {
+		converted, err := c.InBase(tx, base)
+		if err != nil {
+			return err
+		}
+		return fn(converted)
+	})
+}
+
+// ConvertTransactions returns copies of the transactions with amounts in the base currency
+func (c *CurrencyConverter) ConvertTransactions(transactions []models.Transaction, base string) ([]models.Transaction, error) {
+	converted := make([]models.Transaction, len(transactions))
+	for i, tx := range transactions {
+		tx, err := c.InBase(tx, base)
+		if err != nil {
+			return nil, err
+		}
+		converted[i] = tx
+	}
+	return converted, nil
+}
@@ -0,0 +1,98 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// transactionsBetween returns the user's transactions dated from from
+// (inclusive) to to (exclusive), reading only that range of each month
+func transactionsBetween(ctx context.Context, repo repository.Repository, userID string, from, to time.Time) ([]models.Transaction, error) {
+	var transactions []models.Transaction
+	last := to.Add(-time.Nanosecond)
+	for month := time.Date(from.Year(), from.Month(), 1, 0, 0, 0, 0, time.UTC); month.Before(to); month = month.AddDate(0, 1, 0) {
+		iterator := repository.IterateTransactionsByMonthBetween(repo, userID, month.Format("2006-01"), from, last)
+		err := repository.ForEachTransaction(ctx, iterator, func(tx models.Transaction) error {
+			if !tx.Date.Before(from) && tx.Date.Before(to) {
+				transactions = append(transactions, tx)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get transactions from %s to %s: %w", from.Format("2006-01-02"), to.Format("2006-01-02"), err)
+		}
+	}
+	return transactions, nil
+}
+
+// duplicateWindow returns the dates a likely duplicate of a transaction dated
+// date may have, as a half-open range of whole days
+func duplicateWindow(date time.Time) (time.Time, time.Time) {
+	date = date.UTC()
+	day := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	return day.AddDate(0, 0, -models.DuplicateWindowDays), day.AddDate(0, 0, models.DuplicateWindowDays+1)
+}
+
+// findLikelyDuplicate returns the stored transaction tx most likely repeats,
+// the closest one in date, or nil
+func findLikelyDuplicate(stored []models.Transaction, tx *models.Transaction) *models.Transaction {
+	var best *models.Transaction
+	for i := range stored {
+		candidate := &stored[i]
+		if !tx.IsLikelyDuplicate(candidate) {
+			continue
+		}
+		if best == nil || tx.DaysApart(candidate) < tx.DaysApart(best) {
+			best = candidate
+		}
+	}
+	return best
+}
+
+// duplicateKey groups transactions that can be likely duplicates of each other
+func duplicateKey(tx *models.Transaction) string {
+	return tx.Type + "|" + tx.Amount.Currency + "|" + tx.Amount.String() + "|" + models.FoldDescription(tx.Description)
+}
+
+// findDuplicatePairs pairs each transaction with the earlier one it likely
+// repeats. Three copies of a movement yield two pairs with the same original.
+func findDuplicatePairs(transactions []models.Transaction) []models.DuplicatePair {
+	groups := make(map[string][]models.Transaction)
+	for _, tx := range transactions {
+		key := duplicateKey(&tx)
+		groups[key] = append(groups[key], tx)
+	}
+
+	pairs := []models.DuplicatePair{}
+	for _, group := range groups {
+		sort.Slice(group, func(i, j int) bool {
+			if !group[i].Date.Equal(group[j].Date) {
+				return group[i].Date.Before(group[j].Date)
+			}
+			return group[i].CreatedAt.Before(group[j].CreatedAt)
+		})
+
+		original := 0
+		for i := 1; i < len(group); i++ {
+			if !group[i].IsLikelyDuplicate(&group[original]) {
+				original = i
+				continue
+			}
+			pairs = append(pairs, models.DuplicatePair{
+				Original:  group[original],
+				Duplicate: group[i],
+				DaysApart: group[i].DaysApart(&group[original]),
+			})
+		}
+	}
+
+	sort.Slice(pairs, func(i, j int) bool {
+		return pairs[i].Duplicate.Date.After(pairs[j].Duplicate.Date)
+	})
+	return pairs
+}
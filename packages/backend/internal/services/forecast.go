@@ -0,0 +1,418 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// ErrInvalidForecast is returned for forecast horizons and backtests out of range
+var ErrInvalidForecast = errors.New("invalid forecast")
+
+// forecastHistory is what a forecast is built from: the user's transactions
+// in the base currency without transfers, oldest first, the transfer entries,
+// the active recurring transactions and the accounts
+type forecastHistory struct {
+	base         string
+	transactions []models.Transaction
+	transfers    []models.Transaction
+	rules        []models.RecurringTransaction
+	accounts     []models.Account
+}
+
+// GetCashFlowForecast projects the user's balance over the days after asOf
+// from their recurring transactions, detected subscriptions and income
+// payments, and average daily spend and income per category
+func (s *analyticsService) GetCashFlowForecast(ctx context.Context, userID string, days int, asOf time.Time) (*models.CashFlowForecast, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+	if days == 0 {
+		days = models.DefaultForecastDays
+	}
+	if days < 1 || days > models.MaxForecastDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", ErrInvalidForecast, models.MaxForecastDays)
+	}
+
+	history, err := s.loadForecastHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.forecast(history, asOf, days)
+}
+
+// BacktestForecast forecasts each of the complete months before asOf's month
+// from the history before it, and scores the forecast against what happened.
+// Recurring transactions are the current ones.
+func (s *analyticsService) BacktestForecast(ctx context.Context, userID string, months int, asOf time.Time) (*models.ForecastBacktest, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+	if months == 0 {
+		months = models.DefaultBacktestMonths
+	}
+	if months < 1 || months > models.MaxBacktestMonths {
+		return nil, fmt.Errorf("%w: months must be between 1 and %d", ErrInvalidForecast, models.MaxBacktestMonths)
+	}
+
+	history, err := s.loadForecastHistory(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	base := history.base
+
+	result := &models.ForecastBacktest{
+		Currency: base,
+		Months:   make([]models.BacktestMonth, 0, months),
+	}
+	asOf = asOf.UTC()
+	current := time.Date(asOf.Year(), asOf.Month(), 1, 0, 0, 0, 0, time.UTC)
+	errorSum, scored := 0.0, 0
+	for i := months; i >= 1; i-- {
+		start := current.AddDate(0, -i, 0)
+		end := start.AddDate(0, 1, 0)
+
+		forecast, err := s.forecast(history, start.Add(-time.Nanosecond), int(end.Sub(start).Hours()/24))
+		if err != nil {
+			return nil, err
+		}
+
+		month := models.BacktestMonth{
+			Month:             start.Format(monthLayout),
+			PredictedIncome:   forecast.ExpectedIncome,
+			PredictedExpenses: forecast.ExpectedExpenses,
+			ActualIncome:      models.ZeroMoney(base),
+			ActualExpenses:    models.ZeroMoney(base),
+		}
+		for _, tx := range history.transactions {
+			if tx.Date.Before(start) || !tx.Date.Before(end) {
+				continue
+			}
+			switch tx.Type {
+			case models.TransactionTypeIncome:
+				month.ActualIncome = month.ActualIncome.Add(tx.Amount.Abs())
+			case models.TransactionTypeExpense:
+				month.ActualExpenses = month.ActualExpenses.Add(tx.Amount.Abs())
+			}
+		}
+		month.PredictedNet = month.PredictedIncome.Sub(month.PredictedExpenses)
+		month.ActualNet = month.ActualIncome.Sub(month.ActualExpenses)
+
+		if flow := month.ActualIncome.Add(month.ActualExpenses); flow.IsPositive() {
+			missed := month.PredictedIncome.Sub(month.ActualIncome).Abs().Add(month.PredictedExpenses.Sub(month.ActualExpenses).Abs())
+			percent := missed.Ratio(flow) * 100
+			month.ErrorPercent = &percent
+			errorSum += percent
+			scored++
+		}
+		result.Months = append(result.Months, month)
+	}
+
+	if scored > 0 {
+		mean := errorSum / float64(scored)
+		accuracy := math.Max(100-mean, 0)
+		result.MeanErrorPercent = &mean
+		result.Accuracy = &accuracy
+	}
+	return result, nil
+}
+
+func (s *analyticsService) loadForecastHistory(ctx context.Context, userID string) (*forecastHistory, error) {
+	base, err := s.converter.BaseCurrency(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	history := &forecastHistory{base: base}
+
+	err = s.converter.ForEachInBase(ctx, repository.IterateTransactionsByUser(s.repo, userID), history.base, func(tx models.Transaction) error {
+		if tx.IsTransfer() {
+			history.transfers = append(history.transfers, tx)
+		} else {
+			history.transactions = append(history.transactions, tx)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user transactions: %w", err)
+	}
+	sort.SliceStable(history.transactions, func(i, j int) bool {
+		return history.transactions[i].Date.Before(history.transactions[j].Date)
+	})
+
+	rules, err := s.repo.ListRecurringTransactions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list recurring transactions: %w", err)
+	}
+	for _, rule := range rules {
+		if rule.Active {
+			history.rules = append(history.rules, rule)
+		}
+	}
+
+	history.accounts, err = s.repo.ListAccounts(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %w", err)
+	}
+	return history, nil
+}
+
+// forecast projects the balance over the days after asOf's day using only the
+// transactions up to asOf. Recurring transactions, detected subscriptions and
+// income payments that repeat on a cadence fall on their expected dates; the
+// rest of the spending and income is spread evenly at each category's average
+// over the last days.
+func (s *analyticsService) forecast(history *forecastHistory, asOf time.Time, days int) (*models.CashFlowForecast, error) {
+	base := history.base
+	today := startOfDay(asOf)
+	first := today.AddDate(0, 0, 1)
+	end := first.AddDate(0, 0, days)
+
+	balance, err := s.startingBalance(history, asOf)
+	if err != nil {
+		return nil, err
+	}
+	var past, expenses, income []models.Transaction
+	for _, tx := range history.transactions {
+		if tx.Date.After(asOf) {
+			break
+		}
+		past = append(past, tx)
+		switch tx.Type {
+		case models.TransactionTypeIncome:
+			// Income a recurring transaction generated is projected by it
+			if tx.RecurringID == "" {
+				income = append(income, tx)
+			}
+		case models.TransactionTypeExpense:
+			expenses = append(expenses, tx)
+		}
+	}
+
+	result := &models.CashFlowForecast{
+		Currency:         base,
+		AsOf:             today,
+		Days:             days,
+		StartingBalance:  balance,
+		ExpectedIncome:   models.ZeroMoney(base),
+		ExpectedExpenses: models.ZeroMoney(base),
+		DailySpending:    []models.CategorySpendRate{},
+		DailyIncome:      []models.CategorySpendRate{},
+		Balances:         make([]models.ForecastDay, days),
+	}
+	for i := range result.Balances {
+		result.Balances[i] = models.ForecastDay{
+			Date:     first.AddDate(0, 0, i),
+			Income:   models.ZeroMoney(base),
+			Expenses: models.ZeroMoney(base),
+		}
+	}
+	addEvent := func(date time.Time, event models.ForecastEvent) {
+		day := startOfDay(date)
+		if day.Before(first) || !day.Before(end) {
+			return
+		}
+		projected := &result.Balances[int(day.Sub(first).Hours()/24)]
+		projected.Events = append(projected.Events, event)
+		if event.Amount.IsNegative() {
+			projected.Expenses = projected.Expenses.Sub(event.Amount)
+		} else {
+			projected.Income = projected.Income.Add(event.Amount)
+		}
+	}
+
+	// Descriptions whose charges are projected on their dates rather than
+	// in the daily spend
+	scheduled := make(map[string]bool)
+	for _, rule := range history.rules {
+		scheduled[normalizeDescription(rule.Description)] = true
+		for n := 0; rule.Count == 0 || n < rule.Count; n++ {
+			occurrence := rule.Occurrence(n)
+			if rule.EndDate != nil && !occurrence.Before(rule.EndDate.AddDate(0, 0, 1)) {
+				break
+			}
+			if !startOfDay(occurrence).Before(end) {
+				break
+			}
+			if !startOfDay(occurrence).After(today) {
+				continue
+			}
+			amount, err := s.converter.Convert(rule.Amount.Abs(), base, occurrence)
+			if err != nil {
+				return nil, fmt.Errorf("recurring transaction %s: %w", rule.ID, err)
+			}
+			if rule.Type == models.TransactionTypeExpense {
+				amount = amount.Neg()
+			}
+			addEvent(occurrence, models.ForecastEvent{
+				Source:      models.ForecastSourceRecurring,
+				Description: rule.Description,
+				Category:    rule.Category,
+				Amount:      amount,
+			})
+		}
+	}
+
+	// Subscriptions and income payments a recurring transaction already
+	// covers are left out. Income repeats like a subscription does: a salary
+	// paid on the same day each month is detected as a monthly series.
+	var detected []string
+	for _, series := range []struct {
+		source       string
+		transactions []models.Transaction
+		sign         func(models.Money) models.Money
+	}{
+		{models.ForecastSourceSubscription, expenses, models.Money.Neg},
+		{models.ForecastSourceIncome, income, models.Money.Abs},
+	} {
+		for _, subscription := range detectSubscriptions(series.transactions, base, asOf) {
+			key := normalizeDescription(subscription.Name)
+			if !subscription.Active || scheduled[key] {
+				continue
+			}
+			detected = append(detected, key)
+			next := cadenceNamed(subscription.Cadence).next
+			for date := subscription.NextExpected; startOfDay(date).Before(end); date = next(date) {
+				addEvent(date, models.ForecastEvent{
+					Source:      series.source,
+					Description: subscription.Name,
+					Category:    subscription.Category,
+					Amount:      series.sign(subscription.LastAmount),
+				})
+			}
+		}
+	}
+	for _, key := range detected {
+		scheduled[key] = true
+	}
+
+	result.DailySpending = dailyRates(past, models.TransactionTypeExpense, scheduled, base, first)
+	result.DailyIncome = dailyRates(past, models.TransactionTypeIncome, scheduled, base, first)
+	daily := models.ZeroMoney(base)
+	for _, rate := range result.DailySpending {
+		daily = daily.Add(rate.DailyAmount)
+	}
+	dailyIncome := models.ZeroMoney(base)
+	for _, rate := range result.DailyIncome {
+		dailyIncome = dailyIncome.Add(rate.DailyAmount)
+	}
+
+	for i := range result.Balances {
+		day := &result.Balances[i]
+		day.Expenses = day.Expenses.Add(daily)
+		day.Income = day.Income.Add(dailyIncome)
+		balance = balance.Add(day.Income).Sub(day.Expenses)
+		day.Balance = balance
+
+		result.ExpectedIncome = result.ExpectedIncome.Add(day.Income)
+		result.ExpectedExpenses = result.ExpectedExpenses.Add(day.Expenses)
+		if i == 0 || balance.Cmp(result.LowestBalance) < 0 {
+			result.LowestBalance = balance
+			result.LowestBalanceDate = day.Date
+		}
+		if balance.IsNegative() && result.OverdraftDate == nil {
+			date := day.Date
+			result.OverdraftDate = &date
+			result.OverdraftRisk = true
+		}
+	}
+	result.EndingBalance = balance
+
+	return result, nil
+}
+
+// startingBalance is the sum of the balances of the user's accounts as of
+// asOf, with the opening balances converted at asOf's rate. Without accounts
+// it is the income minus the expenses up to asOf.
+func (s *analyticsService) startingBalance(history *forecastHistory, asOf time.Time) (models.Money, error) {
+	balance := models.ZeroMoney(history.base)
+	if len(history.accounts) == 0 {
+		for _, tx := range history.transactions {
+			if tx.Date.After(asOf) {
+				break
+			}
+			switch tx.Type {
+			case models.TransactionTypeIncome:
+				balance = balance.Add(tx.Amount.Abs())
+			case models.TransactionTypeExpense:
+				balance = balance.Sub(tx.Amount.Abs())
+			}
+		}
+		return balance, nil
+	}
+
+	accounts := make(map[string]bool, len(history.accounts))
+	for _, account := range history.accounts {
+		opening, err := s.converter.Convert(account.OpeningBalance, history.base, asOf)
+		if err != nil {
+			return models.Money{}, fmt.Errorf("account %s: %w", account.ID, err)
+		}
+		balance = balance.Add(opening)
+		accounts[account.ID] = true
+	}
+	// Transactions outside the accounts are not part of their balances
+	for _, transactions := range [][]models.Transaction{history.transactions, history.transfers} {
+		for _, tx := range transactions {
+			if accounts[tx.AccountID] && !tx.Date.After(asOf) {
+				balance = balance.Add(tx.Amount)
+			}
+		}
+	}
+	return balance, nil
+}
+
+// dailyRates averages each category's transactions of the type over the
+// ForecastLookbackDays before first, or since the first transaction when the
+// history is shorter. Transactions generated by recurring transactions or with
+// a scheduled description are projected on their own dates instead.
+func dailyRates(past []models.Transaction, txType string, scheduled map[string]bool, base string, first time.Time) []models.CategorySpendRate {
+	if len(past) == 0 {
+		return []models.CategorySpendRate{}
+	}
+
+	from := first.AddDate(0, 0, -models.ForecastLookbackDays)
+	lookback := models.ForecastLookbackDays
+	if oldest := startOfDay(past[0].Date); oldest.After(from) {
+		lookback = int(first.Sub(oldest).Hours() / 24)
+	}
+
+	totals := make(map[string]models.Money)
+	for _, tx := range past {
+		if tx.Type != txType || tx.Date.Before(from) || tx.RecurringID != "" ||
+			scheduled[normalizeDescription(tx.Description)] {
+			continue
+		}
+		for _, split := range tx.Allocations() {
+			totals[split.Category] = totals[split.Category].Add(split.Amount.Abs())
+		}
+	}
+
+	rates := []models.CategorySpendRate{}
+	for category, total := range totals {
+		if amount := models.NewMoney(total.Minor, base).DivInt(lookback); amount.IsPositive() {
+			rates = append(rates, models.CategorySpendRate{Category: category, DailyAmount: amount})
+		}
+	}
+	sort.Slice(rates, func(i, j int) bool {
+		if c := rates[i].DailyAmount.Cmp(rates[j].DailyAmount); c != 0 {
+			return c > 0
+		}
+		return rates[i].Category < rates[j].Category
+	})
+	return rates
+}
+
+// cadenceNamed returns the subscription cadence with the given name
+func cadenceNamed(name string) cadence {
+	for _, c := range cadences {
+		if c.name == name {
+			return c
+		}
+	}
+	return cadences[len(cadences)-1]
+}
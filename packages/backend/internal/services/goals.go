@@ -0,0 +1,373 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"backend/internal/models"
+	"backend/internal/rates"
+	"backend/internal/repository"
+)
+
+// ErrInvalidGoal is returned for goals and contributions that fail validation
+var ErrInvalidGoal = errors.New("invalid goal")
+
+type GoalService interface {
+	ListGoals(ctx context.Context, userID string) ([]models.Goal, error)
+	GetGoal(ctx context.Context, userID, goalID string) (*models.Goal, error)
+	CreateGoal(ctx context.Context, goal *models.Goal) error
+	UpdateGoal(ctx context.Context, goal *models.Goal) error
+	DeleteGoal(ctx context.Context, userID, goalID string) error
+	ListContributions(ctx context.Context, userID, goalID string) ([]models.GoalContribution, error)
+	AddContribution(ctx context.Context, contribution *models.GoalContribution) error
+	DeleteContribution(ctx context.Context, userID, goalID, contributionID string) error
+	GetProgress(ctx context.Context, userID, goalID string, asOf time.Time) (*models.GoalProgress, error)
+}
+
+type goalService struct {
+	repo      repository.Repository
+	converter *CurrencyConverter
+}
+
+func NewGoalService(repo repository.Repository) GoalService {
+	return NewGoalServiceWithRates(repo, nil)
+}
+
+// NewGoalServiceWithRates creates a goal service that converts linked
+// balances and savings in other currencies to each goal's currency
+func NewGoalServiceWithRates(repo repository.Repository, store *rates.Store) GoalService {
+	return &goalService{
+		repo:      repo,
+		converter: NewCurrencyConverter(repo, store),
+	}
+}
+
+// ListGoals returns the user's goals by name
+func (s *goalService) ListGoals(ctx context.Context, userID string) ([]models.Goal, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	goals, err := s.repo.ListGoals(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if goals == nil {
+		goals = []models.Goal{}
+	}
+
+	sort.Slice(goals, func(i, j int) bool {
+		return goals[i].Name < goals[j].Name
+	})
+	return goals, nil
+}
+
+// GetGoal returns one of the user's goals
+func (s *goalService) GetGoal(ctx context.Context, userID, goalID string) (*models.Goal, error) {
+	if userID == "" || goalID == "" {
+		return nil, fmt.Errorf("userID and goalID are required")
+	}
+	return s.repo.GetGoal(ctx, userID, goalID)
+}
+
+// CreateGoal stores a new goal with a generated ID
+func (s *goalService) CreateGoal(ctx context.Context, goal *models.Goal) error {
+	if goal == nil {
+		return fmt.Errorf("%w: goal cannot be nil", ErrInvalidGoal)
+	}
+	if err := goal.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGoal, err)
+	}
+	if err := s.checkAccount(ctx, goal); err != nil {
+		return err
+	}
+
+	existing, err := s.repo.ListGoals(ctx, goal.UserID)
+	if err != nil {
+		return err
+	}
+	if len(existing) >= models.MaxGoalsPerUser {
+		return fmt.Errorf("%w: at most %d goals are allowed", ErrInvalidGoal, models.MaxGoalsPerUser)
+	}
+
+	now := time.Now()
+	goal.ID = uuid.New().String()
+	goal.CreatedAt = now
+	goal.UpdatedAt = now
+	goal.Version = 1
+
+	return s.repo.CreateGoal(ctx, goal)
+}
+
+// UpdateGoal replaces the name, target and link of a goal. The currency
+// cannot change, since its contributions are recorded in it.
+func (s *goalService) UpdateGoal(ctx context.Context, goal *models.Goal) error {
+	if goal == nil {
+		return fmt.Errorf("%w: goal cannot be nil", ErrInvalidGoal)
+	}
+
+	existing, err := s.repo.GetGoal(ctx, goal.UserID, goal.ID)
+	if err != nil {
+		return err
+	}
+	if goal.Currency == "" {
+		goal.Currency = existing.Currency
+	}
+	if err := goal.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGoal, err)
+	}
+	if goal.Currency != existing.Currency {
+		return fmt.Errorf("%w: the currency of a goal cannot change", ErrInvalidGoal)
+	}
+	if err := s.checkAccount(ctx, goal); err != nil {
+		return err
+	}
+
+	goal.CreatedAt = existing.CreatedAt
+	goal.Version = existing.Version
+
+	return s.repo.UpdateGoal(ctx, goal)
+}
+
+// checkAccount verifies the account a goal is linked to exists
+func (s *goalService) checkAccount(ctx context.Context, goal *models.Goal) error {
+	if goal.AccountID == "" {
+		return nil
+	}
+	_, err := s.repo.GetAccount(ctx, goal.UserID, goal.AccountID)
+	if errors.Is(err, repository.ErrAccountNotFound) {
+		return fmt.Errorf("%w: account %s not found", ErrInvalidGoal, goal.AccountID)
+	}
+	return err
+}
+
+// DeleteGoal removes a goal with its contributions
+func (s *goalService) DeleteGoal(ctx context.Context, userID, goalID string) error {
+	if userID == "" || goalID == "" {
+		return fmt.Errorf("userID and goalID are required")
+	}
+
+	if _, err := s.repo.GetGoal(ctx, userID, goalID); err != nil {
+		return err
+	}
+
+	contributions, err := s.repo.ListGoalContributions(ctx, userID, goalID)
+	if err != nil {
+		return err
+	}
+	for _, contribution := range contributions {
+		err := s.repo.DeleteGoalContribution(ctx, userID, goalID, contribution.ID)
+		if err != nil && !errors.Is(err, repository.ErrGoalContributionNotFound) {
+			return err
+		}
+	}
+
+	return s.repo.DeleteGoal(ctx, userID, goalID)
+}
+
+// ListContributions returns the goal's contributions, newest first
+func (s *goalService) ListContributions(ctx context.Context, userID, goalID string) ([]models.GoalContribution, error) {
+	if userID == "" || goalID == "" {
+		return nil, fmt.Errorf("userID and goalID are required")
+	}
+
+	if _, err := s.repo.GetGoal(ctx, userID, goalID); err != nil {
+		return nil, err
+	}
+
+	contributions, err := s.repo.ListGoalContributions(ctx, userID, goalID)
+	if err != nil {
+		return nil, err
+	}
+	if contributions == nil {
+		contributions = []models.GoalContribution{}
+	}
+
+	sort.SliceStable(contributions, func(i, j int) bool {
+		return contributions[i].Date.After(contributions[j].Date)
+	})
+	return contributions, nil
+}
+
+// AddContribution records money put toward a goal, in the goal's currency
+func (s *goalService) AddContribution(ctx context.Context, contribution *models.GoalContribution) error {
+	if contribution == nil {
+		return fmt.Errorf("%w: contribution cannot be nil", ErrInvalidGoal)
+	}
+	if contribution.UserID == "" || contribution.GoalID == "" {
+		return fmt.Errorf("userID and goalID are required")
+	}
+
+	goal, err := s.repo.GetGoal(ctx, contribution.UserID, contribution.GoalID)
+	if err != nil {
+		return err
+	}
+	if err := contribution.Validate(goal.Currency); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidGoal, err)
+	}
+
+	contribution.ID = uuid.New().String()
+	contribution.CreatedAt = time.Now()
+
+	return s.repo.CreateGoalContribution(ctx, contribution)
+}
+
+// DeleteContribution removes one contribution from a goal
+func (s *goalService) DeleteContribution(ctx context.Context, userID, goalID, contributionID string) error {
+	if userID == "" || goalID == "" || contributionID == "" {
+		return fmt.Errorf("userID, goalID and contributionID are required")
+	}
+	return s.repo.DeleteGoalContribution(ctx, userID, goalID, contributionID)
+}
+
+// GetProgress computes how much of the goal is saved as of the given time,
+// the monthly amount still needed to reach it by its target date, and when it
+// is reached at the pace the user saved over the last complete months
+func (s *goalService) GetProgress(ctx context.Context, userID, goalID string, asOf time.Time) (*models.GoalProgress, error) {
+	if userID == "" || goalID == "" {
+		return nil, fmt.Errorf("userID and goalID are required")
+	}
+
+	goal, err := s.repo.GetGoal(ctx, userID, goalID)
+	if err != nil {
+		return nil, err
+	}
+	currency := goal.Currency
+	today := startOfDay(asOf)
+
+	progress := &models.GoalProgress{
+		Goal:          *goal,
+		Contributions: models.ZeroMoney(currency),
+	}
+
+	contributions, err := s.repo.ListGoalContributions(ctx, userID, goalID)
+	if err != nil {
+		return nil, err
+	}
+	for _, contribution := range contributions {
+		if contribution.Date.After(asOf) {
+			continue
+		}
+		progress.Contributions = progress.Contributions.Add(models.NewMoney(contribution.Amount.Minor, currency))
+	}
+
+	progress.Linked, err = s.linkedAmount(ctx, goal, asOf)
+	if err != nil {
+		return nil, err
+	}
+	progress.Saved = progress.Contributions.Add(progress.Linked)
+
+	progress.Remaining = goal.TargetAmount.Sub(progress.Saved)
+	if !progress.Remaining.IsPositive() {
+		progress.Remaining = models.ZeroMoney(currency)
+	}
+	progress.Complete = progress.Remaining.IsZero()
+	progress.Percentage = math.Min(math.Max(progress.Saved.Ratio(goal.TargetAmount)*100, 0), 100)
+
+	progress.AverageMonthlySavings, err = s.averageMonthlySavings(ctx, userID, currency, today)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case progress.Complete:
+		progress.ProjectedCompletion = &today
+	case progress.AverageMonthlySavings.IsPositive():
+		months := int(math.Ceil(progress.Remaining.Ratio(progress.AverageMonthlySavings)))
+		projected := today.AddDate(0, months, 0)
+		progress.ProjectedCompletion = &projected
+	}
+
+	if goal.TargetDate != nil {
+		progress.MonthsLeft = monthsUntil(today, *goal.TargetDate)
+		required := progress.Remaining.DivInt(max(progress.MonthsLeft, 1))
+		progress.RequiredMonthly = &required
+		onTrack := progress.ProjectedCompletion != nil && !progress.ProjectedCompletion.After(*goal.TargetDate)
+		progress.OnTrack = &onTrack
+	}
+
+	return progress, nil
+}
+
+// linkedAmount returns the balance of the goal's account, or the total of the
+// transactions carrying its tag, as of asOf in the goal's currency
+func (s *goalService) linkedAmount(ctx context.Context, goal *models.Goal, asOf time.Time) (models.Money, error) {
+	linked := models.ZeroMoney(goal.Currency)
+
+	switch {
+	case goal.AccountID != "":
+		account, err := s.repo.GetAccount(ctx, goal.UserID, goal.AccountID)
+		if err != nil {
+			return models.Money{}, fmt.Errorf("failed to get account of goal %s: %w", goal.ID, err)
+		}
+		transactions, err := accountTransactions(ctx, s.repo, s.converter, account)
+		if err != nil {
+			return models.Money{}, err
+		}
+		balance := account.OpeningBalance
+		for _, tx := range transactions {
+			if tx.Date.After(asOf) {
+				break
+			}
+			balance = balance.Add(tx.Amount)
+		}
+		return s.converter.Convert(balance, goal.Currency, asOf)
+
+	case goal.Tag != "":
+		err := s.converter.ForEachInBase(ctx, repository.IterateTransactionsByTag(s.repo, goal.UserID, goal.Tag), goal.Currency, func(tx models.Transaction) error {
+			if !tx.Date.After(asOf) {
+				linked = linked.Add(tx.Amount.Abs())
+			}
+			return nil
+		})
+		if err != nil {
+			return models.Money{}, fmt.Errorf("failed to get transactions tagged %s: %w", goal.Tag, err)
+		}
+	}
+
+	return linked, nil
+}
+
+// averageMonthlySavings returns the user's income minus expenses per month
+// over the GoalSavingsMonths complete months before today's, in currency.
+// Transfers move money between the user's accounts and are left out.
+func (s *goalService) averageMonthlySavings(ctx context.Context, userID, currency string, today time.Time) (models.Money, error) {
+	to := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	from := to.AddDate(0, -models.GoalSavingsMonths, 0)
+
+	transactions, err := transactionsBetween(ctx, s.repo, userID, from, to)
+	if err != nil {
+		return models.Money{}, err
+	}
+	transactions, err = s.converter.ConvertTransactions(transactions, currency)
+	if err != nil {
+		return models.Money{}, err
+	}
+
+	net := models.ZeroMoney(currency)
+	for _, tx := range transactions {
+		if tx.IsTransfer() {
+			continue
+		}
+		net = net.Add(tx.Amount)
+	}
+	return net.DivInt(models.GoalSavingsMonths), nil
+}
+
+// monthsUntil counts the whole or partial months from today to the target
+// date, none once it has passed
+func monthsUntil(today, target time.Time) int {
+	if !target.After(today) {
+		return 0
+	}
+	months := (target.Year()-today.Year())*12 + int(target.Month()-today.Month())
+	if target.Day() > today.Day() {
+		months++
+	}
+	return max(months, 1)
+}
@@ -0,0 +1,424 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"backend/internal/importer"
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// ErrInvalidImport is returned for unreadable files, invalid mappings and
+// commits of files with invalid rows
+var ErrInvalidImport = errors.New("invalid import")
+
+// invoiceMatchDays is how far from the invoice's payment date an existing
+// expense of the same amount may be to get the invoice attached
+const invoiceMatchDays = 3
+
+// ImportOptions controls what an import does with the parsed rows
+type ImportOptions struct {
+	DryRun      bool   // only preview the rows
+	SkipInvalid bool   // commit the valid rows even if some rows are invalid
+	Duplicates  string // models.DuplicatePolicyFlag (default) or models.DuplicatePolicySkip for likely duplicates
+}
+
+// InvoiceFile is one uploaded invoice
+type InvoiceFile struct {
+	Name    string
+	Content io.Reader
+}
+
+// InvoiceOptions controls what an invoice import does
+type InvoiceOptions struct {
+	DryRun        bool   // only preview what would be created or attached
+	TransactionID string // attach the (single) invoice to this expense
+	Category      string // category of the expenses created for invoices
+}
+
+type ImportService interface {
+	ImportCSV(ctx context.Context, userID string, file io.Reader, mapping models.ImportMapping, opts ImportOptions) (*models.ImportResult, error)
+	ImportOFX(ctx context.Context, userID string, file io.Reader, opts ImportOptions) (*models.ImportResult, error)
+	ImportCFDI(ctx context.Context, userID string, files []InvoiceFile, opts InvoiceOptions) (*models.InvoiceImportResult, error)
+	SaveMapping(ctx context.Context, mapping *models.ImportMapping) error
+	GetMapping(ctx context.Context, userID, name string) (*models.ImportMapping, error)
+	ListMappings(ctx context.Context, userID string) ([]models.ImportMapping, error)
+	DeleteMapping(ctx context.Context, userID, name string) error
+}
+
+type importService struct {
+	repo repository.Repository
+}
+
+func NewImportService(repo repository.Repository) ImportService {
+	return &importService{repo: repo}
+}
+
+// ImportCSV parses a bank CSV export with the mapping and previews or commits
+// its rows
+func (s *importService) ImportCSV(ctx context.Context, userID string, file io.Reader, mapping models.ImportMapping, opts ImportOptions) (*models.ImportResult, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	rows, err := importer.ParseCSV(file, mapping, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	return s.apply(ctx, userID, importer.FormatCSV, rows, opts)
+}
+
+// ImportOFX parses an OFX or QFX statement and previews or commits its
+// transactions. Transactions imported from the same statement before are
+// reported as duplicates and skipped.
+func (s *importService) ImportOFX(ctx context.Context, userID string, file io.Reader, opts ImportOptions) (*models.ImportResult, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	rows, err := importer.ParseOFX(file, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	return s.apply(ctx, userID, importer.FormatOFX, rows, opts)
+}
+
+// apply builds the result of the parsed rows and, unless it is a dry run,
+// writes the valid ones that were not imported before. Without SkipInvalid a
+// file with invalid rows is rejected as a whole so it can be fixed and
+// imported again. Likely duplicates of stored transactions are flagged, or
+// left out with the skip policy. The user's categorization rules run on every
+// valid row, so the preview shows the categories that will be stored.
+func (s *importService) apply(ctx context.Context, userID, format string, rows []models.ImportRow, opts ImportOptions) (*models.ImportResult, error) {
+	if !models.ValidDuplicatePolicy(opts.Duplicates) {
+		return nil, fmt.Errorf("%w: duplicates must be %s or %s", ErrInvalidImport, models.DuplicatePolicyFlag, models.DuplicatePolicySkip)
+	}
+	if err := s.markDuplicates(ctx, userID, rows); err != nil {
+		return nil, err
+	}
+
+	rules, err := loadRules(ctx, s.repo, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		if row.Transaction != nil {
+			models.ApplyRules(rules, row.Transaction)
+		}
+	}
+
+	result := &models.ImportResult{
+		Format:    format,
+		DryRun:    opts.DryRun,
+		TotalRows: len(rows),
+		Rows:      rows,
+	}
+
+	var transactions []models.Transaction
+	for _, row := range rows {
+		if row.Transaction == nil {
+			result.InvalidRows++
+			continue
+		}
+		result.ValidRows++
+		if row.Duplicate {
+			result.Duplicates++
+			continue
+		}
+		if row.Transaction.DuplicateOf != "" {
+			result.LikelyDuplicates++
+			if opts.Duplicates == models.DuplicatePolicySkip {
+				continue
+			}
+		}
+		transactions = append(transactions, *row.Transaction)
+	}
+
+	if opts.DryRun {
+		return result, nil
+	}
+	if result.InvalidRows > 0 && !opts.SkipInvalid {
+		return nil, fmt.Errorf("%w: %d of %d rows are invalid; preview the file with dry_run or import with skip_invalid",
+			ErrInvalidImport, result.InvalidRows, result.TotalRows)
+	}
+
+	if len(transactions) > 0 {
+		if err := s.repo.BatchCreateTransactions(ctx, transactions); err != nil {
+			return nil, fmt.Errorf("failed to import transactions: %w", err)
+		}
+	}
+	result.Imported = len(transactions)
+
+	return result, nil
+}
+
+// markDuplicates flags the rows whose transaction is already stored, and
+// points the others that likely repeat a stored transaction to it. Imported
+// transactions with a bank ID get an ID derived from it, so a re-import is
+// found by ID; anything else is matched by date, amount and description.
+func (s *importService) markDuplicates(ctx context.Context, userID string, rows []models.ImportRow) error {
+	months := make(map[string]bool)
+	for _, row := range rows {
+		if row.Transaction != nil {
+			from, to := duplicateWindow(row.Transaction.Date)
+			months[from.Format("2006-01")] = true
+			months[to.AddDate(0, 0, -1).Format("2006-01")] = true
+		}
+	}
+
+	imported := make(map[string]bool)
+	stored := make(map[string][]models.Transaction)
+	for month := range months {
+		err := repository.ForEachTransaction(ctx, repository.IterateTransactionsByMonth(s.repo, userID, month), func(tx models.Transaction) error {
+			if tx.ExternalID != "" {
+				imported[tx.ID] = true
+			}
+			key := duplicateKey(&tx)
+			stored[key] = append(stored[key], tx)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to check imported transactions: %w", err)
+		}
+	}
+
+	for i := range rows {
+		tx := rows[i].Transaction
+		switch {
+		case tx == nil:
+		case imported[tx.ID]:
+			rows[i].Duplicate = true
+		default:
+			if duplicate := findLikelyDuplicate(stored[duplicateKey(tx)], tx); duplicate != nil {
+				tx.DuplicateOf = duplicate.ID
+			}
+		}
+	}
+	return nil
+}
+
+// ImportCFDI attaches each CFDI invoice to the expense it pays for, or creates
+// the expense when there is none. An invoice is attached to the expense given
+// in the options or else to an expense of the same amount and currency paid
+// within invoiceMatchDays of the invoice. Invalid files are reported and
+// skipped.
+func (s *importService) ImportCFDI(ctx context.Context, userID string, files []InvoiceFile, opts InvoiceOptions) (*models.InvoiceImportResult, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+	if opts.TransactionID != "" && len(files) != 1 {
+		return nil, fmt.Errorf("%w: transaction_id requires exactly one invoice", ErrInvalidImport)
+	}
+
+	result := &models.InvoiceImportResult{DryRun: opts.DryRun, Invoices: []models.InvoiceImport{}}
+	rules, err := loadRules(ctx, s.repo, userID)
+	if err != nil {
+		return nil, err
+	}
+	batch := &invoiceBatch{uuids: map[string]bool{}, transactions: map[string]bool{}, rules: rules}
+
+	for _, file := range files {
+		entry := models.InvoiceImport{File: file.Name}
+
+		invoice, err := importer.ParseCFDI(file.Content)
+		if err == nil {
+			entry.Invoice = invoice
+			err = s.importInvoice(ctx, userID, &entry, opts, batch)
+		}
+		if err != nil && !errors.Is(err, importer.ErrInvalidFile) && !errors.Is(err, ErrInvalidImport) {
+			return nil, err
+		}
+
+		switch {
+		case err != nil:
+			entry.Action = ""
+			entry.Errors = []string{err.Error()}
+			result.Invalid++
+		case entry.Action == models.InvoiceActionCreated:
+			result.Created++
+		case entry.Action == models.InvoiceActionAttached:
+			result.Attached++
+		case entry.Action == models.InvoiceActionDuplicate:
+			result.Duplicates++
+		}
+		result.Invoices = append(result.Invoices, entry)
+	}
+
+	return result, nil
+}
+
+// invoiceBatch tracks the invoices and expenses used by earlier files of an
+// upload, so two files never claim the same expense
+type invoiceBatch struct {
+	uuids        map[string]bool
+	transactions map[string]bool
+	rules        []models.CategoryRule // categorize the expenses created for invoices
+}
+
+func (s *importService) importInvoice(ctx context.Context, userID string, entry *models.InvoiceImport, opts InvoiceOptions, batch *invoiceBatch) error {
+	invoice := entry.Invoice
+	if batch.uuids[invoice.UUID] {
+		entry.Action = models.InvoiceActionDuplicate
+		return nil
+	}
+	batch.uuids[invoice.UUID] = true
+
+	candidates, err := transactionsBetween(ctx, s.repo, userID,
+		invoice.PaymentDate.AddDate(0, 0, -invoiceMatchDays), invoice.PaymentDate.AddDate(0, 0, invoiceMatchDays+1))
+	if err != nil {
+		return err
+	}
+	for i := range candidates {
+		if candidates[i].Invoice != nil && candidates[i].Invoice.UUID == invoice.UUID {
+			entry.Action = models.InvoiceActionDuplicate
+			entry.Transaction = &candidates[i]
+			return nil
+		}
+	}
+
+	var target *models.Transaction
+	if opts.TransactionID != "" {
+		if target, err = s.repo.GetTransaction(ctx, userID, opts.TransactionID); err != nil {
+			return err
+		}
+		if target.Invoice != nil && target.Invoice.UUID == invoice.UUID {
+			entry.Action = models.InvoiceActionDuplicate
+			entry.Transaction = target
+			return nil
+		}
+		if target.Invoice != nil {
+			return fmt.Errorf("%w: transaction already has invoice %s", ErrInvalidImport, target.Invoice.UUID)
+		}
+		if target.Type != models.TransactionTypeExpense {
+			return fmt.Errorf("%w: invoices can only be attached to expenses", ErrInvalidImport)
+		}
+	} else {
+		target = matchInvoice(candidates, invoice, batch)
+	}
+
+	if target != nil {
+		batch.transactions[target.ID] = true
+		target.Invoice = invoice
+		entry.Action = models.InvoiceActionAttached
+		entry.Transaction = target
+		if opts.DryRun {
+			return nil
+		}
+		return s.repo.UpdateTransaction(ctx, target)
+	}
+
+	category := strings.ToLower(strings.TrimSpace(opts.Category))
+	if category == "" {
+		category = models.DefaultImportCategory
+	}
+	description := invoice.EmitterName
+	if description == "" {
+		description = invoice.EmitterRFC
+	}
+
+	tx := models.NewTransaction(userID, models.TransactionTypeExpense, category, description, invoice.Total.Neg(), invoice.PaymentDate)
+	tx.ID = importer.ExternalTransactionID(userID, importer.FormatCFDI, invoice.UUID)
+	tx.Invoice = invoice
+	models.ApplyRules(batch.rules, tx)
+	tx.GenerateKeys()
+	entry.Action = models.InvoiceActionCreated
+	entry.Transaction = tx
+	if opts.DryRun {
+		return nil
+	}
+
+	err = s.repo.CreateTransaction(ctx, tx)
+	if errors.Is(err, repository.ErrTransactionExists) {
+		entry.Action = models.InvoiceActionDuplicate
+		return nil
+	}
+	return err
+}
+
+// matchInvoice picks the expense without an invoice whose amount equals the
+// invoice total and whose date is closest to the payment date
+func matchInvoice(candidates []models.Transaction, invoice *models.Invoice, batch *invoiceBatch) *models.Transaction {
+	var best *models.Transaction
+	var bestDistance time.Duration
+	for i := range candidates {
+		tx := &candidates[i]
+		if tx.Type != models.TransactionTypeExpense || tx.Invoice != nil || batch.transactions[tx.ID] ||
+			tx.Amount.Currency != invoice.Currency || tx.Amount.Abs().Cmp(invoice.Total) != 0 {
+			continue
+		}
+		distance := tx.Date.Sub(invoice.PaymentDate)
+		if distance < 0 {
+			distance = -distance
+		}
+		if best == nil || distance < bestDistance {
+			best, bestDistance = tx, distance
+		}
+	}
+	return best
+}
+
+// SaveMapping stores a mapping under its name, replacing the user's mapping
+// with the same name
+func (s *importService) SaveMapping(ctx context.Context, mapping *models.ImportMapping) error {
+	if mapping == nil {
+		return fmt.Errorf("%w: mapping cannot be nil", ErrInvalidImport)
+	}
+	if err := mapping.ValidateName(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+	if err := mapping.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidImport, err)
+	}
+
+	now := time.Now()
+	mapping.CreatedAt = now
+	existing, err := s.repo.GetImportMapping(ctx, mapping.UserID, mapping.Name)
+	switch {
+	case err == nil:
+		mapping.CreatedAt = existing.CreatedAt
+	case !errors.Is(err, repository.ErrImportMappingNotFound):
+		return err
+	}
+	mapping.UpdatedAt = now
+	mapping.GenerateKeys()
+
+	return s.repo.SaveImportMapping(ctx, mapping)
+}
+
+func (s *importService) GetMapping(ctx context.Context, userID, name string) (*models.ImportMapping, error) {
+	if userID == "" || name == "" {
+		return nil, fmt.Errorf("userID and name are required")
+	}
+
+	return s.repo.GetImportMapping(ctx, userID, name)
+}
+
+func (s *importService) ListMappings(ctx context.Context, userID string) ([]models.ImportMapping, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	mappings, err := s.repo.ListImportMappings(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if mappings == nil {
+		mappings = []models.ImportMapping{}
+	}
+	return mappings, nil
+}
+
+func (s *importService) DeleteMapping(ctx context.Context, userID, name string) error {
+	if userID == "" || name == "" {
+		return fmt.Errorf("userID and name are required")
+	}
+
+	return s.repo.DeleteImportMapping(ctx, userID, name)
+}
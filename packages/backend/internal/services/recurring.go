@@ -0,0 +1,200 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// ErrInvalidRecurring is returned for recurring transactions with an invalid template or schedule
+var ErrInvalidRecurring = errors.New("invalid recurring transaction")
+
+// MaxOccurrencesPerRun bounds how many missed occurrences one rule catches up
+// in a single scheduler run; the rest are generated by the following runs
+const MaxOccurrencesPerRun = 366
+
+type RecurringService interface {
+	CreateRecurring(ctx context.Context, recurring *models.RecurringTransaction) error
+	GetRecurring(ctx context.Context, userID, recurringID string) (*models.RecurringTransaction, error)
+	ListRecurring(ctx context.Context, userID string) ([]models.RecurringTransaction, error)
+	UpdateRecurring(ctx context.Context, recurring *models.RecurringTransaction) error
+	DeleteRecurring(ctx context.Context, userID, recurringID string) error
+	MaterializeDue(ctx context.Context, now time.Time) (*models.RecurringRunResult, error)
+}
+
+type recurringService struct {
+	repo repository.Repository
+}
+
+func NewRecurringService(repo repository.Repository) RecurringService {
+	return &recurringService{repo: repo}
+}
+
+// CreateRecurring stores a new rule scheduled from its start date. Occurrences
+// already in the past are generated by the next scheduler run.
+func (s *recurringService) CreateRecurring(ctx context.Context, recurring *models.RecurringTransaction) error {
+	if recurring == nil {
+		return fmt.Errorf("%w: recurring transaction cannot be nil", ErrInvalidRecurring)
+	}
+
+	recurring.ID = uuid.New().String()
+	if recurring.Interval == 0 {
+		recurring.Interval = 1
+	}
+	if err := recurring.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurring, err)
+	}
+
+	now := time.Now()
+	recurring.Generated = 0
+	recurring.LastRun = nil
+	recurring.Schedule(nil)
+	recurring.CreatedAt = now
+	recurring.UpdatedAt = now
+	recurring.Version = 1
+	recurring.GenerateKeys()
+
+	return s.repo.CreateRecurringTransaction(ctx, recurring)
+}
+
+func (s *recurringService) GetRecurring(ctx context.Context, userID, recurringID string) (*models.RecurringTransaction, error) {
+	if userID == "" || recurringID == "" {
+		return nil, fmt.Errorf("userID and recurringID are required")
+	}
+
+	return s.repo.GetRecurringTransaction(ctx, userID, recurringID)
+}
+
+func (s *recurringService) ListRecurring(ctx context.Context, userID string) ([]models.RecurringTransaction, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	rules, err := s.repo.ListRecurringTransactions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []models.RecurringTransaction{}
+	}
+	return rules, nil
+}
+
+// UpdateRecurring replaces the template and schedule of an existing rule while
+// keeping its scheduler state. Transactions already generated are not changed.
+// Resuming a paused rule skips the occurrences missed while it was paused.
+func (s *recurringService) UpdateRecurring(ctx context.Context, recurring *models.RecurringTransaction) error {
+	if recurring == nil {
+		return fmt.Errorf("%w: recurring transaction cannot be nil", ErrInvalidRecurring)
+	}
+
+	existing, err := s.GetRecurring(ctx, recurring.UserID, recurring.ID)
+	if err != nil {
+		return err
+	}
+
+	if recurring.Interval == 0 {
+		recurring.Interval = 1
+	}
+	if err := recurring.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRecurring, err)
+	}
+
+	recurring.Generated = existing.Generated
+	recurring.LastRun = existing.LastRun
+	recurring.CreatedAt = existing.CreatedAt
+	recurring.Version = existing.Version
+
+	after := recurring.LastRun
+	if recurring.Active && !existing.Active {
+		now := time.Now()
+		if after == nil || after.Before(now) {
+			after = &now
+		}
+	}
+	recurring.Schedule(after)
+
+	return s.repo.UpdateRecurringTransaction(ctx, recurring)
+}
+
+func (s *recurringService) DeleteRecurring(ctx context.Context, userID, recurringID string) error {
+	if userID == "" || recurringID == "" {
+		return fmt.Errorf("userID and recurringID are required")
+	}
+
+	return s.repo.DeleteRecurringTransaction(ctx, userID, recurringID)
+}
+
+// MaterializeDue generates the transactions of every rule due at now. It is
+// idempotent: occurrences have deterministic IDs, so an occurrence created by
+// a run that failed before saving the rule is recognized and not duplicated,
+// and concurrent runs are serialized by the rule's version.
+func (s *recurringService) MaterializeDue(ctx context.Context, now time.Time) (*models.RecurringRunResult, error) {
+	rules, err := s.repo.GetDueRecurringTransactions(ctx, now)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get due recurring transactions: %w", err)
+	}
+
+	result := &models.RecurringRunResult{}
+	for i := range rules {
+		result.Rules++
+		if err := s.materialize(ctx, &rules[i], now, result); err != nil {
+			result.Failed++
+			log.Printf("Failed to materialize recurring transaction %s for user %s: %v", rules[i].ID, rules[i].UserID, err)
+		}
+	}
+
+	return result, nil
+}
+
+func (s *recurringService) materialize(ctx context.Context, recurring *models.RecurringTransaction, now time.Time, result *models.RecurringRunResult) error {
+	for i := 0; i < MaxOccurrencesPerRun && recurring.NextRun != nil && !recurring.NextRun.After(now); i++ {
+		occurrence := *recurring.NextRun
+
+		err := s.repo.CreateTransaction(ctx, recurring.NewOccurrence(occurrence))
+		switch {
+		case err == nil:
+			result.Generated++
+		case errors.Is(err, repository.ErrTransactionExists):
+			result.Duplicates++
+		default:
+			return fmt.Errorf("failed to create occurrence of %s: %w", occurrence.Format("2006-01-02"), err)
+		}
+
+		recurring.Generated++
+		recurring.LastRun = &occurrence
+		recurring.Schedule(&occurrence)
+	}
+
+	return s.repo.UpdateRecurringTransaction(ctx, recurring)
+}
+
+// RunRecurringScheduler materializes due occurrences right away and then every
+// interval until the context is cancelled
+func RunRecurringScheduler(ctx context.Context, service RecurringService, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		result, err := service.MaterializeDue(ctx, time.Now())
+		if err != nil {
+			log.Printf("Recurring scheduler run failed: %v", err)
+		} else if result.Rules > 0 {
+			log.Printf("Recurring scheduler: %d rules, %d transactions generated, %d already existed, %d failed",
+				result.Rules, result.Generated, result.Duplicates, result.Failed)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+
+	"backend/internal/models"
+	"backend/internal/period"
+	"backend/internal/repository"
+)
+
+// ErrInvalidRule is returned for categorization rules without conditions or
+// actions, with an invalid regex, or beyond the per-user limit
+var ErrInvalidRule = errors.New("invalid categorization rule")
+
+type RuleService interface {
+	CreateRule(ctx context.Context, rule *models.CategoryRule) error
+	GetRule(ctx context.Context, userID, ruleID string) (*models.CategoryRule, error)
+	ListRules(ctx context.Context, userID string) ([]models.CategoryRule, error)
+	UpdateRule(ctx context.Context, rule *models.CategoryRule) error
+	DeleteRule(ctx context.Context, userID, ruleID string) error
+	ApplyRules(ctx context.Context, userID string, rng period.Range, dryRun bool) (*models.RuleRunResult, error)
+}
+
+type ruleService struct {
+	repo repository.Repository
+}
+
+func NewRuleService(repo repository.Repository) RuleService {
+	return &ruleService{repo: repo}
+}
+
+// loadRules returns the user's active rules in the order they run
+func loadRules(ctx context.Context, repo repository.Repository, userID string) ([]models.CategoryRule, error) {
+	rules, err := repo.ListRules(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load categorization rules: %w", err)
+	}
+
+	active := make([]models.CategoryRule, 0, len(rules))
+	for _, rule := range rules {
+		if rule.Active {
+			active = append(active, rule)
+		}
+	}
+	models.SortRules(active)
+	return active, nil
+}
+
+// CreateRule stores a new rule. It applies to transactions created or imported
+// from now on; ApplyRules runs it over existing ones.
+func (s *ruleService) CreateRule(ctx context.Context, rule *models.CategoryRule) error {
+	if rule == nil {
+		return fmt.Errorf("%w: rule cannot be nil", ErrInvalidRule)
+	}
+
+	rule.ID = uuid.New().String()
+	if err := rule.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+
+	existing, err := s.repo.ListRules(ctx, rule.UserID)
+	if err != nil {
+		return err
+	}
+	if len(existing) >= models.MaxRulesPerUser {
+		return fmt.Errorf("%w: at most %d rules are allowed", ErrInvalidRule, models.MaxRulesPerUser)
+	}
+
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	rule.Version = 1
+	rule.GenerateKeys()
+
+	return s.repo.CreateRule(ctx, rule)
+}
+
+func (s *ruleService) GetRule(ctx context.Context, userID, ruleID string) (*models.CategoryRule, error) {
+	if userID == "" || ruleID == "" {
+		return nil, fmt.Errorf("userID and ruleID are required")
+	}
+
+	return s.repo.GetRule(ctx, userID, ruleID)
+}
+
+// ListRules returns the user's rules, active or not, in the order they run
+func (s *ruleService) ListRules(ctx context.Context, userID string) ([]models.CategoryRule, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	rules, err := s.repo.ListRules(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []models.CategoryRule{}
+	}
+	models.SortRules(rules)
+	return rules, nil
+}
+
+// UpdateRule replaces the conditions and actions of an existing rule.
+// Transactions it already categorized are not changed.
+func (s *ruleService) UpdateRule(ctx context.Context, rule *models.CategoryRule) error {
+	if rule == nil {
+		return fmt.Errorf("%w: rule cannot be nil", ErrInvalidRule)
+	}
+
+	existing, err := s.GetRule(ctx, rule.UserID, rule.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := rule.Validate(); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRule, err)
+	}
+
+	rule.CreatedAt = existing.CreatedAt
+	rule.Version = existing.Version
+
+	return s.repo.UpdateRule(ctx, rule)
+}
+
+func (s *ruleService) DeleteRule(ctx context.Context, userID, ruleID string) error {
+	if userID == "" || ruleID == "" {
+		return fmt.Errorf("userID and ruleID are required")
+	}
+
+	return s.repo.DeleteRule(ctx, userID, ruleID)
+}
+
+// ApplyRules runs the active rules over the transactions dated in the range
+// and saves the ones they change, or only reports the changes in a dry run.
+// Changes are listed most recent first.
+func (s *ruleService) ApplyRules(ctx context.Context, userID string, rng period.Range, dryRun bool) (*models.RuleRunResult, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	rules, err := loadRules(ctx, s.repo, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	transactions, err := transactionsBetween(ctx, s.repo, userID, rng.Start, rng.End)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(transactions, func(i, j int) bool {
+		return transactions[i].Date.After(transactions[j].Date)
+	})
+
+	result := &models.RuleRunResult{DryRun: dryRun, Scanned: len(transactions), Changes: []models.RuleChange{}}
+	for i := range transactions {
+		tx := &transactions[i]
+		oldCategory := tx.Category
+		oldTags := len(tx.Tags)
+
+		ruleIDs := models.ApplyRules(rules, tx)
+		if len(ruleIDs) == 0 {
+			continue
+		}
+
+		if !dryRun {
+			if err := s.repo.UpdateTransaction(ctx, tx); err != nil {
+				return nil, fmt.Errorf("failed to update transaction %s: %w", tx.ID, err)
+			}
+		}
+
+		result.Changed++
+		result.Changes = append(result.Changes, models.RuleChange{
+			TransactionID: tx.ID,
+			Date:          tx.Date,
+			Description:   tx.Description,
+			OldCategory:   oldCategory,
+			NewCategory:   tx.Category,
+			AddedTags:     tx.Tags[oldTags:],
+			RuleIDs:       ruleIDs,
+		})
+	}
+
+	return result, nil
+}
@@ -0,0 +1,207 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// cadence describes how often a subscription charges and how far a real
+// charge may drift from the nominal interval
+type cadence struct {
+	name       string
+	days       float64 // nominal interval between charges
+	tolerance  float64 // allowed deviation in days
+	minCharges int     // charges needed before the pattern is trusted
+	perYear    float64
+	next       func(time.Time) time.Time
+}
+
+var cadences = []cadence{
+	{models.CadenceWeekly, 7, 2, 3, 52, func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }},
+	{models.CadenceMonthly, 30.44, 4, 3, 12, func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+	{models.CadenceAnnual, 365.25, 15, 2, 1, func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+}
+
+const (
+	// Charges of one description within 25% of the cluster's smallest charge
+	// belong to the same subscription, so price increases stay together
+	subscriptionAmountTolerance = 0.25
+	// Share of the intervals between charges that must match the cadence
+	subscriptionRegularity = 0.75
+)
+
+// DetectSubscriptions scans the user's expenses for charges that repeat weekly,
+// monthly or yearly with the same description and a similar amount
+func (s *analyticsService) DetectSubscriptions(ctx context.Context, userID string) ([]models.Subscription, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	base, err := s.converter.BaseCurrency(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	var expenses []models.Transaction
+
+	err = s.converter.ForEachInBase(ctx, repository.IterateTransactionsByUser(s.repo, userID), base, func(tx models.Transaction) error {
+		if tx.Type == models.TransactionTypeExpense {
+			expenses = append(expenses, tx)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user transactions: %w", err)
+	}
+
+	return detectSubscriptions(expenses, base, time.Now()), nil
+}
+
+// detectSubscriptions finds the subscriptions among expenses in the base
+// currency, most expensive first. Subscriptions whose next charge is overdue
+// as of now are inactive.
+func detectSubscriptions(expenses []models.Transaction, base string, now time.Time) []models.Subscription {
+	groups := make(map[string][]models.Transaction)
+	for _, tx := range expenses {
+		if key := normalizeDescription(tx.Description); key != "" {
+			groups[key] = append(groups[key], tx)
+		}
+	}
+
+	subscriptions := []models.Subscription{}
+	for _, charges := range groups {
+		for _, cluster := range clusterByAmount(charges) {
+			if subscription, ok := detectSubscription(cluster, base, now); ok {
+				subscriptions = append(subscriptions, subscription)
+			}
+		}
+	}
+
+	// Most expensive first
+	sort.Slice(subscriptions, func(i, j int) bool {
+		if c := subscriptions[i].AnnualizedCost.Cmp(subscriptions[j].AnnualizedCost); c != 0 {
+			return c > 0
+		}
+		return subscriptions[i].Name < subscriptions[j].Name
+	})
+
+	return subscriptions
+}
+
+// normalizeDescription lowercases the description and drops digits and
+// punctuation, so "NETFLIX.COM 8841" and "Netflix.com 9920" match
+func normalizeDescription(description string) string {
+	fields := strings.FieldsFunc(strings.ToLower(description), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// clusterByAmount splits charges of one description into groups of similar
+// amounts, each sorted by date
+func clusterByAmount(charges []models.Transaction) [][]models.Transaction {
+	sort.Slice(charges, func(i, j int) bool {
+		return charges[i].Amount.Abs().Cmp(charges[j].Amount.Abs()) < 0
+	})
+
+	var clusters [][]models.Transaction
+	var smallest models.Money
+	for _, tx := range charges {
+		amount := tx.Amount.Abs()
+		if len(clusters) == 0 || amount.Float64() > smallest.Float64()*(1+subscriptionAmountTolerance) {
+			clusters = append(clusters, nil)
+			smallest = amount
+		}
+		clusters[len(clusters)-1] = append(clusters[len(clusters)-1], tx)
+	}
+
+	for _, cluster := range clusters {
+		sort.Slice(cluster, func(i, j int) bool { return cluster[i].Date.Before(cluster[j].Date) })
+	}
+	return clusters
+}
+
+// detectSubscription matches the intervals between date-sorted charges
+// against the known cadences
+func detectSubscription(charges []models.Transaction, base string, now time.Time) (models.Subscription, bool) {
+	if len(charges) < 2 {
+		return models.Subscription{}, false
+	}
+
+	intervals := make([]float64, len(charges)-1)
+	for i := 1; i < len(charges); i++ {
+		intervals[i-1] = charges[i].Date.Sub(charges[i-1].Date).Hours() / 24
+	}
+	median := medianOf(intervals)
+
+	for _, c := range cadences {
+		if len(charges) < c.minCharges || math.Abs(median-c.days) > c.tolerance {
+			continue
+		}
+
+		matching := 0
+		for _, interval := range intervals {
+			if math.Abs(interval-c.days) <= c.tolerance {
+				matching++
+			}
+		}
+		if float64(matching) < subscriptionRegularity*float64(len(intervals)) {
+			return models.Subscription{}, false
+		}
+
+		return newSubscription(charges, c, base, now), true
+	}
+
+	return models.Subscription{}, false
+}
+
+func newSubscription(charges []models.Transaction, c cadence, base string, now time.Time) models.Subscription {
+	first, last := charges[0], charges[len(charges)-1]
+
+	total := models.ZeroMoney(base)
+	for _, tx := range charges {
+		total = total.Add(tx.Amount.Abs())
+	}
+
+	lastAmount := last.Amount.Abs()
+	next := c.next(last.Date)
+	subscription := models.Subscription{
+		Name:           last.Description,
+		Category:       last.Category,
+		Cadence:        c.name,
+		Occurrences:    len(charges),
+		AverageAmount:  total.DivInt(len(charges)),
+		LastAmount:     lastAmount,
+		AnnualizedCost: lastAmount.MulFloat(c.perYear),
+		FirstCharge:    first.Date,
+		LastCharge:     last.Date,
+		NextExpected:   next,
+		Active:         !now.After(next.Add(time.Duration(c.tolerance * float64(24*time.Hour)))),
+	}
+
+	previous := charges[len(charges)-2].Amount.Abs()
+	if lastAmount.Cmp(previous) > 0 {
+		subscription.PriceIncrease = true
+		subscription.PreviousAmount = &previous
+		subscription.PriceChangePercent = percentChange(previous, lastAmount)
+	}
+
+	return subscription
+}
+
+func medianOf(values []float64) float64 {
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+	mid := len(sorted) / 2
+	if len(sorted)%2 == 0 {
+		return (sorted[mid-1] + sorted[mid]) / 2
+	}
+	return sorted[mid]
+}
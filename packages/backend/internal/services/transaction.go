@@ -2,83 +2,199 @@ package services
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/google/uuid"
 
 	"backend/internal/models"
+	"backend/internal/pagination"
+	"backend/internal/period"
 	"backend/internal/repository"
 )
 
+var (
+	// ErrInvalidCreateOptions is returned for malformed idempotency keys and
+	// unknown duplicate policies
+	ErrInvalidCreateOptions = errors.New("invalid create options")
+
+	// ErrIdempotencyKeyMismatch is returned when an idempotency key is reused
+	// with a different request body
+	ErrIdempotencyKeyMismatch = errors.New("idempotency key was used with a different request")
+
+	// ErrIdempotencyKeyInProgress is returned while the request that claimed an
+	// idempotency key has not created its transaction yet
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is in progress")
+)
+
+// CreateOptions controls how a transaction is created
+type CreateOptions struct {
+	IdempotencyKey string // replay the transaction an earlier request with the key created
+	Duplicates     string // models.DuplicatePolicyFlag (default) or models.DuplicatePolicySkip
+}
+
+// CreateResult reports what creating a transaction did
+type CreateResult struct {
+	Transaction *models.Transaction // the new transaction, or the stored one when replayed or skipped
+	Replayed    bool                // an earlier request with the same idempotency key created it
+	Skipped     bool                // not created: it likely duplicates the stored Transaction
+}
+
+// TransactionFilter narrows a transaction listing
+type TransactionFilter struct {
+	Tag string // only transactions carrying the tag
+}
+
+// matches reports whether the transaction passes the filter
+func (f TransactionFilter) matches(tx *models.Transaction) bool {
+	return f.Tag == "" || tx.HasTag(f.Tag)
+}
+
+// scope extends a listing's cursor scope, so a cursor is only accepted with
+// the filter that issued it
+func (f TransactionFilter) scope(listing string) string {
+	if f.Tag == "" {
+		return listing
+	}
+	return listing + "#tag#" + f.Tag
+}
+
 type TransactionService interface {
-	GetTransactionsByUser(ctx context.Context, userID string, limit int) ([]models.Transaction, error)
-	GetTransactionsByMonth(ctx context.Context, userID, month string, limit int) ([]models.Transaction, error)
-	GetTransactionsByCategory(ctx context.Context, userID, category string, limit int) ([]models.Transaction, error)
+	GetTransactionsByUser(ctx context.Context, userID string, limit int, cursor string, filter TransactionFilter) (*models.TransactionPage, error)
+	GetTransactionsByMonth(ctx context.Context, userID, month string, limit int, cursor string, filter TransactionFilter) (*models.TransactionPage, error)
+	GetTransactionsByCategory(ctx context.Context, userID, category string, limit int, cursor string, filter TransactionFilter) (*models.TransactionPage, error)
 	GetTransaction(ctx context.Context, userID, transactionID string) (*models.Transaction, error)
-	CreateTransaction(ctx context.Context, transaction *models.Transaction) error
+	CreateTransaction(ctx context.Context, transaction *models.Transaction, opts CreateOptions) (*CreateResult, error)
 	UpdateTransaction(ctx context.Context, transaction *models.Transaction) error
 	DeleteTransaction(ctx context.Context, userID, transactionID string) error
 	ValidateTransaction(transaction *models.Transaction) error
+	FindDuplicates(ctx context.Context, userID string, rng period.Range) ([]models.DuplicatePair, error)
 }
 
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
 type transactionService struct {
-	repo repository.Repository
+	repo    repository.Repository
+	cursors *pagination.Codec
 }
 
 func NewTransactionService(repo repository.Repository) TransactionService {
+	return NewTransactionServiceWithCursors(repo, pagination.NewCodec(""))
+}
+
+// NewTransactionServiceWithCursors creates a transaction service whose page
+// cursors are signed by the given codec, so they stay valid across instances
+func NewTransactionServiceWithCursors(repo repository.Repository, cursors *pagination.Codec) TransactionService {
 	return &transactionService{
-		repo: repo,
+		repo:    repo,
+		cursors: cursors,
 	}
 }
 
-func (s *transactionService) GetTransactionsByUser(ctx context.Context, userID string, limit int) ([]models.Transaction, error) {
-	if userID == "" {
-		return nil, fmt.Errorf("userID is required")
+// pageQuery fetches one page of a listing, resuming from the cursor. The scope
+// identifies the listing so cursors cannot be reused across users or queries.
+type pageQuery func(limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error)
+
+// fetchPage returns one page of the listing. Filtered-out transactions still
+// count toward the limit, so a filtered page can come back short while more
+// remain.
+func (s *transactionService) fetchPage(scope string, limit int, cursor string, filter TransactionFilter, query pageQuery) (*models.TransactionPage, error) {
+	if limit <= 0 {
+		limit = defaultPageSize
+	}
+	if limit > maxPageSize {
+		limit = maxPageSize
 	}
 	
-	if limit <= 0 {
-		limit = 50
+	scope = filter.scope(scope)
+	lastKey, err := s.cursors.Decode(scope, cursor)
+	if err != nil {
+		return nil, err
+	}
+	
+	transactions, nextKey, err := query(limit, lastKey)
+	if err != nil {
+		return nil, err
+	}
+	
+	nextCursor, err := s.cursors.Encode(scope, nextKey)
+	if err != nil {
+		return nil, err
 	}
 	
-	transactions, _, err := s.repo.GetTransactionsByUser(ctx, userID, limit, nil)
+	filtered := []models.Transaction{}
+	for i := range transactions {
+		if filter.matches(&transactions[i]) {
+			filtered = append(filtered, transactions[i])
+		}
+	}
+	
+	return &models.TransactionPage{
+		Transactions: filtered,
+		NextCursor:   nextCursor,
+		HasMore:      nextCursor != "",
+	}, nil
+}
+
+// GetTransactionsByUser lists the user's transactions. Filtered by tag, the
+// pages are read from the tag's items instead of filtering each page.
+func (s *transactionService) GetTransactionsByUser(ctx context.Context, userID string, limit int, cursor string, filter TransactionFilter) (*models.TransactionPage, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+	filter = normalizeFilter(filter)
+	
+	page, err := s.fetchPage("user#"+userID, limit, cursor, filter, func(limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+		if filter.Tag != "" {
+			return s.repo.GetTransactionsByTag(ctx, userID, filter.Tag, limit, lastKey)
+		}
+		return s.repo.GetTransactionsByUser(ctx, userID, limit, lastKey)
+	})
 	if err != nil {
 		return nil, err
 	}
 	
 	// Sort transactions by date in descending order (most recent first)
-	sort.Slice(transactions, func(i, j int) bool {
-		return transactions[i].Date.After(transactions[j].Date)
+	sort.Slice(page.Transactions, func(i, j int) bool {
+		return page.Transactions[i].Date.After(page.Transactions[j].Date)
 	})
 	
-	return transactions, nil
+	return page, nil
 }
 
-func (s *transactionService) GetTransactionsByMonth(ctx context.Context, userID, month string, limit int) ([]models.Transaction, error) {
+func (s *transactionService) GetTransactionsByMonth(ctx context.Context, userID, month string, limit int, cursor string, filter TransactionFilter) (*models.TransactionPage, error) {
 	if userID == "" || month == "" {
 		return nil, fmt.Errorf("userID and month are required")
 	}
 	
-	if limit <= 0 {
-		limit = 50
-	}
-	
-	transactions, _, err := s.repo.GetTransactionsByMonth(ctx, userID, month, limit, nil)
-	return transactions, err
+	return s.fetchPage("month#"+month+"#"+userID, limit, cursor, normalizeFilter(filter), func(limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+		return s.repo.GetTransactionsByMonth(ctx, userID, month, limit, lastKey)
+	})
 }
 
-func (s *transactionService) GetTransactionsByCategory(ctx context.Context, userID, category string, limit int) ([]models.Transaction, error) {
+func (s *transactionService) GetTransactionsByCategory(ctx context.Context, userID, category string, limit int, cursor string, filter TransactionFilter) (*models.TransactionPage, error) {
 	if userID == "" || category == "" {
 		return nil, fmt.Errorf("userID and category are required")
 	}
 	
-	if limit <= 0 {
-		limit = 50
-	}
-	
-	transactions, _, err := s.repo.GetTransactionsByCategory(ctx, userID, category, limit, nil)
-	return transactions, err
+	return s.fetchPage("category#"+strings.ToUpper(category)+"#"+userID, limit, cursor, normalizeFilter(filter), func(limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+		return s.repo.GetTransactionsByCategory(ctx, userID, category, limit, lastKey)
+	})
+}
+
+// normalizeFilter matches tags the way they are stored
+func normalizeFilter(filter TransactionFilter) TransactionFilter {
+	filter.Tag = strings.ToLower(strings.TrimSpace(filter.Tag))
+	return filter
 }
 
 func (s *transactionService) GetTransaction(ctx context.Context, userID, transactionID string) (*models.Transaction, error) {
@@ -89,15 +205,166 @@ func (s *transactionService) GetTransaction(ctx context.Context, userID, transac
 	return s.repo.GetTransaction(ctx, userID, transactionID)
 }
 
-func (s *transactionService) CreateTransaction(ctx context.Context, transaction *models.Transaction) error {
+// CreateTransaction stores a new transaction after running the user's
+// categorization rules on it. A likely duplicate of a stored
+// transaction is flagged with DuplicateOf, or not created with the skip
+// policy. With an idempotency key, retries of the request return the
+// transaction the first one created.
+func (s *transactionService) CreateTransaction(ctx context.Context, transaction *models.Transaction, opts CreateOptions) (*CreateResult, error) {
+	if !models.ValidDuplicatePolicy(opts.Duplicates) {
+		return nil, fmt.Errorf("%w: duplicates must be %s or %s", ErrInvalidCreateOptions, models.DuplicatePolicyFlag, models.DuplicatePolicySkip)
+	}
+	if opts.IdempotencyKey != "" {
+		if err := models.ValidateIdempotencyKey(opts.IdempotencyKey); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidCreateOptions, err)
+		}
+	}
+	
+	// Fingerprint the request as sent, before rules and validation fill in
+	// defaults
+	requestHash := transactionRequestHash(transaction)
+	clearServerFields(transaction)
+	
+	// Rules run first so they can categorize a transaction sent without one
+	if transaction != nil && transaction.UserID != "" {
+		rules, err := loadRules(ctx, s.repo, transaction.UserID)
+		if err != nil {
+			return nil, err
+		}
+		models.ApplyRules(rules, transaction)
+	}
+	
 	if err := s.ValidateTransaction(transaction); err != nil {
-		return fmt.Errorf("validation failed: %w", err)
+		return nil, fmt.Errorf("validation failed: %w", err)
+	}
+	if err := s.checkAccount(ctx, transaction.UserID, transaction.AccountID); err != nil {
+		return nil, err
 	}
 	
 	// Generate DynamoDB keys after validation and ID generation
 	transaction.GenerateKeys()
 	
-	return s.repo.CreateTransaction(ctx, transaction)
+	if opts.IdempotencyKey == "" {
+		return s.createTransaction(ctx, transaction, opts)
+	}
+	
+	key := models.NewIdempotencyKey(transaction.UserID, opts.IdempotencyKey, transaction.ID, requestHash, time.Now())
+	err := s.repo.CreateIdempotencyKey(ctx, key)
+	if errors.Is(err, repository.ErrIdempotencyKeyExists) {
+		return s.replay(ctx, transaction.UserID, opts.IdempotencyKey, requestHash)
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	// The key is released when nothing was created: a failed request can be
+	// retried, and a retry of a skipped one is skipped again
+	result, err := s.createTransaction(ctx, transaction, opts)
+	if err != nil || result.Skipped {
+		if releaseErr := s.repo.DeleteIdempotencyKey(ctx, transaction.UserID, opts.IdempotencyKey); releaseErr != nil && err == nil {
+			err = releaseErr
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (s *transactionService) createTransaction(ctx context.Context, transaction *models.Transaction, opts CreateOptions) (*CreateResult, error) {
+	from, to := duplicateWindow(transaction.Date)
+	stored, err := transactionsBetween(ctx, s.repo, transaction.UserID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	
+	if duplicate := findLikelyDuplicate(stored, transaction); duplicate != nil {
+		if opts.Duplicates == models.DuplicatePolicySkip {
+			return &CreateResult{Transaction: duplicate, Skipped: true}, nil
+		}
+		transaction.DuplicateOf = duplicate.ID
+	}
+	
+	if err := s.repo.CreateTransaction(ctx, transaction); err != nil {
+		return nil, err
+	}
+	return &CreateResult{Transaction: transaction}, nil
+}
+
+// clearServerFields drops the fields only the server sets: the ID, generated
+// by validation so that no two transactions share one, and the links. A
+// transfer entry is created with its transfer, never on its own, a duplicate is
+// flagged by the detection that runs on create, invoices and bank IDs come from
+// imports and occurrences of a recurring rule from the scheduler
+func clearServerFields(transaction *models.Transaction) {
+	if transaction == nil {
+		return
+	}
+	transaction.ID = ""
+	transaction.TransferID = ""
+	transaction.DuplicateOf = ""
+	transaction.Invoice = nil
+	transaction.RecurringID = ""
+	transaction.ExternalID = ""
+}
+
+// replay returns the transaction created by the earlier request with the key
+func (s *transactionService) replay(ctx context.Context, userID, key, requestHash string) (*CreateResult, error) {
+	stored, err := s.repo.GetIdempotencyKey(ctx, userID, key)
+	if errors.Is(err, repository.ErrIdempotencyKeyNotFound) {
+		// It expired or was released between our attempt to claim it and now
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+	if stored.RequestHash != requestHash {
+		return nil, ErrIdempotencyKeyMismatch
+	}
+	
+	transaction, err := s.repo.GetTransaction(ctx, userID, stored.TransactionID)
+	if errors.Is(err, repository.ErrTransactionNotFound) {
+		return nil, ErrIdempotencyKeyInProgress
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &CreateResult{Transaction: transaction, Replayed: true}, nil
+}
+
+// transactionRequestHash fingerprints the fields a client sends to create a
+// transaction, to tell a retry from a different request reusing its key
+func transactionRequestHash(transaction *models.Transaction) string {
+	if transaction == nil {
+		return ""
+	}
+	fields := strings.Join([]string{
+		transaction.Type,
+		transaction.Category,
+		transaction.Description,
+		transaction.Amount.String(),
+		transaction.Amount.Currency,
+		transaction.Date.UTC().Format(time.RFC3339Nano),
+	}, "\x00")
+	for _, split := range transaction.Splits {
+		fields += "\x00" + split.Category + "\x00" + split.Amount.String() + "\x00" + split.Note
+	}
+	sum := sha256.Sum256([]byte(fields))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindDuplicates lists the transactions dated in the range that likely repeat
+// an earlier one, most recent first
+func (s *transactionService) FindDuplicates(ctx context.Context, userID string, rng period.Range) ([]models.DuplicatePair, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+	
+	transactions, err := transactionsBetween(ctx, s.repo, userID, rng.Start, rng.End)
+	if err != nil {
+		return nil, err
+	}
+	return findDuplicatePairs(transactions), nil
 }
 
 func (s *transactionService) UpdateTransaction(ctx context.Context, transaction *models.Transaction) error {
@@ -105,6 +372,26 @@ func (s *transactionService) UpdateTransaction(ctx context.Context, transaction
 		return fmt.Errorf("validation failed: %w", err)
 	}
 	
+	// Links to the recurring rule, bank import, invoice and duplicated
+	// transaction are kept as stored
+	existing, err := s.repo.GetTransaction(ctx, transaction.UserID, transaction.ID)
+	if err != nil {
+		return err
+	}
+	if existing.TransferID != "" {
+		return fmt.Errorf("%w: change transfer %s instead", ErrTransferEntry, existing.TransferID)
+	}
+	if transaction.AccountID != existing.AccountID {
+		if err := s.checkAccount(ctx, transaction.UserID, transaction.AccountID); err != nil {
+			return err
+		}
+	}
+	transaction.TransferID = ""
+	transaction.RecurringID = existing.RecurringID
+	transaction.ExternalID = existing.ExternalID
+	transaction.Invoice = existing.Invoice
+	transaction.DuplicateOf = existing.DuplicateOf
+	
 	return s.repo.UpdateTransaction(ctx, transaction)
 }
 
@@ -113,9 +400,29 @@ func (s *transactionService) DeleteTransaction(ctx context.Context, userID, tran
 		return fmt.Errorf("userID and transactionID are required")
 	}
 	
+	existing, err := s.repo.GetTransaction(ctx, userID, transactionID)
+	if err != nil {
+		return err
+	}
+	if existing.TransferID != "" {
+		return fmt.Errorf("%w: delete transfer %s instead", ErrTransferEntry, existing.TransferID)
+	}
+	
 	return s.repo.DeleteTransaction(ctx, userID, transactionID)
 }
 
+// checkAccount verifies the account a transaction is linked to exists
+func (s *transactionService) checkAccount(ctx context.Context, userID, accountID string) error {
+	if accountID == "" {
+		return nil
+	}
+	_, err := s.repo.GetAccount(ctx, userID, accountID)
+	if errors.Is(err, repository.ErrAccountNotFound) {
+		return fmt.Errorf("%w: account %s not found", ErrInvalidAccount, accountID)
+	}
+	return err
+}
+
 func (s *transactionService) ValidateTransaction(transaction *models.Transaction) error {
 	if transaction == nil {
 		return fmt.Errorf("transaction cannot be nil")
@@ -130,10 +437,16 @@ func (s *transactionService) ValidateTransaction(transaction *models.Transaction
 		return fmt.Errorf("user ID is required")
 	}
 	
-	if transaction.Amount == 0 {
+	if transaction.Amount.IsZero() {
 		return fmt.Errorf("amount cannot be zero")
 	}
 	
+	// Splits must add up to the amount; a split transaction can leave the
+	// category to its largest split
+	if err := transaction.ValidateSplits(); err != nil {
+		return err
+	}
+	
 	if transaction.Category == "" {
 		return fmt.Errorf("category is required")
 	}
@@ -146,6 +459,16 @@ func (s *transactionService) ValidateTransaction(transaction *models.Transaction
 		return fmt.Errorf("description is required")
 	}
 	
+	transaction.Tags = models.NormalizeTags(transaction.Tags)
+	if len(transaction.Tags) > models.MaxTransactionTags {
+		return fmt.Errorf("a transaction can have at most %d tags", models.MaxTransactionTags)
+	}
+	for _, tag := range transaction.Tags {
+		if len(tag) > models.MaxTagLength {
+			return fmt.Errorf("tag %q is longer than %d characters", tag, models.MaxTagLength)
+		}
+	}
+	
 	if transaction.Date.IsZero() {
 		transaction.Date = time.Now()
 	}
@@ -0,0 +1,203 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+// ErrInvalidRange is returned for malformed, inverted or oversized month ranges
+var ErrInvalidRange = errors.New("invalid month range")
+
+// MaxTrendMonths bounds the months returned by GetMonthlyTrends
+const MaxTrendMonths = 60
+
+const monthLayout = "2006-01"
+
+// trendBuilder buckets transactions already expressed in the base currency
+// into per-month totals between two months (inclusive)
+type trendBuilder struct {
+	base   string
+	first  string
+	last   string
+	months map[string]*models.MonthlyTrendPoint
+}
+
+func newTrendBuilder(base string, first, last time.Time) *trendBuilder {
+	return &trendBuilder{
+		base:   base,
+		first:  first.Format(monthLayout),
+		last:   last.Format(monthLayout),
+		months: make(map[string]*models.MonthlyTrendPoint),
+	}
+}
+
+func (b *trendBuilder) add(tx models.Transaction) {
+	month := tx.Date.Format(monthLayout)
+	if tx.IsTransfer() || month < b.first || month > b.last {
+		return
+	}
+
+	point, ok := b.months[month]
+	if !ok {
+		empty := b.emptyPoint(month)
+		point = &empty
+		b.months[month] = point
+	}
+
+	point.TransactionCount++
+	if tx.Type == models.TransactionTypeIncome {
+		point.TotalIncome = point.TotalIncome.Add(tx.Amount)
+	} else if tx.Type == models.TransactionTypeExpense {
+		point.TotalExpenses = point.TotalExpenses.Add(tx.Amount.Abs())
+	}
+	point.NetBalance = point.TotalIncome.Sub(point.TotalExpenses)
+	for _, split := range tx.Allocations() {
+		point.Categories[split.Category] = point.Categories[split.Category].Add(split.Amount)
+	}
+}
+
+func (b *trendBuilder) emptyPoint(month string) models.MonthlyTrendPoint {
+	return models.MonthlyTrendPoint{
+		Month:         month,
+		TotalIncome:   models.ZeroMoney(b.base),
+		TotalExpenses: models.ZeroMoney(b.base),
+		NetBalance:    models.ZeroMoney(b.base),
+		Categories:    make(map[string]models.Money),
+	}
+}
+
+// point returns the totals of the month; months without transactions are zero
+func (b *trendBuilder) point(month time.Time) models.MonthlyTrendPoint {
+	key := month.Format(monthLayout)
+	if point, ok := b.months[key]; ok {
+		return *point
+	}
+	return b.emptyPoint(key)
+}
+
+// series returns every month from one to another with month-over-month and
+// year-over-year deltas. Deltas are only set when the earlier month was collected.
+func (b *trendBuilder) series(from, to time.Time) []models.MonthlyTrendPoint {
+	var points []models.MonthlyTrendPoint
+	for month := from; !month.After(to); month = month.AddDate(0, 1, 0) {
+		point := b.point(month)
+		if previous := month.AddDate(0, -1, 0); b.covers(previous) {
+			point.MonthOverMonth = compareMonths(point, b.point(previous))
+		}
+		if lastYear := month.AddDate(-1, 0, 0); b.covers(lastYear) {
+			point.YearOverYear = compareMonths(point, b.point(lastYear))
+		}
+		points = append(points, point)
+	}
+	return points
+}
+
+func (b *trendBuilder) covers(month time.Time) bool {
+	key := month.Format(monthLayout)
+	return key >= b.first && key <= b.last
+}
+
+func compareMonths(current, earlier models.MonthlyTrendPoint) *models.TrendDelta {
+	return &models.TrendDelta{
+		Compared:          earlier.Month,
+		Income:            current.TotalIncome.Sub(earlier.TotalIncome),
+		Expenses:          current.TotalExpenses.Sub(earlier.TotalExpenses),
+		NetBalance:        current.NetBalance.Sub(earlier.NetBalance),
+		IncomePercent:     percentChange(earlier.TotalIncome, current.TotalIncome),
+		ExpensesPercent:   percentChange(earlier.TotalExpenses, current.TotalExpenses),
+		NetBalancePercent: percentChange(earlier.NetBalance, current.NetBalance),
+	}
+}
+
+// percentChange returns the change from earlier to current as a percentage of
+// the earlier magnitude, or nil when earlier is zero
+func percentChange(earlier, current models.Money) *float64 {
+	if earlier.IsZero() {
+		return nil
+	}
+	change := current.Sub(earlier).Ratio(earlier.Abs()) * 100
+	return &change
+}
+
+// parseMonthRange validates a YYYY-MM range and returns the first day of both months
+func parseMonthRange(fromMonth, toMonth string) (time.Time, time.Time, error) {
+	from, err := time.Parse(monthLayout, fromMonth)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from month %q, expected YYYY-MM", ErrInvalidRange, fromMonth)
+	}
+	to, err := time.Parse(monthLayout, toMonth)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: to month %q, expected YYYY-MM", ErrInvalidRange, toMonth)
+	}
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: from month must not be after to month", ErrInvalidRange)
+	}
+	if months := monthsBetween(from, to) + 1; months > MaxTrendMonths {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: range spans %d months, at most %d allowed", ErrInvalidRange, months, MaxTrendMonths)
+	}
+	return from, to, nil
+}
+
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()-from.Month())
+}
+
+// collectTrends walks the user's history once and buckets the months from
+// first to last in the base currency
+func (s *analyticsService) collectTrends(ctx context.Context, userID string, first, last time.Time) (*trendBuilder, string, error) {
+	base, err := s.converter.BaseCurrency(ctx, userID)
+	if err != nil {
+		return nil, "", err
+	}
+	builder := newTrendBuilder(base, first, last)
+
+	err = s.converter.ForEachInBase(ctx, repository.IterateTransactionsByUser(s.repo, userID), base, func(tx models.Transaction) error {
+		builder.add(tx)
+		return nil
+	})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to get user transactions: %w", err)
+	}
+	return builder, base, nil
+}
+
+// GetMonthlyTrends returns income, expenses, balance and category totals for
+// every month of the range, each compared with the previous month and with the
+// same month of the previous year
+func (s *analyticsService) GetMonthlyTrends(ctx context.Context, userID, fromMonth, toMonth string) (*models.MonthlyTrends, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	from, to, err := parseMonthRange(fromMonth, toMonth)
+	if err != nil {
+		return nil, err
+	}
+
+	// A year of history before the range feeds the year-over-year deltas
+	builder, base, err := s.collectTrends(ctx, userID, from.AddDate(-1, 0, 0), to)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.MonthlyTrends{
+		From:     fromMonth,
+		To:       toMonth,
+		Currency: base,
+		Months:   builder.series(from, to),
+	}, nil
+}
+
+// GetMonthTrend returns a single month of the trends series with its deltas
+func (s *analyticsService) GetMonthTrend(ctx context.Context, userID, month string) (*models.MonthlyTrendPoint, error) {
+	trends, err := s.GetMonthlyTrends(ctx, userID, month, month)
+	if err != nil {
+		return nil, err
+	}
+	return &trends.Months[0], nil
+}
@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+)
+
+type UserService interface {
+	GetProfile(ctx context.Context, userID string) (*models.User, error)
+	UpdateProfile(ctx context.Context, userID string, update *models.UserProfileUpdate) (*models.User, error)
+}
+
+type userService struct {
+	repo repository.Repository
+}
+
+func NewUserService(repo repository.Repository) UserService {
+	return &userService{
+		repo: repo,
+	}
+}
+
+// GetProfile returns the stored profile, or the defaults for users who never saved one
+func (s *userService) GetProfile(ctx context.Context, userID string) (*models.User, error) {
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	user, err := s.repo.GetUser(ctx, userID)
+	if errors.Is(err, repository.ErrUserNotFound) {
+		user = &models.User{ID: userID}
+		user.GenerateKeys()
+	} else if err != nil {
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	user.BaseCurrency = user.Currency()
+	return user, nil
+}
+
+// UpdateProfile applies the provided fields and stores the profile
+func (s *userService) UpdateProfile(ctx context.Context, userID string, update *models.UserProfileUpdate) (*models.User, error) {
+	user, err := s.GetProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil {
+		user.Name = strings.TrimSpace(*update.Name)
+	}
+	if update.Email != nil {
+		user.Email = strings.TrimSpace(*update.Email)
+	}
+	if update.BaseCurrency != nil {
+		currency, err := models.NormalizeCurrency(*update.BaseCurrency)
+		if err != nil {
+			return nil, err
+		}
+		user.BaseCurrency = currency
+	}
+
+	if err := s.repo.UpdateUser(ctx, user); err != nil {
+		return nil, fmt.Errorf("failed to update user: %w", err)
+	}
+
+	return user, nil
+}
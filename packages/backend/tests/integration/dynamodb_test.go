@@ -35,7 +35,7 @@ func (suite *DynamoDBIntegrationSuite) TestCreateAndRetrieveTransaction() {
 	transaction := &models.Transaction{
 		ID:          uuid.New().String(),
 		UserID:      userID,
-		Amount:      100.50,
+		Amount:      models.MoneyFromFloat(100.50, models.DefaultCurrency),
 		Type:        "expense",
 		Category:    "food",
 		Description: "Test transaction",
@@ -2,6 +2,7 @@ package mocks
 
 import (
 	"context"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/mock"
@@ -71,6 +72,18 @@ func (m *MockRepository) GetTransactionsByMonth(ctx context.Context, userID stri
 	return args.Get(0).([]models.Transaction), nextKey, args.Error(2)
 }
 
+func (m *MockRepository) GetTransactionsByMonthBetween(ctx context.Context, userID string, month string, from, to time.Time, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+	args := m.Called(ctx, userID, month, from, to, limit, lastKey)
+	var nextKey map[string]types.AttributeValue
+	if args.Get(1) != nil {
+		nextKey = args.Get(1).(map[string]types.AttributeValue)
+	}
+	if args.Get(0) == nil {
+		return nil, nextKey, args.Error(2)
+	}
+	return args.Get(0).([]models.Transaction), nextKey, args.Error(2)
+}
+
 func (m *MockRepository) GetTransactionsByCategory(ctx context.Context, userID string, category string, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
 	args := m.Called(ctx, userID, category, limit, lastKey)
 	if args.Get(0) == nil {
@@ -87,6 +100,22 @@ func (m *MockRepository) GetTransactionsByCategory(ctx context.Context, userID s
 	return args.Get(0).([]models.Transaction), nextKey, args.Error(2)
 }
 
+func (m *MockRepository) GetTransactionsByTag(ctx context.Context, userID string, tag string, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+	args := m.Called(ctx, userID, tag, limit, lastKey)
+	if args.Get(0) == nil {
+		var nextKey map[string]types.AttributeValue
+		if args.Get(1) != nil {
+			nextKey = args.Get(1).(map[string]types.AttributeValue)
+		}
+		return nil, nextKey, args.Error(2)
+	}
+	var nextKey map[string]types.AttributeValue
+	if args.Get(1) != nil {
+		nextKey = args.Get(1).(map[string]types.AttributeValue)
+	}
+	return args.Get(0).([]models.Transaction), nextKey, args.Error(2)
+}
+
 // Batch operations
 func (m *MockRepository) BatchCreateTransactions(ctx context.Context, transactions []models.Transaction) error {
 	args := m.Called(ctx, transactions)
@@ -116,6 +145,27 @@ func (m *MockRepository) GetBudgetsByMonth(ctx context.Context, userID, month st
 	return args.Get(0).([]models.Budget), args.Error(1)
 }
 
+func (m *MockRepository) BatchUpsertBudgets(ctx context.Context, budgets []models.Budget) error {
+	args := m.Called(ctx, budgets)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetBudgetsInRange(ctx context.Context, userID, fromMonth, toMonth string) ([]models.Budget, error) {
+	args := m.Called(ctx, userID, fromMonth, toMonth)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Budget), args.Error(1)
+}
+
+func (m *MockRepository) ListBudgets(ctx context.Context, userID string) ([]models.Budget, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Budget), args.Error(1)
+}
+
 func (m *MockRepository) GetBudget(ctx context.Context, userID, month, category string) (*models.Budget, error) {
 	args := m.Called(ctx, userID, month, category)
 	if args.Get(0) == nil {
@@ -129,6 +179,259 @@ func (m *MockRepository) DeleteBudget(ctx context.Context, userID, month, catego
 	return args.Error(0)
 }
 
+// Recurring transaction operations
+func (m *MockRepository) CreateRecurringTransaction(ctx context.Context, recurring *models.RecurringTransaction) error {
+	args := m.Called(ctx, recurring)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRecurringTransaction(ctx context.Context, userID, recurringID string) (*models.RecurringTransaction, error) {
+	args := m.Called(ctx, userID, recurringID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.RecurringTransaction), args.Error(1)
+}
+
+func (m *MockRepository) ListRecurringTransactions(ctx context.Context, userID string) ([]models.RecurringTransaction, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.RecurringTransaction), args.Error(1)
+}
+
+func (m *MockRepository) UpdateRecurringTransaction(ctx context.Context, recurring *models.RecurringTransaction) error {
+	args := m.Called(ctx, recurring)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteRecurringTransaction(ctx context.Context, userID, recurringID string) error {
+	args := m.Called(ctx, userID, recurringID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetDueRecurringTransactions(ctx context.Context, before time.Time) ([]models.RecurringTransaction, error) {
+	args := m.Called(ctx, before)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.RecurringTransaction), args.Error(1)
+}
+
+// Import mapping operations
+func (m *MockRepository) SaveImportMapping(ctx context.Context, mapping *models.ImportMapping) error {
+	args := m.Called(ctx, mapping)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetImportMapping(ctx context.Context, userID, name string) (*models.ImportMapping, error) {
+	args := m.Called(ctx, userID, name)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.ImportMapping), args.Error(1)
+}
+
+func (m *MockRepository) ListImportMappings(ctx context.Context, userID string) ([]models.ImportMapping, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.ImportMapping), args.Error(1)
+}
+
+func (m *MockRepository) DeleteImportMapping(ctx context.Context, userID, name string) error {
+	args := m.Called(ctx, userID, name)
+	return args.Error(0)
+}
+
+// Account operations
+func (m *MockRepository) CreateAccount(ctx context.Context, account *models.Account) error {
+	args := m.Called(ctx, account)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetAccount(ctx context.Context, userID, accountID string) (*models.Account, error) {
+	args := m.Called(ctx, userID, accountID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Account), args.Error(1)
+}
+
+func (m *MockRepository) ListAccounts(ctx context.Context, userID string) ([]models.Account, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Account), args.Error(1)
+}
+
+func (m *MockRepository) UpdateAccount(ctx context.Context, account *models.Account) error {
+	args := m.Called(ctx, account)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteAccount(ctx context.Context, userID, accountID string) error {
+	args := m.Called(ctx, userID, accountID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetTransactionsByAccount(ctx context.Context, userID string, accountID string, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+	args := m.Called(ctx, userID, accountID, limit, lastKey)
+	if args.Get(0) == nil {
+		var nextKey map[string]types.AttributeValue
+		if args.Get(1) != nil {
+			nextKey = args.Get(1).(map[string]types.AttributeValue)
+		}
+		return nil, nextKey, args.Error(2)
+	}
+	var nextKey map[string]types.AttributeValue
+	if args.Get(1) != nil {
+		nextKey = args.Get(1).(map[string]types.AttributeValue)
+	}
+	return args.Get(0).([]models.Transaction), nextKey, args.Error(2)
+}
+
+func (m *MockRepository) CreateTransfer(ctx context.Context, transfer *models.Transfer) error {
+	args := m.Called(ctx, transfer)
+	return args.Error(0)
+}
+
+// Savings goal operations
+func (m *MockRepository) CreateGoal(ctx context.Context, goal *models.Goal) error {
+	args := m.Called(ctx, goal)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetGoal(ctx context.Context, userID, goalID string) (*models.Goal, error) {
+	args := m.Called(ctx, userID, goalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Goal), args.Error(1)
+}
+
+func (m *MockRepository) ListGoals(ctx context.Context, userID string) ([]models.Goal, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Goal), args.Error(1)
+}
+
+func (m *MockRepository) UpdateGoal(ctx context.Context, goal *models.Goal) error {
+	args := m.Called(ctx, goal)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteGoal(ctx context.Context, userID, goalID string) error {
+	args := m.Called(ctx, userID, goalID)
+	return args.Error(0)
+}
+
+func (m *MockRepository) CreateGoalContribution(ctx context.Context, contribution *models.GoalContribution) error {
+	args := m.Called(ctx, contribution)
+	return args.Error(0)
+}
+
+func (m *MockRepository) ListGoalContributions(ctx context.Context, userID, goalID string) ([]models.GoalContribution, error) {
+	args := m.Called(ctx, userID, goalID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.GoalContribution), args.Error(1)
+}
+
+func (m *MockRepository) DeleteGoalContribution(ctx context.Context, userID, goalID, contributionID string) error {
+	args := m.Called(ctx, userID, goalID, contributionID)
+	return args.Error(0)
+}
+
+// Category catalog operations
+func (m *MockRepository) CreateCategory(ctx context.Context, category *models.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetCategory(ctx context.Context, userID, categoryID string) (*models.Category, error) {
+	args := m.Called(ctx, userID, categoryID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.Category), args.Error(1)
+}
+
+func (m *MockRepository) ListCategories(ctx context.Context, userID string) ([]models.Category, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.Category), args.Error(1)
+}
+
+func (m *MockRepository) UpdateCategory(ctx context.Context, category *models.Category) error {
+	args := m.Called(ctx, category)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteCategory(ctx context.Context, userID, categoryID string) error {
+	args := m.Called(ctx, userID, categoryID)
+	return args.Error(0)
+}
+
+// Categorization rule operations
+func (m *MockRepository) CreateRule(ctx context.Context, rule *models.CategoryRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetRule(ctx context.Context, userID, ruleID string) (*models.CategoryRule, error) {
+	args := m.Called(ctx, userID, ruleID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.CategoryRule), args.Error(1)
+}
+
+func (m *MockRepository) ListRules(ctx context.Context, userID string) ([]models.CategoryRule, error) {
+	args := m.Called(ctx, userID)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).([]models.CategoryRule), args.Error(1)
+}
+
+func (m *MockRepository) UpdateRule(ctx context.Context, rule *models.CategoryRule) error {
+	args := m.Called(ctx, rule)
+	return args.Error(0)
+}
+
+func (m *MockRepository) DeleteRule(ctx context.Context, userID, ruleID string) error {
+	args := m.Called(ctx, userID, ruleID)
+	return args.Error(0)
+}
+
+// Idempotency key operations
+func (m *MockRepository) CreateIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	args := m.Called(ctx, key)
+	return args.Error(0)
+}
+
+func (m *MockRepository) GetIdempotencyKey(ctx context.Context, userID, key string) (*models.IdempotencyKey, error) {
+	args := m.Called(ctx, userID, key)
+	if args.Get(0) == nil {
+		return nil, args.Error(1)
+	}
+	return args.Get(0).(*models.IdempotencyKey), args.Error(1)
+}
+
+func (m *MockRepository) DeleteIdempotencyKey(ctx context.Context, userID, key string) error {
+	args := m.Called(ctx, userID, key)
+	return args.Error(0)
+}
+
 // User operations
 func (m *MockRepository) CreateUser(ctx context.Context, user *models.User) error {
 	args := m.Called(ctx, user)
@@ -143,6 +446,11 @@ func (m *MockRepository) GetUser(ctx context.Context, userID string) (*models.Us
 	return args.Get(0).(*models.User), args.Error(1)
 }
 
+func (m *MockRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	args := m.Called(ctx, user)
+	return args.Error(0)
+}
+
 // MockOpenAIClient is a mock implementation of the OpenAI client
 type MockOpenAIClient struct {
 	mock.Mock
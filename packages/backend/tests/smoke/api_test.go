@@ -12,6 +12,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
 
+	"backend/internal/auth"
 	"backend/internal/models"
 )
 
@@ -36,7 +37,16 @@ func (suite *SmokeTestSuite) SetupSuite() {
 
 	// Test user ID for smoke tests
 	suite.userID = "smoke-test-user-" + fmt.Sprintf("%d", time.Now().Unix())
-	suite.authToken = "test-token" // In real scenarios, this would be a JWT
+	suite.authToken = os.Getenv("API_AUTH_TOKEN")
+	if secret := os.Getenv("JWT_SECRET"); suite.authToken == "" && secret != "" {
+		// Mint a short-lived token for the test user from the shared secret
+		token, err := auth.NewHS256Token(auth.Claims{
+			Subject:   suite.userID,
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		}, secret)
+		suite.Require().NoError(err)
+		suite.authToken = token
+	}
 }
 
 func (suite *SmokeTestSuite) TearDownSuite() {
@@ -59,7 +69,7 @@ func (suite *SmokeTestSuite) TestHealthEndpoint() {
 
 func (suite *SmokeTestSuite) TestCreateTransaction() {
 	transaction := models.Transaction{
-		Amount:      150.75,
+		Amount:      models.MoneyFromFloat(150.75, models.DefaultCurrency),
 		Description: "Smoke test transaction",
 		Category:    "food",
 		Type:        "expense",
@@ -137,10 +147,16 @@ func (suite *SmokeTestSuite) TestGetTransactionsWithFilters() {
 
 	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
 
-	var transactions []models.Transaction
-	err = json.NewDecoder(resp.Body).Decode(&transactions)
+	var page struct {
+		Data []models.Transaction `json:"data"`
+		Meta models.APIMeta       `json:"meta"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&page)
 	assert.NoError(suite.T(), err)
-	assert.NotNil(suite.T(), transactions)
+	assert.NotNil(suite.T(), page.Data)
+	if page.Meta.HasMore {
+		assert.NotEmpty(suite.T(), page.Meta.NextCursor)
+	}
 }
 
 func (suite *SmokeTestSuite) TestGetAnalytics() {
@@ -398,21 +414,21 @@ func (suite *SmokeTestSuite) TestEndToEndFlow() {
 	// 1. Create multiple transactions
 	transactions := []models.Transaction{
 		{
-			Amount:      100.50,
+			Amount:      models.MoneyFromFloat(100.50, models.DefaultCurrency),
 			Description: "Grocery shopping",
 			Category:    "food",
 			Type:        "expense",
 			UserID:      suite.userID,
 		},
 		{
-			Amount:      2500.00,
+			Amount:      models.MoneyFromFloat(2500.00, models.DefaultCurrency),
 			Description: "Monthly salary",
 			Category:    "salary",
 			Type:        "income",
 			UserID:      suite.userID,
 		},
 		{
-			Amount:      50.00,
+			Amount:      models.MoneyFromFloat(50.00, models.DefaultCurrency),
 			Description: "Gas",
 			Category:    "transportation",
 			Type:        "expense",
@@ -459,10 +475,12 @@ func (suite *SmokeTestSuite) TestEndToEndFlow() {
 
 	assert.Equal(suite.T(), http.StatusOK, resp.StatusCode)
 
-	var result []models.Transaction
+	var result struct {
+		Data []models.Transaction `json:"data"`
+	}
 	err = json.NewDecoder(resp.Body).Decode(&result)
 	assert.NoError(suite.T(), err)
-	assert.True(suite.T(), len(result) >= len(transactions))
+	assert.True(suite.T(), len(result.Data) >= len(transactions))
 
 	resp.Body.Close()
 
@@ -481,8 +499,8 @@ func (suite *SmokeTestSuite) TestEndToEndFlow() {
 	var analytics models.MonthlyAnalytics
 	err = json.NewDecoder(resp.Body).Decode(&analytics)
 	assert.NoError(suite.T(), err)
-	assert.True(suite.T(), analytics.TotalIncome >= 2500.00)
-	assert.True(suite.T(), analytics.TotalExpense >= 150.50)
+	assert.True(suite.T(), analytics.TotalIncome.Cmp(models.MoneyFromFloat(2500.00, models.DefaultCurrency)) >= 0)
+	assert.True(suite.T(), analytics.TotalExpense.Cmp(models.MoneyFromFloat(150.50, models.DefaultCurrency)) >= 0)
 
 	resp.Body.Close()
 
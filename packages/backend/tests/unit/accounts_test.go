@@ -0,0 +1,234 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/period"
+	"backend/internal/rates"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+func TestAccount_Validate(t *testing.T) {
+	tests := []struct {
+		name         string
+		account      models.Account
+		expectError  bool
+		wantName     string
+		wantType     string
+		wantCurrency string
+	}{
+		{
+			name:         "name, type and currency are normalized",
+			account:      models.Account{UserID: "user123", Name: "  Nómina ", Type: "Checking", Currency: "usd"},
+			wantName:     "Nómina",
+			wantType:     models.AccountTypeChecking,
+			wantCurrency: "USD",
+		},
+		{
+			name:         "currency defaults",
+			account:      models.Account{UserID: "user123", Name: "Cartera", Type: models.AccountTypeCash},
+			wantName:     "Cartera",
+			wantType:     models.AccountTypeCash,
+			wantCurrency: models.DefaultCurrency,
+		},
+		{
+			name:        "unknown type should fail",
+			account:     models.Account{UserID: "user123", Name: "Inversiones", Type: "brokerage"},
+			expectError: true,
+		},
+		{
+			name:        "missing name should fail",
+			account:     models.Account{UserID: "user123", Type: models.AccountTypeSavings},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.account.Validate()
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, tt.account.Name)
+			assert.Equal(t, tt.wantType, tt.account.Type)
+			assert.Equal(t, tt.wantCurrency, tt.account.Currency)
+			assert.Equal(t, tt.wantCurrency, tt.account.OpeningBalance.Currency)
+		})
+	}
+}
+
+func TestAccountService_CreateTransfer(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetAccount", mock.Anything, "user123", "checking").Return(testAccount("checking", "MXN", 0), nil)
+	mockRepo.On("GetAccount", mock.Anything, "user123", "savings").Return(testAccount("savings", "MXN", 0), nil)
+	mockRepo.On("CreateTransfer", mock.Anything, mock.AnythingOfType("*models.Transfer")).Return(nil)
+	service := services.NewAccountService(mockRepo)
+
+	transfer, err := service.CreateTransfer(context.Background(), "user123", models.TransferInput{
+		FromAccountID: "checking",
+		ToAccountID:   "savings",
+		Amount:        models.NewMoney(500000, "MXN"),
+		Date:          time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+
+	fromID, toID := models.TransferEntryIDs(transfer.ID)
+	assert.Equal(t, fromID, transfer.From.ID)
+	assert.Equal(t, toID, transfer.To.ID)
+	assert.Equal(t, models.NewMoney(-500000, "MXN"), transfer.From.Amount)
+	assert.Equal(t, models.NewMoney(500000, "MXN"), transfer.To.Amount)
+	assert.Equal(t, "checking", transfer.From.AccountID)
+	assert.Equal(t, "savings", transfer.To.AccountID)
+	assert.True(t, transfer.From.IsTransfer())
+	assert.Equal(t, transfer.ID, transfer.To.TransferID)
+
+	_, err = service.CreateTransfer(context.Background(), "user123", models.TransferInput{
+		FromAccountID: "checking",
+		ToAccountID:   "checking",
+		Amount:        models.NewMoney(100, "MXN"),
+	})
+	assert.ErrorIs(t, err, services.ErrInvalidTransfer)
+}
+
+func TestAccountService_CreateTransfer_ConvertsBetweenCurrencies(t *testing.T) {
+	store := rates.NewStore()
+	require.NoError(t, store.AddRate(rates.Rate{Date: "2025-03-01", Base: "USD", Quote: "MXN", Rate: 20}))
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetAccount", mock.Anything, "user123", "dollars").Return(testAccount("dollars", "USD", 0), nil)
+	mockRepo.On("GetAccount", mock.Anything, "user123", "pesos").Return(testAccount("pesos", "MXN", 0), nil)
+	mockRepo.On("CreateTransfer", mock.Anything, mock.AnythingOfType("*models.Transfer")).Return(nil)
+	service := services.NewAccountServiceWithRates(mockRepo, store)
+
+	transfer, err := service.CreateTransfer(context.Background(), "user123", models.TransferInput{
+		FromAccountID: "dollars",
+		ToAccountID:   "pesos",
+		Amount:        models.NewMoney(10000, "USD"),
+		Date:          time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(-10000, "USD"), transfer.From.Amount)
+	assert.Equal(t, models.NewMoney(200000, "MXN"), transfer.To.Amount)
+
+	// The amount the bank actually credited wins over the published rate
+	credited := models.NewMoney(198050, "MXN")
+	transfer, err = service.CreateTransfer(context.Background(), "user123", models.TransferInput{
+		FromAccountID: "dollars",
+		ToAccountID:   "pesos",
+		Amount:        models.NewMoney(10000, "USD"),
+		ToAmount:      &credited,
+		Date:          time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, credited, transfer.To.Amount)
+}
+
+func TestAccountService_GetLedger(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetAccount", mock.Anything, "user123", "checking").Return(testAccount("checking", "MXN", 100000), nil)
+	mockRepo.On("GetTransactionsByAccount", mock.Anything, "user123", "checking", mock.Anything, mock.Anything).
+		Return(inAccount("checking",
+			testTransaction("april", -5000, testDate(time.April, 2)),
+			testTransaction("rent", -40000, testDate(time.March, 10)),
+			testTransaction("february", -10000, testDate(time.February, 20)),
+			testTransaction("groceries", -2500, testDate(time.March, 5)),
+		), map[string]types.AttributeValue{}, nil)
+	service := services.NewAccountService(mockRepo)
+
+	rng, err := period.Parse("", "2025-03-01", "2025-03-31", time.Now())
+	require.NoError(t, err)
+
+	ledger, err := service.GetLedger(context.Background(), "user123", "checking", rng)
+	require.NoError(t, err)
+
+	assert.Equal(t, models.NewMoney(90000, "MXN"), ledger.StartingBalance)
+	require.Len(t, ledger.Entries, 2)
+	assert.Equal(t, "groceries", ledger.Entries[0].Description)
+	assert.Equal(t, models.NewMoney(87500, "MXN"), ledger.Entries[0].Balance)
+	assert.Equal(t, "rent", ledger.Entries[1].Description)
+	assert.Equal(t, models.NewMoney(47500, "MXN"), ledger.Entries[1].Balance)
+	assert.Equal(t, models.NewMoney(47500, "MXN"), ledger.EndingBalance)
+}
+
+func TestAccountService_DeleteAccountInUse(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetAccount", mock.Anything, "user123", "checking").Return(testAccount("checking", "MXN", 0), nil)
+	mockRepo.On("GetTransactionsByAccount", mock.Anything, "user123", "checking", mock.Anything, mock.Anything).
+		Return(inAccount("checking", testTransaction("rent", -40000, testDate(time.March, 10))), map[string]types.AttributeValue{}, nil)
+	service := services.NewAccountService(mockRepo)
+
+	err := service.DeleteAccount(context.Background(), "user123", "checking")
+	assert.ErrorIs(t, err, services.ErrAccountInUse)
+	mockRepo.AssertNotCalled(t, "DeleteAccount", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestAnalyticsService_MonthlyAnalyticsExcludesTransfers(t *testing.T) {
+	transfer := models.NewTransfer("user123", models.TransferInput{
+		FromAccountID: "checking",
+		ToAccountID:   "savings",
+		Date:          time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+	}, models.NewMoney(500000, "MXN"), models.NewMoney(500000, "MXN"))
+
+	salary := testTransaction("salary", 2000000, testDate(time.March, 1))
+	salary.Type = models.TransactionTypeIncome
+	salary.Category = "salary"
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByMonth", mock.Anything, "user123", "2025-03", mock.Anything, mock.Anything).
+		Return(append(inAccount("checking", salary, testTransaction("rent", -40000, testDate(time.March, 10))), transfer.From, transfer.To),
+			map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, "user123").Return(nil, repository.ErrUserNotFound)
+	service := services.NewAnalyticsService(mockRepo)
+
+	analytics, err := service.GetMonthlyAnalytics(context.Background(), "user123", "2025-03")
+	require.NoError(t, err)
+
+	assert.Equal(t, models.NewMoney(2000000, "MXN"), analytics.TotalIncome)
+	assert.Equal(t, models.NewMoney(40000, "MXN"), analytics.TotalExpense)
+	assert.Equal(t, 2, analytics.TransactionCount)
+	assert.NotContains(t, analytics.CategoryBreakdown, models.CategoryTransfer)
+}
+
+func TestTransactionService_TransferEntriesAreReadOnly(t *testing.T) {
+	transfer := models.NewTransfer("user123", models.TransferInput{
+		FromAccountID: "checking",
+		ToAccountID:   "savings",
+		Date:          time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC),
+	}, models.NewMoney(500000, "MXN"), models.NewMoney(500000, "MXN"))
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransaction", mock.Anything, "user123", transfer.From.ID).Return(&transfer.From, nil)
+	service := services.NewTransactionService(mockRepo)
+
+	err := service.DeleteTransaction(context.Background(), "user123", transfer.From.ID)
+	assert.ErrorIs(t, err, services.ErrTransferEntry)
+	mockRepo.AssertNotCalled(t, "DeleteTransaction", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestTransactionService_CreateTransactionIgnoresTransferID(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	withRules(mockRepo)
+	withoutStoredTransactions(mockRepo)
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+		return tx.TransferID == ""
+	})).Return(nil)
+	service := services.NewTransactionService(mockRepo)
+
+	tx := testTransaction("Rent", -1200000, testDate(time.March, 1))
+	tx.TransferID = "transfer-1"
+	_, err := service.CreateTransaction(context.Background(), tx, services.CreateOptions{})
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
@@ -32,9 +32,9 @@ func TestAIService_GetFinancialAdvice(t *testing.T) {
 			mockSetup: func(repo *mocks.MockRepository) {
 				analytics := &models.MonthlyAnalytics{
 					Month:        "2024-01",
-					TotalIncome:  5000.0,
-					TotalExpense: 3500.0,
-					Balance:      1500.0,
+					TotalIncome:  models.MoneyFromFloat(5000.0, models.DefaultCurrency),
+					TotalExpense: models.MoneyFromFloat(3500.0, models.DefaultCurrency),
+					Balance:      models.MoneyFromFloat(1500.0, models.DefaultCurrency),
 				}
 				repo.On("GetMonthlyAnalytics", mock.Anything, mock.AnythingOfType("string"), mock.AnythingOfType("string")).Return(analytics, nil)
 			},
@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
 
 	"backend/internal/models"
+	"backend/internal/rates"
+	"backend/internal/repository"
 	"backend/internal/services"
 	"backend/tests/mocks"
 )
@@ -17,18 +20,22 @@ import (
 func TestAnalyticsService_GetMonthlyAnalytics(t *testing.T) {
 	userID := "user123"
 	month := "2024-01"
-	mockAnalytics := &models.MonthlyAnalytics{
-		Month:             month,
-		TotalIncome:       5000.0,
-		TotalExpense:      3500.0,
-		Balance:           1500.0,
-		CategoryBreakdown: map[string]float64{
-			"food":           800.0,
-			"transportation": 400.0,
-			"entertainment":  300.0,
-			"salary":         5000.0,
-		},
-		TransactionCount: 10,
+	monthTransaction := func(id, txType, category string, amount float64) models.Transaction {
+		return models.Transaction{
+			ID:       id,
+			UserID:   userID,
+			Type:     txType,
+			Category: category,
+			Amount:   models.MoneyFromFloat(amount, models.DefaultCurrency),
+			Date:     time.Date(2024, 1, 15, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	mockTransactions := []models.Transaction{
+		monthTransaction("tx1", "income", "salary", 5000.0),
+		monthTransaction("tx2", "expense", "food", -800.0),
+		monthTransaction("tx3", "expense", "transportation", -400.0),
+		monthTransaction("tx4", "expense", "entertainment", -300.0),
+		monthTransaction("tx5", "expense", "rent", -2000.0),
 	}
 
 	tests := []struct {
@@ -44,15 +51,18 @@ func TestAnalyticsService_GetMonthlyAnalytics(t *testing.T) {
 			userID: userID,
 			month:  month,
 			mockSetup: func(repo *mocks.MockRepository) {
-				repo.On("GetMonthlyAnalytics", mock.Anything, userID, month).Return(mockAnalytics, nil)
+				repo.On("GetTransactionsByMonth", mock.Anything, userID, month, 1000, mock.Anything).Return(mockTransactions, map[string]types.AttributeValue{}, nil)
+				repo.On("GetUser", mock.Anything, userID).Return(nil, repository.ErrUserNotFound)
 			},
 			expectedError: nil,
 			validateResult: func(t *testing.T, result *models.MonthlyAnalytics) {
 				assert.Equal(t, month, result.Month)
-				assert.Equal(t, 5000.0, result.TotalIncome)
-				assert.Equal(t, 3500.0, result.TotalExpense)
-				assert.Equal(t, 1500.0, result.Balance)
-				assert.Equal(t, 10, result.TransactionCount)
+				assert.Equal(t, models.NewMoney(500000, models.DefaultCurrency), result.TotalIncome)
+				assert.Equal(t, models.NewMoney(350000, models.DefaultCurrency), result.TotalExpense)
+				assert.Equal(t, models.NewMoney(150000, models.DefaultCurrency), result.Balance)
+				assert.Equal(t, models.DefaultCurrency, result.Currency)
+				assert.Equal(t, models.NewMoney(-80000, models.DefaultCurrency), result.CategoryBreakdown["food"])
+				assert.Equal(t, 5, result.TransactionCount)
 			},
 		},
 		{
@@ -60,7 +70,7 @@ func TestAnalyticsService_GetMonthlyAnalytics(t *testing.T) {
 			userID: userID,
 			month:  month,
 			mockSetup: func(repo *mocks.MockRepository) {
-				repo.On("GetMonthlyAnalytics", mock.Anything, userID, month).Return(nil, errors.New("database error"))
+				repo.On("GetTransactionsByMonth", mock.Anything, userID, month, 1000, mock.Anything).Return(nil, nil, errors.New("database error"))
 			},
 			expectedError: errors.New("database error"),
 		},
@@ -129,19 +139,22 @@ func TestAnalyticsService_GetFinancialSummary(t *testing.T) {
 					{
 						ID:       "tx1",
 						UserID:   userID,
-						Amount:   2800.0,
+						Amount:   models.MoneyFromFloat(2800.0, models.DefaultCurrency),
 						Type:     "income",
 						Category: "salary",
 					},
 					{
 						ID:       "tx2",
 						UserID:   userID,
-						Amount:   -100.0,
+						Amount:   models.MoneyFromFloat(-100.0, models.DefaultCurrency),
 						Type:     "expense",
 						Category: "food",
 					},
 				}
 				repo.On("GetTransactionsByUser", mock.Anything, userID, 1000, mock.Anything).Return(mockTransactions, map[string]types.AttributeValue{}, nil)
+				repo.On("GetUser", mock.Anything, userID).Return(nil, repository.ErrUserNotFound)
+				withCategories(repo)
+				withAccounts(repo)
 			},
 			expectedError: nil,
 		},
@@ -176,3 +189,155 @@ func TestAnalyticsService_GetFinancialSummary(t *testing.T) {
 		})
 	}
 }
+
+func TestAnalyticsService_GetFinancialSummary_ConvertsToBaseCurrency(t *testing.T) {
+	userID := "user123"
+	store := rates.NewStore()
+	assert.NoError(t, store.AddRate(rates.Rate{Date: "2025-08-01", Base: "USD", Quote: "MXN", Rate: 18.5}))
+	assert.NoError(t, store.AddRate(rates.Rate{Date: "2025-08-15", Base: "USD", Quote: "MXN", Rate: 19.0}))
+
+	mockTransactions := []models.Transaction{
+		{
+			ID:       "tx1",
+			UserID:   userID,
+			Amount:   models.MoneyFromFloat(10000.0, "MXN"),
+			Type:     "income",
+			Category: "salary",
+			Date:     time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// Uses the 2025-08-01 rate: no rate was published on the 10th
+			ID:       "tx2",
+			UserID:   userID,
+			Amount:   models.MoneyFromFloat(-100.0, "USD"),
+			Type:     "expense",
+			Category: "travel",
+			Date:     time.Date(2025, 8, 10, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			ID:       "tx3",
+			UserID:   userID,
+			Amount:   models.MoneyFromFloat(-10.0, "USD"),
+			Type:     "expense",
+			Category: "travel",
+			Date:     time.Date(2025, 8, 20, 0, 0, 0, 0, time.UTC),
+		},
+	}
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByUser", mock.Anything, userID, 1000, mock.Anything).Return(mockTransactions, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, userID).Return(&models.User{ID: userID, BaseCurrency: "MXN"}, nil)
+	withCategories(mockRepo)
+	withAccounts(mockRepo)
+
+	service := services.NewAnalyticsServiceWithRates(mockRepo, store)
+	result, err := service.GetFinancialSummary(context.Background(), userID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, "MXN", result.Currency)
+	assert.Equal(t, models.NewMoney(1000000, "MXN"), result.MonthlyIncome)
+	// 100 USD * 18.5 + 10 USD * 19.0 = 2040 MXN
+	assert.Equal(t, models.NewMoney(-204000, "MXN"), result.MonthlyExpenses)
+	assert.Equal(t, models.NewMoney(796000, "MXN"), result.TotalBalance)
+
+	// Without a rate for the pair the summary fails instead of mixing currencies
+	failing := services.NewAnalyticsService(mockRepo)
+	_, err = failing.GetFinancialSummary(context.Background(), userID)
+	assert.ErrorIs(t, err, rates.ErrRateNotFound)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestAnalyticsService_GetMonthlyTrends(t *testing.T) {
+	userID := "user123"
+	tx := func(id string, amount float64, txType, category string, year int, month time.Month) models.Transaction {
+		return models.Transaction{
+			ID:       id,
+			UserID:   userID,
+			Amount:   models.MoneyFromFloat(amount, models.DefaultCurrency),
+			Type:     txType,
+			Category: category,
+			Date:     time.Date(year, month, 10, 0, 0, 0, 0, time.UTC),
+		}
+	}
+	mockTransactions := []models.Transaction{
+		tx("tx1", 1000, "income", "salary", 2025, time.March),
+		tx("tx2", -300, "expense", "food", 2025, time.March),
+		tx("tx3", -100, "expense", "transport", 2025, time.March),
+		tx("tx4", 1000, "income", "salary", 2025, time.February),
+		tx("tx5", -200, "expense", "food", 2025, time.February),
+		tx("tx6", 800, "income", "salary", 2024, time.March),
+		tx("tx7", -400, "expense", "food", 2024, time.March),
+		// Outside the range and its year of history
+		tx("tx8", -999, "expense", "food", 2023, time.December),
+	}
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByUser", mock.Anything, userID, 1000, mock.Anything).Return(mockTransactions, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, userID).Return(nil, repository.ErrUserNotFound)
+
+	service := services.NewAnalyticsService(mockRepo)
+	trends, err := service.GetMonthlyTrends(context.Background(), userID, "2025-01", "2025-03")
+	assert.NoError(t, err)
+	assert.Equal(t, models.DefaultCurrency, trends.Currency)
+	assert.Len(t, trends.Months, 3)
+
+	// Months without transactions are present with zero totals
+	january := trends.Months[0]
+	assert.Equal(t, "2025-01", january.Month)
+	assert.Equal(t, 0, january.TransactionCount)
+	assert.True(t, january.NetBalance.IsZero())
+
+	march := trends.Months[2]
+	assert.Equal(t, "2025-03", march.Month)
+	assert.Equal(t, 3, march.TransactionCount)
+	assert.Equal(t, models.NewMoney(100000, models.DefaultCurrency), march.TotalIncome)
+	assert.Equal(t, models.NewMoney(40000, models.DefaultCurrency), march.TotalExpenses)
+	assert.Equal(t, models.NewMoney(60000, models.DefaultCurrency), march.NetBalance)
+	assert.Equal(t, models.NewMoney(-30000, models.DefaultCurrency), march.Categories["food"])
+
+	// Month over month: expenses 200 -> 400
+	assert.Equal(t, "2025-02", march.MonthOverMonth.Compared)
+	assert.Equal(t, models.NewMoney(20000, models.DefaultCurrency), march.MonthOverMonth.Expenses)
+	assert.InDelta(t, 100.0, *march.MonthOverMonth.ExpensesPercent, 0.001)
+	assert.InDelta(t, 0.0, *march.MonthOverMonth.IncomePercent, 0.001)
+
+	// Year over year: balance 400 -> 600
+	assert.Equal(t, "2024-03", march.YearOverYear.Compared)
+	assert.Equal(t, models.NewMoney(20000, models.DefaultCurrency), march.YearOverYear.NetBalance)
+	assert.InDelta(t, 50.0, *march.YearOverYear.NetBalancePercent, 0.001)
+
+	// No income in February 2024: the percentage is undefined
+	february := trends.Months[1]
+	assert.Equal(t, "2024-02", february.YearOverYear.Compared)
+	assert.Nil(t, february.YearOverYear.IncomePercent)
+
+	_, err = service.GetMonthlyTrends(context.Background(), userID, "2025-03", "2025-01")
+	assert.ErrorIs(t, err, services.ErrInvalidRange)
+	_, err = service.GetMonthlyTrends(context.Background(), userID, "2015-01", "2025-01")
+	assert.ErrorIs(t, err, services.ErrInvalidRange)
+}
+
+func TestAnalyticsService_GetFinancialSummary_MonthlyTrend(t *testing.T) {
+	userID := "user123"
+	now := time.Now()
+	currentMonth := time.Date(now.Year(), now.Month(), 1, 12, 0, 0, 0, time.UTC)
+
+	mockTransactions := []models.Transaction{
+		{ID: "tx1", UserID: userID, Amount: models.MoneyFromFloat(-150, models.DefaultCurrency), Type: "expense", Category: "food", Date: currentMonth},
+		{ID: "tx2", UserID: userID, Amount: models.MoneyFromFloat(-100, models.DefaultCurrency), Type: "expense", Category: "food", Date: currentMonth.AddDate(0, -1, 0)},
+	}
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByUser", mock.Anything, userID, 1000, mock.Anything).Return(mockTransactions, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, userID).Return(nil, repository.ErrUserNotFound)
+	withCategories(mockRepo)
+	withAccounts(mockRepo)
+
+	service := services.NewAnalyticsService(mockRepo)
+	summary, err := service.GetFinancialSummary(context.Background(), userID)
+
+	assert.NoError(t, err)
+	// Expenses went from 100 last month to 150 this month
+	assert.InDelta(t, 50.0, summary.MonthlyTrend, 0.001)
+}
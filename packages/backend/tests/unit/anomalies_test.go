@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/config"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+// groceryHistory spends 3,000.00 a month on groceries over the six
+// months before current, then 9,000.00 at a new store in current
+func groceryHistory(current time.Time) []models.Transaction {
+	day := func(month time.Time, d int) time.Time {
+		return time.Date(month.Year(), month.Month(), d, 0, 0, 0, 0, time.UTC)
+	}
+
+	var transactions []models.Transaction
+	for i := models.AnomalyBaselineMonths; i >= 1; i-- {
+		month := current.AddDate(0, -i, 0)
+		market := -1000.0
+		if i == 1 {
+			market = -1300
+		}
+		transactions = append(transactions,
+			charge(month.Format("01")+"-a", "Supermercado", -800, "groceries", day(month, 3)),
+			charge(month.Format("01")+"-b", "Mercado", market, "groceries", day(month, 12)),
+			charge(month.Format("01")+"-c", "Supermercado", -1200, "groceries", day(month, 21)),
+		)
+	}
+	return append(transactions,
+		charge("usual", "Supermercado", -900, "groceries", day(current, 3)),
+		charge("gourmet", "Tienda Gourmet", -9000, "groceries", day(current, 12)),
+	)
+}
+
+func TestAnalyticsService_DetectAnomalies(t *testing.T) {
+	july := time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC)
+	byMonth := make(map[string][]models.Transaction)
+	for _, tx := range groceryHistory(july) {
+		month := tx.Date.Format("2006-01")
+		byMonth[month] = append(byMonth[month], tx)
+	}
+
+	mockRepo := mocks.NewMockRepository()
+	for month, transactions := range byMonth {
+		mockRepo.On("GetTransactionsByMonthBetween", mock.Anything, "user123", month, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return(transactions, map[string]types.AttributeValue{}, nil)
+	}
+	mockRepo.On("GetUser", mock.Anything, "user123").Return(nil, repository.ErrUserNotFound)
+	service := services.NewAnalyticsService(mockRepo)
+
+	anomalies, err := service.DetectAnomalies(context.Background(), "user123", 1, time.Date(2025, 7, 20, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, "2025-07", anomalies.From)
+	assert.Equal(t, "2025-07", anomalies.To)
+
+	// The new store has no history, so the expense is scored within groceries
+	require.Len(t, anomalies.Transactions, 1)
+	gourmet := anomalies.Transactions[0]
+	assert.Equal(t, "gourmet", gourmet.TransactionID)
+	assert.Equal(t, models.AnomalyScopeCategory, gourmet.Scope)
+	assert.Equal(t, models.MoneyFromFloat(9000, models.DefaultCurrency), gourmet.Amount)
+	assert.Equal(t, models.MoneyFromFloat(1000, models.DefaultCurrency), gourmet.Typical)
+	assert.InDelta(t, 0.6745*800000/20000, gourmet.Score, 0.0001)
+
+	// Five months at 3,000.00 and one at 3,300.00 leave no median deviation
+	require.Len(t, anomalies.CategorySpikes, 1)
+	spike := anomalies.CategorySpikes[0]
+	assert.Equal(t, "2025-07", spike.Month)
+	assert.Equal(t, "groceries", spike.Category)
+	assert.Equal(t, models.MoneyFromFloat(9900, models.DefaultCurrency), spike.Amount)
+	assert.Equal(t, models.MoneyFromFloat(3000, models.DefaultCurrency), spike.Typical)
+	assert.InDelta(t, 690000/(1.253314*5000), spike.Score, 0.0001)
+	require.NotNil(t, spike.IncreasePercent)
+	assert.InDelta(t, 230.0, *spike.IncreasePercent, 0.0001)
+}
+
+func TestAnalyticsService_DetectAnomaliesRejectsLongRanges(t *testing.T) {
+	service := services.NewAnalyticsService(mocks.NewMockRepository())
+
+	_, err := service.DetectAnomalies(context.Background(), "user123", models.MaxAnomalyMonths+1, time.Now())
+	assert.ErrorIs(t, err, services.ErrInvalidRange)
+}
+
+func TestAIService_BuildFinancialContextMentionsAnomalies(t *testing.T) {
+	now := time.Now().UTC()
+	current := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByUser", mock.Anything, "user123", mock.Anything, mock.Anything).
+		Return(groceryHistory(current), map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, "user123").Return(nil, repository.ErrUserNotFound)
+
+	service, err := services.NewAIService(&config.Config{OpenAIAPIKey: "test-key"}, mockRepo)
+	require.NoError(t, err)
+
+	financial, err := service.BuildFinancialContext(context.Background(), "user123")
+	require.NoError(t, err)
+
+	assert.Contains(t, financial.SpendingTrends, "Gasto inusual de $9000.00 en Tienda Gourmet (groceries) el "+
+		current.AddDate(0, 0, 11).Format("2006-01-02")+", cuando lo típico es $1000.00")
+	assert.Contains(t, financial.SpendingTrends, "Tu gasto en groceries subió a $9900.00 en "+
+		current.Format("2006-01")+", frente a $3000.00 en un mes típico")
+}
@@ -0,0 +1,186 @@
+package services
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/auth"
+	"backend/internal/config"
+)
+
+func TestVerifier_HS256(t *testing.T) {
+	secret := "test-secret"
+	verifier, err := auth.NewVerifier(&config.Config{JWTSecret: secret, JWTIssuer: "stori", JWTAudience: "expense-tracker"})
+	require.NoError(t, err)
+
+	valid := auth.Claims{
+		Subject:   "user-123",
+		Issuer:    "stori",
+		Audience:  auth.Audience{"expense-tracker"},
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	}
+
+	tests := []struct {
+		name        string
+		token       func() string
+		expectError error
+	}{
+		{
+			name: "valid token",
+			token: func() string {
+				token, _ := auth.NewHS256Token(valid, secret)
+				return token
+			},
+		},
+		{
+			name: "wrong secret",
+			token: func() string {
+				token, _ := auth.NewHS256Token(valid, "other-secret")
+				return token
+			},
+			expectError: auth.ErrInvalidToken,
+		},
+		{
+			name: "expired token",
+			token: func() string {
+				claims := valid
+				claims.ExpiresAt = time.Now().Add(-time.Hour).Unix()
+				token, _ := auth.NewHS256Token(claims, secret)
+				return token
+			},
+			expectError: auth.ErrExpiredToken,
+		},
+		{
+			name: "unexpected audience",
+			token: func() string {
+				claims := valid
+				claims.Audience = auth.Audience{"someone-else"}
+				token, _ := auth.NewHS256Token(claims, secret)
+				return token
+			},
+			expectError: auth.ErrInvalidToken,
+		},
+		{
+			name: "missing subject",
+			token: func() string {
+				claims := valid
+				claims.Subject = ""
+				token, _ := auth.NewHS256Token(claims, secret)
+				return token
+			},
+			expectError: auth.ErrInvalidToken,
+		},
+		{
+			name:        "malformed token",
+			token:       func() string { return "not-a-jwt" },
+			expectError: auth.ErrInvalidToken,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := verifier.Verify(tt.token())
+
+			if tt.expectError != nil {
+				assert.ErrorIs(t, err, tt.expectError)
+				assert.Nil(t, claims)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "user-123", claims.Subject)
+			}
+		})
+	}
+}
+
+func TestVerifier_RS256WithJWKS(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	jwks := map[string]interface{}{
+		"keys": []map[string]string{
+			{
+				"kty": "RSA",
+				"kid": "key-1",
+				"alg": "RS256",
+				"use": "sig",
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			},
+		},
+	}
+	data, err := json.Marshal(jwks)
+	require.NoError(t, err)
+
+	jwksFile := filepath.Join(t.TempDir(), "jwks.json")
+	require.NoError(t, os.WriteFile(jwksFile, data, 0o600))
+
+	verifier, err := auth.NewVerifier(&config.Config{JWKSFile: jwksFile})
+	require.NoError(t, err)
+
+	sign := func(kid string, signer *rsa.PrivateKey) string {
+		header, _ := json.Marshal(map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid})
+		payload, _ := json.Marshal(auth.Claims{Subject: "user-456", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+		signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+		digest := sha256.Sum256([]byte(signingInput))
+		signature, err := rsa.SignPKCS1v15(rand.Reader, signer, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+		return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+	}
+
+	claims, err := verifier.Verify(sign("key-1", key))
+	assert.NoError(t, err)
+	assert.Equal(t, "user-456", claims.Subject)
+
+	_, err = verifier.Verify(sign("unknown-key", key))
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	_, err = verifier.Verify(sign("key-1", otherKey))
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+
+	// HS256 tokens must be rejected when no shared secret is configured
+	hsToken, _ := auth.NewHS256Token(auth.Claims{Subject: "user-456", ExpiresAt: time.Now().Add(time.Hour).Unix()}, "secret")
+	_, err = verifier.Verify(hsToken)
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestNewVerifier_NotConfigured(t *testing.T) {
+	verifier, err := auth.NewVerifier(&config.Config{})
+	assert.ErrorIs(t, err, auth.ErrNotConfigured)
+	assert.Nil(t, verifier)
+}
+
+func TestBearerToken(t *testing.T) {
+	token, err := auth.BearerToken("Bearer abc.def.ghi")
+	assert.NoError(t, err)
+	assert.Equal(t, "abc.def.ghi", token)
+
+	_, err = auth.BearerToken("")
+	assert.ErrorIs(t, err, auth.ErrMissingToken)
+
+	_, err = auth.BearerToken("Basic dXNlcjpwYXNz")
+	assert.ErrorIs(t, err, auth.ErrInvalidToken)
+}
+
+func TestUserIDFromContext(t *testing.T) {
+	_, ok := auth.UserIDFromContext(context.Background())
+	assert.False(t, ok)
+
+	userID, ok := auth.UserIDFromContext(auth.WithUserID(context.Background(), "user-123"))
+	assert.True(t, ok)
+	assert.Equal(t, "user-123", userID)
+}
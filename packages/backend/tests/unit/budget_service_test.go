@@ -9,6 +9,7 @@ import (
 	"github.com/stretchr/testify/mock"
 
 	"backend/internal/models"
+	"backend/internal/repository"
 	"backend/internal/services"
 	"backend/tests/mocks"
 )
@@ -19,7 +20,7 @@ func TestBudgetService_CreateOrUpdateBudget(t *testing.T) {
 		userID      string
 		month       string
 		category    string
-		amount      float64
+		amount      models.Money
 		mockSetup   func(*mocks.MockRepository)
 		expectError bool
 		errorMsg    string
@@ -29,8 +30,9 @@ func TestBudgetService_CreateOrUpdateBudget(t *testing.T) {
 			userID:   "user-123",
 			month:    "2025-08",
 			category: "Food",
-			amount:   500.0,
+			amount:   models.MoneyFromFloat(500.0, models.DefaultCurrency),
 			mockSetup: func(repo *mocks.MockRepository) {
+				repo.On("GetUser", mock.Anything, "user-123").Return(nil, repository.ErrUserNotFound)
 				repo.On("CreateOrUpdateBudget", mock.Anything, mock.AnythingOfType("*models.Budget")).Return(nil)
 			},
 			expectError: false,
@@ -40,7 +42,7 @@ func TestBudgetService_CreateOrUpdateBudget(t *testing.T) {
 			userID:      "user-123",
 			month:       "2025-08",
 			category:    "Food",
-			amount:      -100.0,
+			amount:      models.MoneyFromFloat(-100.0, models.DefaultCurrency),
 			mockSetup:   func(repo *mocks.MockRepository) {},
 			expectError: true,
 			errorMsg:    "budget amount cannot be negative",
@@ -50,7 +52,7 @@ func TestBudgetService_CreateOrUpdateBudget(t *testing.T) {
 			userID:      "user-123",
 			month:       "invalid-month",
 			category:    "Food",
-			amount:      500.0,
+			amount:      models.MoneyFromFloat(500.0, models.DefaultCurrency),
 			mockSetup:   func(repo *mocks.MockRepository) {},
 			expectError: true,
 			errorMsg:    "invalid month format",
@@ -89,13 +91,13 @@ func TestBudgetService_GetBudgetsByMonth(t *testing.T) {
 			UserID:   userID,
 			Month:    month,
 			Category: "Food",
-			Amount:   500.0,
+			Amount:   models.MoneyFromFloat(500.0, models.DefaultCurrency),
 		},
 		{
 			UserID:   userID,
 			Month:    month,
 			Category: "Transportation",
-			Amount:   200.0,
+			Amount:   models.MoneyFromFloat(200.0, models.DefaultCurrency),
 		},
 	}
 
@@ -161,7 +163,7 @@ func TestBudgetService_GetBudgetUtilization(t *testing.T) {
 			UserID:   userID,
 			Month:    month,
 			Category: "Food",
-			Amount:   500.0,
+			Amount:   models.MoneyFromFloat(500.0, models.DefaultCurrency),
 		},
 	}
 
@@ -169,7 +171,7 @@ func TestBudgetService_GetBudgetUtilization(t *testing.T) {
 		{
 			ID:       "tx-1",
 			UserID:   userID,
-			Amount:   -50.0,
+			Amount:   models.MoneyFromFloat(-50.0, models.DefaultCurrency),
 			Type:     "expense",
 			Category: "Food",
 			Date:     time.Date(2025, 8, 15, 0, 0, 0, 0, time.UTC),
@@ -192,6 +194,7 @@ func TestBudgetService_GetBudgetUtilization(t *testing.T) {
 				repo.On("GetBudgetsByMonth", mock.Anything, userID, month).Return(mockBudgets, nil)
 				// Mock GetTransactionsByUser for utilization calculation - return nil for nextKey to stop pagination
 				repo.On("GetTransactionsByUser", mock.Anything, userID, 1000, mock.Anything).Return(mockTransactions, nil, nil)
+				repo.On("GetUser", mock.Anything, userID).Return(nil, repository.ErrUserNotFound)
 			},
 			expectError: false,
 		},
@@ -216,9 +219,9 @@ func TestBudgetService_GetBudgetUtilization(t *testing.T) {
 				// Check that Food category exists in the result
 				foodUtil, exists := result["Food"]
 				assert.True(t, exists)
-				assert.Equal(t, 500.0, foodUtil.BudgetAmount)
-				assert.Equal(t, 50.0, foodUtil.SpentAmount) // Amount should be positive (absolute value)
-				assert.Equal(t, 450.0, foodUtil.Remaining)
+				assert.Equal(t, models.NewMoney(50000, models.DefaultCurrency), foodUtil.BudgetAmount)
+				assert.Equal(t, models.NewMoney(5000, models.DefaultCurrency), foodUtil.SpentAmount) // Amount should be positive (absolute value)
+				assert.Equal(t, models.NewMoney(45000, models.DefaultCurrency), foodUtil.Remaining)
 				assert.Equal(t, 10.0, foodUtil.Percentage) // 50/500 * 100
 			}
 
@@ -226,3 +229,208 @@ func TestBudgetService_GetBudgetUtilization(t *testing.T) {
 		})
 	}
 }
+
+func TestBudgetService_BulkUpsertBudgets(t *testing.T) {
+	userID := "user-123"
+	food := models.BudgetInput{Month: "2025-08", Category: "Food", Amount: models.MoneyFromFloat(500.0, models.DefaultCurrency)}
+	transport := models.BudgetInput{Month: "2025-09", Category: "Transport", Amount: models.MoneyFromFloat(120.0, models.DefaultCurrency)}
+
+	tests := []struct {
+		name        string
+		inputs      []models.BudgetInput
+		mockSetup   func(*mocks.MockRepository)
+		expectError bool
+		errorMsg    string
+	}{
+		{
+			name:   "successful bulk upsert",
+			inputs: []models.BudgetInput{food, transport},
+			mockSetup: func(repo *mocks.MockRepository) {
+				repo.On("GetUser", mock.Anything, userID).Return(&models.User{ID: userID, BaseCurrency: "USD"}, nil)
+				repo.On("BatchUpsertBudgets", mock.Anything, mock.MatchedBy(func(budgets []models.Budget) bool {
+					return len(budgets) == 2 && budgets[0].UserID == userID && budgets[1].Amount.Currency == "USD"
+				})).Return(nil)
+			},
+		},
+		{
+			name:        "empty request should fail",
+			inputs:      nil,
+			mockSetup:   func(repo *mocks.MockRepository) {},
+			expectError: true,
+			errorMsg:    "at least one budget is required",
+		},
+		{
+			name:        "invalid entry rejects the whole batch",
+			inputs:      []models.BudgetInput{food, {Month: "2025-13", Category: "Food", Amount: food.Amount}},
+			mockSetup:   func(repo *mocks.MockRepository) {},
+			expectError: true,
+			errorMsg:    "budget 2",
+		},
+		{
+			name:        "duplicate month and category should fail",
+			inputs:      []models.BudgetInput{food, {Month: "2025-08", Category: "food", Amount: food.Amount}},
+			mockSetup:   func(repo *mocks.MockRepository) {},
+			expectError: true,
+			errorMsg:    "budgets 1 and 2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockRepository()
+			tt.mockSetup(mockRepo)
+
+			service := services.NewBudgetService(mockRepo)
+			budgets, err := service.BulkUpsertBudgets(context.Background(), userID, tt.inputs)
+
+			if tt.expectError {
+				assert.ErrorIs(t, err, services.ErrInvalidBudget)
+				assert.Contains(t, err.Error(), tt.errorMsg)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, budgets, len(tt.inputs))
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBudgetService_GetBudgetsInRange(t *testing.T) {
+	userID := "user-123"
+
+	tests := []struct {
+		name        string
+		from        string
+		to          string
+		mockSetup   func(*mocks.MockRepository)
+		expectError bool
+	}{
+		{
+			name: "successful retrieval across months",
+			from: "2025-01",
+			to:   "2025-03",
+			mockSetup: func(repo *mocks.MockRepository) {
+				repo.On("GetBudgetsInRange", mock.Anything, userID, "2025-01", "2025-03").Return([]models.Budget{
+					{UserID: userID, Month: "2025-01", Category: "Food", Amount: models.MoneyFromFloat(500.0, models.DefaultCurrency)},
+					{UserID: userID, Month: "2025-03", Category: "Food", Amount: models.MoneyFromFloat(450.0, models.DefaultCurrency)},
+				}, nil)
+			},
+		},
+		{
+			name:        "inverted range should fail",
+			from:        "2025-03",
+			to:          "2025-01",
+			mockSetup:   func(repo *mocks.MockRepository) {},
+			expectError: true,
+		},
+		{
+			name:        "range longer than the limit should fail",
+			from:        "2023-01",
+			to:          "2025-01",
+			mockSetup:   func(repo *mocks.MockRepository) {},
+			expectError: true,
+		},
+		{
+			name:        "invalid month should fail",
+			from:        "2025-1",
+			to:          "2025-03",
+			mockSetup:   func(repo *mocks.MockRepository) {},
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockRepository()
+			tt.mockSetup(mockRepo)
+
+			service := services.NewBudgetService(mockRepo)
+			budgets, err := service.GetBudgetsInRange(context.Background(), userID, tt.from, tt.to)
+
+			if tt.expectError {
+				assert.ErrorIs(t, err, services.ErrInvalidBudget)
+			} else {
+				assert.NoError(t, err)
+				assert.Len(t, budgets, 2)
+			}
+
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestBudgetService_GetBudgetPacing(t *testing.T) {
+	userID := "user-123"
+	expense := func(id, category string, amount float64, date time.Time) models.Transaction {
+		return models.Transaction{
+			ID:       id,
+			UserID:   userID,
+			Amount:   models.MoneyFromFloat(amount, models.DefaultCurrency),
+			Type:     "expense",
+			Category: category,
+			Date:     date,
+		}
+	}
+
+	// Food is usually half spent on the 2nd and half on the 20th; Travel has no history
+	byMonth := make(map[string][]models.Transaction)
+	for i := 1; i <= models.PacingHistoryMonths; i++ {
+		month := time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC).AddDate(0, -i, 0)
+		byMonth[month.Format("2006-01")] = []models.Transaction{
+			expense(month.Format("01")+"-a", "Food", -300, month.AddDate(0, 0, 1)),
+			expense(month.Format("01")+"-b", "Food", -300, month.AddDate(0, 0, 19)),
+		}
+	}
+	byMonth["2025-06"] = []models.Transaction{
+		expense("food", "Food", -400, time.Date(2025, 6, 3, 0, 0, 0, 0, time.UTC)),
+		expense("flight", "Travel", -100, time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC)),
+		expense("later", "Food", -50, time.Date(2025, 6, 12, 0, 0, 0, 0, time.UTC)),
+	}
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetBudgetsByMonth", mock.Anything, userID, "2025-06").Return([]models.Budget{
+		{UserID: userID, Month: "2025-06", Category: "Food", Amount: models.MoneyFromFloat(500.0, models.DefaultCurrency)},
+		{UserID: userID, Month: "2025-06", Category: "Travel", Amount: models.MoneyFromFloat(1000.0, models.DefaultCurrency)},
+	}, nil)
+	for month, transactions := range byMonth {
+		mockRepo.On("GetTransactionsByMonthBetween", mock.Anything, userID, month, mock.Anything, mock.Anything, mock.Anything, mock.Anything).Return(transactions, nil, nil)
+	}
+	mockRepo.On("GetUser", mock.Anything, userID).Return(nil, repository.ErrUserNotFound)
+
+	service := services.NewBudgetService(mockRepo)
+	result, err := service.GetBudgetPacing(context.Background(), userID, "2025-06", time.Date(2025, 6, 10, 18, 0, 0, 0, time.UTC))
+	assert.NoError(t, err)
+	assert.Len(t, result, 2)
+
+	// Half of a usual month is spent by the 10th, but 400.00 of 500.00 already is
+	food := result["Food"]
+	assert.Equal(t, 10, food.Day)
+	assert.Equal(t, 30, food.DaysInMonth)
+	assert.Equal(t, models.PacingCurveHistory, food.Curve)
+	assert.Equal(t, models.NewMoney(40000, models.DefaultCurrency), food.SpentAmount)
+	assert.InDelta(t, 50.0, food.ExpectedPercent, 0.0001)
+	assert.Equal(t, models.NewMoney(25000, models.DefaultCurrency), food.ExpectedSpent)
+	assert.Equal(t, models.PaceAhead, food.Pace)
+	assert.Equal(t, models.NewMoney(70000, models.DefaultCurrency), food.ProjectedSpent)
+	assert.Equal(t, models.NewMoney(-20000, models.DefaultCurrency), food.ProjectedRemaining)
+	// The 31-day months reach their second charge by the 19th
+	if assert.NotNil(t, food.RunOutDate) {
+		assert.Equal(t, time.Date(2025, 6, 19, 0, 0, 0, 0, time.UTC), *food.RunOutDate)
+	}
+
+	travel := result["Travel"]
+	assert.Equal(t, models.PacingCurveLinear, travel.Curve)
+	assert.Equal(t, models.PaceBehind, travel.Pace)
+	assert.Equal(t, models.NewMoney(30000, models.DefaultCurrency), travel.ProjectedSpent)
+	assert.Nil(t, travel.RunOutDate)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBudgetService_GetBudgetPacingRejectsInvalidMonth(t *testing.T) {
+	service := services.NewBudgetService(mocks.NewMockRepository())
+
+	_, err := service.GetBudgetPacing(context.Background(), "user-123", "June", time.Now())
+	assert.ErrorIs(t, err, services.ErrInvalidBudget)
+}
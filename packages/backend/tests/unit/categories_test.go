@@ -0,0 +1,258 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/period"
+	"backend/internal/rates"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+func TestCategory_Validate(t *testing.T) {
+	tests := []struct {
+		name     string
+		category models.Category
+		valid    bool
+	}{
+		{"normalizes the ID and color", models.Category{ID: " Cafe ", NameES: "Café", Color: "#a1b2c3"}, true},
+		{"missing names", models.Category{ID: "cafe"}, false},
+		{"invalid ID", models.Category{ID: "café con leche", NameES: "Café"}, false},
+		{"invalid color", models.Category{ID: "cafe", NameES: "Café", Color: "red"}, false},
+		{"own parent", models.Category{ID: "cafe", Parent: "cafe", NameES: "Café"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.category.UserID = "user123"
+			err := tt.category.Validate()
+			if !tt.valid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "cafe", tt.category.ID)
+			assert.Equal(t, "Café", tt.category.NameEN)
+			assert.Equal(t, "#A1B2C3", tt.category.Color)
+		})
+	}
+}
+
+func TestCategoryCatalog(t *testing.T) {
+	catalog := models.NewCategoryCatalog([]models.Category{
+		{ID: "food", NameES: "Comida", NameEN: "Food"},
+		{ID: "coffee", Parent: "food", NameES: "Café", NameEN: "Coffee", Color: "#6D4C41"},
+	})
+
+	entry, ok := catalog.Lookup("Coffee")
+	require.True(t, ok)
+	assert.Equal(t, "coffee", entry.ID)
+	assert.Equal(t, "food", catalog.RollUp("coffee"))
+	assert.Equal(t, "food", catalog.RollUp("food"))
+	assert.Equal(t, "travel", catalog.RollUp("travel"))
+	assert.Equal(t, "Café", catalog.Label("coffee", ""))
+	assert.Equal(t, "Coffee", catalog.Label("coffee", models.LanguageEnglish))
+	assert.Equal(t, "Travel", catalog.Label("travel", models.LanguageEnglish))
+	assert.Equal(t, []string{"coffee"}, catalog.Children("food"))
+}
+
+func TestCategoryService_CreateCategory(t *testing.T) {
+	t.Run("stores the default catalog first", func(t *testing.T) {
+		mockRepo := new(mocks.MockRepository)
+		withCategories(mockRepo)
+		mockRepo.On("CreateCategory", mock.Anything, mock.AnythingOfType("*models.Category")).Return(nil)
+		service := services.NewCategoryService(mockRepo)
+
+		category := &models.Category{UserID: "user123", ID: "coffee", Parent: "dining", NameES: "Café"}
+		require.NoError(t, service.CreateCategory(context.Background(), category))
+
+		assert.Equal(t, 1, category.Version)
+		mockRepo.AssertNumberOfCalls(t, "CreateCategory", len(models.DefaultCategories("user123"))+1)
+	})
+
+	t.Run("keeps the hierarchy two levels deep", func(t *testing.T) {
+		mockRepo := new(mocks.MockRepository)
+		withCategories(mockRepo, testCategory("food", "", ""), testCategory("coffee", "food", ""))
+		service := services.NewCategoryService(mockRepo)
+
+		category := &models.Category{UserID: "user123", ID: "espresso", Parent: "coffee", NameES: "Espresso"}
+		err := service.CreateCategory(context.Background(), category)
+		assert.True(t, errors.Is(err, services.ErrInvalidCategory))
+
+		category = &models.Category{UserID: "user123", ID: "coffee", NameES: "Café"}
+		err = service.CreateCategory(context.Background(), category)
+		assert.True(t, errors.Is(err, repository.ErrCategoryExists))
+	})
+}
+
+func TestCategoryService_RenameCategory(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	tx := models.NewTransaction("user123", models.TransactionTypeExpense, "Food", "Tacos", models.NewMoney(-8000, "MXN"), day)
+	other := models.NewTransaction("user123", models.TransactionTypeExpense, "travel", "Flight", models.NewMoney(-300000, "MXN"), day)
+	rule := testRule("r1", 0, "food")
+	recurring := models.RecurringTransaction{ID: "rec1", UserID: "user123", Category: "other"}
+
+	mockRepo := new(mocks.MockRepository)
+	withCategories(mockRepo, testCategory("food", "", "#FB8C00"), testCategory("coffee", "food", ""))
+	mockRepo.On("CreateCategory", mock.Anything, mock.MatchedBy(func(c *models.Category) bool {
+		return c.ID == "groceries" && c.Color == "#FB8C00"
+	})).Return(nil).Once()
+	mockRepo.On("UpdateCategory", mock.Anything, mock.MatchedBy(func(c *models.Category) bool {
+		return c.ID == "coffee" && c.Parent == "groceries"
+	})).Return(nil).Once()
+	mockRepo.On("GetTransactionsByUser", mock.Anything, "user123", mock.Anything, mock.Anything).
+		Return([]models.Transaction{*tx, *other}, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("UpdateTransaction", mock.Anything, mock.MatchedBy(func(updated *models.Transaction) bool {
+		return updated.ID == tx.ID && updated.Category == "groceries"
+	})).Return(nil).Once()
+	withRules(mockRepo, rule)
+	mockRepo.On("UpdateRule", mock.Anything, mock.MatchedBy(func(r *models.CategoryRule) bool {
+		return r.ID == "r1" && r.Category == "groceries"
+	})).Return(nil).Once()
+	mockRepo.On("ListRecurringTransactions", mock.Anything, "user123").Return([]models.RecurringTransaction{recurring}, nil)
+	mockRepo.On("ListBudgets", mock.Anything, "user123").Return([]models.Budget{
+		testBudget("2025-03", "Food", models.NewMoney(500000, "MXN")),
+		testBudget("2025-03", "travel", models.NewMoney(900000, "MXN")),
+	}, nil)
+	mockRepo.On("CreateOrUpdateBudget", mock.Anything, mock.MatchedBy(func(b *models.Budget) bool {
+		return b.Month == "2025-03" && b.Category == "groceries" && b.Amount == models.NewMoney(500000, "MXN")
+	})).Return(nil).Once()
+	mockRepo.On("DeleteBudget", mock.Anything, "user123", "2025-03", "Food").Return(nil).Once()
+	mockRepo.On("DeleteCategory", mock.Anything, "user123", "food").Return(nil).Once()
+	service := services.NewCategoryService(mockRepo)
+
+	change, err := service.RenameCategory(context.Background(), "user123", "food", "Groceries")
+
+	require.NoError(t, err)
+	assert.Equal(t, models.CategoryChange{From: "food", To: "groceries", Transactions: 1, Rules: 1, Children: 1, Budgets: 1}, *change)
+	mockRepo.AssertExpectations(t)
+
+	_, err = service.RenameCategory(context.Background(), "user123", "coffee", "food")
+	assert.True(t, errors.Is(err, repository.ErrCategoryExists))
+}
+
+func TestCategoryService_MergeCategory_Validation(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	withCategories(mockRepo,
+		testCategory("food", "", ""), testCategory("coffee", "food", ""),
+		testCategory("travel", "", ""), testCategory("flights", "travel", ""))
+	service := services.NewCategoryService(mockRepo)
+
+	_, err := service.MergeCategory(context.Background(), "user123", "food", "food")
+	assert.True(t, errors.Is(err, services.ErrInvalidCategory))
+
+	_, err = service.MergeCategory(context.Background(), "user123", "food", "missing")
+	assert.True(t, errors.Is(err, repository.ErrCategoryNotFound))
+
+	_, err = service.MergeCategory(context.Background(), "user123", "food", "flights")
+	assert.True(t, errors.Is(err, services.ErrInvalidCategory))
+}
+
+func TestCategoryService_MergeCategory_Budgets(t *testing.T) {
+	store := rates.NewStore()
+	require.NoError(t, store.AddRate(rates.Rate{Date: "2025-03-01", Base: "USD", Quote: "MXN", Rate: 20}))
+
+	tests := []struct {
+		name     string
+		budgets  []models.Budget
+		expected models.Budget // the food budget written for March
+	}{
+		{
+			name:     "moves a budget the target does not have",
+			budgets:  []models.Budget{testBudget("2025-03", "coffee", models.NewMoney(20000, "MXN"))},
+			expected: testBudget("2025-03", "food", models.NewMoney(20000, "MXN")),
+		},
+		{
+			name: "adds to the target's budget of the month",
+			budgets: []models.Budget{
+				testBudget("2025-03", "coffee", models.NewMoney(20000, "MXN")),
+				testBudget("2025-03", "Food", models.NewMoney(50000, "MXN")),
+				testBudget("2025-04", "Food", models.NewMoney(60000, "MXN")),
+			},
+			expected: testBudget("2025-03", "Food", models.NewMoney(70000, "MXN")),
+		},
+		{
+			name: "converts to the target's currency",
+			budgets: []models.Budget{
+				testBudget("2025-03", "coffee", models.NewMoney(1000, "USD")),
+				testBudget("2025-03", "food", models.NewMoney(50000, "MXN")),
+			},
+			expected: testBudget("2025-03", "food", models.NewMoney(70000, "MXN")),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := new(mocks.MockRepository)
+			withCategories(mockRepo, testCategory("food", "", ""), testCategory("coffee", "food", ""))
+			mockRepo.On("GetTransactionsByUser", mock.Anything, "user123", mock.Anything, mock.Anything).
+				Return([]models.Transaction{}, map[string]types.AttributeValue{}, nil)
+			withRules(mockRepo)
+			mockRepo.On("ListRecurringTransactions", mock.Anything, "user123").Return([]models.RecurringTransaction{}, nil)
+			mockRepo.On("ListBudgets", mock.Anything, "user123").Return(tt.budgets, nil)
+			mockRepo.On("CreateOrUpdateBudget", mock.Anything, mock.MatchedBy(func(b *models.Budget) bool {
+				return b.Month == tt.expected.Month && b.Category == tt.expected.Category && b.Amount == tt.expected.Amount
+			})).Return(nil).Once()
+			mockRepo.On("DeleteBudget", mock.Anything, "user123", "2025-03", "coffee").Return(nil).Once()
+			mockRepo.On("DeleteCategory", mock.Anything, "user123", "coffee").Return(nil).Once()
+			service := services.NewCategoryServiceWithRates(mockRepo, store)
+
+			change, err := service.MergeCategory(context.Background(), "user123", "coffee", "food")
+
+			require.NoError(t, err)
+			assert.Equal(t, 1, change.Budgets)
+			mockRepo.AssertExpectations(t)
+		})
+	}
+}
+
+func TestAnalyticsService_GetCategoryBreakdown_RollUp(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	expense := func(category string, minor int64) models.Transaction {
+		return *models.NewTransaction("user123", models.TransactionTypeExpense, category, category, models.NewMoney(minor, models.DefaultCurrency), day)
+	}
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByMonth", mock.Anything, "user123", "2025-03", mock.Anything, mock.Anything).
+		Return([]models.Transaction{expense("food", -5000), expense("coffee", -3000), expense("travel", -2000)}, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, "user123").Return(nil, repository.ErrUserNotFound)
+	withCategories(mockRepo, testCategory("food", "", "#FB8C00"), testCategory("coffee", "food", "#6D4C41"))
+	service := services.NewAnalyticsService(mockRepo)
+
+	rng, err := period.Parse("", "2025-03-01", "2025-03-31", time.Now())
+	require.NoError(t, err)
+
+	breakdown, err := service.GetCategoryBreakdown(context.Background(), "user123", rng, services.BreakdownOptions{})
+	require.NoError(t, err)
+	require.Len(t, breakdown, 3)
+
+	breakdown, err = service.GetCategoryBreakdown(context.Background(), "user123", rng, services.BreakdownOptions{RollUp: true})
+	require.NoError(t, err)
+	byCategory := make(map[string]models.CategoryBreakdown)
+	for _, b := range breakdown {
+		byCategory[b.Category] = b
+	}
+	require.Len(t, byCategory, 2)
+
+	food := byCategory["food"]
+	assert.Equal(t, models.NewMoney(8000, models.DefaultCurrency), food.Amount)
+	assert.Equal(t, 2, food.Count)
+	assert.InDelta(t, 80.0, food.Percentage, 0.001)
+	assert.Equal(t, "#FB8C00", food.Color)
+	require.Len(t, food.Subcategories, 1)
+	assert.Equal(t, "coffee", food.Subcategories[0].Category)
+	assert.Equal(t, "#6D4C41", food.Subcategories[0].Color)
+
+	assert.Equal(t, "Travel", byCategory["travel"].Label)
+	assert.Empty(t, byCategory["travel"].Color)
+}
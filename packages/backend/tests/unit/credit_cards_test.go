@@ -0,0 +1,188 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+// creditCard is a card closing on the 20th with payment due on the 10th
+func creditCard() *models.Account {
+	account := testAccount("card", "MXN", 0)
+	account.Type = models.AccountTypeCreditCard
+	account.CreditCard = &models.CreditCardTerms{
+		ClosingDay:  20,
+		DueDay:      10,
+		CreditLimit: models.NewMoney(5000000, "MXN"),
+		APR:         36.5,
+	}
+	return account
+}
+
+// withCardHistory stores creditCard with 1,500.00 charged in the cycle
+// closing February 20, a 600.00 payment and 300.00 charged since
+func withCardHistory(mockRepo *mocks.MockRepository) {
+	payment := testTransaction("payment", 60000, testDate(time.March, 1))
+	payment.Type = models.TransactionTypeTransfer
+
+	mockRepo.On("GetAccount", mock.Anything, "user123", "card").Return(creditCard(), nil)
+	mockRepo.On("GetTransactionsByAccount", mock.Anything, "user123", "card", mock.Anything, mock.Anything).
+		Return(inAccount("card",
+			testTransaction("flights", -100000, testDate(time.January, 25)),
+			testTransaction("hotel", -50000, testDate(time.February, 5)),
+			testTransaction("dinner", -20000, testDate(time.February, 21)),
+			payment,
+			testTransaction("groceries", -10000, testDate(time.March, 15)),
+		), map[string]types.AttributeValue{}, nil)
+}
+
+func TestCreditCardTerms_Cycles(t *testing.T) {
+	tests := []struct {
+		name     string
+		terms    models.CreditCardTerms
+		on       time.Time
+		closing  time.Time
+		previous time.Time
+		due      time.Time
+	}{
+		{
+			// Cut-off days past the end of a month fall on its last day
+			name:     "closing day past the month's end",
+			terms:    models.CreditCardTerms{ClosingDay: 31, DueDay: 20},
+			on:       time.Date(2025, 2, 10, 15, 0, 0, 0, time.UTC),
+			closing:  testDate(time.February, 28),
+			previous: testDate(time.January, 31),
+			due:      testDate(time.March, 20),
+		},
+		{
+			name:     "day after the cut-off",
+			terms:    models.CreditCardTerms{ClosingDay: 5, DueDay: 25},
+			on:       testDate(time.March, 6),
+			closing:  testDate(time.April, 5),
+			previous: testDate(time.March, 5),
+			due:      testDate(time.April, 25),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			closing := tt.terms.ClosingOn(tt.on)
+			assert.Equal(t, tt.closing, closing)
+			assert.Equal(t, tt.previous, tt.terms.PreviousClosing(closing))
+			assert.Equal(t, tt.due, tt.terms.DueDate(closing))
+		})
+	}
+}
+
+func TestAccount_ValidateCreditCardTerms(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(*models.Account)
+		expectError bool
+	}{
+		{
+			name:   "limit without currency takes the card's",
+			modify: func(account *models.Account) { account.CreditCard.CreditLimit = models.NewMoney(5000000, "") },
+		},
+		{
+			name:        "closing day out of range should fail",
+			modify:      func(account *models.Account) { account.CreditCard.ClosingDay = 32 },
+			expectError: true,
+		},
+		{
+			name:        "terms on a checking account should fail",
+			modify:      func(account *models.Account) { account.Type = models.AccountTypeChecking },
+			expectError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := creditCard()
+			tt.modify(account)
+
+			err := account.Validate()
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "MXN", account.CreditCard.CreditLimit.Currency)
+		})
+	}
+}
+
+func TestAccountService_GetCardStatements(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	withCardHistory(mockRepo)
+	service := services.NewAccountService(mockRepo)
+
+	card, err := service.GetCardStatements(context.Background(), "user123", "card", 2, time.Date(2025, 3, 12, 9, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, models.NewMoney(120000, "MXN"), card.CurrentBalance)
+	assert.Equal(t, models.NewMoney(4880000, "MXN"), card.AvailableCredit)
+	require.Len(t, card.Statements, 2)
+
+	current := card.Statements[0]
+	assert.False(t, current.Closed)
+	assert.Equal(t, time.Date(2025, 2, 21, 0, 0, 0, 0, time.UTC), current.PeriodStart)
+	assert.Equal(t, time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC), current.ClosingDate)
+	assert.Equal(t, models.NewMoney(150000, "MXN"), current.PreviousBalance)
+	assert.Equal(t, models.NewMoney(30000, "MXN"), current.Charges)
+	assert.Equal(t, models.NewMoney(60000, "MXN"), current.Payments)
+	assert.Equal(t, models.NewMoney(120000, "MXN"), current.StatementBalance)
+
+	last := card.Statements[1]
+	assert.True(t, last.Closed)
+	assert.Equal(t, time.Date(2025, 2, 20, 0, 0, 0, 0, time.UTC), last.ClosingDate)
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), last.DueDate)
+	assert.Equal(t, models.NewMoney(150000, "MXN"), last.StatementBalance)
+	// 1.25% of the limit is more than 1.5% of the balance
+	assert.Equal(t, models.NewMoney(62500, "MXN"), last.MinimumPayment)
+	assert.Equal(t, models.NewMoney(60000, "MXN"), last.PaidSinceClosing)
+	assert.Equal(t, models.NewMoney(90000, "MXN"), last.AmountDue)
+	// 900.00 at 0.1% a day over the 28 days to the next cut-off
+	assert.Equal(t, models.NewMoney(2520, "MXN"), last.ProjectedInterest)
+	assert.InDelta(t, 3.0, last.Utilization, 0.001)
+}
+
+func TestAccountService_GetCardStatementsOfOtherAccounts(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetAccount", mock.Anything, "user123", "checking").Return(testAccount("checking", "MXN", 0), nil)
+	service := services.NewAccountService(mockRepo)
+
+	_, err := service.GetCardStatements(context.Background(), "user123", "checking", 0, time.Now())
+	assert.ErrorIs(t, err, services.ErrNotCreditCard)
+}
+
+func TestAccountService_UpcomingPayments(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	withCardHistory(mockRepo)
+	withAccounts(mockRepo, *creditCard(), *testAccount("checking", "MXN", 0))
+	service := services.NewAccountService(mockRepo)
+
+	payments, err := service.UpcomingPayments(context.Background(), "user123", time.Date(2025, 3, 5, 18, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	require.Len(t, payments, 1)
+	assert.Equal(t, "card", payments[0].AccountID)
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), payments[0].DueDate)
+	assert.Equal(t, 5, payments[0].DaysUntilDue)
+	assert.Equal(t, models.NewMoney(90000, "MXN"), payments[0].AmountDue)
+	assert.Equal(t, models.NewMoney(62500, "MXN"), payments[0].MinimumPayment)
+
+	// Past the due date the statement is no longer upcoming
+	payments, err = service.UpcomingPayments(context.Background(), "user123", time.Date(2025, 3, 12, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Empty(t, payments)
+}
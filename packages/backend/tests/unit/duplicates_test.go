@@ -0,0 +1,297 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/period"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+func TestFoldDescription(t *testing.T) {
+	assert.Equal(t, "cafe oxxo 12", models.FoldDescription("  Café  OXXO #12 "))
+	assert.Equal(t, "pago tarjeta", models.FoldDescription("PAGO-TARJETA."))
+	assert.Equal(t, "", models.FoldDescription("***"))
+}
+
+func TestTransaction_IsLikelyDuplicate(t *testing.T) {
+	day := time.Date(2025, 3, 10, 18, 0, 0, 0, time.UTC)
+	tx := testTransaction("Café OXXO", -4550, day)
+
+	tests := []struct {
+		name  string
+		other *models.Transaction
+		want  bool
+	}{
+		{"same movement next day", testTransaction("cafe oxxo", -4550, day.AddDate(0, 0, 1).Add(-12*time.Hour)), true},
+		{"same movement day before", testTransaction("CAFE OXXO.", -4550, day.AddDate(0, 0, -1)), true},
+		{"two days apart", testTransaction("Café OXXO", -4550, day.AddDate(0, 0, 2)), false},
+		{"different amount", testTransaction("Café OXXO", -4500, day), false},
+		{"different description", testTransaction("Café Starbucks", -4550, day), false},
+		{"income of the same amount", models.NewTransaction("user123", models.TransactionTypeIncome, "food", "Café OXXO",
+			models.NewMoney(-4550, models.DefaultCurrency), day), false},
+		{"itself", tx, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tx.IsLikelyDuplicate(tt.other))
+		})
+	}
+}
+
+func TestTransactionService_CreateTransaction_Duplicates(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	stored := testTransaction("Groceries", -54020, day)
+
+	newRepo := func() *mocks.MockRepository {
+		mockRepo := new(mocks.MockRepository)
+		mockRepo.On("GetTransactionsByMonthBetween", mock.Anything, "user123", "2025-03", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]models.Transaction{*stored}, nil, nil)
+		withRules(mockRepo)
+		return mockRepo
+	}
+
+	t.Run("flags a likely duplicate", func(t *testing.T) {
+		mockRepo := newRepo()
+		mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+			return tx.DuplicateOf == stored.ID
+		})).Return(nil)
+		service := services.NewTransactionService(mockRepo)
+
+		result, err := service.CreateTransaction(context.Background(), testTransaction("GROCERIES", -54020, day.AddDate(0, 0, 1)), services.CreateOptions{})
+
+		require.NoError(t, err)
+		assert.False(t, result.Skipped)
+		assert.Equal(t, stored.ID, result.Transaction.DuplicateOf)
+		// Only the days of the window are read
+		mockRepo.AssertCalled(t, "GetTransactionsByMonthBetween", mock.Anything, "user123", "2025-03",
+			day, day.AddDate(0, 0, 3).Add(-time.Nanosecond), mock.Anything, mock.Anything)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("skips a likely duplicate", func(t *testing.T) {
+		mockRepo := newRepo()
+		service := services.NewTransactionService(mockRepo)
+
+		result, err := service.CreateTransaction(context.Background(), testTransaction("Groceries", -54020, day),
+			services.CreateOptions{Duplicates: models.DuplicatePolicySkip})
+
+		require.NoError(t, err)
+		assert.True(t, result.Skipped)
+		assert.Equal(t, stored.ID, result.Transaction.ID)
+		mockRepo.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+	})
+
+	t.Run("ignores a client-sent duplicate_of", func(t *testing.T) {
+		mockRepo := newRepo()
+		mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+			return tx.DuplicateOf == ""
+		})).Return(nil)
+		service := services.NewTransactionService(mockRepo)
+
+		tx := testTransaction("Pharmacy", -23000, day)
+		tx.DuplicateOf = stored.ID
+		_, err := service.CreateTransaction(context.Background(), tx, services.CreateOptions{})
+
+		require.NoError(t, err)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects unknown policies", func(t *testing.T) {
+		service := services.NewTransactionService(new(mocks.MockRepository))
+
+		_, err := service.CreateTransaction(context.Background(), testTransaction("Groceries", -54020, day),
+			services.CreateOptions{Duplicates: "merge"})
+
+		assert.True(t, errors.Is(err, services.ErrInvalidCreateOptions))
+	})
+}
+
+func TestTransactionService_UpdateTransaction_KeepsDuplicateOf(t *testing.T) {
+	flagged := testTransaction("Groceries", -54020, time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC))
+	flagged.DuplicateOf = "original"
+
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("GetTransaction", mock.Anything, "user123", flagged.ID).Return(flagged, nil)
+	mockRepo.On("UpdateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+		return tx.DuplicateOf == "original" && tx.Description == "Groceries at Soriana"
+	})).Return(nil)
+	service := services.NewTransactionService(mockRepo)
+
+	update := *flagged
+	update.Description = "Groceries at Soriana"
+	update.DuplicateOf = ""
+	require.NoError(t, service.UpdateTransaction(context.Background(), &update))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransactionService_CreateTransaction_IdempotencyKey(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	t.Run("stores the key with the new transaction", func(t *testing.T) {
+		mockRepo := new(mocks.MockRepository)
+		withoutStoredTransactions(mockRepo)
+		withRules(mockRepo)
+		mockRepo.On("CreateIdempotencyKey", mock.Anything, mock.MatchedBy(func(key *models.IdempotencyKey) bool {
+			return key.Key == "retry-1" && key.UserID == "user123" && key.TransactionID != "" &&
+				key.ExpiresAt > time.Now().Add(23*time.Hour).Unix()
+		})).Return(nil)
+		mockRepo.On("CreateTransaction", mock.Anything, mock.Anything).Return(nil)
+		service := services.NewTransactionService(mockRepo)
+
+		result, err := service.CreateTransaction(context.Background(), testTransaction("Groceries", -54020, day),
+			services.CreateOptions{IdempotencyKey: "retry-1"})
+
+		require.NoError(t, err)
+		assert.False(t, result.Replayed)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("replays the transaction of a retry", func(t *testing.T) {
+		created := testTransaction("Groceries", -54020, day)
+		var stored *models.IdempotencyKey
+
+		mockRepo := new(mocks.MockRepository)
+		mockRepo.On("CreateIdempotencyKey", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			stored = args.Get(1).(*models.IdempotencyKey)
+		}).Return(nil).Once()
+		withoutStoredTransactions(mockRepo)
+		withRules(mockRepo)
+		mockRepo.On("CreateTransaction", mock.Anything, mock.Anything).Return(nil).Once()
+		service := services.NewTransactionService(mockRepo)
+
+		request := func() *models.Transaction {
+			tx := *created
+			return &tx
+		}
+		first, err := service.CreateTransaction(context.Background(), request(), services.CreateOptions{IdempotencyKey: "retry-1"})
+		require.NoError(t, err)
+
+		mockRepo.On("CreateIdempotencyKey", mock.Anything, mock.Anything).Return(repository.ErrIdempotencyKeyExists)
+		mockRepo.On("GetIdempotencyKey", mock.Anything, "user123", "retry-1").Return(stored, nil)
+		mockRepo.On("GetTransaction", mock.Anything, "user123", first.Transaction.ID).Return(first.Transaction, nil)
+
+		retry, err := service.CreateTransaction(context.Background(), request(), services.CreateOptions{IdempotencyKey: "retry-1"})
+		require.NoError(t, err)
+		assert.True(t, retry.Replayed)
+		assert.Equal(t, first.Transaction.ID, retry.Transaction.ID)
+
+		changed := request()
+		changed.Description = "Something else"
+		_, err = service.CreateTransaction(context.Background(), changed, services.CreateOptions{IdempotencyKey: "retry-1"})
+		assert.True(t, errors.Is(err, services.ErrIdempotencyKeyMismatch))
+
+		mockRepo.AssertNumberOfCalls(t, "CreateTransaction", 1)
+	})
+
+	t.Run("releases the key when creation fails", func(t *testing.T) {
+		mockRepo := new(mocks.MockRepository)
+		withoutStoredTransactions(mockRepo)
+		withRules(mockRepo)
+		mockRepo.On("CreateIdempotencyKey", mock.Anything, mock.Anything).Return(nil)
+		mockRepo.On("CreateTransaction", mock.Anything, mock.Anything).Return(errors.New("throttled"))
+		mockRepo.On("DeleteIdempotencyKey", mock.Anything, "user123", "retry-1").Return(nil)
+		service := services.NewTransactionService(mockRepo)
+
+		_, err := service.CreateTransaction(context.Background(), testTransaction("Groceries", -54020, day),
+			services.CreateOptions{IdempotencyKey: "retry-1"})
+
+		assert.EqualError(t, err, "throttled")
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects malformed keys", func(t *testing.T) {
+		service := services.NewTransactionService(new(mocks.MockRepository))
+
+		_, err := service.CreateTransaction(context.Background(), testTransaction("Groceries", -54020, day),
+			services.CreateOptions{IdempotencyKey: "has spaces"})
+		assert.True(t, errors.Is(err, services.ErrInvalidCreateOptions))
+
+		_, err = service.CreateTransaction(context.Background(), testTransaction("Groceries", -54020, day),
+			services.CreateOptions{IdempotencyKey: strings.Repeat("k", models.MaxIdempotencyKeyLength+1)})
+		assert.True(t, errors.Is(err, services.ErrInvalidCreateOptions))
+	})
+}
+
+func TestTransactionService_FindDuplicates(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	original := testTransaction("Netflix", -21900, day)
+	copy1 := testTransaction("NETFLIX", -21900, day)
+	copy2 := testTransaction("netflix.", -21900, day.AddDate(0, 0, 1))
+	copy1.CreatedAt = original.CreatedAt.Add(time.Minute)
+	copy2.CreatedAt = original.CreatedAt.Add(time.Hour)
+	later := testTransaction("Netflix", -21900, day.AddDate(0, 0, 5))
+	other := testTransaction("Groceries", -21900, day)
+
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("GetTransactionsByMonthBetween", mock.Anything, "user123", "2025-03", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]models.Transaction{*later, *copy2, *other, *copy1, *original}, nil, nil)
+	service := services.NewTransactionService(mockRepo)
+
+	rng, err := period.Parse("", "2025-03-01", "2025-03-31", time.Now())
+	require.NoError(t, err)
+	pairs, err := service.FindDuplicates(context.Background(), "user123", rng)
+
+	require.NoError(t, err)
+	require.Len(t, pairs, 2)
+	assert.Equal(t, original.ID, pairs[0].Original.ID)
+	assert.Equal(t, copy2.ID, pairs[0].Duplicate.ID)
+	assert.Equal(t, 1, pairs[0].DaysApart)
+	assert.Equal(t, original.ID, pairs[1].Original.ID)
+	assert.Equal(t, copy1.ID, pairs[1].Duplicate.ID)
+}
+
+func TestImportService_ImportCSV_LikelyDuplicates(t *testing.T) {
+	stored := testTransaction("GROCERIES", -54020, time.Date(2025, 1, 14, 0, 0, 0, 0, time.UTC))
+
+	newRepo := func() *mocks.MockRepository {
+		mockRepo := new(mocks.MockRepository)
+		mockRepo.On("GetTransactionsByMonth", mock.Anything, "user123", "2025-01", mock.Anything, mock.Anything).
+			Return([]models.Transaction{*stored}, nil, nil)
+		withRules(mockRepo)
+		return mockRepo
+	}
+
+	t.Run("flags them", func(t *testing.T) {
+		mockRepo := newRepo()
+		mockRepo.On("BatchCreateTransactions", mock.Anything, mock.MatchedBy(func(txs []models.Transaction) bool {
+			return len(txs) == 2 && txs[0].DuplicateOf == stored.ID && txs[1].DuplicateOf == ""
+		})).Return(nil)
+		service := services.NewImportService(mockRepo)
+
+		result, err := service.ImportCSV(context.Background(), "user123", strings.NewReader(importTestFile), importTestMapping,
+			services.ImportOptions{SkipInvalid: true})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.LikelyDuplicates)
+		assert.Equal(t, 2, result.Imported)
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("skips them", func(t *testing.T) {
+		mockRepo := newRepo()
+		mockRepo.On("BatchCreateTransactions", mock.Anything, mock.MatchedBy(func(txs []models.Transaction) bool {
+			return len(txs) == 1 && txs[0].Description == "Salary"
+		})).Return(nil)
+		service := services.NewImportService(mockRepo)
+
+		result, err := service.ImportCSV(context.Background(), "user123", strings.NewReader(importTestFile), importTestMapping,
+			services.ImportOptions{SkipInvalid: true, Duplicates: models.DuplicatePolicySkip})
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.LikelyDuplicates)
+		assert.Equal(t, 1, result.Imported)
+		mockRepo.AssertExpectations(t)
+	})
+}
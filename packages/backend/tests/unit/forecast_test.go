@@ -0,0 +1,265 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+func monthlyRule(id, txType, description string, minor int64, day int) models.RecurringTransaction {
+	return models.RecurringTransaction{
+		ID:          id,
+		UserID:      "user123",
+		Type:        txType,
+		Category:    id,
+		Description: description,
+		Amount:      models.NewMoney(minor, "MXN"),
+		Frequency:   models.FrequencyMonthly,
+		Interval:    1,
+		StartDate:   time.Date(2025, 1, day, 0, 0, 0, 0, time.UTC),
+		Active:      true,
+	}
+}
+
+// forecastHistoryRepo stores a monthly salary and rent generated by recurring
+// transactions, a Netflix subscription on the 15th and 900.00 of groceries a
+// month under different descriptions
+func forecastHistoryRepo(rules ...models.RecurringTransaction) *mocks.MockRepository {
+	salary := charge("salary", "Nómina", 20000, "salary", time.Date(2025, 6, 1, 0, 0, 0, 0, time.UTC))
+	salary.Type = models.TransactionTypeIncome
+	salary.RecurringID = "salary"
+	rent := charge("rent", "Renta", -8000, "rent", time.Date(2025, 6, 5, 0, 0, 0, 0, time.UTC))
+	rent.RecurringID = "rent"
+
+	transactions := []models.Transaction{
+		charge("coffee", "Café", -100, "food", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)),
+		salary,
+		rent,
+		charge("n1", "NETFLIX.COM", -219, "entertainment", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)),
+		charge("n2", "NETFLIX.COM", -219, "entertainment", time.Date(2025, 4, 15, 0, 0, 0, 0, time.UTC)),
+		charge("n3", "NETFLIX.COM", -219, "entertainment", time.Date(2025, 5, 15, 0, 0, 0, 0, time.UTC)),
+		charge("n4", "NETFLIX.COM", -219, "entertainment", time.Date(2025, 6, 15, 0, 0, 0, 0, time.UTC)),
+		charge("g1", "Supermercado", -900, "groceries", time.Date(2025, 4, 10, 0, 0, 0, 0, time.UTC)),
+		charge("g2", "Mercado", -900, "groceries", time.Date(2025, 5, 10, 0, 0, 0, 0, time.UTC)),
+		charge("g3", "Tienda", -900, "groceries", time.Date(2025, 6, 10, 0, 0, 0, 0, time.UTC)),
+	}
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByUser", mock.Anything, "user123", mock.Anything, mock.Anything).Return(transactions, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, "user123").Return(nil, repository.ErrUserNotFound)
+	mockRepo.On("ListRecurringTransactions", mock.Anything, "user123").Return(rules, nil)
+	withAccounts(mockRepo)
+	return mockRepo
+}
+
+func TestAnalyticsService_GetCashFlowForecast(t *testing.T) {
+	mockRepo := forecastHistoryRepo(
+		monthlyRule("salary", models.TransactionTypeIncome, "Nómina", 2000000, 1),
+		monthlyRule("rent", models.TransactionTypeExpense, "Renta", 800000, 5),
+	)
+	service := services.NewAnalyticsService(mockRepo)
+
+	forecast, err := service.GetCashFlowForecast(context.Background(), "user123", 30, time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+
+	assert.Equal(t, time.Date(2025, 6, 30, 0, 0, 0, 0, time.UTC), forecast.AsOf)
+	assert.Equal(t, models.NewMoney(832400, "MXN"), forecast.StartingBalance)
+	require.Len(t, forecast.Balances, 30)
+	assert.Equal(t, time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), forecast.Balances[0].Date)
+
+	// Groceries are the only spend left to the daily average: 2,700.00 over 90 days
+	require.Len(t, forecast.DailySpending, 1)
+	assert.Equal(t, "groceries", forecast.DailySpending[0].Category)
+	assert.Equal(t, models.NewMoney(3000, "MXN"), forecast.DailySpending[0].DailyAmount)
+	assert.Empty(t, forecast.DailyIncome)
+
+	salary := forecast.Balances[0]
+	require.Len(t, salary.Events, 1)
+	assert.Equal(t, models.ForecastSourceRecurring, salary.Events[0].Source)
+	assert.Equal(t, models.NewMoney(2000000, "MXN"), salary.Income)
+
+	rent := forecast.Balances[4]
+	require.Len(t, rent.Events, 1)
+	assert.Equal(t, models.NewMoney(-800000, "MXN"), rent.Events[0].Amount)
+	assert.Equal(t, models.NewMoney(803000, "MXN"), rent.Expenses)
+
+	netflix := forecast.Balances[14]
+	require.Len(t, netflix.Events, 1)
+	assert.Equal(t, models.ForecastSourceSubscription, netflix.Events[0].Source)
+	assert.Equal(t, models.NewMoney(-21900, "MXN"), netflix.Events[0].Amount)
+
+	assert.Equal(t, models.NewMoney(2000000, "MXN"), forecast.ExpectedIncome)
+	assert.Equal(t, models.NewMoney(911900, "MXN"), forecast.ExpectedExpenses)
+	assert.Equal(t, models.NewMoney(1920500, "MXN"), forecast.EndingBalance)
+	assert.Equal(t, forecast.EndingBalance, forecast.Balances[29].Balance)
+	assert.False(t, forecast.OverdraftRisk)
+	assert.Nil(t, forecast.OverdraftDate)
+}
+
+func TestAnalyticsService_GetCashFlowForecastOverdraft(t *testing.T) {
+	paused := monthlyRule("salary", models.TransactionTypeIncome, "Nómina", 2000000, 1)
+	paused.Active = false
+	mockRepo := forecastHistoryRepo(paused, monthlyRule("rent", models.TransactionTypeExpense, "Renta", 800000, 5))
+	service := services.NewAnalyticsService(mockRepo)
+
+	forecast, err := service.GetCashFlowForecast(context.Background(), "user123", 0, time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, models.DefaultForecastDays, forecast.Days)
+
+	// 8,324.00 less 8,000.00 of rent and 30.00 a day runs out on July 11
+	assert.True(t, forecast.OverdraftRisk)
+	require.NotNil(t, forecast.OverdraftDate)
+	assert.Equal(t, time.Date(2025, 7, 11, 0, 0, 0, 0, time.UTC), *forecast.OverdraftDate)
+	assert.Equal(t, time.Date(2025, 7, 30, 0, 0, 0, 0, time.UTC), forecast.LowestBalanceDate)
+	assert.Equal(t, models.NewMoney(-79500, "MXN"), forecast.LowestBalance)
+}
+
+func TestAnalyticsService_GetCashFlowForecastWithoutRules(t *testing.T) {
+	income := func(id, description string, amount float64, category string, date time.Time) models.Transaction {
+		tx := charge(id, description, amount, category, date)
+		tx.Type = models.TransactionTypeIncome
+		return tx
+	}
+	inChecking := func(transactions ...models.Transaction) []models.Transaction {
+		for i := range transactions {
+			transactions[i].AccountID = "checking"
+		}
+		return transactions
+	}
+	salary := []models.Transaction{
+		income("s1", "Nómina", 20000, "salary", testDate(time.April, 1)),
+		income("s2", "Nómina", 20000, "salary", testDate(time.May, 1)),
+		income("s3", "Nómina", 20000, "salary", testDate(time.June, 1)),
+	}
+	rent := []models.Transaction{
+		charge("r1", "Renta", -8000, "rent", testDate(time.April, 25)),
+		charge("r2", "Renta", -8000, "rent", testDate(time.May, 25)),
+		charge("r3", "Renta", -8000, "rent", testDate(time.June, 25)),
+	}
+	// 27,000.00 of groceries over the last 90 days is 300.00 a day
+	groceries := []models.Transaction{
+		charge("g1", "Supermercado", -9000, "groceries", testDate(time.April, 10)),
+		charge("g2", "Mercado", -9000, "groceries", testDate(time.May, 10)),
+		charge("g3", "Tienda", -9000, "groceries", testDate(time.June, 10)),
+	}
+	freelance := []models.Transaction{
+		income("f1", "Honorarios", 27000, "freelance", testDate(time.April, 2)),
+		income("f2", "Proyecto web", 27000, "freelance", testDate(time.June, 2)),
+	}
+
+	tests := []struct {
+		name            string
+		transactions    [][]models.Transaction
+		accounts        []models.Account
+		startingBalance models.Money
+		dailyIncome     []models.CategorySpendRate
+		incomeEvents    int
+		expectedIncome  models.Money
+		endingBalance   models.Money
+	}{
+		{
+			// The salary repeats monthly, so July's is expected on the 1st
+			name:            "manually recorded monthly salary",
+			transactions:    [][]models.Transaction{salary, rent, groceries},
+			startingBalance: models.NewMoney(900000, "MXN"),
+			dailyIncome:     []models.CategorySpendRate{},
+			incomeEvents:    1,
+			expectedIncome:  models.NewMoney(2000000, "MXN"),
+			endingBalance:   models.NewMoney(1200000, "MXN"),
+		},
+		{
+			// 54,000.00 of irregular income over 90 days is 600.00 a day
+			name:            "irregular income",
+			transactions:    [][]models.Transaction{freelance, rent, groceries},
+			startingBalance: models.NewMoney(300000, "MXN"),
+			dailyIncome:     []models.CategorySpendRate{{Category: "freelance", DailyAmount: models.NewMoney(60000, "MXN")}},
+			expectedIncome:  models.NewMoney(1800000, "MXN"),
+			endingBalance:   models.NewMoney(400000, "MXN"),
+		},
+		{
+			// 1,000.00 opening plus the checking account's salary and rent;
+			// groceries are paid in cash, outside the accounts
+			name:            "balance of the accounts",
+			transactions:    [][]models.Transaction{inChecking(salary...), inChecking(rent...), groceries},
+			accounts:        []models.Account{*testAccount("checking", "MXN", 100000)},
+			startingBalance: models.NewMoney(3700000, "MXN"),
+			dailyIncome:     []models.CategorySpendRate{},
+			incomeEvents:    1,
+			expectedIncome:  models.NewMoney(2000000, "MXN"),
+			endingBalance:   models.NewMoney(4000000, "MXN"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var transactions []models.Transaction
+			for _, group := range tt.transactions {
+				transactions = append(transactions, group...)
+			}
+
+			mockRepo := mocks.NewMockRepository()
+			mockRepo.On("GetTransactionsByUser", mock.Anything, "user123", mock.Anything, mock.Anything).Return(transactions, map[string]types.AttributeValue{}, nil)
+			mockRepo.On("GetUser", mock.Anything, "user123").Return(nil, repository.ErrUserNotFound)
+			mockRepo.On("ListRecurringTransactions", mock.Anything, "user123").Return([]models.RecurringTransaction{}, nil)
+			withAccounts(mockRepo, tt.accounts...)
+			service := services.NewAnalyticsService(mockRepo)
+
+			forecast, err := service.GetCashFlowForecast(context.Background(), "user123", 30, time.Date(2025, 6, 30, 12, 0, 0, 0, time.UTC))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.startingBalance, forecast.StartingBalance)
+			assert.Equal(t, tt.dailyIncome, forecast.DailyIncome)
+			assert.Len(t, forecast.Balances[0].Events, tt.incomeEvents)
+			for _, event := range forecast.Balances[0].Events {
+				assert.Equal(t, models.ForecastSourceIncome, event.Source)
+				assert.Equal(t, models.NewMoney(2000000, "MXN"), event.Amount)
+			}
+			assert.Equal(t, tt.expectedIncome, forecast.ExpectedIncome)
+			assert.Equal(t, models.NewMoney(1700000, "MXN"), forecast.ExpectedExpenses)
+			assert.Equal(t, tt.endingBalance, forecast.EndingBalance)
+			assert.False(t, forecast.OverdraftRisk)
+		})
+	}
+}
+
+func TestAnalyticsService_GetCashFlowForecastRejectsLongHorizons(t *testing.T) {
+	service := services.NewAnalyticsService(mocks.NewMockRepository())
+
+	_, err := service.GetCashFlowForecast(context.Background(), "user123", models.MaxForecastDays+1, time.Now())
+	assert.ErrorIs(t, err, services.ErrInvalidForecast)
+}
+
+func TestAnalyticsService_BacktestForecast(t *testing.T) {
+	mockRepo := forecastHistoryRepo(
+		monthlyRule("salary", models.TransactionTypeIncome, "Nómina", 2000000, 1),
+		monthlyRule("rent", models.TransactionTypeExpense, "Renta", 800000, 5),
+	)
+	service := services.NewAnalyticsService(mockRepo)
+
+	backtest, err := service.BacktestForecast(context.Background(), "user123", 1, time.Date(2025, 7, 10, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	require.Len(t, backtest.Months, 1)
+
+	// Forecast from the history before June, with groceries at 20.00 a day
+	june := backtest.Months[0]
+	assert.Equal(t, "2025-06", june.Month)
+	assert.Equal(t, models.NewMoney(2000000, "MXN"), june.PredictedIncome)
+	assert.Equal(t, models.NewMoney(881900, "MXN"), june.PredictedExpenses)
+	assert.Equal(t, models.NewMoney(2000000, "MXN"), june.ActualIncome)
+	assert.Equal(t, models.NewMoney(911900, "MXN"), june.ActualExpenses)
+	require.NotNil(t, june.ErrorPercent)
+	assert.InDelta(t, 30000.0/2911900*100, *june.ErrorPercent, 0.0001)
+
+	require.NotNil(t, backtest.Accuracy)
+	assert.InDelta(t, 100-*june.ErrorPercent, *backtest.Accuracy, 0.0001)
+}
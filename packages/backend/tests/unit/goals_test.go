@@ -0,0 +1,221 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+// tripGoal is a 20,000.00 goal for October 31, 2025 linked to the savings
+// account
+func tripGoal() *models.Goal {
+	target := testDate(time.October, 31)
+	return &models.Goal{
+		ID:           "trip",
+		UserID:       "user123",
+		Name:         "Viaje",
+		Currency:     "MXN",
+		TargetAmount: models.NewMoney(2000000, "MXN"),
+		TargetDate:   &target,
+		AccountID:    "savings",
+	}
+}
+
+func tripContribution(id string, minor int64, month time.Month, day int) models.GoalContribution {
+	return models.GoalContribution{
+		ID:     id,
+		UserID: "user123",
+		GoalID: "trip",
+		Amount: models.NewMoney(minor, "MXN"),
+		Date:   testDate(month, day),
+	}
+}
+
+// withSavingsHistory stores 30,000 of income and 6,000 of expenses in March
+// 2025, with a transfer that is not savings, and nothing in other months
+func withSavingsHistory(mockRepo *mocks.MockRepository) {
+	income := testTransaction("salary", 3000000, testDate(time.March, 1))
+	income.Type = models.TransactionTypeIncome
+	transfer := testTransaction("to-savings", 1000000, testDate(time.March, 2))
+	transfer.Type = models.TransactionTypeTransfer
+	transfer.AccountID = "savings"
+
+	mockRepo.On("GetTransactionsByMonthBetween", mock.Anything, "user123", "2025-03", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]models.Transaction{*income, *transfer, *testTransaction("rent", -600000, testDate(time.March, 5))}, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetTransactionsByMonthBetween", mock.Anything, "user123", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]models.Transaction{}, map[string]types.AttributeValue{}, nil)
+}
+
+func TestGoal_Validate(t *testing.T) {
+	var goal models.Goal
+	require.NoError(t, json.Unmarshal([]byte(`{"name":" Enganche ","currency":"usd","target_amount":"10000.00","target_date":"2026-06-15T18:00:00Z","tag":" Casa "}`), &goal))
+	goal.UserID = "user123"
+	require.NoError(t, goal.Validate())
+	assert.Equal(t, "Enganche", goal.Name)
+	assert.Equal(t, "USD", goal.TargetAmount.Currency)
+	assert.Equal(t, time.Date(2026, 6, 15, 0, 0, 0, 0, time.UTC), *goal.TargetDate)
+	assert.Equal(t, "casa", goal.Tag)
+
+	goal.AccountID = "savings"
+	assert.Error(t, goal.Validate())
+
+	goal = *tripGoal()
+	goal.TargetAmount = models.NewMoney(0, "MXN")
+	assert.Error(t, goal.Validate())
+}
+
+func TestGoalService_CreateGoalWithUnknownAccount(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetAccount", mock.Anything, "user123", "savings").Return(nil, repository.ErrAccountNotFound)
+	service := services.NewGoalService(mockRepo)
+
+	err := service.CreateGoal(context.Background(), tripGoal())
+	assert.ErrorIs(t, err, services.ErrInvalidGoal)
+	mockRepo.AssertNotCalled(t, "CreateGoal", mock.Anything, mock.Anything)
+}
+
+func TestGoalService_AddContribution(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetGoal", mock.Anything, "user123", "trip").Return(tripGoal(), nil)
+	mockRepo.On("CreateGoalContribution", mock.Anything, mock.AnythingOfType("*models.GoalContribution")).Return(nil)
+	service := services.NewGoalService(mockRepo)
+
+	contribution := &models.GoalContribution{UserID: "user123", GoalID: "trip", Amount: models.NewMoney(150000, "")}
+	require.NoError(t, service.AddContribution(context.Background(), contribution))
+	assert.NotEmpty(t, contribution.ID)
+	assert.Equal(t, "MXN", contribution.Amount.Currency)
+	assert.False(t, contribution.Date.IsZero())
+
+	err := service.AddContribution(context.Background(), &models.GoalContribution{UserID: "user123", GoalID: "trip"})
+	assert.ErrorIs(t, err, services.ErrInvalidGoal)
+}
+
+func TestGoalService_DeleteGoalDeletesContributions(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetGoal", mock.Anything, "user123", "trip").Return(tripGoal(), nil)
+	mockRepo.On("ListGoalContributions", mock.Anything, "user123", "trip").
+		Return([]models.GoalContribution{tripContribution("c1", 100000, time.May, 1)}, nil)
+	mockRepo.On("DeleteGoalContribution", mock.Anything, "user123", "trip", "c1").Return(nil)
+	mockRepo.On("DeleteGoal", mock.Anything, "user123", "trip").Return(nil)
+	service := services.NewGoalService(mockRepo)
+
+	require.NoError(t, service.DeleteGoal(context.Background(), "user123", "trip"))
+	mockRepo.AssertExpectations(t)
+}
+
+func TestGoalService_GetProgress(t *testing.T) {
+	projected := testDate(time.October, 15)
+	completed := testDate(time.July, 15)
+	required := models.NewMoney(225000, "MXN")
+	onTrack := true
+
+	tests := []struct {
+		name      string
+		goal      func() *models.Goal
+		mockSetup func(*mocks.MockRepository)
+
+		contributions       models.Money
+		linked              models.Money
+		saved               models.Money
+		remaining           models.Money
+		percentage          float64
+		complete            bool
+		averageSavings      models.Money
+		projectedCompletion *time.Time
+		monthsLeft          int
+		requiredMonthly     *models.Money
+		onTrack             *bool
+	}{
+		{
+			// 24,000 saved over the six months from January to June, and
+			// July to October left until October 31
+			name: "linked account with a target date",
+			goal: tripGoal,
+			mockSetup: func(repo *mocks.MockRepository) {
+				repo.On("ListGoalContributions", mock.Anything, "user123", "trip").Return([]models.GoalContribution{
+					tripContribution("c1", 300000, time.April, 1),
+					tripContribution("c2", 200000, time.June, 1),
+					tripContribution("later", 100000, time.August, 1),
+				}, nil)
+				repo.On("GetAccount", mock.Anything, "user123", "savings").Return(testAccount("savings", "MXN", 500000), nil)
+				repo.On("GetTransactionsByAccount", mock.Anything, "user123", "savings", mock.Anything, mock.Anything).
+					Return(inAccount("savings", testTransaction("interest", 100000, testDate(time.June, 30))), map[string]types.AttributeValue{}, nil)
+				withSavingsHistory(repo)
+			},
+			contributions:       models.NewMoney(500000, "MXN"),
+			linked:              models.NewMoney(600000, "MXN"),
+			saved:               models.NewMoney(1100000, "MXN"),
+			remaining:           models.NewMoney(900000, "MXN"),
+			percentage:          55,
+			averageSavings:      models.NewMoney(400000, "MXN"),
+			projectedCompletion: &projected,
+			monthsLeft:          4,
+			requiredMonthly:     &required,
+			onTrack:             &onTrack,
+		},
+		{
+			name: "linked tag without a target date",
+			goal: func() *models.Goal {
+				goal := tripGoal()
+				goal.AccountID = ""
+				goal.Tag = "viaje"
+				goal.TargetDate = nil
+				return goal
+			},
+			mockSetup: func(repo *mocks.MockRepository) {
+				repo.On("ListGoalContributions", mock.Anything, "user123", "trip").Return([]models.GoalContribution{}, nil)
+				repo.On("GetTransactionsByTag", mock.Anything, "user123", "viaje", mock.Anything, mock.Anything).
+					Return([]models.Transaction{
+						*testTransaction("flights", -1500000, testDate(time.May, 10)),
+						*testTransaction("hotel", -800000, testDate(time.June, 2)),
+					}, map[string]types.AttributeValue{}, nil)
+				repo.On("GetTransactionsByMonthBetween", mock.Anything, "user123", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return([]models.Transaction{}, map[string]types.AttributeValue{}, nil)
+			},
+			contributions:  models.ZeroMoney("MXN"),
+			linked:         models.NewMoney(2300000, "MXN"),
+			saved:          models.NewMoney(2300000, "MXN"),
+			remaining:      models.ZeroMoney("MXN"),
+			percentage:     100,
+			complete:       true,
+			averageSavings: models.ZeroMoney("MXN"),
+			// A complete goal is reached on the day of the progress
+			projectedCompletion: &completed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockRepository()
+			mockRepo.On("GetGoal", mock.Anything, "user123", "trip").Return(tt.goal(), nil)
+			tt.mockSetup(mockRepo)
+			service := services.NewGoalService(mockRepo)
+
+			progress, err := service.GetProgress(context.Background(), "user123", "trip", time.Date(2025, 7, 15, 12, 0, 0, 0, time.UTC))
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.contributions, progress.Contributions)
+			assert.Equal(t, tt.linked, progress.Linked)
+			assert.Equal(t, tt.saved, progress.Saved)
+			assert.Equal(t, tt.remaining, progress.Remaining)
+			assert.InDelta(t, tt.percentage, progress.Percentage, 0.001)
+			assert.Equal(t, tt.complete, progress.Complete)
+			assert.Equal(t, tt.averageSavings, progress.AverageMonthlySavings)
+			assert.Equal(t, tt.projectedCompletion, progress.ProjectedCompletion)
+			assert.Equal(t, tt.monthsLeft, progress.MonthsLeft)
+			assert.Equal(t, tt.requiredMonthly, progress.RequiredMonthly)
+			assert.Equal(t, tt.onTrack, progress.OnTrack)
+		})
+	}
+}
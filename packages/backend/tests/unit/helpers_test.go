@@ -0,0 +1,123 @@
+package services
+
+import (
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"backend/internal/models"
+	"backend/tests/mocks"
+)
+
+// Builders and mock setups shared by the service tests. Fixtures of a single
+// scenario stay in the file that tests it.
+
+// testDate returns the day of 2025 at midnight UTC
+func testDate(month time.Month, day int) time.Time {
+	return time.Date(2025, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// testTransaction builds an MXN expense of user123 in the food category with
+// a fresh ID; tests set the fields they exercise on it
+func testTransaction(description string, minor int64, date time.Time) *models.Transaction {
+	return models.NewTransaction("user123", models.TransactionTypeExpense, "food", description, models.NewMoney(minor, "MXN"), date)
+}
+
+// charge builds an expense of user123 in the default currency from a decimal
+// amount
+func charge(id, description string, amount float64, category string, date time.Time) models.Transaction {
+	return models.Transaction{
+		ID:          id,
+		UserID:      "user123",
+		Description: description,
+		Amount:      models.MoneyFromFloat(amount, models.DefaultCurrency),
+		Type:        "expense",
+		Category:    category,
+		Date:        date,
+	}
+}
+
+// inAccount links the transactions to the account
+func inAccount(accountID string, transactions ...*models.Transaction) []models.Transaction {
+	linked := make([]models.Transaction, 0, len(transactions))
+	for _, tx := range transactions {
+		tx.AccountID = accountID
+		linked = append(linked, *tx)
+	}
+	return linked
+}
+
+// tagged returns the transaction carrying the tags
+func tagged(tx *models.Transaction, tags ...string) models.Transaction {
+	tx.Tags = tags
+	return *tx
+}
+
+// testAccount builds a checking account of user123 named after its ID
+func testAccount(id, currency string, opening int64) *models.Account {
+	return &models.Account{
+		ID:             id,
+		UserID:         "user123",
+		Name:           id,
+		Type:           models.AccountTypeChecking,
+		Currency:       currency,
+		OpeningBalance: models.NewMoney(opening, currency),
+	}
+}
+
+// testCategory builds a category of user123 named after its ID
+func testCategory(id, parent, color string) models.Category {
+	return models.Category{ID: id, UserID: "user123", Parent: parent, NameES: id, NameEN: id, Color: color, Version: 1}
+}
+
+// testBudget builds a budget of user123 for the month and category
+func testBudget(month, category string, amount models.Money) models.Budget {
+	return models.Budget{ID: month + "-" + category, UserID: "user123", Month: month, Category: category, Amount: amount}
+}
+
+// testRule builds an active rule of user123
+func testRule(id string, priority int, category string, tags ...string) models.CategoryRule {
+	return models.CategoryRule{
+		ID:       id,
+		UserID:   "user123",
+		Name:     "Rule " + id,
+		Priority: priority,
+		Active:   true,
+		Category: category,
+		Tags:     tags,
+	}
+}
+
+// withAccounts makes the mock repository return the accounts; without
+// accounts the user has none
+func withAccounts(mockRepo *mocks.MockRepository, accounts ...models.Account) {
+	if accounts == nil {
+		accounts = []models.Account{}
+	}
+	mockRepo.On("ListAccounts", mock.Anything, mock.Anything).Return(accounts, nil)
+}
+
+// withCategories makes the mock repository return the stored catalog; without
+// categories the user has the default one
+func withCategories(mockRepo *mocks.MockRepository, categories ...models.Category) {
+	if categories == nil {
+		categories = []models.Category{}
+	}
+	mockRepo.On("ListCategories", mock.Anything, mock.Anything).Return(categories, nil)
+}
+
+// withRules makes the mock repository return the rules for "user123"
+func withRules(mockRepo *mocks.MockRepository, rules ...models.CategoryRule) {
+	if rules == nil {
+		rules = []models.CategoryRule{}
+	}
+	mockRepo.On("ListRules", mock.Anything, "user123").Return(rules, nil)
+}
+
+// withoutStoredTransactions makes every month of the mock repository empty
+func withoutStoredTransactions(mockRepo *mocks.MockRepository) {
+	mockRepo.On("GetTransactionsByMonth", mock.Anything, "user123", mock.Anything, mock.Anything, mock.Anything).
+		Return([]models.Transaction{}, nil, nil).Maybe()
+	mockRepo.On("GetTransactionsByMonthBetween", mock.Anything, "user123", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]models.Transaction{}, nil, nil).Maybe()
+}
@@ -0,0 +1,526 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/importer"
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+func TestParseCSV_SignedAmountColumn(t *testing.T) {
+	file := "\ufeffFecha;Concepto;Importe\n" +
+		"15/01/2025;OXXO;-1.234,50\n" +
+		"16/01/2025;Nomina;\"25.000,00\"\n" +
+		"\n" +
+		"17/01/2025;Comision;(35,00)\n"
+	mapping := models.ImportMapping{
+		Delimiter:         ";",
+		DateColumn:        "fecha",
+		DateFormat:        "DD/MM/YYYY",
+		DescriptionColumn: "Concepto",
+		AmountColumn:      "importe",
+		DecimalComma:      true,
+	}
+
+	rows, err := importer.ParseCSV(strings.NewReader(file), mapping, "user123")
+	require.NoError(t, err)
+	require.Len(t, rows, 3)
+
+	first := rows[0]
+	assert.Equal(t, 2, first.Line)
+	require.NotNil(t, first.Transaction)
+	assert.Equal(t, models.TransactionTypeExpense, first.Transaction.Type)
+	assert.Equal(t, models.NewMoney(-123450, models.DefaultCurrency), first.Transaction.Amount)
+	assert.Equal(t, time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), first.Transaction.Date)
+	assert.Equal(t, models.DefaultImportCategory, first.Transaction.Category)
+	assert.Equal(t, "OXXO", first.Transaction.Description)
+
+	require.NotNil(t, rows[1].Transaction)
+	assert.Equal(t, models.TransactionTypeIncome, rows[1].Transaction.Type)
+	assert.Equal(t, models.NewMoney(2500000, models.DefaultCurrency), rows[1].Transaction.Amount)
+
+	// Parentheses mark negatives; the blank line is skipped but still counted
+	assert.Equal(t, 5, rows[2].Line)
+	require.NotNil(t, rows[2].Transaction)
+	assert.Equal(t, models.NewMoney(-3500, models.DefaultCurrency), rows[2].Transaction.Amount)
+}
+
+func TestParseCSV_DebitCreditColumnsAndRowErrors(t *testing.T) {
+	file := "Date,Description,Debit,Credit,Currency\n" +
+		"01/31/2025,Coffee,$4.50,,USD\n" +
+		"02/01/2025,Refund,,\"$1,020.00\",usd\n" +
+		"2025-02-02,Bad date,10,,USD\n" +
+		"02/03/2025,No amount,,,USD\n" +
+		"02/04/2025,Bad currency,3,,DOLLARS\n"
+	mapping := models.ImportMapping{
+		DateColumn:        "Date",
+		DateFormat:        "MM/DD/YYYY",
+		DescriptionColumn: "Description",
+		DebitColumn:       "Debit",
+		CreditColumn:      "Credit",
+		CurrencyColumn:    "Currency",
+		DefaultCategory:   "Card",
+	}
+
+	rows, err := importer.ParseCSV(strings.NewReader(file), mapping, "user123")
+	require.NoError(t, err)
+	require.Len(t, rows, 5)
+
+	require.NotNil(t, rows[0].Transaction)
+	assert.Equal(t, models.NewMoney(-450, "USD"), rows[0].Transaction.Amount)
+	assert.Equal(t, "card", rows[0].Transaction.Category)
+
+	require.NotNil(t, rows[1].Transaction)
+	assert.Equal(t, models.NewMoney(102000, "USD"), rows[1].Transaction.Amount)
+	assert.Equal(t, models.TransactionTypeIncome, rows[1].Transaction.Type)
+
+	for _, row := range rows[2:] {
+		assert.Nil(t, row.Transaction, "line %d", row.Line)
+		assert.Len(t, row.Errors, 1, "line %d", row.Line)
+	}
+	assert.Contains(t, rows[2].Errors[0], "invalid date")
+	assert.Contains(t, rows[3].Errors[0], "amount is missing")
+}
+
+func TestParseCSV_InvertedSignWithoutHeader(t *testing.T) {
+	file := "2025-03-01,Restaurant,850.00\n2025-03-02,Payment,-5000\n"
+	mapping := models.ImportMapping{
+		NoHeader:          true,
+		DateColumn:        "1",
+		DescriptionColumn: "2",
+		AmountColumn:      "3",
+		AmountSign:        models.AmountSignInverted,
+	}
+
+	rows, err := importer.ParseCSV(strings.NewReader(file), mapping, "user123")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+	assert.Equal(t, 1, rows[0].Line)
+	assert.Equal(t, models.TransactionTypeExpense, rows[0].Transaction.Type)
+	assert.Equal(t, models.TransactionTypeIncome, rows[1].Transaction.Type)
+}
+
+func TestParseCSV_InvalidFile(t *testing.T) {
+	mapping := models.ImportMapping{DateColumn: "Date", AmountColumn: "Amount"}
+
+	_, err := importer.ParseCSV(strings.NewReader("Fecha,Importe\n2025-01-01,10\n"), mapping, "user123")
+	assert.True(t, errors.Is(err, importer.ErrInvalidFile))
+
+	_, err = importer.ParseCSV(strings.NewReader(""), mapping, "user123")
+	assert.True(t, errors.Is(err, importer.ErrInvalidFile))
+
+	mapping.DebitColumn = "Debit"
+	_, err = importer.ParseCSV(strings.NewReader("Date,Amount,Debit\n"), mapping, "user123")
+	assert.True(t, errors.Is(err, importer.ErrInvalidFile))
+}
+
+const importTestFile = "Date,Description,Amount\n" +
+	"2025-01-15,Groceries,-540.20\n" +
+	"not a date,Broken,10\n" +
+	"2025-01-16,Salary,30000\n"
+
+var importTestMapping = models.ImportMapping{
+	DateColumn:        "Date",
+	DescriptionColumn: "Description",
+	AmountColumn:      "Amount",
+}
+
+func TestImportService_ImportCSV_DryRun(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	withoutStoredTransactions(mockRepo)
+	withRules(mockRepo)
+	service := services.NewImportService(mockRepo)
+
+	result, err := service.ImportCSV(context.Background(), "user123", strings.NewReader(importTestFile), importTestMapping, services.ImportOptions{DryRun: true})
+
+	require.NoError(t, err)
+	assert.True(t, result.DryRun)
+	assert.Equal(t, 3, result.TotalRows)
+	assert.Equal(t, 2, result.ValidRows)
+	assert.Equal(t, 1, result.InvalidRows)
+	assert.Equal(t, 0, result.Imported)
+	assert.Equal(t, 3, result.Rows[1].Line)
+	mockRepo.AssertNotCalled(t, "BatchCreateTransactions", mock.Anything, mock.Anything)
+}
+
+func TestImportService_ImportCSV_Commit(t *testing.T) {
+	t.Run("rejects files with invalid rows", func(t *testing.T) {
+		mockRepo := new(mocks.MockRepository)
+		withoutStoredTransactions(mockRepo)
+		withRules(mockRepo)
+		service := services.NewImportService(mockRepo)
+
+		_, err := service.ImportCSV(context.Background(), "user123", strings.NewReader(importTestFile), importTestMapping, services.ImportOptions{})
+
+		assert.True(t, errors.Is(err, services.ErrInvalidImport))
+		mockRepo.AssertNotCalled(t, "BatchCreateTransactions", mock.Anything, mock.Anything)
+	})
+
+	t.Run("skips invalid rows when asked", func(t *testing.T) {
+		mockRepo := new(mocks.MockRepository)
+		withoutStoredTransactions(mockRepo)
+		withRules(mockRepo)
+		mockRepo.On("BatchCreateTransactions", mock.Anything, mock.MatchedBy(func(txs []models.Transaction) bool {
+			return len(txs) == 2 && txs[0].Description == "Groceries" && txs[1].UserID == "user123"
+		})).Return(nil)
+		service := services.NewImportService(mockRepo)
+
+		result, err := service.ImportCSV(context.Background(), "user123", strings.NewReader(importTestFile), importTestMapping, services.ImportOptions{SkipInvalid: true})
+
+		require.NoError(t, err)
+		assert.False(t, result.DryRun)
+		assert.Equal(t, 2, result.Imported)
+		mockRepo.AssertExpectations(t)
+	})
+}
+
+func TestImportService_SaveMapping(t *testing.T) {
+	created := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("GetImportMapping", mock.Anything, "user123", "banorte").Return(&models.ImportMapping{CreatedAt: created}, nil)
+	mockRepo.On("SaveImportMapping", mock.Anything, mock.AnythingOfType("*models.ImportMapping")).Return(nil)
+	service := services.NewImportService(mockRepo)
+
+	mapping := importTestMapping
+	mapping.UserID = "user123"
+	mapping.Name = "banorte"
+	require.NoError(t, service.SaveMapping(context.Background(), &mapping))
+	assert.Equal(t, created, mapping.CreatedAt)
+	assert.Equal(t, "IMPORTMAPPING#banorte", mapping.SK)
+
+	mapping.Name = "Banorte Débito"
+	assert.True(t, errors.Is(service.SaveMapping(context.Background(), &mapping), services.ErrInvalidImport))
+
+	mockRepo.On("GetImportMapping", mock.Anything, "user123", "missing").Return(nil, repository.ErrImportMappingNotFound)
+	_, err := service.GetMapping(context.Background(), "user123", "missing")
+	assert.True(t, errors.Is(err, repository.ErrImportMappingNotFound))
+}
+
+const sgmlStatement = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+
+<OFX>
+<BANKMSGSRSV1><STMTTRNRS><STMTRS>
+<CURDEF>MXN
+<BANKACCTFROM><BANKID>072<ACCTID>0123456789<ACCTTYPE>CHECKING</BANKACCTFROM>
+<BANKTRANLIST>
+<STMTTRN>
+<TRNTYPE>POS
+<DTPOSTED>20250115120000.000[-6:CST]
+<TRNAMT>245.50
+<FITID>2025011501
+<NAME>WALMART SUPERCENTER
+<MEMO>COMPRA TARJETA
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DIRECTDEP
+<DTPOSTED>20250131
+<TRNAMT>25000.00
+<FITID>2025013101
+<NAME>NOMINA ACME &amp; CO
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>XFER
+<DTPOSTED>20250201
+<TRNAMT>-1500.00
+<FITID>2025020101
+<MEMO>SPEI A TERCEROS
+<BANKACCTTO><BANKID>012<ACCTID>999<ACCTTYPE>CHECKING</BANKACCTTO>
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20250202
+<TRNAMT>-10.00
+<FITID>2025020101
+</STMTTRN>
+</BANKTRANLIST>
+</STMTRS></STMTTRNRS></BANKMSGSRSV1>
+</OFX>
+`
+
+func TestParseOFX_SGML(t *testing.T) {
+	rows, err := importer.ParseOFX(strings.NewReader(sgmlStatement), "user123")
+	require.NoError(t, err)
+	require.Len(t, rows, 4)
+
+	// Positive amounts of debit types are still expenses
+	pos := rows[0].Transaction
+	require.NotNil(t, pos)
+	assert.Equal(t, 10, rows[0].Line)
+	assert.Equal(t, models.TransactionTypeExpense, pos.Type)
+	assert.Equal(t, models.NewMoney(-24550, "MXN"), pos.Amount)
+	assert.Equal(t, time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC), pos.Date)
+	assert.Equal(t, "WALMART SUPERCENTER", pos.Description)
+	assert.Equal(t, "2025011501", pos.ExternalID)
+
+	deposit := rows[1].Transaction
+	require.NotNil(t, deposit)
+	assert.Equal(t, models.TransactionTypeIncome, deposit.Type)
+	assert.Equal(t, "NOMINA ACME & CO", deposit.Description)
+
+	// Transfers follow the sign; the destination account does not leak out
+	transfer := rows[2].Transaction
+	require.NotNil(t, transfer)
+	assert.Equal(t, models.TransactionTypeExpense, transfer.Type)
+	assert.Equal(t, "SPEI A TERCEROS", transfer.Description)
+
+	assert.Nil(t, rows[3].Transaction)
+	assert.Contains(t, rows[3].Errors[0], "duplicate FITID")
+
+	// IDs are derived from the account and FITID
+	again, err := importer.ParseOFX(strings.NewReader(sgmlStatement), "user123")
+	require.NoError(t, err)
+	assert.Equal(t, pos.ID, again[0].Transaction.ID)
+	other, err := importer.ParseOFX(strings.NewReader(sgmlStatement), "user456")
+	require.NoError(t, err)
+	assert.NotEqual(t, pos.ID, other[0].Transaction.ID)
+}
+
+func TestParseOFX_XML(t *testing.T) {
+	statement := `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX><CREDITCARDMSGSRSV1><CCSTMTTRNRS><CCSTMTRS>
+  <CURDEF>USD</CURDEF>
+  <CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+  <BANKTRANLIST>
+    <STMTTRN><TRNTYPE>DEBIT</TRNTYPE><DTPOSTED>20250305</DTPOSTED><TRNAMT>-12.99</TRNAMT><FITID>A1</FITID><NAME>Spotify</NAME></STMTTRN>
+    <STMTTRN><TRNTYPE>CREDIT</TRNTYPE><DTPOSTED>bad</DTPOSTED><TRNAMT>50</TRNAMT><FITID>A2</FITID></STMTTRN>
+  </BANKTRANLIST>
+</CCSTMTRS></CCSTMTTRNRS></CREDITCARDMSGSRSV1></OFX>`
+
+	rows, err := importer.ParseOFX(strings.NewReader(statement), "user123")
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	require.NotNil(t, rows[0].Transaction)
+	assert.Equal(t, models.NewMoney(-1299, "USD"), rows[0].Transaction.Amount)
+	assert.Equal(t, "Spotify", rows[0].Transaction.Description)
+	assert.Contains(t, rows[1].Errors[0], "invalid date")
+
+	_, err = importer.ParseOFX(strings.NewReader("Date,Amount\n"), "user123")
+	assert.True(t, errors.Is(err, importer.ErrInvalidFile))
+}
+
+func TestImportService_ImportOFX_SkipsImportedTransactions(t *testing.T) {
+	rows, err := importer.ParseOFX(strings.NewReader(sgmlStatement), "user123")
+	require.NoError(t, err)
+	imported := *rows[0].Transaction
+
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("GetTransactionsByMonth", mock.Anything, "user123", "2025-01", mock.Anything, mock.Anything).
+		Return([]models.Transaction{imported}, nil, nil)
+	mockRepo.On("GetTransactionsByMonth", mock.Anything, "user123", "2025-02", mock.Anything, mock.Anything).
+		Return([]models.Transaction{}, nil, nil)
+	withRules(mockRepo)
+	mockRepo.On("BatchCreateTransactions", mock.Anything, mock.MatchedBy(func(txs []models.Transaction) bool {
+		return len(txs) == 2 && txs[0].ExternalID == "2025013101" && txs[1].ExternalID == "2025020101"
+	})).Return(nil)
+	service := services.NewImportService(mockRepo)
+
+	result, err := service.ImportOFX(context.Background(), "user123", strings.NewReader(sgmlStatement), services.ImportOptions{SkipInvalid: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, importer.FormatOFX, result.Format)
+	assert.Equal(t, 3, result.ValidRows)
+	assert.Equal(t, 1, result.Duplicates)
+	assert.True(t, result.Rows[0].Duplicate)
+	assert.Equal(t, 2, result.Imported)
+	mockRepo.AssertExpectations(t)
+}
+
+const cfdiInvoice = `<?xml version="1.0" encoding="UTF-8"?>
+<cfdi:Comprobante xmlns:cfdi="http://www.sat.gob.mx/cfd/4" xmlns:tfd="http://www.sat.gob.mx/TimbreFiscalDigital"
+    Version="4.0" Serie="A" Folio="1234" Fecha="2025-03-10T12:30:00" FormaPago="04" MetodoPago="PUE"
+    SubTotal="1000.00" Moneda="MXN" Total="1160.00" TipoDeComprobante="I" Exportacion="01" LugarExpedicion="06600">
+  <cfdi:Emisor Rfc="ofi920113kz8" Nombre="OFFICE DEPOT DE MEXICO" RegimenFiscal="601"/>
+  <cfdi:Receptor Rfc="XAXX010101000" Nombre="JUAN PEREZ" UsoCFDI="G03" DomicilioFiscalReceptor="06600" RegimenFiscalReceptor="612"/>
+  <cfdi:Conceptos>
+    <cfdi:Concepto ClaveProdServ="44121600" Cantidad="2" ClaveUnidad="H87" Unidad="Pieza" Descripcion="Cartucho de tinta" ValorUnitario="500.00" Importe="1000.00" ObjetoImp="02">
+      <cfdi:Impuestos>
+        <cfdi:Traslados>
+          <cfdi:Traslado Base="1000.00" Impuesto="002" TipoFactor="Tasa" TasaOCuota="0.160000" Importe="160.00"/>
+        </cfdi:Traslados>
+      </cfdi:Impuestos>
+    </cfdi:Concepto>
+  </cfdi:Conceptos>
+  <cfdi:Impuestos TotalImpuestosTrasladados="160.00">
+    <cfdi:Traslados>
+      <cfdi:Traslado Base="1000.00" Impuesto="002" TipoFactor="Tasa" TasaOCuota="0.160000" Importe="160.00"/>
+    </cfdi:Traslados>
+  </cfdi:Impuestos>
+  <cfdi:Complemento>
+    <tfd:TimbreFiscalDigital Version="1.1" UUID="6f1c2b3a-4d5e-4f60-8a9b-0c1d2e3f4a5b" FechaTimbrado="2025-03-10T12:31:00"/>
+  </cfdi:Complemento>
+</cfdi:Comprobante>`
+
+func TestParseCFDI(t *testing.T) {
+	invoice, err := importer.ParseCFDI(strings.NewReader("\ufeff" + cfdiInvoice))
+
+	require.NoError(t, err)
+	assert.Equal(t, "6F1C2B3A-4D5E-4F60-8A9B-0C1D2E3F4A5B", invoice.UUID)
+	assert.Equal(t, "OFI920113KZ8", invoice.EmitterRFC)
+	assert.Equal(t, "OFFICE DEPOT DE MEXICO", invoice.EmitterName)
+	assert.Equal(t, "G03", invoice.ReceiverUse)
+	assert.Equal(t, "MXN", invoice.Currency)
+	assert.Equal(t, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC), invoice.PaymentDate)
+	assert.Equal(t, models.NewMoney(116000, "MXN"), invoice.Total)
+	assert.Equal(t, models.NewMoney(16000, "MXN"), invoice.IVA)
+	assert.Equal(t, models.NewMoney(100000, "MXN"), invoice.Subtotal)
+	require.Len(t, invoice.Concepts, 1)
+	assert.Equal(t, "Cartucho de tinta", invoice.Concepts[0].Description)
+	assert.Equal(t, 2.0, invoice.Concepts[0].Quantity)
+	assert.Equal(t, models.NewMoney(50000, "MXN"), invoice.Concepts[0].UnitPrice)
+}
+
+func TestParseCFDI_RejectsInvalidInvoices(t *testing.T) {
+	tests := map[string]string{
+		"not a CFDI":  `<OFX></OFX>`,
+		"payment":     strings.Replace(cfdiInvoice, `TipoDeComprobante="I"`, `TipoDeComprobante="P"`, 1),
+		"not stamped": strings.Replace(cfdiInvoice, "tfd:TimbreFiscalDigital", "tfd:Otro", 1),
+		"bad total":   strings.Replace(cfdiInvoice, `Total="1160.00"`, `Total="abc"`, 1),
+		"malformed":   cfdiInvoice[:200],
+		"old version": strings.Replace(cfdiInvoice, `Version="4.0"`, `Version="3.2"`, 1),
+	}
+
+	for name, content := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := importer.ParseCFDI(strings.NewReader(content))
+			assert.True(t, errors.Is(err, importer.ErrInvalidFile), err)
+		})
+	}
+}
+
+func cfdiFile(name string) services.InvoiceFile {
+	return services.InvoiceFile{Name: name, Content: strings.NewReader(cfdiInvoice)}
+}
+
+func TestImportService_ImportCFDI_AttachesToMatchingExpense(t *testing.T) {
+	expense := models.NewTransaction("user123", models.TransactionTypeExpense, "office", "Office Depot",
+		models.NewMoney(-116000, "MXN"), time.Date(2025, 3, 11, 0, 0, 0, 0, time.UTC))
+	other := models.NewTransaction("user123", models.TransactionTypeExpense, "office", "Paper",
+		models.NewMoney(-5000, "MXN"), time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("GetTransactionsByMonthBetween", mock.Anything, "user123", "2025-03", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]models.Transaction{*other, *expense}, nil, nil)
+	withRules(mockRepo)
+	mockRepo.On("UpdateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+		return tx.ID == expense.ID && tx.Invoice != nil && tx.Invoice.EmitterRFC == "OFI920113KZ8"
+	})).Return(nil)
+	service := services.NewImportService(mockRepo)
+
+	result, err := service.ImportCFDI(context.Background(), "user123", []services.InvoiceFile{cfdiFile("a.xml")}, services.InvoiceOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Attached)
+	assert.Equal(t, models.InvoiceActionAttached, result.Invoices[0].Action)
+	assert.Equal(t, expense.ID, result.Invoices[0].Transaction.ID)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportService_ImportCFDI_CreatesExpenseAndSkipsDuplicates(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("GetTransactionsByMonthBetween", mock.Anything, "user123", "2025-03", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]models.Transaction{}, nil, nil)
+	withRules(mockRepo)
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+		return tx.Type == models.TransactionTypeExpense && tx.Category == "office" &&
+			tx.Amount == models.NewMoney(-116000, "MXN") && tx.Description == "OFFICE DEPOT DE MEXICO" &&
+			tx.Invoice != nil && tx.Invoice.UUID == "6F1C2B3A-4D5E-4F60-8A9B-0C1D2E3F4A5B"
+	})).Return(nil).Once()
+	service := services.NewImportService(mockRepo)
+
+	files := []services.InvoiceFile{
+		cfdiFile("a.xml"),
+		cfdiFile("copy.xml"),
+		{Name: "broken.xml", Content: strings.NewReader("<nope/>")},
+	}
+	result, err := service.ImportCFDI(context.Background(), "user123", files, services.InvoiceOptions{Category: "Office"})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Created)
+	assert.Equal(t, 1, result.Duplicates)
+	assert.Equal(t, 1, result.Invalid)
+	assert.Equal(t, models.InvoiceActionDuplicate, result.Invoices[1].Action)
+	assert.NotEmpty(t, result.Invoices[2].Errors)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportService_ImportCFDI_AlreadyImported(t *testing.T) {
+	invoice, err := importer.ParseCFDI(strings.NewReader(cfdiInvoice))
+	require.NoError(t, err)
+	imported := models.NewTransaction("user123", models.TransactionTypeExpense, "office", "Office Depot",
+		models.NewMoney(-116000, "MXN"), time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+	imported.Invoice = invoice
+
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("GetTransactionsByMonthBetween", mock.Anything, "user123", "2025-03", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+		Return([]models.Transaction{*imported}, nil, nil)
+	withRules(mockRepo)
+	service := services.NewImportService(mockRepo)
+
+	result, err := service.ImportCFDI(context.Background(), "user123", []services.InvoiceFile{cfdiFile("a.xml")}, services.InvoiceOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, 1, result.Duplicates)
+	mockRepo.AssertNotCalled(t, "CreateTransaction", mock.Anything, mock.Anything)
+	mockRepo.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything)
+}
+
+func TestTransactionService_CreateTransactionIgnoresExternalID(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	withRules(mockRepo)
+	withoutStoredTransactions(mockRepo)
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+		return tx.ExternalID == ""
+	})).Return(nil)
+	service := services.NewTransactionService(mockRepo)
+
+	tx := testTransaction("Coffee", -4550, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+	tx.ExternalID = "20250310001"
+	_, err := service.CreateTransaction(context.Background(), tx, services.CreateOptions{})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransactionService_CreateTransactionIgnoresInvoice(t *testing.T) {
+	mockRepo := new(mocks.MockRepository)
+	withRules(mockRepo)
+	withoutStoredTransactions(mockRepo)
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+		return tx.Invoice == nil
+	})).Return(nil)
+	service := services.NewTransactionService(mockRepo)
+
+	invoice, err := importer.ParseCFDI(strings.NewReader(cfdiInvoice))
+	require.NoError(t, err)
+	tx := testTransaction("Office Depot", -116000, time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+	tx.Invoice = invoice
+	_, err = service.CreateTransaction(context.Background(), tx, services.CreateOptions{})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportService_ImportCFDI_TransactionIDRequiresOneFile(t *testing.T) {
+	service := services.NewImportService(new(mocks.MockRepository))
+
+	_, err := service.ImportCFDI(context.Background(), "user123",
+		[]services.InvoiceFile{cfdiFile("a.xml"), cfdiFile("b.xml")}, services.InvoiceOptions{TransactionID: "tx1"})
+
+	assert.True(t, errors.Is(err, services.ErrInvalidImport))
+}
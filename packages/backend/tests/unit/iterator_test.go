@@ -0,0 +1,163 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+// pageKey builds a LastEvaluatedKey pointing after the given transaction
+func pageKey(userID, txID string) map[string]types.AttributeValue {
+	return map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+		"SK": &types.AttributeValueMemberS{Value: "TRANSACTION#" + txID},
+	}
+}
+
+func generateTransactions(userID, prefix string, n int, amount float64, txType, category string, date time.Time) []models.Transaction {
+	transactions := make([]models.Transaction, n)
+	for i := range transactions {
+		transactions[i] = models.Transaction{
+			ID:       fmt.Sprintf("%s-%d", prefix, i),
+			UserID:   userID,
+			Amount:   models.MoneyFromFloat(amount, models.DefaultCurrency),
+			Type:     txType,
+			Category: category,
+			Date:     date,
+		}
+	}
+	return transactions
+}
+
+func TestTransactionIterator_WalksAllPages(t *testing.T) {
+	pages := [][]models.Transaction{
+		generateTransactions("user123", "a", 3, -1, "expense", "food", time.Now()),
+		{}, // DynamoDB may return an empty page with a LastEvaluatedKey
+		generateTransactions("user123", "b", 2, -1, "expense", "food", time.Now()),
+	}
+
+	calls := 0
+	it := repository.NewTransactionIterator(func(ctx context.Context, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+		assert.Equal(t, 3, limit)
+		if calls > 0 {
+			assert.NotEmpty(t, lastKey)
+		}
+		page := pages[calls]
+		calls++
+		if calls == len(pages) {
+			return page, nil, nil
+		}
+		return page, pageKey("user123", fmt.Sprint(calls)), nil
+	}, 3)
+
+	transactions, err := repository.CollectTransactions(context.Background(), it)
+	require.NoError(t, err)
+	assert.Len(t, transactions, 5)
+	assert.Equal(t, "b-1", transactions[4].ID)
+	assert.Equal(t, 3, calls)
+
+	// An exhausted iterator does not query again
+	assert.False(t, it.Next(context.Background()))
+	assert.Equal(t, 3, calls)
+}
+
+func TestTransactionIterator_StopsOnError(t *testing.T) {
+	calls := 0
+	it := repository.NewTransactionIterator(func(ctx context.Context, limit int, lastKey map[string]types.AttributeValue) ([]models.Transaction, map[string]types.AttributeValue, error) {
+		calls++
+		if calls == 2 {
+			return nil, nil, errors.New("throttled")
+		}
+		return generateTransactions("user123", "a", 2, -1, "expense", "food", time.Now()), pageKey("user123", "a-1"), nil
+	}, 2)
+
+	seen := 0
+	err := repository.ForEachTransaction(context.Background(), it, func(models.Transaction) error {
+		seen++
+		return nil
+	})
+	assert.EqualError(t, err, "throttled")
+	assert.Equal(t, 2, seen)
+}
+
+func TestAnalyticsService_AggregatesPastPageBoundary(t *testing.T) {
+	userID := "user123"
+	lastKey := pageKey(userID, "expense-999")
+
+	// A full first page of 1000 expenses in January, then income in February
+	firstPage := generateTransactions(userID, "expense", 1000, -1.5, "expense", "food", time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC))
+	secondPage := generateTransactions(userID, "income", 2, 1000, "income", "salary", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC))
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByUser", mock.Anything, userID, 1000, map[string]types.AttributeValue(nil)).Return(firstPage, lastKey, nil)
+	mockRepo.On("GetTransactionsByUser", mock.Anything, userID, 1000, lastKey).Return(secondPage, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, userID).Return(nil, repository.ErrUserNotFound)
+	withCategories(mockRepo)
+	withAccounts(mockRepo)
+
+	service := services.NewAnalyticsService(mockRepo)
+	ctx := context.Background()
+
+	summary, err := service.GetFinancialSummary(ctx, userID)
+	require.NoError(t, err)
+	assert.Equal(t, models.NewMoney(200000, models.DefaultCurrency), summary.MonthlyIncome)
+	assert.Equal(t, models.NewMoney(-150000, models.DefaultCurrency), summary.MonthlyExpenses)
+	assert.Equal(t, models.NewMoney(50000, models.DefaultCurrency), summary.TotalBalance)
+	require.Len(t, summary.CategoryBreakdown, 1)
+	assert.Equal(t, 1000, summary.CategoryBreakdown[0].Count)
+
+	months, err := service.GetMonthsWithTransactions(ctx, userID)
+	require.NoError(t, err)
+	sort.Strings(months)
+	assert.Equal(t, []string{"2025-01", "2025-02"}, months)
+
+	categories, err := service.GetUniqueCategories(ctx, userID)
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []string{"food", "salary"}, categories)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestBudgetService_GetBudgetUtilization_PastPageBoundary(t *testing.T) {
+	userID := "user123"
+	month := "2025-01"
+	lastKey := pageKey(userID, "old-999")
+
+	// Transactions are listed newest first: the month's spending spans both pages
+	firstPage := generateTransactions(userID, "new", 1000, -2, "expense", "food", time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC))
+	secondPage := append(
+		generateTransactions(userID, "old", 10, -5, "expense", "food", time.Date(2025, 1, 2, 0, 0, 0, 0, time.UTC)),
+		generateTransactions(userID, "dec", 10, -5, "expense", "food", time.Date(2024, 12, 30, 0, 0, 0, 0, time.UTC))...,
+	)
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetBudgetsByMonth", mock.Anything, userID, month).Return([]models.Budget{
+		{UserID: userID, Month: month, Category: "food", Amount: models.MoneyFromFloat(3000, models.DefaultCurrency)},
+	}, nil)
+	mockRepo.On("GetTransactionsByUser", mock.Anything, userID, 1000, map[string]types.AttributeValue(nil)).Return(firstPage, lastKey, nil)
+	mockRepo.On("GetTransactionsByUser", mock.Anything, userID, 1000, lastKey).Return(secondPage, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, userID).Return(nil, repository.ErrUserNotFound)
+
+	service := services.NewBudgetService(mockRepo)
+	utilization, err := service.GetBudgetUtilization(context.Background(), userID, month)
+	require.NoError(t, err)
+
+	// 1000 * 2 + 10 * 5; December spending is excluded
+	assert.Equal(t, models.NewMoney(205000, models.DefaultCurrency), utilization["food"].SpentAmount)
+	assert.Equal(t, models.NewMoney(95000, models.DefaultCurrency), utilization["food"].Remaining)
+
+	mockRepo.AssertExpectations(t)
+}
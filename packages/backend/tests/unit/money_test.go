@@ -0,0 +1,94 @@
+package services
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+)
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected int64
+		wantErr  bool
+	}{
+		{input: "149.99", expected: 14999},
+		{input: "-25.5", expected: -2550},
+		{input: "2800", expected: 280000},
+		{input: "0.30000000000000004", expected: 30},
+		{input: "1.005", expected: 101},
+		{input: "-1.005", expected: -101},
+		{input: "abc", wantErr: true},
+		{input: "", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			money, err := models.ParseMoney(tt.input, models.DefaultCurrency)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, money.Minor)
+			assert.Equal(t, models.DefaultCurrency, money.Currency)
+		})
+	}
+}
+
+func TestMoney_SumDoesNotDrift(t *testing.T) {
+	total := models.ZeroMoney(models.DefaultCurrency)
+	for i := 0; i < 1000; i++ {
+		total = total.Add(models.MoneyFromFloat(0.1, models.DefaultCurrency))
+	}
+
+	assert.Equal(t, models.NewMoney(10000, models.DefaultCurrency), total)
+	assert.Equal(t, "100.00", total.String())
+
+	budget := models.MoneyFromFloat(250, models.DefaultCurrency)
+	assert.Equal(t, "149.99", budget.Sub(models.MoneyFromFloat(100.01, models.DefaultCurrency)).String())
+}
+
+func TestMoney_JSON(t *testing.T) {
+	data, err := json.Marshal(struct {
+		Amount models.Money `json:"amount"`
+	}{Amount: models.NewMoney(-2550, models.DefaultCurrency)})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"amount": -25.50}`, string(data))
+
+	var decoded struct {
+		Amount models.Money `json:"amount"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": 1234.56}`), &decoded))
+	assert.Equal(t, models.NewMoney(123456, models.DefaultCurrency), decoded.Amount)
+
+	require.NoError(t, json.Unmarshal([]byte(`{"amount": "-0.07"}`), &decoded))
+	assert.Equal(t, models.NewMoney(-7, models.DefaultCurrency), decoded.Amount)
+
+	assert.Error(t, json.Unmarshal([]byte(`{"amount": "ten"}`), &decoded))
+}
+
+func TestMoney_DynamoDBAttributeValue(t *testing.T) {
+	money := models.NewMoney(-14999, "USD")
+
+	av, err := money.MarshalDynamoDBAttributeValue()
+	require.NoError(t, err)
+	assert.False(t, models.IsLegacyAmount(av))
+
+	var decoded models.Money
+	require.NoError(t, decoded.UnmarshalDynamoDBAttributeValue(av))
+	assert.Equal(t, money, decoded)
+
+	// Items written before the fixed-point migration store a float number
+	legacy := &types.AttributeValueMemberN{Value: "149.99"}
+	assert.True(t, models.IsLegacyAmount(legacy))
+	require.NoError(t, decoded.UnmarshalDynamoDBAttributeValue(legacy))
+	assert.Equal(t, models.NewMoney(14999, models.DefaultCurrency), decoded)
+
+	assert.Error(t, decoded.UnmarshalDynamoDBAttributeValue(&types.AttributeValueMemberS{Value: "149.99"}))
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/period"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+func TestPeriod_Parse(t *testing.T) {
+	now := time.Date(2025, 8, 14, 18, 30, 0, 0, time.UTC)
+	day := func(month time.Month, d int) time.Time {
+		return time.Date(2025, month, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	tests := []struct {
+		name      string
+		period    string
+		from, to  string
+		wantName  string
+		wantStart time.Time
+		wantLast  time.Time
+	}{
+		{"default is current month", "", "", "", period.Month, day(8, 1), day(8, 31)},
+		{"rolling week", "week", "", "", period.Week, day(8, 8), day(8, 14)},
+		{"rolling 30 days", "30d", "", "", period.Days30, day(7, 16), day(8, 14)},
+		{"calendar month", "month", "", "", period.Month, day(8, 1), day(8, 31)},
+		{"calendar quarter", "quarter", "", "", period.Quarter, day(7, 1), day(9, 30)},
+		{"year to date", "year", "", "", period.Year, day(1, 1), day(8, 14)},
+		{"explicit dates imply custom", "", "2025-02-10", "2025-03-05", period.Custom, day(2, 10), day(3, 5)},
+		{"single custom day", "custom", "2025-02-10", "2025-02-10", period.Custom, day(2, 10), day(2, 10)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rng, err := period.Parse(tt.period, tt.from, tt.to, now)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, rng.Name)
+			assert.Equal(t, tt.wantStart, rng.Start)
+			assert.Equal(t, tt.wantLast, rng.Last())
+		})
+	}
+}
+
+func TestPeriod_ParseInvalid(t *testing.T) {
+	now := time.Date(2025, 8, 14, 0, 0, 0, 0, time.UTC)
+
+	for _, args := range [][3]string{
+		{"fortnight", "", ""},
+		{"custom", "2025-02-10", ""},
+		{"custom", "2025-03-05", "2025-02-10"},
+		{"custom", "10/02/2025", "2025-03-05"},
+		{"month", "2025-02-10", "2025-03-05"},
+		{"custom", "2015-01-01", "2025-03-05"},
+	} {
+		_, err := period.Parse(args[0], args[1], args[2], now)
+		assert.ErrorIs(t, err, period.ErrInvalidPeriod, "%v", args)
+	}
+}
+
+func TestPeriod_RangeMonths(t *testing.T) {
+	rng, err := period.Parse("custom", "2024-12-20", "2025-02-01", time.Now())
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"2024-12", "2025-01", "2025-02"}, rng.Months())
+	assert.True(t, rng.Contains(time.Date(2025, 2, 1, 23, 59, 0, 0, time.UTC)))
+	assert.False(t, rng.Contains(time.Date(2025, 2, 2, 0, 0, 0, 0, time.UTC)))
+	assert.False(t, rng.Contains(time.Date(2024, 12, 19, 12, 0, 0, 0, time.UTC)))
+}
+
+func TestPeriod_WholeMonths(t *testing.T) {
+	from, to := period.WholeMonths("2024-02", "2025-02")
+	assert.Equal(t, "2024-02-01", from)
+	assert.Equal(t, "2025-02-28", to)
+
+	from, to = period.WholeMonths("2025-02-10", "")
+	assert.Equal(t, "2025-02-10", from)
+	assert.Equal(t, "", to)
+}
+
+func TestAnalyticsService_GetCategoryBreakdown_Period(t *testing.T) {
+	userID := "user123"
+	expense := func(id string, amount float64, category string, date time.Time) models.Transaction {
+		return models.Transaction{
+			ID:       id,
+			UserID:   userID,
+			Amount:   models.MoneyFromFloat(amount, models.DefaultCurrency),
+			Type:     "expense",
+			Category: category,
+			Date:     date,
+		}
+	}
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByMonth", mock.Anything, userID, "2025-01", 1000, mock.Anything).Return([]models.Transaction{
+		expense("tx1", -100, "food", time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)), // before the period
+		expense("tx2", -50, "food", time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC)),
+	}, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetTransactionsByMonth", mock.Anything, userID, "2025-02", 1000, mock.Anything).Return([]models.Transaction{
+		expense("tx3", -150, "transport", time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)),
+		expense("tx4", -500, "transport", time.Date(2025, 2, 25, 0, 0, 0, 0, time.UTC)), // after the period
+	}, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, userID).Return(nil, repository.ErrUserNotFound)
+	withCategories(mockRepo)
+
+	rng, err := period.Parse("", "2025-01-15", "2025-02-10", time.Now())
+	require.NoError(t, err)
+
+	service := services.NewAnalyticsService(mockRepo)
+	breakdown, err := service.GetCategoryBreakdown(context.Background(), userID, rng, services.BreakdownOptions{})
+	require.NoError(t, err)
+
+	byCategory := make(map[string]models.CategoryBreakdown)
+	for _, b := range breakdown {
+		byCategory[b.Category] = b
+	}
+	require.Len(t, byCategory, 2)
+	assert.Equal(t, models.NewMoney(5000, models.DefaultCurrency), byCategory["food"].Amount)
+	assert.InDelta(t, 25.0, byCategory["food"].Percentage, 0.001)
+	assert.Equal(t, 1, byCategory["transport"].Count)
+
+	mockRepo.AssertExpectations(t)
+}
@@ -0,0 +1,122 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/rates"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+func TestRates_LoadCSVAndLookup(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	csv := "date,base,quote,rate\n" +
+		"2025-08-01,USD,MXN,18.50\n" +
+		"2025-08-04,usd,mxn,18.70\n"
+	require.NoError(t, os.WriteFile(path, []byte(csv), 0o600))
+
+	store, err := rates.LoadFile(path)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name     string
+		base     string
+		quote    string
+		on       time.Time
+		expected float64
+	}{
+		{name: "exact date", base: "USD", quote: "MXN", on: time.Date(2025, 8, 4, 15, 0, 0, 0, time.UTC), expected: 18.70},
+		{name: "weekend uses previous rate", base: "USD", quote: "MXN", on: time.Date(2025, 8, 3, 0, 0, 0, 0, time.UTC), expected: 18.50},
+		{name: "before first rate uses earliest", base: "USD", quote: "MXN", on: time.Date(2025, 7, 1, 0, 0, 0, 0, time.UTC), expected: 18.50},
+		{name: "inverse pair", base: "MXN", quote: "USD", on: time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC), expected: 1 / 18.50},
+		{name: "same currency", base: "MXN", quote: "MXN", on: time.Now(), expected: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rate, err := store.Rate(tt.base, tt.quote, tt.on)
+			require.NoError(t, err)
+			assert.InDelta(t, tt.expected, rate, 1e-9)
+		})
+	}
+
+	_, err = store.Rate("EUR", "MXN", time.Now())
+	assert.ErrorIs(t, err, rates.ErrRateNotFound)
+
+	converted, err := store.Convert(models.MoneyFromFloat(-12.34, "USD"), "MXN", time.Date(2025, 8, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	// -12.34 * 18.50 = -228.29
+	assert.Equal(t, models.NewMoney(-22829, "MXN"), converted)
+}
+
+func TestRates_LoadJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.json")
+	data := `[{"date": "2025-08-01", "base": "EUR", "quote": "MXN", "rate": 21.75}]`
+	require.NoError(t, os.WriteFile(path, []byte(data), 0o600))
+
+	store, err := rates.LoadFile(path)
+	require.NoError(t, err)
+
+	rate, err := store.Rate("EUR", "MXN", time.Date(2025, 8, 2, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, 21.75, rate)
+
+	badPath := filepath.Join(t.TempDir(), "bad.json")
+	require.NoError(t, os.WriteFile(badPath, []byte(`[{"date": "2025-08-01", "base": "EURO", "quote": "MXN", "rate": 21.75}]`), 0o600))
+	_, err = rates.LoadFile(badPath)
+	assert.Error(t, err)
+}
+
+func TestCurrencyConverter_BaseCurrency(t *testing.T) {
+	throttled := errors.New("throttled")
+
+	tests := []struct {
+		name     string
+		user     *models.User
+		err      error
+		expected string
+		wantErr  error
+	}{
+		{
+			name:     "stored profile",
+			user:     &models.User{ID: "user123", BaseCurrency: "USD"},
+			expected: "USD",
+		},
+		{
+			name:     "no profile reports in the default currency",
+			err:      repository.ErrUserNotFound,
+			expected: models.DefaultCurrency,
+		},
+		{
+			name:    "failed read is returned",
+			err:     throttled,
+			wantErr: throttled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockRepo := mocks.NewMockRepository()
+			mockRepo.On("GetUser", mock.Anything, "user123").Return(tt.user, tt.err)
+
+			base, err := services.NewCurrencyConverter(mockRepo, nil).BaseCurrency(context.Background(), "user123")
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, base)
+		})
+	}
+}
@@ -0,0 +1,204 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+func monthlyRent(start time.Time) models.RecurringTransaction {
+	return models.RecurringTransaction{
+		ID:          "rule-1",
+		UserID:      "user123",
+		Type:        models.TransactionTypeExpense,
+		Category:    "rent",
+		Description: "Rent",
+		Amount:      models.MoneyFromFloat(-1200, models.DefaultCurrency),
+		Frequency:   models.FrequencyMonthly,
+		Interval:    1,
+		StartDate:   start,
+		Active:      true,
+		Version:     1,
+	}
+}
+
+func TestRecurringTransaction_Occurrence(t *testing.T) {
+	rule := monthlyRent(time.Date(2025, 1, 31, 9, 0, 0, 0, time.UTC))
+
+	// Months without a 31st fall on their last day without drifting
+	assert.Equal(t, time.Date(2025, 2, 28, 9, 0, 0, 0, time.UTC), rule.Occurrence(1))
+	assert.Equal(t, time.Date(2025, 3, 31, 9, 0, 0, 0, time.UTC), rule.Occurrence(2))
+	assert.Equal(t, time.Date(2025, 4, 30, 9, 0, 0, 0, time.UTC), rule.Occurrence(3))
+
+	rule.Frequency = models.FrequencyWeekly
+	rule.Interval = 2
+	assert.Equal(t, time.Date(2025, 2, 28, 9, 0, 0, 0, time.UTC), rule.Occurrence(2))
+
+	rule.Frequency = models.FrequencyYearly
+	rule.Interval = 1
+	rule.StartDate = time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)
+	assert.Equal(t, time.Date(2025, 2, 28, 0, 0, 0, 0, time.UTC), rule.Occurrence(1))
+}
+
+func TestRecurringTransaction_ScheduleEnds(t *testing.T) {
+	rule := monthlyRent(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC))
+	end := time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)
+	rule.EndDate = &end
+
+	rule.Schedule(nil)
+	require.NotNil(t, rule.NextRun)
+	assert.Equal(t, rule.StartDate, *rule.NextRun)
+
+	// The end date is inclusive
+	after := time.Date(2025, 2, 15, 0, 0, 0, 0, time.UTC)
+	rule.Schedule(&after)
+	require.NotNil(t, rule.NextRun)
+	assert.Equal(t, end, *rule.NextRun)
+
+	rule.Schedule(&end)
+	assert.Nil(t, rule.NextRun)
+
+	// Exhausted rules leave the due index
+	rule.GenerateKeys()
+	assert.Empty(t, rule.GSI1PK)
+
+	rule.EndDate = nil
+	rule.Count = 2
+	rule.Generated = 2
+	rule.Schedule(&after)
+	assert.Nil(t, rule.NextRun)
+}
+
+func TestRecurringTransaction_NewOccurrenceIsDeterministic(t *testing.T) {
+	rule := monthlyRent(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	date := rule.Occurrence(3)
+
+	first := rule.NewOccurrence(date)
+	second := rule.NewOccurrence(date)
+	assert.Equal(t, first.ID, second.ID)
+	assert.Equal(t, first.SK, second.SK)
+	assert.Equal(t, "rule-1", first.RecurringID)
+	assert.Equal(t, "MONTH#2025-04#user123", first.GSI1PK)
+
+	assert.NotEqual(t, first.ID, rule.NewOccurrence(rule.Occurrence(4)).ID)
+}
+
+func TestRecurringService_CreateRecurring(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("CreateRecurringTransaction", mock.Anything, mock.AnythingOfType("*models.RecurringTransaction")).Return(nil)
+
+	service := services.NewRecurringService(mockRepo)
+
+	rule := monthlyRent(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	rule.Interval = 0
+	rule.Generated = 7
+	require.NoError(t, service.CreateRecurring(context.Background(), &rule))
+
+	assert.NotEqual(t, "rule-1", rule.ID)
+	assert.Equal(t, 1, rule.Interval)
+	assert.Equal(t, 0, rule.Generated)
+	require.NotNil(t, rule.NextRun)
+	assert.Equal(t, rule.StartDate, *rule.NextRun)
+	assert.Equal(t, models.RecurringDuePartition, rule.GSI1PK)
+	assert.Equal(t, "NEXT#00000000001735689600#"+rule.ID, rule.GSI1SK)
+
+	invalid := monthlyRent(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC))
+	invalid.Frequency = "HOURLY"
+	err := service.CreateRecurring(context.Background(), &invalid)
+	assert.ErrorIs(t, err, services.ErrInvalidRecurring)
+
+	mockRepo.AssertNumberOfCalls(t, "CreateRecurringTransaction", 1)
+}
+
+func TestRecurringService_UpdateRecurring_ResumeSkipsMissedOccurrences(t *testing.T) {
+	lastRun := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	existing := monthlyRent(lastRun)
+	existing.Active = false
+	existing.Generated = 1
+	existing.LastRun = &lastRun
+	existing.Version = 4
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetRecurringTransaction", mock.Anything, "user123", "rule-1").Return(&existing, nil)
+	mockRepo.On("UpdateRecurringTransaction", mock.Anything, mock.AnythingOfType("*models.RecurringTransaction")).Return(nil)
+
+	update := monthlyRent(lastRun)
+	update.Version = 0
+	require.NoError(t, services.NewRecurringService(mockRepo).UpdateRecurring(context.Background(), &update))
+
+	assert.Equal(t, 1, update.Generated)
+	assert.Equal(t, 4, update.Version)
+	require.NotNil(t, update.NextRun)
+	assert.True(t, update.NextRun.After(time.Now()), "resumed rule is scheduled in the future, got %s", update.NextRun)
+}
+
+func TestRecurringService_MaterializeDue(t *testing.T) {
+	now := time.Date(2025, 3, 20, 0, 0, 0, 0, time.UTC)
+	start := time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC)
+
+	rent := monthlyRent(start)
+	rent.Schedule(nil)
+
+	broken := monthlyRent(start)
+	broken.ID = "rule-2"
+	broken.Schedule(nil)
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetDueRecurringTransactions", mock.Anything, now).Return([]models.RecurringTransaction{rent, broken}, nil)
+
+	// January was created by an earlier run that failed before saving the rule
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+		return tx.RecurringID == "rule-1" && tx.Date.Month() == time.January
+	})).Return(repository.ErrTransactionExists)
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+		return tx.RecurringID == "rule-1"
+	})).Return(nil)
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+		return tx.RecurringID == "rule-2"
+	})).Return(errors.New("throttled"))
+
+	var saved *models.RecurringTransaction
+	mockRepo.On("UpdateRecurringTransaction", mock.Anything, mock.AnythingOfType("*models.RecurringTransaction")).
+		Run(func(args mock.Arguments) { saved = args.Get(1).(*models.RecurringTransaction) }).
+		Return(nil)
+
+	result, err := services.NewRecurringService(mockRepo).MaterializeDue(context.Background(), now)
+	require.NoError(t, err)
+
+	assert.Equal(t, &models.RecurringRunResult{Rules: 2, Generated: 2, Duplicates: 1, Failed: 1}, result)
+
+	// Only the healthy rule is saved, advanced past now
+	mockRepo.AssertNumberOfCalls(t, "UpdateRecurringTransaction", 1)
+	require.NotNil(t, saved)
+	assert.Equal(t, "rule-1", saved.ID)
+	assert.Equal(t, 3, saved.Generated)
+	assert.Equal(t, time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC), *saved.LastRun)
+	assert.Equal(t, time.Date(2025, 4, 5, 0, 0, 0, 0, time.UTC), *saved.NextRun)
+}
+
+func TestTransactionService_CreateTransactionIgnoresRecurringID(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	withRules(mockRepo)
+	withoutStoredTransactions(mockRepo)
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+		return tx.RecurringID == ""
+	})).Return(nil)
+	service := services.NewTransactionService(mockRepo)
+
+	tx := testTransaction("Rent", -120000, time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC))
+	tx.RecurringID = "rule-1"
+	_, err := service.CreateTransaction(context.Background(), tx, services.CreateOptions{})
+
+	require.NoError(t, err)
+	mockRepo.AssertExpectations(t)
+}
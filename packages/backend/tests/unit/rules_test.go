@@ -0,0 +1,275 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/period"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+func TestCategoryRule_Validate(t *testing.T) {
+	min, max := models.NewMoney(50000, "MXN"), models.NewMoney(1000, "MXN")
+
+	tests := []struct {
+		name   string
+		modify func(*models.CategoryRule)
+		valid  bool
+	}{
+		{"contains and category", func(r *models.CategoryRule) { r.DescriptionContains = "uber" }, true},
+		{"without conditions", func(r *models.CategoryRule) {}, false},
+		{"without actions", func(r *models.CategoryRule) { r.DescriptionContains = "uber"; r.Category = "" }, false},
+		{"tags only", func(r *models.CategoryRule) { r.Type = "expense"; r.Category = ""; r.Tags = []string{" Trips "} }, true},
+		{"invalid regex", func(r *models.CategoryRule) { r.DescriptionRegex = "uber(" }, false},
+		{"invalid type", func(r *models.CategoryRule) { r.Type = "transfer" }, false},
+		{"min above max", func(r *models.CategoryRule) { r.MinAmount, r.MaxAmount = &min, &max }, false},
+		{"punctuation only", func(r *models.CategoryRule) { r.DescriptionContains = "***" }, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := testRule("r1", 0, "transportation")
+			tt.modify(&rule)
+			err := rule.Validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestCategoryRule_Matches(t *testing.T) {
+	tx := models.NewTransaction("user123", models.TransactionTypeExpense, "", "OXXO SUC. 123 Café",
+		models.NewMoney(-8550, "MXN"), time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+	min, max := models.NewMoney(5000, "MXN"), models.NewMoney(10000, "MXN")
+
+	tests := []struct {
+		name    string
+		modify  func(*models.CategoryRule)
+		matches bool
+	}{
+		{"contains ignores case and accents", func(r *models.CategoryRule) { r.DescriptionContains = "cafe" }, true},
+		{"regex is case-insensitive", func(r *models.CategoryRule) { r.DescriptionRegex = `^oxxo suc\. \d+` }, true},
+		{"merchant leading words", func(r *models.CategoryRule) { r.Merchant = "Oxxo" }, true},
+		{"merchant elsewhere", func(r *models.CategoryRule) { r.Merchant = "cafe" }, false},
+		{"absolute amount in range", func(r *models.CategoryRule) { r.MinAmount, r.MaxAmount = &min, &max }, true},
+		{"amount below minimum", func(r *models.CategoryRule) { r.MinAmount = &max }, false},
+		{"every condition must hold", func(r *models.CategoryRule) { r.DescriptionContains = "oxxo"; r.Type = "income" }, false},
+		{"no conditions", func(r *models.CategoryRule) {}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rule := testRule("r1", 0, "convenience")
+			tt.modify(&rule)
+			assert.Equal(t, tt.matches, rule.Matches(tx))
+		})
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	newTx := func(category string) *models.Transaction {
+		return models.NewTransaction("user123", models.TransactionTypeExpense, category, "UBER TRIP HELP.UBER.COM",
+			models.NewMoney(-12000, "MXN"), time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+	}
+	first := testRule("first", 1, "transportation", "rides")
+	first.DescriptionContains = "uber"
+	second := testRule("second", 2, "dining", "work")
+	second.DescriptionContains = "uber"
+	inactive := testRule("inactive", 0, "other", "ignored")
+	inactive.DescriptionContains = "uber"
+	inactive.Active = false
+	rules := []models.CategoryRule{second, inactive, first}
+	models.SortRules(rules)
+
+	t.Run("first category wins and every rule tags", func(t *testing.T) {
+		tx := newTx(models.DefaultImportCategory)
+		applied := models.ApplyRules(rules, tx)
+
+		assert.Equal(t, []string{"first", "second"}, applied)
+		assert.Equal(t, "transportation", tx.Category)
+		assert.Equal(t, []string{"rides", "work"}, tx.Tags)
+	})
+
+	t.Run("keeps the category the user chose", func(t *testing.T) {
+		tx := newTx("travel")
+		models.ApplyRules(rules, tx)
+		assert.Equal(t, "travel", tx.Category)
+	})
+
+	t.Run("override replaces it", func(t *testing.T) {
+		overriding := append([]models.CategoryRule(nil), rules...)
+		overriding[1].Override = true
+		tx := newTx("travel")
+		models.ApplyRules(overriding, tx)
+		assert.Equal(t, "transportation", tx.Category)
+	})
+
+	t.Run("reports nothing when already applied", func(t *testing.T) {
+		tx := newTx("transportation")
+		tx.Tags = []string{"rides", "work"}
+		assert.Empty(t, models.ApplyRules(rules, tx))
+	})
+
+	t.Run("stops tagging at the tag limit", func(t *testing.T) {
+		var many []models.CategoryRule
+		for i := 0; i < 3; i++ {
+			rule := testRule(fmt.Sprintf("rule-%d", i), i, "")
+			rule.DescriptionContains = "uber"
+			for j := 0; j < models.MaxRuleTags; j++ {
+				rule.Tags = append(rule.Tags, fmt.Sprintf("tag-%d-%d", i, j))
+			}
+			many = append(many, rule)
+		}
+
+		tx := newTx("transportation")
+		tx.Tags = []string{"rides"}
+		applied := models.ApplyRules(many, tx)
+
+		assert.Len(t, tx.Tags, models.MaxTransactionTags)
+		assert.Equal(t, "tag-1-8", tx.Tags[len(tx.Tags)-1])
+		assert.Equal(t, []string{"rule-0", "rule-1"}, applied)
+	})
+}
+
+func TestTransactionService_CreateTransaction_AppliesRules(t *testing.T) {
+	rule := testRule("r1", 0, "transportation", "rides")
+	rule.DescriptionContains = "uber"
+
+	mockRepo := new(mocks.MockRepository)
+	withoutStoredTransactions(mockRepo)
+	withRules(mockRepo, rule)
+	mockRepo.On("CreateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+		return tx.Category == "transportation" && tx.HasTag("rides") && tx.HasTag("mine") &&
+			strings.HasPrefix(tx.GSI2PK, "CATEGORY#TRANSPORTATION#")
+	})).Return(nil)
+	service := services.NewTransactionService(mockRepo)
+
+	tx := &models.Transaction{
+		UserID:      "user123",
+		Type:        models.TransactionTypeExpense,
+		Description: "Uber trip",
+		Amount:      models.NewMoney(-12000, "MXN"),
+		Date:        time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC),
+		Tags:        []string{" Mine ", "mine"},
+	}
+	result, err := service.CreateTransaction(context.Background(), tx, services.CreateOptions{})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"mine", "rides"}, result.Transaction.Tags)
+	mockRepo.AssertExpectations(t)
+}
+
+func TestImportService_ImportCSV_AppliesRules(t *testing.T) {
+	rule := testRule("r1", 0, "groceries")
+	rule.DescriptionContains = "groceries"
+
+	mockRepo := new(mocks.MockRepository)
+	withoutStoredTransactions(mockRepo)
+	withRules(mockRepo, rule)
+	service := services.NewImportService(mockRepo)
+
+	result, err := service.ImportCSV(context.Background(), "user123", strings.NewReader(importTestFile), importTestMapping,
+		services.ImportOptions{DryRun: true})
+
+	require.NoError(t, err)
+	assert.Equal(t, "groceries", result.Rows[0].Transaction.Category)
+	assert.Equal(t, models.DefaultImportCategory, result.Rows[2].Transaction.Category)
+}
+
+func TestRuleService_CreateRule(t *testing.T) {
+	t.Run("stores a valid rule", func(t *testing.T) {
+		mockRepo := new(mocks.MockRepository)
+		withRules(mockRepo)
+		mockRepo.On("CreateRule", mock.Anything, mock.MatchedBy(func(rule *models.CategoryRule) bool {
+			return rule.ID != "" && rule.Version == 1 && rule.SK == "RULE#"+rule.ID
+		})).Return(nil)
+		service := services.NewRuleService(mockRepo)
+
+		rule := testRule("", 0, "transportation")
+		rule.DescriptionContains = "uber"
+		require.NoError(t, service.CreateRule(context.Background(), &rule))
+		mockRepo.AssertExpectations(t)
+	})
+
+	t.Run("rejects invalid rules", func(t *testing.T) {
+		service := services.NewRuleService(new(mocks.MockRepository))
+
+		rule := testRule("", 0, "transportation")
+		err := service.CreateRule(context.Background(), &rule)
+		assert.True(t, errors.Is(err, services.ErrInvalidRule))
+	})
+
+	t.Run("enforces the limit", func(t *testing.T) {
+		mockRepo := new(mocks.MockRepository)
+		withRules(mockRepo, make([]models.CategoryRule, models.MaxRulesPerUser)...)
+		service := services.NewRuleService(mockRepo)
+
+		rule := testRule("", 0, "transportation")
+		rule.DescriptionContains = "uber"
+		err := service.CreateRule(context.Background(), &rule)
+		assert.True(t, errors.Is(err, services.ErrInvalidRule))
+	})
+}
+
+func TestRuleService_ApplyRules(t *testing.T) {
+	day := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+	uber := models.NewTransaction("user123", models.TransactionTypeExpense, models.DefaultImportCategory, "UBER TRIP",
+		models.NewMoney(-12000, "MXN"), day)
+	rent := models.NewTransaction("user123", models.TransactionTypeExpense, "rent", "Rent",
+		models.NewMoney(-900000, "MXN"), day)
+	rule := testRule("r1", 0, "transportation")
+	rule.DescriptionContains = "uber"
+
+	newRepo := func() *mocks.MockRepository {
+		mockRepo := new(mocks.MockRepository)
+		mockRepo.On("GetTransactionsByMonthBetween", mock.Anything, "user123", "2025-03", mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+			Return([]models.Transaction{*uber, *rent}, nil, nil)
+		withRules(mockRepo, rule)
+		return mockRepo
+	}
+	rng, err := period.Parse("", "2025-03-01", "2025-03-31", time.Now())
+	require.NoError(t, err)
+
+	t.Run("dry run only reports", func(t *testing.T) {
+		mockRepo := newRepo()
+		service := services.NewRuleService(mockRepo)
+
+		result, err := service.ApplyRules(context.Background(), "user123", rng, true)
+
+		require.NoError(t, err)
+		assert.Equal(t, 2, result.Scanned)
+		assert.Equal(t, 1, result.Changed)
+		assert.Equal(t, uber.ID, result.Changes[0].TransactionID)
+		assert.Equal(t, models.DefaultImportCategory, result.Changes[0].OldCategory)
+		assert.Equal(t, "transportation", result.Changes[0].NewCategory)
+		assert.Equal(t, []string{"r1"}, result.Changes[0].RuleIDs)
+		mockRepo.AssertNotCalled(t, "UpdateTransaction", mock.Anything, mock.Anything)
+	})
+
+	t.Run("saves the changes", func(t *testing.T) {
+		mockRepo := newRepo()
+		mockRepo.On("UpdateTransaction", mock.Anything, mock.MatchedBy(func(tx *models.Transaction) bool {
+			return tx.ID == uber.ID && tx.Category == "transportation"
+		})).Return(nil).Once()
+		service := services.NewRuleService(mockRepo)
+
+		result, err := service.ApplyRules(context.Background(), "user123", rng, false)
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, result.Changed)
+		mockRepo.AssertExpectations(t)
+	})
+}
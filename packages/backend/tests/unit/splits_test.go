@@ -0,0 +1,167 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/period"
+	"backend/internal/rates"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+// supermarketReceipt is a 300.00 MXN supermarket receipt split into groceries
+// and household items
+func supermarketReceipt() *models.Transaction {
+	tx := models.NewTransaction("user123", models.TransactionTypeExpense, "", "Supermercado", models.NewMoney(-30000, "MXN"), time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+	tx.Splits = []models.Split{
+		{Category: "groceries", Amount: models.NewMoney(-20000, "MXN")},
+		{Category: "household", Amount: models.NewMoney(-10000, "MXN"), Note: "Detergente"},
+	}
+	return tx
+}
+
+func TestTransactionService_ValidateTransaction_Splits(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(tx *models.Transaction)
+		valid  bool
+	}{
+		{"splits add up to the amount", func(tx *models.Transaction) {}, true},
+		{"splits do not add up", func(tx *models.Transaction) { tx.Splits[1].Amount = models.NewMoney(-9999, "MXN") }, false},
+		{"a single split", func(tx *models.Transaction) { tx.Splits = tx.Splits[:1]; tx.Amount = tx.Splits[0].Amount }, false},
+		{"split without category", func(tx *models.Transaction) { tx.Splits[0].Category = " " }, false},
+		{"repeated category", func(tx *models.Transaction) { tx.Splits[1].Category = "Groceries" }, false},
+		{"split with the opposite sign", func(tx *models.Transaction) {
+			tx.Splits[0].Amount = models.NewMoney(-40000, "MXN")
+			tx.Splits[1].Amount = models.NewMoney(10000, "MXN")
+		}, false},
+		{"split in another currency", func(tx *models.Transaction) { tx.Splits[1].Amount.Currency = "USD" }, false},
+	}
+
+	service := services.NewTransactionService(new(mocks.MockRepository))
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := supermarketReceipt()
+			tt.modify(tx)
+			err := service.ValidateTransaction(tx)
+			if !tt.valid {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "groceries", tx.Category) // the largest split
+		})
+	}
+}
+
+func TestTransaction_UnmarshalJSON_SplitCurrency(t *testing.T) {
+	body := `{"amount": -30, "currency": "usd", "type": "expense", "description": "Walmart",
+		"splits": [{"category": "groceries", "amount": -20}, {"category": "household", "amount": "-10.00"}]}`
+
+	var tx models.Transaction
+	require.NoError(t, json.Unmarshal([]byte(body), &tx))
+
+	require.Len(t, tx.Splits, 2)
+	assert.Equal(t, models.NewMoney(-2000, "USD"), tx.Splits[0].Amount)
+	assert.Equal(t, models.NewMoney(-1000, "USD"), tx.Splits[1].Amount)
+}
+
+func TestTransaction_RenameCategory_JoinsSplits(t *testing.T) {
+	tx := supermarketReceipt()
+	tx.Category = "groceries"
+
+	assert.False(t, tx.RenameCategory("travel", "flights"))
+	assert.True(t, tx.RenameCategory("household", "Groceries"))
+
+	assert.Equal(t, "groceries", tx.Category)
+	assert.Nil(t, tx.Splits)
+}
+
+func TestCurrencyConverter_InBase_Splits(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rates.csv")
+	require.NoError(t, os.WriteFile(path, []byte("date,base,quote,rate\n2025-03-10,USD,MXN,17.333\n"), 0o600))
+	store, err := rates.LoadFile(path)
+	require.NoError(t, err)
+
+	tx := models.NewTransaction("user123", models.TransactionTypeExpense, "groceries", "Walmart", models.NewMoney(-1000, "USD"), time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC))
+	tx.Splits = []models.Split{
+		{Category: "groceries", Amount: models.NewMoney(-333, "USD")},
+		{Category: "household", Amount: models.NewMoney(-333, "USD")},
+		{Category: "pharmacy", Amount: models.NewMoney(-334, "USD")},
+	}
+
+	converted, err := services.NewCurrencyConverter(new(mocks.MockRepository), store).InBase(*tx, "MXN")
+	require.NoError(t, err)
+
+	total := models.ZeroMoney("MXN")
+	for _, split := range converted.Splits {
+		assert.Equal(t, "MXN", split.Amount.Currency)
+		total = total.Add(split.Amount)
+	}
+	assert.Equal(t, converted.Amount, total)
+	assert.Equal(t, models.NewMoney(-334, "USD"), tx.Splits[2].Amount) // the original is left as is
+}
+
+func TestAnalyticsService_GetCategoryBreakdown_Splits(t *testing.T) {
+	receipt := supermarketReceipt()
+	receipt.Category = "groceries"
+	other := models.NewTransaction("user123", models.TransactionTypeExpense, "groceries", "Mercado", models.NewMoney(-10000, "MXN"), receipt.Date)
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByMonth", mock.Anything, "user123", "2025-03", mock.Anything, mock.Anything).
+		Return([]models.Transaction{*receipt, *other}, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, "user123").Return(nil, repository.ErrUserNotFound)
+	withCategories(mockRepo)
+	service := services.NewAnalyticsService(mockRepo)
+
+	rng, err := period.Parse("", "2025-03-01", "2025-03-31", time.Now())
+	require.NoError(t, err)
+
+	breakdown, err := service.GetCategoryBreakdown(context.Background(), "user123", rng, services.BreakdownOptions{})
+	require.NoError(t, err)
+
+	byCategory := make(map[string]models.CategoryBreakdown)
+	for _, b := range breakdown {
+		byCategory[b.Category] = b
+	}
+	require.Len(t, byCategory, 2)
+	assert.Equal(t, models.NewMoney(30000, "MXN"), byCategory["groceries"].Amount)
+	assert.Equal(t, 2, byCategory["groceries"].Count)
+	assert.InDelta(t, 75.0, byCategory["groceries"].Percentage, 0.001)
+	assert.Equal(t, models.NewMoney(10000, "MXN"), byCategory["household"].Amount)
+	assert.Equal(t, 1, byCategory["household"].Count)
+}
+
+func TestBudgetService_GetBudgetUtilization_Splits(t *testing.T) {
+	receipt := supermarketReceipt()
+	receipt.Category = "groceries"
+	budgets := []models.Budget{
+		{UserID: "user123", Month: "2025-03", Category: "groceries", Amount: models.NewMoney(100000, "MXN")},
+		{UserID: "user123", Month: "2025-03", Category: "household", Amount: models.NewMoney(20000, "MXN")},
+	}
+
+	mockRepo := new(mocks.MockRepository)
+	mockRepo.On("GetBudgetsByMonth", mock.Anything, "user123", "2025-03").Return(budgets, nil)
+	mockRepo.On("GetTransactionsByUser", mock.Anything, "user123", mock.Anything, mock.Anything).Return([]models.Transaction{*receipt}, nil, nil)
+	mockRepo.On("GetUser", mock.Anything, "user123").Return(nil, repository.ErrUserNotFound)
+	service := services.NewBudgetService(mockRepo)
+
+	utilization, err := service.GetBudgetUtilization(context.Background(), "user123", "2025-03")
+	require.NoError(t, err)
+
+	assert.Equal(t, models.NewMoney(20000, "MXN"), utilization["groceries"].SpentAmount)
+	assert.Equal(t, models.NewMoney(10000, "MXN"), utilization["household"].SpentAmount)
+	assert.InDelta(t, 50.0, utilization["household"].Percentage, 0.001)
+}
@@ -0,0 +1,80 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+func TestAnalyticsService_DetectSubscriptions(t *testing.T) {
+	userID := "user123"
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+	monthsAgo := func(n, drift int) time.Time { return today.AddDate(0, -n, drift) }
+
+	transactions := []models.Transaction{
+		// Monthly with a price increase and a reference number in the description
+		charge("n1", "NETFLIX.COM 8841", -219, "entertainment", monthsAgo(4, 0)),
+		charge("n2", "Netflix.com 9920", -219, "entertainment", monthsAgo(3, 1)),
+		charge("n3", "NETFLIX.COM 1203", -219, "entertainment", monthsAgo(2, -1)),
+		charge("n4", "Netflix.com 7781", -249, "entertainment", monthsAgo(1, 0)),
+		// Weekly, stopped a month ago
+		charge("g1", "Gym class", -100, "health", today.AddDate(0, 0, -49)),
+		charge("g2", "Gym class", -100, "health", today.AddDate(0, 0, -42)),
+		charge("g3", "Gym class", -100, "health", today.AddDate(0, 0, -35)),
+		charge("g4", "Gym class", -100, "health", today.AddDate(0, 0, -28)),
+		// Annual
+		charge("a1", "Amazon Prime", -899, "shopping", today.AddDate(-2, 0, 10)),
+		charge("a2", "Amazon Prime", -899, "shopping", today.AddDate(-1, 0, 10)),
+		// Irregular purchases are not subscriptions
+		charge("r1", "Restaurant dinner", -450, "food", monthsAgo(3, 0)),
+		charge("r2", "Restaurant dinner", -380, "food", monthsAgo(3, 9)),
+		charge("r3", "Restaurant dinner", -420, "food", monthsAgo(1, 3)),
+		// Same description but a very different amount stays out of the Netflix cluster
+		charge("n5", "Netflix gift card", -1000, "entertainment", monthsAgo(2, 5)),
+		// Income never counts
+		{ID: "s1", UserID: userID, Description: "Salary", Amount: models.MoneyFromFloat(20000, models.DefaultCurrency), Type: "income", Category: "salary", Date: monthsAgo(3, 0)},
+		{ID: "s2", UserID: userID, Description: "Salary", Amount: models.MoneyFromFloat(20000, models.DefaultCurrency), Type: "income", Category: "salary", Date: monthsAgo(2, 0)},
+		{ID: "s3", UserID: userID, Description: "Salary", Amount: models.MoneyFromFloat(20000, models.DefaultCurrency), Type: "income", Category: "salary", Date: monthsAgo(1, 0)},
+	}
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByUser", mock.Anything, userID, 1000, mock.Anything).Return(transactions, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, userID).Return(nil, repository.ErrUserNotFound)
+
+	subscriptions, err := services.NewAnalyticsService(mockRepo).DetectSubscriptions(context.Background(), userID)
+	require.NoError(t, err)
+	require.Len(t, subscriptions, 3)
+
+	// Sorted by annualized cost: gym 5200, netflix 2988, prime 899
+	gym, netflix, prime := subscriptions[0], subscriptions[1], subscriptions[2]
+
+	assert.Equal(t, "Gym class", gym.Name)
+	assert.Equal(t, models.CadenceWeekly, gym.Cadence)
+	assert.Equal(t, models.NewMoney(520000, models.DefaultCurrency), gym.AnnualizedCost)
+	assert.False(t, gym.Active, "a missed weekly charge marks the subscription inactive")
+
+	assert.Equal(t, "Netflix.com 7781", netflix.Name)
+	assert.Equal(t, models.CadenceMonthly, netflix.Cadence)
+	assert.Equal(t, 4, netflix.Occurrences)
+	assert.Equal(t, models.NewMoney(22650, models.DefaultCurrency), netflix.AverageAmount)
+	assert.Equal(t, models.NewMoney(298800, models.DefaultCurrency), netflix.AnnualizedCost)
+	assert.Equal(t, monthsAgo(1, 0).AddDate(0, 1, 0), netflix.NextExpected)
+	assert.True(t, netflix.Active)
+	assert.True(t, netflix.PriceIncrease)
+	assert.Equal(t, models.NewMoney(21900, models.DefaultCurrency), *netflix.PreviousAmount)
+	assert.InDelta(t, 13.7, *netflix.PriceChangePercent, 0.1)
+
+	assert.Equal(t, models.CadenceAnnual, prime.Cadence)
+	assert.False(t, prime.PriceIncrease)
+	assert.Nil(t, prime.PreviousAmount)
+}
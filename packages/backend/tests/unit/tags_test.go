@@ -0,0 +1,119 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"backend/internal/models"
+	"backend/internal/pagination"
+	"backend/internal/period"
+	"backend/internal/repository"
+	"backend/internal/services"
+	"backend/tests/mocks"
+)
+
+func TestTransactionService_ListByTag(t *testing.T) {
+	lastKey := map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "USER#user123"},
+		"SK": &types.AttributeValueMemberS{Value: "TAG#vacation-2024#1741564800#tx-1"},
+	}
+	hotel := tagged(testTransaction("Hotel", -250000, testDate(time.March, 10)), "vacation-2024", "reimbursable")
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByTag", mock.Anything, "user123", "vacation-2024", 1, map[string]types.AttributeValue(nil)).
+		Return([]models.Transaction{hotel}, lastKey, nil)
+	service := services.NewTransactionServiceWithCursors(mockRepo, pagination.NewCodec("test-secret"))
+	ctx := context.Background()
+
+	page, err := service.GetTransactionsByUser(ctx, "user123", 1, "", services.TransactionFilter{Tag: " Vacation-2024 "})
+	require.NoError(t, err)
+	require.Len(t, page.Transactions, 1)
+	assert.Equal(t, "Hotel", page.Transactions[0].Description)
+	assert.True(t, page.HasMore)
+
+	// The cursor belongs to the tag listing, not to the whole history
+	_, err = service.GetTransactionsByUser(ctx, "user123", 1, page.NextCursor, services.TransactionFilter{})
+	assert.True(t, errors.Is(err, pagination.ErrInvalidCursor))
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestTransaction_TagKeys(t *testing.T) {
+	tx := tagged(testTransaction("Hotel", -250000, testDate(time.March, 10)), "vacation-2024", "reimbursable")
+	tx.ID = "tx-1"
+
+	assert.Equal(t, []string{
+		"TAG#vacation-2024#1741564800#tx-1",
+		"TAG#reimbursable#1741564800#tx-1",
+	}, tx.TagKeys())
+}
+
+func TestTransactionService_ValidateTransaction_TagLimit(t *testing.T) {
+	service := services.NewTransactionService(mocks.NewMockRepository())
+
+	tags := make([]string, models.MaxTransactionTags+1)
+	for i := range tags {
+		tags[i] = fmt.Sprintf("tag-%d", i)
+	}
+	tx := tagged(testTransaction("Hotel", -250000, testDate(time.March, 10)), tags[:models.MaxTransactionTags]...)
+	assert.NoError(t, service.ValidateTransaction(&tx))
+
+	tx = tagged(testTransaction("Hotel", -250000, testDate(time.March, 10)), tags...)
+	assert.Error(t, service.ValidateTransaction(&tx))
+}
+
+func TestTransactionService_FilterMonthByTag(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByMonth", mock.Anything, "user123", "2025-03", 50, map[string]types.AttributeValue(nil)).
+		Return([]models.Transaction{
+			tagged(testTransaction("Hotel", -250000, testDate(time.March, 10)), "vacation-2024"),
+			tagged(testTransaction("Taxi", -30000, testDate(time.March, 10)), "work", "reimbursable"),
+		}, map[string]types.AttributeValue(nil), nil)
+	service := services.NewTransactionService(mockRepo)
+
+	page, err := service.GetTransactionsByMonth(context.Background(), "user123", "2025-03", 0, "", services.TransactionFilter{Tag: "reimbursable"})
+	require.NoError(t, err)
+
+	require.Len(t, page.Transactions, 1)
+	assert.Equal(t, "Taxi", page.Transactions[0].Description)
+	assert.False(t, page.HasMore)
+}
+
+func TestAnalyticsService_GetTagBreakdown(t *testing.T) {
+	income := tagged(testTransaction("Reembolso", 30000, testDate(time.March, 10)), "reimbursable")
+	income.Type = models.TransactionTypeIncome
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByMonth", mock.Anything, "user123", "2025-03", mock.Anything, mock.Anything).
+		Return([]models.Transaction{
+			tagged(testTransaction("Hotel", -250000, testDate(time.March, 10)), "vacation-2024"),
+			tagged(testTransaction("Taxi", -30000, testDate(time.March, 10)), "vacation-2024", "reimbursable"),
+			*testTransaction("Super", -20000, testDate(time.March, 10)),
+			income,
+		}, map[string]types.AttributeValue{}, nil)
+	mockRepo.On("GetUser", mock.Anything, "user123").Return(nil, repository.ErrUserNotFound)
+	service := services.NewAnalyticsService(mockRepo)
+
+	rng, err := period.Parse("", "2025-03-01", "2025-03-31", time.Now())
+	require.NoError(t, err)
+
+	breakdown, err := service.GetTagBreakdown(context.Background(), "user123", rng)
+	require.NoError(t, err)
+
+	require.Len(t, breakdown, 2)
+	assert.Equal(t, "vacation-2024", breakdown[0].Tag)
+	assert.Equal(t, models.NewMoney(280000, "MXN"), breakdown[0].Amount)
+	assert.Equal(t, 2, breakdown[0].Count)
+	assert.InDelta(t, 93.333, breakdown[0].Percentage, 0.001)
+	assert.Equal(t, "reimbursable", breakdown[1].Tag)
+	assert.Equal(t, models.NewMoney(30000, "MXN"), breakdown[1].Amount)
+	assert.InDelta(t, 10.0, breakdown[1].Percentage, 0.001)
+}
@@ -2,6 +2,7 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -9,8 +10,10 @@ import (
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
 	"backend/internal/models"
+	"backend/internal/pagination"
 	"backend/internal/services"
 	"backend/tests/mocks"
 )
@@ -19,7 +22,7 @@ func TestTransactionService_CreateTransaction(t *testing.T) {
 	mockTransaction := &models.Transaction{
 		ID:          uuid.New().String(),
 		UserID:      "user123",
-		Amount:      100.50,
+		Amount:      models.MoneyFromFloat(100.50, models.DefaultCurrency),
 		Type:        "expense",
 		Category:    "food",
 		Description: "Lunch",
@@ -36,6 +39,9 @@ func TestTransactionService_CreateTransaction(t *testing.T) {
 			name:        "successful transaction creation",
 			transaction: mockTransaction,
 			mockSetup: func(repo *mocks.MockRepository) {
+				repo.On("GetTransactionsByMonthBetween", mock.Anything, "user123", mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Return([]models.Transaction{}, nil, nil)
+				withRules(repo)
 				repo.On("CreateTransaction", mock.Anything, mock.AnythingOfType("*models.Transaction")).Return(nil)
 			},
 			expectError: false,
@@ -56,7 +62,7 @@ func TestTransactionService_CreateTransaction(t *testing.T) {
 			service := services.NewTransactionService(mockRepo)
 			ctx := context.Background()
 			
-			err := service.CreateTransaction(ctx, tt.transaction)
+			_, err := service.CreateTransaction(ctx, tt.transaction, services.CreateOptions{})
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -69,13 +75,37 @@ func TestTransactionService_CreateTransaction(t *testing.T) {
 	}
 }
 
+func TestTransactionService_CreateTransactionGeneratesID(t *testing.T) {
+	mockRepo := mocks.NewMockRepository()
+	withRules(mockRepo)
+	withoutStoredTransactions(mockRepo)
+	var ids []string
+	mockRepo.On("CreateTransaction", mock.Anything, mock.AnythingOfType("*models.Transaction")).
+		Run(func(args mock.Arguments) { ids = append(ids, args.Get(1).(*models.Transaction).ID) }).
+		Return(nil)
+	service := services.NewTransactionService(mockRepo)
+
+	// Two requests sending the same ID still create two transactions
+	for _, description := range []string{"Lunch", "Dinner"} {
+		tx := testTransaction(description, -25000, testDate(time.March, 10))
+		tx.ID = "client-id"
+		_, err := service.CreateTransaction(context.Background(), tx, services.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	require.Len(t, ids, 2)
+	assert.NotEqual(t, "client-id", ids[0])
+	assert.NotEqual(t, "client-id", ids[1])
+	assert.NotEqual(t, ids[0], ids[1])
+}
+
 func TestTransactionService_GetTransactionsByUser(t *testing.T) {
 	userID := "user123"
 	mockTransactions := []models.Transaction{
 		{
 			ID:          uuid.New().String(),
 			UserID:      userID,
-			Amount:      100.50,
+			Amount:      models.MoneyFromFloat(100.50, models.DefaultCurrency),
 			Type:        "expense",
 			Category:    "food",
 			Description: "Lunch",
@@ -116,7 +146,7 @@ func TestTransactionService_GetTransactionsByUser(t *testing.T) {
 			service := services.NewTransactionService(mockRepo)
 			ctx := context.Background()
 			
-			result, err := service.GetTransactionsByUser(ctx, tt.userID, tt.limit)
+			result, err := service.GetTransactionsByUser(ctx, tt.userID, tt.limit, "", services.TransactionFilter{})
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -124,7 +154,9 @@ func TestTransactionService_GetTransactionsByUser(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, result)
-				assert.Len(t, result, len(mockTransactions))
+				assert.Len(t, result.Transactions, len(mockTransactions))
+				assert.False(t, result.HasMore)
+				assert.Empty(t, result.NextCursor)
 			}
 
 			mockRepo.AssertExpectations(t)
@@ -140,7 +172,7 @@ func TestTransactionService_GetTransactionsByCategory(t *testing.T) {
 		{
 			ID:          uuid.New().String(),
 			UserID:      userID,
-			Amount:      100.50,
+			Amount:      models.MoneyFromFloat(100.50, models.DefaultCurrency),
 			Type:        "expense",
 			Category:    category,
 			Description: "Lunch",
@@ -184,7 +216,7 @@ func TestTransactionService_GetTransactionsByCategory(t *testing.T) {
 			service := services.NewTransactionService(mockRepo)
 			ctx := context.Background()
 			
-			result, err := service.GetTransactionsByCategory(ctx, tt.userID, tt.category, tt.limit)
+			result, err := service.GetTransactionsByCategory(ctx, tt.userID, tt.category, tt.limit, "", services.TransactionFilter{})
 
 			if tt.expectError {
 				assert.Error(t, err)
@@ -192,10 +224,92 @@ func TestTransactionService_GetTransactionsByCategory(t *testing.T) {
 			} else {
 				assert.NoError(t, err)
 				assert.NotNil(t, result)
-				assert.Len(t, result, len(mockTransactions))
+				assert.Len(t, result.Transactions, len(mockTransactions))
+				assert.False(t, result.HasMore)
+				assert.Empty(t, result.NextCursor)
 			}
 
 			mockRepo.AssertExpectations(t)
 		})
 	}
 }
+
+func TestTransactionService_CursorPagination(t *testing.T) {
+	userID := "user123"
+	lastKey := map[string]types.AttributeValue{
+		"PK": &types.AttributeValueMemberS{Value: "USER#" + userID},
+		"SK": &types.AttributeValueMemberS{Value: "TRANSACTION#1704067200#tx-1"},
+	}
+	firstPage := []models.Transaction{{ID: "tx-1", UserID: userID, Amount: models.MoneyFromFloat(10, models.DefaultCurrency), Date: time.Now()}}
+	secondPage := []models.Transaction{{ID: "tx-2", UserID: userID, Amount: models.MoneyFromFloat(20, models.DefaultCurrency), Date: time.Now()}}
+
+	mockRepo := mocks.NewMockRepository()
+	mockRepo.On("GetTransactionsByUser", mock.Anything, userID, 1, map[string]types.AttributeValue(nil)).Return(firstPage, lastKey, nil)
+	mockRepo.On("GetTransactionsByUser", mock.Anything, userID, 1, lastKey).Return(secondPage, map[string]types.AttributeValue(nil), nil)
+
+	service := services.NewTransactionServiceWithCursors(mockRepo, pagination.NewCodec("test-secret"))
+	ctx := context.Background()
+
+	page, err := service.GetTransactionsByUser(ctx, userID, 1, "", services.TransactionFilter{})
+	require.NoError(t, err)
+	assert.True(t, page.HasMore)
+	assert.NotEmpty(t, page.NextCursor)
+	assert.Equal(t, "tx-1", page.Transactions[0].ID)
+
+	page, err = service.GetTransactionsByUser(ctx, userID, 1, page.NextCursor, services.TransactionFilter{})
+	require.NoError(t, err)
+	assert.False(t, page.HasMore)
+	assert.Empty(t, page.NextCursor)
+	assert.Equal(t, "tx-2", page.Transactions[0].ID)
+
+	mockRepo.AssertExpectations(t)
+}
+
+func TestCursorCodec(t *testing.T) {
+	codec := pagination.NewCodec("test-secret")
+	key := map[string]types.AttributeValue{
+		"PK":     &types.AttributeValueMemberS{Value: "USER#user123"},
+		"SK":     &types.AttributeValueMemberS{Value: "TRANSACTION#1704067200#tx-1"},
+		"GSI1PK": &types.AttributeValueMemberS{Value: "MONTH#2024-01#user123"},
+	}
+
+	cursor, err := codec.Encode("user#user123", key)
+	require.NoError(t, err)
+
+	decoded, err := codec.Decode("user#user123", cursor)
+	require.NoError(t, err)
+	assert.Equal(t, key, decoded)
+
+	empty, err := codec.Encode("user#user123", nil)
+	require.NoError(t, err)
+	assert.Empty(t, empty)
+
+	decoded, err = codec.Decode("user#user123", "")
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	// Cursors are bound to the listing they were issued for
+	_, err = codec.Decode("user#someone-else", cursor)
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+
+	// Tampered or foreign cursors are rejected
+	_, err = pagination.NewCodec("other-secret").Decode("user#user123", cursor)
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+	_, err = codec.Decode("user#user123", "not-a-cursor")
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+	_, err = codec.Decode("user#user123", "e30."+cursor[len(cursor)-10:])
+	assert.ErrorIs(t, err, pagination.ErrInvalidCursor)
+}
+
+func TestTransaction_GenerateKeys(t *testing.T) {
+	tx := models.NewTransaction("user123", "expense", "food", "Lunch", models.MoneyFromFloat(-12.5, models.DefaultCurrency), time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC))
+
+	assert.Equal(t, "USER#user123", tx.PK)
+	assert.Equal(t, fmt.Sprintf("TRANSACTION#%d#%s", tx.Date.Unix(), tx.ID), tx.SK)
+	assert.Equal(t, "MONTH#2024-01#user123", tx.GSI1PK)
+	assert.Equal(t, "CATEGORY#FOOD#user123", tx.GSI2PK)
+
+	// The ID index lets get/update/delete resolve the item with a single keyed query
+	assert.Equal(t, "TRANSACTION#"+tx.ID, tx.GSI3PK)
+	assert.Equal(t, "USER#user123", tx.GSI3SK)
+}